@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nexus/nexus/pkg/coordination"
+	"github.com/spf13/cobra"
+)
+
+var (
+	adminServer      string
+	adminAuditActor  string
+	adminAuditUser   string
+	adminAuditAction string
+	adminAuditTarget string
+	adminAuditResult string
+	adminAuditSince  string
+	adminAuditUntil  string
+	adminAuditLimit  int
+	adminAuditFormat string
+)
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Administer a coordination server",
+	Long: `Administer the coordination server at --server (or $NEXUS_COORD_URL).
+
+Admin commands authenticate with the server's auth.admin_token, read from
+$NEXUS_ADMIN_TOKEN.`,
+}
+
+var adminAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the coordination server's audit log",
+	Long: `Show who created, stopped or deleted workspaces, registered SSH keys, fetched
+GitHub tokens and made other privileged changes on the coordination server,
+newest first.
+
+Filters combine; --action also matches the actions under it, so --action workspace
+shows workspace.create, workspace.stop and workspace.delete. --since and --until take
+an RFC 3339 time or a YYYY-MM-DD date.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runAdminAudit(cmd.Context())
+	},
+}
+
+func init() {
+	adminCmd.PersistentFlags().StringVar(&adminServer, "server", "", "Coordination server URL (default $NEXUS_COORD_URL or http://localhost:3001)")
+
+	adminAuditCmd.Flags().StringVar(&adminAuditActor, "actor", "", "Only actions authenticated with this credential: service, admin, github_webhook or anonymous")
+	adminAuditCmd.Flags().StringVar(&adminAuditUser, "user", "", "Only actions that claimed to act for this user")
+	adminAuditCmd.Flags().StringVar(&adminAuditAction, "action", "", "Only this action, or the actions under it")
+	adminAuditCmd.Flags().StringVar(&adminAuditTarget, "target", "", "Only actions on this workspace, user, node or key")
+	adminAuditCmd.Flags().StringVar(&adminAuditResult, "result", "", "Only this result: success, denied or failure")
+	adminAuditCmd.Flags().StringVar(&adminAuditSince, "since", "", "Only actions at or after this time")
+	adminAuditCmd.Flags().StringVar(&adminAuditUntil, "until", "", "Only actions at or before this time")
+	adminAuditCmd.Flags().IntVar(&adminAuditLimit, "limit", 100, "Show at most this many events (up to 1000)")
+	adminAuditCmd.Flags().StringVar(&adminAuditFormat, "format", "table", "Output format: table or json")
+
+	adminCmd.AddCommand(adminAuditCmd)
+	rootCmd.AddCommand(adminCmd)
+}
+
+// newAdminClient is a client for the admin endpoints of the coordination
+// server, authenticated with $NEXUS_ADMIN_TOKEN
+func newAdminClient() (*coordinationClient, error) {
+	token := os.Getenv("NEXUS_ADMIN_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("set $NEXUS_ADMIN_TOKEN to the coordination server's auth.admin_token")
+	}
+	server := adminServer
+	if server == "" {
+		server = os.Getenv("NEXUS_COORD_URL")
+	}
+	if server == "" {
+		server = "http://localhost:3001"
+	}
+	return &coordinationClient{server: strings.TrimSuffix(server, "/"), token: token}, nil
+}
+
+func runAdminAudit(ctx context.Context) error {
+	if adminAuditFormat != "table" && adminAuditFormat != "json" {
+		return fmt.Errorf("unknown format %q (use table or json)", adminAuditFormat)
+	}
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"limit": {strconv.Itoa(adminAuditLimit)}}
+	for name, value := range map[string]string{
+		"actor":  adminAuditActor,
+		"user":   adminAuditUser,
+		"action": adminAuditAction,
+		"target": adminAuditTarget,
+		"result": adminAuditResult,
+		"since":  adminAuditSince,
+		"until":  adminAuditUntil,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	var resp coordination.AuditResponse
+	if err := client.do(ctx, http.MethodGet, "/api/v1/audit", query, nil, &resp); err != nil {
+		return err
+	}
+
+	if adminAuditFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(resp)
+	}
+	if len(resp.Events) == 0 {
+		fmt.Println("No audit events match")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tACTOR\tUSER\tACTION\tTARGET\tSOURCE\tRESULT")
+	for _, event := range resp.Events {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s (%d)\n",
+			event.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			event.Actor, orDash(event.ClaimedUser), event.Action, orDash(event.Target), event.SourceIP, event.Result, event.Status)
+	}
+	return tw.Flush()
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -19,12 +19,14 @@ import (
 	"github.com/nexus/nexus/pkg/config"
 	"github.com/nexus/nexus/pkg/coordination"
 	"github.com/nexus/nexus/pkg/ctrl"
+	"github.com/nexus/nexus/pkg/logging"
 	"github.com/nexus/nexus/pkg/metrics"
 	"github.com/nexus/nexus/pkg/paths"
 	"github.com/nexus/nexus/pkg/provider"
 	dockerProvider "github.com/nexus/nexus/pkg/provider/docker"
 	lxcProvider "github.com/nexus/nexus/pkg/provider/lxc"
 	"github.com/nexus/nexus/pkg/templates"
+	"github.com/nexus/nexus/pkg/tracing"
 	"github.com/nexus/nexus/pkg/worktree"
 	"github.com/spf13/cobra"
 	goyaml "gopkg.in/yaml.v3"
@@ -75,17 +77,19 @@ var branchCmd = &cobra.Command{
 
 var (
 	branchNode string
+	branchFrom string
 )
 
 var branchCreateCmd = &cobra.Command{
 	Use:   "create <name>",
 	Short: "Create a new branch",
-	Long:  `Create a new branch with the specified name. This will set up a Git worktree and generate AI agent configurations.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a new branch with the specified name. This will set up a Git worktree and generate AI agent configurations.
+The branch starts at the current HEAD, or at --from when given; your current checkout is left untouched.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(_ *cobra.Command, args []string) error {
 		ctx := context.Background()
 		controller := createController()
-		return controller.WorkspaceCreate(ctx, args[0])
+		return controller.WorkspaceCreateFrom(ctx, args[0], branchFrom)
 	},
 }
 
@@ -148,6 +152,17 @@ var branchListCmd = &cobra.Command{
 	},
 }
 
+var branchPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Prune stale branch worktrees",
+	Long:  `Remove Git worktree metadata for branches whose worktree directories no longer exist.`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		ctx := context.Background()
+		controller := createController()
+		return controller.WorkspacePrune(ctx)
+	},
+}
+
 var branchRmCmd = &cobra.Command{
 	Use:   "rm <name>",
 	Short: "Remove a branch",
@@ -160,14 +175,33 @@ var branchRmCmd = &cobra.Command{
 	},
 }
 
+var applyOptions ctrl.ApplyOptions
+
 var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Apply latest configuration to agent configs",
-	Long:  `Apply the latest nexus configuration to all enabled AI agent configuration files (Cursor, OpenCode, Claude, etc.).`,
+	Long: `Apply the latest nexus configuration to all enabled AI agent configuration files (Cursor, OpenCode, Claude, etc.).
+
+Files nexus did not generate, or that were edited since nexus wrote them, are
+not overwritten unless --force is given. Use --dry-run to see a diff of every
+change and --check in CI to fail when generated files are out of date.`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		ctx := context.Background()
+		controller := createController()
+		return controller.Apply(ctx, applyOptions)
+	},
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove all generated agent files",
+	Long: `Remove every rule, skill, command and config file nexus generated for AI agents,
+along with the .gitignore lines it added. Files edited by hand since nexus wrote
+them are kept.`,
 	RunE: func(_ *cobra.Command, _ []string) error {
 		ctx := context.Background()
 		controller := createController()
-		return controller.Apply(ctx)
+		return controller.Clean(ctx)
 	},
 }
 
@@ -188,6 +222,63 @@ var pluginUpdateCmd = &cobra.Command{
 	},
 }
 
+var pluginInstallFrozen bool
+
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install plugins pinned in nexus.lock",
+	Long: `Fetch every plugin pinned in nexus.lock at its exact commit into the plugin cache.
+With --frozen, fail instead of refreshing the lockfile when it is missing or disagrees with config.`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		ctx := context.Background()
+		controller := createController()
+		return controller.PluginInstall(ctx, pluginInstallFrozen)
+	},
+}
+
+var pluginIndexSource string
+
+var pluginSearchCmd = &cobra.Command{
+	Use:   "search [term]",
+	Short: "Search the plugin index",
+	Long:  `Search the plugin index by name, description and tags. Without a term, list every plugin.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		term := ""
+		if len(args) > 0 {
+			term = args[0]
+		}
+		ctx := context.Background()
+		controller := createController()
+		return controller.PluginSearch(ctx, pluginIndexSource, term)
+	},
+}
+
+var pluginAddCmd = &cobra.Command{
+	Use:   "add <name>[@version]",
+	Short: "Add a plugin from the index",
+	Long: `Add a plugin from the plugin index to config.yaml and update nexus.lock.
+The version is an optional constraint such as ^1.2 or ~1.4.0.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		ctx := context.Background()
+		controller := createController()
+		return controller.PluginAdd(ctx, pluginIndexSource, args[0])
+	},
+}
+
+var pluginRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a plugin",
+	Long:  `Remove a plugin from config.yaml and update nexus.lock.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		ctx := context.Background()
+		controller := createController()
+		return controller.PluginRemove(ctx, args[0])
+	},
+}
+
 var pluginListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all loaded plugins",
@@ -210,8 +301,8 @@ var usageSummaryCmd = &cobra.Command{
 	Short: "Generate daily summary report",
 	Long:  `Generate a daily summary of usage metrics and insights. Date format: YYYY-MM-DD (defaults to today).`,
 	Args:  cobra.MaximumNArgs(1),
-	RunE: func(_ *cobra.Command, args []string) error {
-		return runUsageSummary(args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUsageSummary(cmd.Context(), args)
 	},
 }
 
@@ -220,8 +311,8 @@ var usageMetricsCmd = &cobra.Command{
 	Short: "Calculate productivity metrics",
 	Long:  `Calculate detailed productivity metrics for the specified number of days (defaults to 7).`,
 	Args:  cobra.MaximumNArgs(1),
-	RunE: func(_ *cobra.Command, args []string) error {
-		return runUsageMetrics(args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUsageMetrics(cmd.Context(), args)
 	},
 }
 
@@ -230,8 +321,8 @@ var usagePatternsCmd = &cobra.Command{
 	Short: "Analyze usage patterns",
 	Long:  `Analyze usage patterns and trends for the specified number of days (defaults to 7).`,
 	Args:  cobra.MaximumNArgs(1),
-	RunE: func(_ *cobra.Command, args []string) error {
-		return runUsagePatterns(args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUsagePatterns(cmd.Context(), args)
 	},
 }
 
@@ -240,8 +331,8 @@ var usageBenchmarkCmd = &cobra.Command{
 	Short: "Compare baseline and current metrics",
 	Long:  `Compare productivity metrics between baseline period and current period.`,
 	Args:  cobra.ExactArgs(2),
-	RunE: func(_ *cobra.Command, args []string) error {
-		return runUsageBenchmark(args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUsageBenchmark(cmd.Context(), args)
 	},
 }
 
@@ -308,7 +399,7 @@ var coordinationRestartCmd = &cobra.Command{
 	Short: "Restart the coordination server",
 	Long: `Gracefully restart the coordination server for remote node communication.
 Stops any running server, then starts a new instance with the same configuration.`,
-	RunE: func(_ *cobra.Command, _ []string) error {
+	RunE: func(cobraCmd *cobra.Command, _ []string) error {
 		fmt.Println("=== Restarting Coordination Server ===")
 
 		projectRoot := paths.GetProjectRoot()
@@ -356,12 +447,16 @@ Stops any running server, then starts a new instance with the same configuration
 		os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", cmd.Process.Pid)), 0644)
 		fmt.Printf("Server started (PID: %d)\n", cmd.Process.Pid)
 
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		ctx, cancel := context.WithTimeout(cobraCmd.Context(), 15*time.Second)
 		defer cancel()
 
 		fmt.Println("Monitoring startup (15 seconds)...")
 		for i := 0; i < 15; i++ {
-			resp, err := http.Get("http://localhost:3001/health")
+			req, err := http.NewRequestWithContext(ctx, "GET", "http://localhost:3001/health", nil)
+			if err != nil {
+				return err
+			}
+			resp, err := tracedClient.Do(req)
 			if err == nil && resp.StatusCode == 200 {
 				fmt.Println("✅ Server is healthy!")
 				return nil
@@ -408,6 +503,18 @@ The node agent provides branch execution capabilities on this machine.`,
 				Retries:  3,
 			},
 		}
+		cfg.Logging.Level = os.Getenv("NEXUS_LOG_LEVEL")
+		logFile, err := logging.Setup(cfg.Logging)
+		if err != nil {
+			return fmt.Errorf("failed to set up logging: %w", err)
+		}
+		defer logFile.Close()
+		cfg.Tracing.ApplyEnv()
+		shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, "nexus-agent")
+		if err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+		defer shutdownTracing(context.Background())
 		agnt, err := agent.NewAgent(cfg)
 		if err != nil {
 			return fmt.Errorf("failed to create agent: %w", err)
@@ -538,7 +645,7 @@ var sshRegisterCmd = &cobra.Command{
 	Short: "Register your SSH public key with the coordination server",
 	Long:  `Register your SSH public key with the coordination server to get access to branches.`,
 	Args:  cobra.ExactArgs(1),
-	RunE: func(_ *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		server := args[0]
 		pubKey, _, err := ensureSSHKey()
 		if err != nil {
@@ -571,7 +678,12 @@ var sshRegisterCmd = &cobra.Command{
 		}
 		jsonPayload, _ := json.Marshal(payload)
 
-		resp, err := http.Post(url, "application/json", strings.NewReader(string(jsonPayload)))
+		req, err := http.NewRequestWithContext(cmd.Context(), "POST", url, strings.NewReader(string(jsonPayload)))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := tracedClient.Do(req)
 		if err != nil {
 			return fmt.Errorf("failed to connect to server: %w", err)
 		}
@@ -727,6 +839,7 @@ func init() {
 	// Add subcommands
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(cleanCmd)
 	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(pluginCmd)
 	rootCmd.AddCommand(configCmd)
@@ -740,11 +853,22 @@ func init() {
 	// Plugin subcommands
 	pluginCmd.AddCommand(pluginUpdateCmd)
 	pluginCmd.AddCommand(pluginListCmd)
+	pluginCmd.AddCommand(pluginInstallCmd)
+	applyCmd.Flags().BoolVar(&applyOptions.DryRun, "dry-run", false, "Print a diff of every file that would change without writing")
+	applyCmd.Flags().BoolVar(&applyOptions.Check, "check", false, "Exit non-zero if generated files are out of date, without writing")
+	applyCmd.Flags().BoolVar(&applyOptions.Force, "force", false, "Overwrite files nexus did not generate or that were edited by hand")
+	pluginInstallCmd.Flags().BoolVar(&pluginInstallFrozen, "frozen", false, "Fail if nexus.lock is missing or out of date")
+	pluginCmd.AddCommand(pluginSearchCmd)
+	pluginCmd.AddCommand(pluginAddCmd)
+	pluginCmd.AddCommand(pluginRemoveCmd)
+	pluginSearchCmd.Flags().StringVar(&pluginIndexSource, "index", "", "Plugin index URL, git repository or file (defaults to plugin_index in config.yaml)")
+	pluginAddCmd.Flags().StringVar(&pluginIndexSource, "index", "", "Plugin index URL, git repository or file (defaults to plugin_index in config.yaml)")
 
 	// Config subcommands
 	configCmd.AddCommand(configExtractCmd)
 
 	// Usage subcommands
+	usageCmd.PersistentFlags().StringVar(&usageFormat, "format", "json", "Report format: json, table, csv, markdown or html")
 	usageCmd.AddCommand(usageSummaryCmd)
 	usageCmd.AddCommand(usageMetricsCmd)
 	usageCmd.AddCommand(usagePatternsCmd)
@@ -756,10 +880,12 @@ func init() {
 	branchCmd.AddCommand(branchDownCmd)
 	branchCmd.AddCommand(branchListCmd)
 	branchCmd.AddCommand(branchRmCmd)
+	branchCmd.AddCommand(branchPruneCmd)
 	branchCmd.AddCommand(branchShellCmd)
 
 	// Add --node flag to branch commands
 	branchCreateCmd.Flags().StringVarP(&branchNode, "node", "n", "", "Remote node to create branch on")
+	branchCreateCmd.Flags().StringVar(&branchFrom, "from", "", "Base ref (branch, tag or commit) to create the branch from")
 	branchUpCmd.Flags().StringVarP(&branchNode, "node", "n", "", "Remote node to start branch on")
 	branchDownCmd.Flags().StringVarP(&branchNode, "node", "n", "", "Remote node to stop branch on")
 	branchShellCmd.Flags().StringVarP(&branchNode, "node", "n", "", "Remote node to shell into")
@@ -803,7 +929,7 @@ func createController() ctrl.Controller {
 	providers := []provider.Provider{dockerProv, lxcProv}
 
 	// Create worktree manager
-	wtManager := worktree.NewManager(".", ".nexus/worktrees")
+	wtManager := worktree.NewManager(".", paths.GetWorktreesDir(paths.GetProjectRoot()))
 
 	// Create controller
 	return ctrl.NewBaseController(providers, wtManager)
@@ -876,9 +1002,34 @@ func runUpdate() error {
 	return nil
 }
 
-func runUsageSummary(args []string) error {
-	logger := metrics.NewLogger(".")
-	reporter := metrics.NewReporter()
+// usageLogger opens the project's usage store with the retention and
+// compaction settings from .nexus/config.yaml, if there is one
+func usageLogger() *metrics.Logger {
+	options := metrics.DefaultStoreOptions
+	if cfg, err := config.LoadConfig(".nexus/config.yaml"); err == nil {
+		options.RetentionDays = cfg.Usage.RetentionDays
+		if cfg.Usage.CompactAfterDays != 0 {
+			options.CompactAfterDays = max(cfg.Usage.CompactAfterDays, 0)
+		}
+	}
+	return metrics.NewLoggerWithOptions(".", options)
+}
+
+// usageFormat is the --format shared by the usage reports
+var usageFormat string
+
+// renderUsage prints report in the --format the user asked for
+func renderUsage(title string, report interface{}) error {
+	format, err := metrics.ParseFormat(usageFormat)
+	if err != nil {
+		return err
+	}
+	return metrics.Render(os.Stdout, format, title, report)
+}
+
+func runUsageSummary(ctx context.Context, args []string) error {
+	logger := usageLogger()
+	reporter := usageReporter()
 
 	var date time.Time
 	if len(args) > 0 {
@@ -890,22 +1041,19 @@ func runUsageSummary(args []string) error {
 	} else {
 		date = time.Now()
 	}
+	if usageTeam {
+		return runTeamUsageSummary(ctx, date.Format("2006-01-02"))
+	}
 
 	summary, err := reporter.GenerateDailySummary(logger, date)
 	if err != nil {
 		return fmt.Errorf("failed to generate summary: %w", err)
 	}
 
-	data, err := json.MarshalIndent(summary, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal summary: %w", err)
-	}
-
-	fmt.Println(string(data))
-	return nil
+	return renderUsage("Usage summary for "+summary.Date, summary)
 }
 
-func runUsageMetrics(args []string) error {
+func runUsageMetrics(ctx context.Context, args []string) error {
 	days := 7
 	if len(args) > 0 {
 		parsed, err := strconv.Atoi(args[0])
@@ -914,31 +1062,36 @@ func runUsageMetrics(args []string) error {
 		}
 		days = parsed
 	}
-
-	logger := metrics.NewLogger(".")
-	reporter := metrics.NewReporter()
-
-	m, summary, patterns, err := reporter.GenerateReport(logger, days)
-	if err != nil {
-		return fmt.Errorf("failed to generate report: %w", err)
+	if usageTeam {
+		return runTeamUsageReport(ctx, days, nil)
 	}
 
-	result := map[string]interface{}{
-		"summary":  summary,
-		"metrics":  m,
-		"patterns": patterns,
+	logger := usageLogger()
+	reporter := usageReporter()
+	title := fmt.Sprintf("Usage report: last %d days", days)
+
+	// HTML is the dashboard: the report with day-by-day charts
+	if format, _ := metrics.ParseFormat(usageFormat); format == metrics.FormatHTML {
+		dashboard, err := reporter.GenerateDashboard(logger, days)
+		if err != nil {
+			return fmt.Errorf("failed to generate report: %w", err)
+		}
+		return renderUsage(title, dashboard)
 	}
 
-	data, err := json.MarshalIndent(result, "", "  ")
+	m, summary, patterns, err := reporter.GenerateReport(logger, days)
 	if err != nil {
-		return fmt.Errorf("failed to marshal report: %w", err)
+		return fmt.Errorf("failed to generate report: %w", err)
 	}
 
-	fmt.Println(string(data))
-	return nil
+	return renderUsage(title, &metrics.Report{
+		Summary:  summary,
+		Metrics:  m,
+		Patterns: patterns,
+	})
 }
 
-func runUsagePatterns(args []string) error {
+func runUsagePatterns(ctx context.Context, args []string) error {
 	days := 7
 	if len(args) > 0 {
 		parsed, err := strconv.Atoi(args[0])
@@ -947,8 +1100,11 @@ func runUsagePatterns(args []string) error {
 		}
 		days = parsed
 	}
+	if usageTeam {
+		return runTeamUsageReport(ctx, days, func(report *metrics.TeamReport) interface{} { return report.Patterns })
+	}
 
-	logger := metrics.NewLogger(".")
+	logger := usageLogger()
 	analyzer := metrics.NewAnalyzer()
 
 	endDate := time.Now()
@@ -968,16 +1124,10 @@ func runUsagePatterns(args []string) error {
 		return fmt.Errorf("failed to analyze patterns: %w", err)
 	}
 
-	data, err := json.MarshalIndent(patterns, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal patterns: %w", err)
-	}
-
-	fmt.Println(string(data))
-	return nil
+	return renderUsage(fmt.Sprintf("Skill patterns: last %d days", days), patterns)
 }
 
-func runUsageBenchmark(args []string) error {
+func runUsageBenchmark(ctx context.Context, args []string) error {
 	baselineDays, err := strconv.Atoi(args[0])
 	if err != nil {
 		return fmt.Errorf("invalid baseline days: %w", err)
@@ -987,22 +1137,19 @@ func runUsageBenchmark(args []string) error {
 	if err != nil {
 		return fmt.Errorf("invalid current days: %w", err)
 	}
+	if usageTeam {
+		return runTeamUsageBenchmark(ctx, baselineDays, currentDays)
+	}
 
-	logger := metrics.NewLogger(".")
-	reporter := metrics.NewReporter()
+	logger := usageLogger()
+	reporter := usageReporter()
 
 	comparison, err := reporter.GenerateBenchmark(logger, baselineDays, currentDays)
 	if err != nil {
 		return fmt.Errorf("failed to generate benchmark: %w", err)
 	}
 
-	data, err := json.MarshalIndent(comparison, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal benchmark: %w", err)
-	}
-
-	fmt.Println(string(data))
-	return nil
+	return renderUsage(fmt.Sprintf("Benchmark: last %d days against the %d before", currentDays, baselineDays), comparison)
 }
 
 func main() {
@@ -1028,7 +1175,7 @@ func main() {
 		}
 	}
 
-	if err := rootCmd.Execute(); err != nil {
+	if err := executeTraced(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
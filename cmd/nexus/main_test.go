@@ -152,6 +152,19 @@ func TestPluginUpdateCmdExists(t *testing.T) {
 	assert.Equal(t, "update", pluginUpdateCmd.Use)
 }
 
+func TestPluginInstallCmdExists(t *testing.T) {
+	assert.NotNil(t, pluginInstallCmd)
+	assert.Equal(t, "install", pluginInstallCmd.Use)
+	assert.NotNil(t, pluginInstallCmd.Flags().Lookup("frozen"))
+}
+
+func TestPluginIndexCmdsExist(t *testing.T) {
+	assert.Equal(t, "search [term]", pluginSearchCmd.Use)
+	assert.Equal(t, "add <name>[@version]", pluginAddCmd.Use)
+	assert.Equal(t, "remove <name>", pluginRemoveCmd.Use)
+	assert.NotNil(t, pluginAddCmd.Flags().Lookup("index"))
+}
+
 func TestPluginListCmdExists(t *testing.T) {
 	assert.NotNil(t, pluginListCmd)
 	assert.Equal(t, "list", pluginListCmd.Use)
@@ -181,3 +194,18 @@ func TestUsageBenchmarkCmdExists(t *testing.T) {
 	assert.NotNil(t, usageBenchmarkCmd)
 	assert.Equal(t, "benchmark <baseline-days> <current-days>", usageBenchmarkCmd.Use)
 }
+
+func TestApplyCmdFlags(t *testing.T) {
+	for _, name := range []string{"dry-run", "check", "force"} {
+		flag := applyCmd.Flags().Lookup(name)
+		if assert.NotNil(t, flag, name) {
+			assert.Equal(t, "false", flag.DefValue)
+		}
+	}
+}
+
+func TestCleanCmdRegistered(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"clean"})
+	assert.NoError(t, err)
+	assert.Equal(t, cleanCmd, cmd)
+}
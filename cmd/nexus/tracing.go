@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nexus/nexus/pkg/tracing"
+	"github.com/spf13/cobra"
+)
+
+// tracedClient sends requests that carry the trace context of the command
+var tracedClient = &http.Client{Transport: tracing.Transport(nil)}
+
+// isServiceCommand reports whether cmd runs a long-lived service, which sets
+// up tracing under its own service name instead of as one CLI invocation
+func isServiceCommand(cmd *cobra.Command) bool {
+	return cmd == coordinationStartCmd || cmd == agentStartCmd || cmd == usageServeCmd
+}
+
+// executeTraced runs the root command inside a span named after the invoked
+// command, so the requests it makes join one trace with the server and agent
+// work they cause. The CLI has no config of its own, so tracing is enabled
+// with NEXUS_TRACING_ENABLED.
+func executeTraced() error {
+	ctx := context.Background()
+	cmd, _, err := rootCmd.Find(os.Args[1:])
+	if err != nil || isServiceCommand(cmd) {
+		return rootCmd.ExecuteContext(ctx)
+	}
+
+	var cfg tracing.Config
+	cfg.ApplyEnv()
+	shutdown, err := tracing.Setup(ctx, cfg, "nexus-cli")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Tracing disabled: %v\n", err)
+		return rootCmd.ExecuteContext(ctx)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if flushErr := shutdown(flushCtx); flushErr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Failed to flush traces: %v\n", flushErr)
+		}
+	}()
+
+	ctx, span := tracing.Start(ctx, cmd.CommandPath())
+	err = rootCmd.ExecuteContext(ctx)
+	tracing.End(span, err)
+	return err
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nexus/nexus/pkg/metrics"
+	"github.com/nexus/nexus/pkg/paths"
+	"github.com/spf13/cobra"
+)
+
+var (
+	usageLogEntry    metrics.UsageLog
+	usageLogDuration time.Duration
+	usageLogFailed   bool
+	usageLogJSON     bool
+	usageEndpoint    string
+	usageListen      string
+	usageImportFrom  string
+	usageImportAll   bool
+)
+
+var usageLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Record a skill or command invocation",
+	Long: `Record one usage entry, for agent hooks to call after a skill or command runs.
+
+With --json, the entry (or a JSON array of entries) is read from stdin instead of flags.
+With --endpoint or NEXUS_USAGE_ENDPOINT, entries are sent to a 'nexus usage serve'
+endpoint (host:port or unix:<socket>) instead of the project's store.`,
+	Example: `  nexus usage log --agent sisyphus --type skill --name analyze-codebase --duration 12s --tokens 5300
+  echo '{"invocation":{"type":"command","name":"/review"}}' | nexus usage log --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runUsageLog(cmd.Context(), cmd.InOrStdin())
+	},
+}
+
+var usageServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Accept usage entries over HTTP",
+	Long: `Serve POST /api/v1/usage, which records the usage entry or JSON array of entries
+in the request body into the project's store. Requests must be sent with
+Content-Type: application/json.
+
+By default it listens on a Unix socket in the project's runtime state directory,
+so only local agents can report. Use --listen host:port for TCP.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runUsageServe(cmd.Context())
+	},
+}
+
+var usageImportCmd = &cobra.Command{
+	Use:   "import <claude-code|opencode>",
+	Short: "Import usage from agent session transcripts",
+	Long: `Import the slash commands and skills used in Claude Code or OpenCode sessions,
+with their durations, tokens and costs.
+
+Only the current project's sessions are imported unless --all is given.
+Entries already imported are skipped, so it is safe to run repeatedly.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{metrics.ClaudeCodeAgent, metrics.OpenCodeAgent},
+	RunE: func(_ *cobra.Command, args []string) error {
+		return runUsageImport(args[0])
+	},
+}
+
+func init() {
+	usageLogCmd.Flags().StringVar(&usageLogEntry.Agent, "agent", os.Getenv("NEXUS_AGENT"), "Agent that made the invocation (defaults to $NEXUS_AGENT)")
+	usageLogCmd.Flags().StringVar(&usageLogEntry.Invocation.Type, "type", string(metrics.InvocationSkill), "Invocation type: skill, command or rule")
+	usageLogCmd.Flags().StringVar(&usageLogEntry.Invocation.Name, "name", "", "Skill, command or rule name")
+	usageLogCmd.Flags().StringVar(&usageLogEntry.Invocation.Category, "category", "", "Category of the skill or command")
+	usageLogCmd.Flags().StringVar(&usageLogEntry.Context.Task, "task", "", "Task the invocation was for")
+	usageLogCmd.Flags().StringVar(&usageLogEntry.Context.Project, "project", "", "Project name (defaults to the current directory's)")
+	usageLogCmd.Flags().StringSliceVar(&usageLogEntry.Context.Files, "file", nil, "File the invocation touched (repeatable)")
+	usageLogCmd.Flags().DurationVar(&usageLogDuration, "duration", 0, "How long the invocation took, e.g. 1.5s")
+	usageLogCmd.Flags().BoolVar(&usageLogFailed, "failed", false, "Record the invocation as failed")
+	usageLogCmd.Flags().Int64Var(&usageLogEntry.Outcome.TokensUsed, "tokens", 0, "Tokens used")
+	usageLogCmd.Flags().Float64Var(&usageLogEntry.Outcome.Cost, "cost", 0, "Cost in USD")
+	usageLogCmd.Flags().StringVar(&usageLogEntry.Timestamp, "timestamp", "", "RFC 3339 time of the invocation (defaults to now)")
+	usageLogCmd.Flags().BoolVar(&usageLogJSON, "json", false, "Read the entry or an array of entries from stdin as JSON")
+	usageLogCmd.Flags().StringVar(&usageEndpoint, "endpoint", os.Getenv("NEXUS_USAGE_ENDPOINT"), "Send entries to this usage serve endpoint (host:port or unix:<socket>)")
+
+	usageServeCmd.Flags().StringVar(&usageListen, "listen", "", "Address to listen on, host:port or unix:<socket> (defaults to a socket in the runtime state directory)")
+
+	usageImportCmd.Flags().StringVar(&usageImportFrom, "from", "", "Claude Code config directory or OpenCode storage directory (defaults to the agent's own)")
+	usageImportCmd.Flags().BoolVar(&usageImportAll, "all", false, "Import sessions from every project, not just the current one")
+
+	usageCmd.AddCommand(usageLogCmd)
+	usageCmd.AddCommand(usageServeCmd)
+	usageCmd.AddCommand(usageImportCmd)
+}
+
+func runUsageLog(ctx context.Context, stdin io.Reader) error {
+	var entries []metrics.UsageLog
+	if usageLogJSON {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		trimmed := bytes.TrimSpace(data)
+		if bytes.HasPrefix(trimmed, []byte("[")) {
+			err = json.Unmarshal(trimmed, &entries)
+		} else {
+			entries = make([]metrics.UsageLog, 1)
+			err = json.Unmarshal(trimmed, &entries[0])
+		}
+		if err != nil {
+			return fmt.Errorf("invalid usage entry: %w", err)
+		}
+	} else {
+		entry := usageLogEntry
+		entry.Outcome.Duration = usageLogDuration.Milliseconds()
+		entry.Outcome.Success = !usageLogFailed
+		if entry.Context.Project == "" {
+			if cwd, err := os.Getwd(); err == nil {
+				entry.Context.Project = filepath.Base(cwd)
+			}
+		}
+		entries = []metrics.UsageLog{entry}
+	}
+
+	for i := range entries {
+		if err := metrics.Validate(&entries[i]); err != nil {
+			return err
+		}
+	}
+
+	if usageEndpoint != "" {
+		return postUsage(ctx, usageEndpoint, entries)
+	}
+	logger := usageLogger()
+	for _, entry := range entries {
+		if err := logger.Log(entry); err != nil {
+			return fmt.Errorf("failed to record usage: %w", err)
+		}
+	}
+	return nil
+}
+
+// postUsage sends entries to a usage serve endpoint
+func postUsage(ctx context.Context, endpoint string, entries []metrics.UsageLog) error {
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage entries: %w", err)
+	}
+
+	client := tracedClient
+	url := "http://" + endpoint + "/api/v1/usage"
+	if socket, ok := strings.CutPrefix(endpoint, "unix:"); ok {
+		client = &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}}
+		url = "http://nexus/api/v1/usage"
+	} else if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		url = strings.TrimSuffix(endpoint, "/") + "/api/v1/usage"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send usage to %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("usage endpoint rejected entries (%d): %s", resp.StatusCode, apiErr.Error)
+	}
+	return nil
+}
+
+func runUsageServe(ctx context.Context) error {
+	addr := usageListen
+	if addr == "" {
+		addr = "unix:" + filepath.Join(paths.GetStateDir(paths.GetProjectRoot()), "usage.sock")
+	}
+
+	listener, err := metrics.ListenCapture(addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{
+		Handler:           metrics.NewCaptureHandler(usageLogger()),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("📊 Accepting usage entries on %s\n", addr)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func runUsageImport(agent string) error {
+	project := "."
+	if usageImportAll {
+		project = ""
+	}
+
+	var entries []metrics.UsageLog
+	var sessions int
+	switch agent {
+	case metrics.ClaudeCodeAgent:
+		dir := usageImportFrom
+		if dir == "" {
+			dir = claudeConfigDir()
+		}
+		transcripts, err := metrics.ClaudeTranscripts(dir, project)
+		if err != nil {
+			return err
+		}
+		for _, path := range transcripts {
+			parsed, err := metrics.ParseClaudeTranscriptFile(path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			entries = append(entries, parsed...)
+		}
+		sessions = len(transcripts)
+	case metrics.OpenCodeAgent:
+		dir := usageImportFrom
+		if dir == "" {
+			dir = openCodeStorageDir()
+		}
+		ids, err := metrics.OpenCodeSessions(dir, project)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			parsed, err := metrics.ParseOpenCodeSession(dir, id)
+			if err != nil {
+				return fmt.Errorf("session %s: %w", id, err)
+			}
+			entries = append(entries, parsed...)
+		}
+		sessions = len(ids)
+	default:
+		return fmt.Errorf("unknown agent %q, expected %s or %s", agent, metrics.ClaudeCodeAgent, metrics.OpenCodeAgent)
+	}
+
+	imported, err := usageLogger().Import(entries)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	fmt.Printf("✅ Imported %d new entries from %d %s sessions (%d already recorded)\n",
+		imported, sessions, agent, len(entries)-imported)
+	return nil
+}
+
+// claudeConfigDir is where Claude Code keeps its transcripts
+func claudeConfigDir() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".claude")
+}
+
+// openCodeStorageDir is where OpenCode keeps its sessions
+func openCodeStorageDir() string {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		home, _ := os.UserHomeDir()
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataDir, "opencode", "storage")
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexus/nexus/pkg/lock"
+	"github.com/nexus/nexus/pkg/metrics"
+	"github.com/nexus/nexus/pkg/paths"
+	"github.com/spf13/cobra"
+)
+
+var (
+	usageEffectsSince int
+	usageEffectsCuts  []string
+)
+
+var usageEffectsCmd = &cobra.Command{
+	Use:   "effects [window-days]",
+	Short: "Measure how plugin changes affected usage",
+	Long: `Compare usage in the window (defaults to 14 days) before and after each plugin
+added, updated or removed in nexus.lock's git history over the last --since days.
+
+Each change is compared on all usage and on each of the plugin's skills used on both
+sides, with 95% confidence intervals for the change in success rate, duration and
+tokens. Comparisons with fewer than 30 invocations on a side are flagged.
+
+Use --cut to compare around other dates, e.g. --cut 2026-05-01=new-review-rule.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return runUsageEffects(args)
+	},
+}
+
+func init() {
+	usageEffectsCmd.Flags().IntVar(&usageEffectsSince, "since", 90, "Look for nexus.lock changes this many days back")
+	usageEffectsCmd.Flags().StringArrayVar(&usageEffectsCuts, "cut", nil, "Also compare around this date, as YYYY-MM-DD or YYYY-MM-DD=label (repeatable)")
+	usageCmd.AddCommand(usageEffectsCmd)
+}
+
+// usageReporter is a reporter that knows the project's installed rules,
+// skills and commands, so its insights can point out unused ones. Local
+// templates are installed as the base plugin and agent overrides as the
+// override plugin, as templates.Manager.Merge loads them.
+func usageReporter() *metrics.Reporter {
+	configDir := paths.GetConfigDir(paths.GetProjectRoot())
+	installed, err := metrics.InstalledCapabilities("base", filepath.Join(configDir, "templates"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to list installed capabilities: %v\n", err)
+	}
+	// Glob only fails on a malformed pattern
+	agentDirs, _ := filepath.Glob(filepath.Join(configDir, "agents", "*"))
+	overrides, err := metrics.InstalledCapabilities("override", agentDirs...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to list agent overrides: %v\n", err)
+	}
+	return metrics.NewReporter().WithInstalled(append(installed, overrides...))
+}
+
+func runUsageEffects(args []string) error {
+	if usageTeam {
+		return fmt.Errorf("effects are only measured on this project's usage; drop --team")
+	}
+	window := 14
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 1 {
+			return fmt.Errorf("invalid window days: %s", args[0])
+		}
+		window = parsed
+	}
+
+	cuts, err := parseUsageCuts(usageEffectsCuts)
+	if err != nil {
+		return err
+	}
+	since := time.Now().AddDate(0, 0, -usageEffectsSince)
+	changes, err := lock.NewManager(".").History()
+	if err != nil && len(cuts) == 0 {
+		return fmt.Errorf("failed to read nexus.lock history (use --cut to give dates instead): %w", err)
+	}
+	for _, change := range changes {
+		if change.Time.Before(since) {
+			continue
+		}
+		cuts = append(cuts, metrics.CutPoint{Time: change.Time, Plugin: change.Plugin, Change: describeLockChange(change)})
+	}
+	if len(cuts) == 0 {
+		return fmt.Errorf("no plugin changes in nexus.lock in the last %d days; use --cut to give dates", usageEffectsSince)
+	}
+	sort.SliceStable(cuts, func(i, j int) bool { return cuts[i].Time.Before(cuts[j].Time) })
+
+	report, err := usageReporter().GenerateEffectiveness(usageLogger(), cuts, window)
+	if err != nil {
+		return fmt.Errorf("failed to analyze effects: %w", err)
+	}
+	return renderUsage(fmt.Sprintf("Plugin effects: %d days either side", window), report)
+}
+
+func describeLockChange(change lock.Change) string {
+	switch change.Kind {
+	case lock.ChangeAdded:
+		return "added " + change.To
+	case lock.ChangeRemoved:
+		return "removed " + change.From
+	default:
+		return fmt.Sprintf("updated %s → %s", change.From, change.To)
+	}
+}
+
+// parseUsageCuts reads --cut values, dates in the local time zone with an
+// optional =label
+func parseUsageCuts(values []string) ([]metrics.CutPoint, error) {
+	cuts := make([]metrics.CutPoint, 0, len(values))
+	for _, value := range values {
+		date, label, _ := strings.Cut(value, "=")
+		t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --cut %q (use YYYY-MM-DD or YYYY-MM-DD=label)", value)
+		}
+		if label == "" {
+			label = date
+		}
+		cuts = append(cuts, metrics.CutPoint{Time: t, Plugin: label})
+	}
+	return cuts, nil
+}
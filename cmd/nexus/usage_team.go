@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexus/nexus/pkg/auth"
+	"github.com/nexus/nexus/pkg/config"
+	"github.com/nexus/nexus/pkg/metrics"
+	"github.com/spf13/cobra"
+)
+
+// usageUploadBatchSize keeps each upload well under the server's batch limit
+const usageUploadBatchSize = 500
+
+var (
+	usageTeam                bool
+	usageShareAnonymize      bool
+	usageShareIncludeContext bool
+	usageUploadDays          int
+)
+
+var usageShareCmd = &cobra.Command{
+	Use:   "share <on|off>",
+	Short: "Opt in or out of sharing usage with your team",
+	Long: `Opt in or out of uploading your usage metrics to the team's coordination server.
+
+Nothing is uploaded until you opt in. By default uploads use a per-project pseudonym
+instead of your name and leave out task text, touched files and session IDs. The
+pseudonym is derived from a secret key kept in your user config, so teammates can't
+tell from the team reports which pseudonym is yours. A server that checks member
+tokens does record which member uploads under it.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(_ *cobra.Command, args []string) error {
+		return runUsageShare(args[0])
+	},
+}
+
+var usageUploadCmd = &cobra.Command{
+	Use:   "upload",
+	Short: "Upload usage metrics to the team's coordination server",
+	Long: `Upload this project's usage entries from the last --days days to the coordination
+server in usage.team.server (or $NEXUS_COORD_URL), under usage.team.project.
+
+Requires opting in with 'nexus usage share on'. Entries already uploaded are skipped
+by the server, so it is safe to run repeatedly, e.g. from a hook or cron job.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runUsageUpload(cmd.Context())
+	},
+}
+
+func init() {
+	usageCmd.PersistentFlags().BoolVar(&usageTeam, "team", false, "Report on the team's shared usage from the coordination server")
+	usageCmd.Args = cobra.NoArgs
+	usageCmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		if !usageTeam {
+			return cmd.Help()
+		}
+		return runTeamUsageReport(cmd.Context(), 7, nil)
+	}
+
+	usageShareCmd.Flags().BoolVar(&usageShareAnonymize, "anonymize", true, "Upload under a per-project pseudonym instead of your name")
+	usageShareCmd.Flags().BoolVar(&usageShareIncludeContext, "include-context", false, "Also upload task text, touched files and session IDs")
+	usageUploadCmd.Flags().IntVar(&usageUploadDays, "days", 30, "Upload entries from this many days back")
+
+	usageCmd.AddCommand(usageShareCmd)
+	usageCmd.AddCommand(usageUploadCmd)
+}
+
+func runUsageShare(state string) error {
+	if state != "on" && state != "off" {
+		return fmt.Errorf("expected on or off, got %q", state)
+	}
+
+	path := config.GetUserConfigPath()
+	if err := config.EnsureConfigDirectory(filepath.Dir(path)); err != nil {
+		return err
+	}
+	userCfg, err := config.LoadUserConfig(path)
+	if err != nil {
+		userCfg = &config.UserConfig{}
+	}
+	// The key is kept across opt-outs so the pseudonym stays the same
+	key := userCfg.UsageSharing.PseudonymKey
+	if state == "on" && usageShareAnonymize && key == "" {
+		if key, err = metrics.NewPseudonymKey(); err != nil {
+			return err
+		}
+	}
+	userCfg.UsageSharing = config.UsageSharing{
+		Enabled:        state == "on",
+		Anonymize:      usageShareAnonymize,
+		PseudonymKey:   key,
+		IncludeContext: usageShareIncludeContext,
+	}
+	if err := config.SaveUserConfig(path, userCfg); err != nil {
+		return err
+	}
+
+	if state == "off" {
+		fmt.Println("✅ Usage sharing is off; nothing more will be uploaded")
+		return nil
+	}
+	fmt.Println("✅ Usage sharing is on")
+	if usageShareAnonymize {
+		fmt.Println("   Uploads use a per-project pseudonym instead of your name")
+	}
+	if !usageShareIncludeContext {
+		fmt.Println("   Task text, touched files and session IDs are left out")
+	}
+	fmt.Println("   Run 'nexus usage upload' to share your usage")
+	return nil
+}
+
+func runUsageUpload(ctx context.Context) error {
+	userCfg, _ := config.LoadUserConfig(config.GetUserConfigPath())
+	if userCfg == nil || !userCfg.UsageSharing.Enabled {
+		return fmt.Errorf("usage sharing is off; run 'nexus usage share on' to opt in")
+	}
+	sharing := userCfg.UsageSharing
+
+	client, err := newCoordinationClient()
+	if err != nil {
+		return err
+	}
+	user, err := usageUserID(userCfg)
+	if err != nil {
+		return err
+	}
+	if sharing.Anonymize {
+		if sharing.PseudonymKey == "" {
+			return fmt.Errorf("no pseudonym key in your user config; run 'nexus usage share on' again")
+		}
+		user = metrics.Pseudonym(sharing.PseudonymKey, client.project)
+	}
+
+	entries, err := usageLogger().Query(metrics.Filter{StartTime: time.Now().AddDate(0, 0, -usageUploadDays)})
+	if err != nil {
+		return fmt.Errorf("failed to query logs: %w", err)
+	}
+	if !sharing.IncludeContext {
+		for i := range entries {
+			entries[i] = metrics.StripContext(entries[i])
+		}
+	}
+
+	accepted, duplicates := 0, 0
+	for start := 0; start < len(entries); start += usageUploadBatchSize {
+		end := min(start+usageUploadBatchSize, len(entries))
+		var resp struct {
+			Accepted   int `json:"accepted"`
+			Duplicates int `json:"duplicates"`
+		}
+		uploadErr := client.do(ctx, http.MethodPost, "/api/v1/usage/batches", nil, map[string]interface{}{
+			"user":    user,
+			"project": client.project,
+			"entries": entries[start:end],
+		}, &resp)
+		if uploadErr != nil {
+			return fmt.Errorf("failed to upload usage (%d of %d entries uploaded): %w", start, len(entries), uploadErr)
+		}
+		accepted += resp.Accepted
+		duplicates += resp.Duplicates
+	}
+
+	fmt.Printf("✅ Uploaded %d new entries to %s as %s (%d already uploaded)\n", accepted, client.project, user, duplicates)
+	return nil
+}
+
+// usageUserID is who the user is to the team: their GitHub username, their
+// nexus login or, failing both, their OS user name
+func usageUserID(userCfg *config.UserConfig) (string, error) {
+	if userCfg != nil && userCfg.GitHub.Username != "" {
+		return userCfg.GitHub.Username, nil
+	}
+	if session, err := auth.LoadSession(); err == nil && session.UserID != "" {
+		return session.UserID, nil
+	}
+	if user := os.Getenv("USER"); user != "" {
+		return user, nil
+	}
+	return "", fmt.Errorf("can't tell who you are; run 'nexus login' first")
+}
+
+// coordinationClient talks to the API of a coordination server
+type coordinationClient struct {
+	server  string
+	project string
+	token   string
+}
+
+// newCoordinationClient finds the server and project from the usage.team section
+// of .nexus/config.yaml, falling back to $NEXUS_COORD_URL and the project's
+// name. $NEXUS_AUTH_TOKEN authenticates to servers with auth enabled.
+func newCoordinationClient() (*coordinationClient, error) {
+	client := &coordinationClient{
+		server: os.Getenv("NEXUS_COORD_URL"),
+		token:  os.Getenv("NEXUS_AUTH_TOKEN"),
+	}
+	if cfg, err := config.LoadConfig(".nexus/config.yaml"); err == nil {
+		if cfg.Usage.Team.Server != "" {
+			client.server = cfg.Usage.Team.Server
+		}
+		client.project = cfg.Usage.Team.Project
+		if client.project == "" {
+			client.project = cfg.Name
+		}
+	}
+	if client.server == "" {
+		client.server = "http://localhost:3001"
+	}
+	if client.project == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine project: %w", err)
+		}
+		client.project = filepath.Base(cwd)
+	}
+	client.server = strings.TrimSuffix(client.server, "/")
+	return client, nil
+}
+
+// do sends body as JSON, if there is one, and decodes the response into out
+func (c *coordinationClient) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	reader := bytes.NewReader(nil)
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	target := c.server + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := tracedClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", c.server, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("coordination server returned %d: %s", resp.StatusCode, apiErr.Message)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// runTeamUsageReport prints the team report for the last days, or only the
+// part of it pick returns
+func runTeamUsageReport(ctx context.Context, days int, pick func(*metrics.TeamReport) interface{}) error {
+	client, err := newCoordinationClient()
+	if err != nil {
+		return err
+	}
+	var report metrics.TeamReport
+	query := url.Values{"project": {client.project}, "days": {strconv.Itoa(days)}}
+	if err := client.do(ctx, http.MethodGet, "/api/v1/usage/team", query, nil, &report); err != nil {
+		return err
+	}
+	title := fmt.Sprintf("Team usage for %s: last %d days", client.project, days)
+	if pick != nil {
+		return renderUsage(title, pick(&report))
+	}
+	return renderUsage(title, &report)
+}
+
+func runTeamUsageSummary(ctx context.Context, date string) error {
+	client, err := newCoordinationClient()
+	if err != nil {
+		return err
+	}
+	query := url.Values{"project": {client.project}}
+	if date != "" {
+		query.Set("date", date)
+	}
+	var summary metrics.DailySummary
+	if err := client.do(ctx, http.MethodGet, "/api/v1/usage/team/summary", query, nil, &summary); err != nil {
+		return err
+	}
+	return renderUsage(fmt.Sprintf("Team usage for %s on %s", client.project, summary.Date), &summary)
+}
+
+func runTeamUsageBenchmark(ctx context.Context, baselineDays, currentDays int) error {
+	client, err := newCoordinationClient()
+	if err != nil {
+		return err
+	}
+	query := url.Values{
+		"project":  {client.project},
+		"baseline": {strconv.Itoa(baselineDays)},
+		"current":  {strconv.Itoa(currentDays)},
+	}
+	var comparison metrics.BenchmarkComparison
+	if err := client.do(ctx, http.MethodGet, "/api/v1/usage/team/benchmark", query, nil, &comparison); err != nil {
+		return err
+	}
+	return renderUsage(fmt.Sprintf("Team benchmark for %s: last %d days against the %d before", client.project, currentDays, baselineDays), &comparison)
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/nexus/nexus/pkg/coordination"
 	"github.com/nexus/nexus/pkg/github"
 	"github.com/nexus/nexus/pkg/paths"
+	"github.com/nexus/nexus/pkg/tracing"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var workspaceCmd = &cobra.Command{
@@ -25,14 +27,16 @@ var workspaceCreateCmd = &cobra.Command{
 	Use:   "create <repo>",
 	Short: "Create a workspace from a GitHub repository",
 	Long: `Create a new workspace from a GitHub repository.
-You can specify a repo as owner/repo or a full GitHub URL.
+You can specify a repo as owner/repo, a full GitHub URL, or an SSH URL.
+SSH URLs authenticate with your SSH agent or the key given by --ssh-key.
 
 Examples:
   nexus workspace create torvalds/linux
-  nexus workspace create https://github.com/torvalds/linux`,
+  nexus workspace create https://github.com/torvalds/linux
+  nexus workspace create git@github.com:org/private.git --ssh-key ~/.ssh/deploy_key`,
 	Args: cobra.ExactArgs(1),
-	RunE: func(_ *cobra.Command, args []string) error {
-		return runWorkspaceCreate(args[0])
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWorkspaceCreate(cmd.Context(), args[0])
 	},
 }
 
@@ -69,7 +73,11 @@ var workspaceExecCmd = &cobra.Command{
 	},
 }
 
+var workspaceSSHKey string
+
 func init() {
+	workspaceCreateCmd.Flags().StringVar(&workspaceSSHKey, "ssh-key", "", "Private key (e.g. a deploy key) for SSH repository URLs")
+
 	rootCmd.AddCommand(workspaceCmd)
 	workspaceCmd.AddCommand(workspaceCreateCmd)
 	workspaceCmd.AddCommand(workspaceConnectCmd)
@@ -77,7 +85,7 @@ func init() {
 	workspaceCmd.AddCommand(workspaceExecCmd)
 }
 
-func runWorkspaceCreate(repoString string) error {
+func runWorkspaceCreate(ctx context.Context, repoString string) error {
 	fmt.Println("🚀 Creating workspace from GitHub repository")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
@@ -92,6 +100,9 @@ func runWorkspaceCreate(repoString string) error {
 	fmt.Println("📥 Cloning repository...")
 
 	cloneURL := github.BuildCloneURL(owner, repo)
+	if github.IsSSHURL(repoString) {
+		cloneURL = repoString
+	}
 	tempDir := filepath.Join(os.TempDir(), fmt.Sprintf("nexus-workspace-%d", time.Now().Unix()))
 
 	var token string
@@ -114,7 +125,11 @@ func runWorkspaceCreate(repoString string) error {
 		}
 	}
 
-	if err := github.CloneRepository(cloneURL, tempDir, token); err != nil {
+	auth := github.CloneAuth{Token: token, SSHKeyPath: workspaceSSHKey}
+	_, span := tracing.Start(ctx, "git.clone", attribute.String("repository", owner+"/"+repo))
+	err = github.CloneRepository(cloneURL, tempDir, auth)
+	tracing.End(span, err)
+	if err != nil {
 		return fmt.Errorf("failed to clone repository: %w", err)
 	}
 
@@ -0,0 +1,166 @@
+// Package adapters describes the AI agents nexus generates configuration for.
+// Each agent is an AgentAdapter; built-in agents and those declared by
+// plugins are collected in a Registry.
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nexus/nexus/pkg/plugins"
+)
+
+// Skill layouts
+const (
+	// SkillDirs writes <dir>/<plugin>-<name>/SKILL.md
+	SkillDirs = "skill"
+	// SkillRules writes skills as Cursor rules, <dir>/<plugin>/<name>.mdc
+	SkillRules = "mdc"
+)
+
+// AgentAdapter configures one AI agent
+type AgentAdapter interface {
+	// Name identifies the agent in config and plugin conditions, e.g. "cursor"
+	Name() string
+	// Detect reports whether the agent is installed on this machine
+	Detect() bool
+	// Layout tells where the agent reads its config, rules, skills and commands
+	Layout() Layout
+	// MCPFiles renders MCP servers to the agent's MCP config files
+	MCPFiles(servers map[string]plugins.MCPServer) ([]File, error)
+}
+
+// Layout is where an agent reads generated files, relative to the workspace root
+type Layout struct {
+	// TemplatePath is rendered to OutputPath with the project's template data
+	TemplatePath string
+	OutputPath   string
+	// RulesFormat is "md" or "mdc"; no rules are written when empty
+	RulesFormat  string
+	RulesDir     string
+	SkillsLayout string
+	SkillsDir    string
+	CommandsDir  string
+	// Gitignore lists patterns added to .gitignore for the agent's files
+	Gitignore []string
+}
+
+// File is a generated file, relative to the workspace root
+type File struct {
+	Path string
+	Data []byte
+}
+
+// definitionAdapter is an AgentAdapter described by a plugins.AgentDefinition
+type definitionAdapter struct {
+	def plugins.AgentDefinition
+}
+
+// FromDefinition returns the adapter described by def
+func FromDefinition(def plugins.AgentDefinition) (AgentAdapter, error) {
+	if err := validateDefinition(def); err != nil {
+		return nil, fmt.Errorf("agent %q: %w", def.Name, err)
+	}
+	if def.SkillsDir != "" && def.SkillsLayout == "" {
+		def.SkillsLayout = SkillDirs
+	}
+	return &definitionAdapter{def: def}, nil
+}
+
+func (a *definitionAdapter) Name() string {
+	return a.def.Name
+}
+
+func (a *definitionAdapter) Detect() bool {
+	for _, command := range a.def.Detect.Commands {
+		if _, err := exec.LookPath(command); err == nil {
+			return true
+		}
+	}
+	for _, path := range a.def.Detect.Paths {
+		if strings.HasPrefix(path, "~/") {
+			path = filepath.Join(os.Getenv("HOME"), path[2:])
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	for _, name := range a.def.Detect.Env {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *definitionAdapter) Layout() Layout {
+	return Layout{
+		TemplatePath: a.def.Template,
+		OutputPath:   a.def.Output,
+		RulesFormat:  a.def.RulesFormat,
+		RulesDir:     a.def.RulesDir,
+		SkillsLayout: a.def.SkillsLayout,
+		SkillsDir:    a.def.SkillsDir,
+		CommandsDir:  a.def.CommandsDir,
+		Gitignore:    a.def.Gitignore,
+	}
+}
+
+func (a *definitionAdapter) MCPFiles(servers map[string]plugins.MCPServer) ([]File, error) {
+	var files []File
+	for _, mcp := range a.def.MCP {
+		if len(servers) == 0 && !mcp.Always {
+			continue
+		}
+		file, err := renderMCPFile(mcp, servers)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// validateDefinition rejects definitions that are incomplete or would write
+// outside the workspace
+func validateDefinition(def plugins.AgentDefinition) error {
+	if def.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if (def.Template == "") != (def.Output == "") {
+		return fmt.Errorf("template and output must be set together")
+	}
+	switch def.RulesFormat {
+	case "", "md", "mdc":
+	default:
+		return fmt.Errorf("unknown rules_format %q", def.RulesFormat)
+	}
+	if (def.RulesFormat == "") != (def.RulesDir == "") {
+		return fmt.Errorf("rules_format and rules_dir must be set together")
+	}
+	switch def.SkillsLayout {
+	case "", SkillDirs, SkillRules:
+	default:
+		return fmt.Errorf("unknown skills_layout %q", def.SkillsLayout)
+	}
+
+	paths := []string{def.Template, def.Output, def.RulesDir, def.SkillsDir, def.CommandsDir}
+	for _, mcp := range def.MCP {
+		if _, ok := mcpFormats[mcp.Format]; !ok {
+			return fmt.Errorf("unknown MCP format %q", mcp.Format)
+		}
+		if mcp.Path == "" {
+			return fmt.Errorf("MCP file of format %s has no path", mcp.Format)
+		}
+		paths = append(paths, mcp.Path)
+	}
+	for _, path := range paths {
+		if path != "" && !filepath.IsLocal(path) {
+			return fmt.Errorf("path %q must be relative to the workspace", path)
+		}
+	}
+	return nil
+}
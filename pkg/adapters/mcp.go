@@ -0,0 +1,157 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nexus/nexus/pkg/plugins"
+)
+
+// mcpFormats render servers to a complete MCP config document, by the format
+// name used in AgentMCPFile
+var mcpFormats = map[string]func(map[string]plugins.MCPServer) map[string]interface{}{
+	"mcpServers": func(servers map[string]plugins.MCPServer) map[string]interface{} {
+		return map[string]interface{}{"mcpServers": cursorMCPServers(servers)}
+	},
+	"claude-code": func(servers map[string]plugins.MCPServer) map[string]interface{} {
+		return map[string]interface{}{"mcpServers": claudeCodeMCPServers(servers)}
+	},
+	"claude-desktop": func(servers map[string]plugins.MCPServer) map[string]interface{} {
+		return map[string]interface{}{"mcpServers": claudeDesktopMCPServers(servers)}
+	},
+	"opencode": func(servers map[string]plugins.MCPServer) map[string]interface{} {
+		doc := map[string]interface{}{
+			"$schema": "https://opencode.ai/config.json",
+			"instructions": []string{
+				"AGENTS.md",
+				".opencode/rules/**/*.md",
+				".opencode/skills/**/*.md",
+				".opencode/commands/**/*.md",
+			},
+		}
+		if len(servers) > 0 {
+			doc["mcp"] = openCodeMCPServers(servers)
+		}
+		return doc
+	},
+}
+
+// renderMCPFile renders servers to file in its format
+func renderMCPFile(file plugins.AgentMCPFile, servers map[string]plugins.MCPServer) (File, error) {
+	format, ok := mcpFormats[file.Format]
+	if !ok {
+		return File{}, fmt.Errorf("unknown MCP format %q", file.Format)
+	}
+	data, err := json.MarshalIndent(format(servers), "", "  ")
+	if err != nil {
+		return File{}, err
+	}
+	return File{Path: file.Path, Data: data}, nil
+}
+
+// cursorMCPServers renders servers in the mcpServers format of .cursor/mcp.json
+func cursorMCPServers(servers map[string]plugins.MCPServer) map[string]interface{} {
+	result := make(map[string]interface{}, len(servers))
+	for name, s := range servers {
+		if s.IsRemote() {
+			entry := map[string]interface{}{"url": s.URL}
+			if len(s.Headers) > 0 {
+				entry["headers"] = s.Headers
+			}
+			result[name] = entry
+			continue
+		}
+		result[name] = localMCPEntry(s)
+	}
+	return result
+}
+
+// claudeCodeMCPServers renders servers in the mcpServers format of Claude
+// Code's .mcp.json, which names the transport of every server
+func claudeCodeMCPServers(servers map[string]plugins.MCPServer) map[string]interface{} {
+	result := make(map[string]interface{}, len(servers))
+	for name, s := range servers {
+		if s.IsRemote() {
+			transport := "http"
+			if strings.HasSuffix(strings.TrimSuffix(s.URL, "/"), "/sse") {
+				transport = "sse"
+			}
+			entry := map[string]interface{}{"type": transport, "url": s.URL}
+			if len(s.Headers) > 0 {
+				entry["headers"] = s.Headers
+			}
+			result[name] = entry
+			continue
+		}
+		entry := localMCPEntry(s)
+		entry["type"] = "stdio"
+		result[name] = entry
+	}
+	return result
+}
+
+// claudeDesktopMCPServers renders servers for claude_desktop_config.json.
+// Claude Desktop only starts local servers, so remote servers are bridged
+// through mcp-remote.
+func claudeDesktopMCPServers(servers map[string]plugins.MCPServer) map[string]interface{} {
+	result := make(map[string]interface{}, len(servers))
+	for name, s := range servers {
+		if s.IsRemote() {
+			args := []string{"-y", "mcp-remote", s.URL}
+			for _, key := range sortedKeys(s.Headers) {
+				args = append(args, "--header", key+": "+s.Headers[key])
+			}
+			result[name] = map[string]interface{}{"command": "npx", "args": args}
+			continue
+		}
+		result[name] = localMCPEntry(s)
+	}
+	return result
+}
+
+// openCodeMCPServers renders servers in the mcp format of opencode.json
+func openCodeMCPServers(servers map[string]plugins.MCPServer) map[string]interface{} {
+	result := make(map[string]interface{}, len(servers))
+	for name, s := range servers {
+		if s.IsRemote() {
+			entry := map[string]interface{}{"type": "remote", "url": s.URL, "enabled": true}
+			if len(s.Headers) > 0 {
+				entry["headers"] = s.Headers
+			}
+			result[name] = entry
+			continue
+		}
+		entry := map[string]interface{}{
+			"type":    "local",
+			"command": append([]string{s.Command}, s.Args...),
+			"enabled": true,
+		}
+		if len(s.Env) > 0 {
+			entry["environment"] = s.Env
+		}
+		result[name] = entry
+	}
+	return result
+}
+
+func localMCPEntry(s plugins.MCPServer) map[string]interface{} {
+	entry := map[string]interface{}{"command": s.Command}
+	if len(s.Args) > 0 {
+		entry["args"] = s.Args
+	}
+	if len(s.Env) > 0 {
+		entry["env"] = s.Env
+	}
+	return entry
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,52 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nexus/nexus/pkg/plugins"
+)
+
+var testMCPServers = map[string]plugins.MCPServer{
+	"github": {Command: "npx", Args: []string{"-y", "server-github"}, Env: map[string]string{"GITHUB_TOKEN": "${GITHUB_TOKEN}"}},
+	"docs":   {URL: "https://mcp.example.com/sse", Headers: map[string]string{"Authorization": "Bearer x"}},
+	"search": {URL: "https://search.example.com/mcp"},
+}
+
+func TestCursorMCPServers(t *testing.T) {
+	got := cursorMCPServers(testMCPServers)
+	assert.Equal(t, map[string]interface{}{
+		"command": "npx",
+		"args":    []string{"-y", "server-github"},
+		"env":     map[string]string{"GITHUB_TOKEN": "${GITHUB_TOKEN}"},
+	}, got["github"])
+	assert.Equal(t, map[string]interface{}{"url": "https://search.example.com/mcp"}, got["search"])
+}
+
+func TestClaudeCodeMCPServers(t *testing.T) {
+	got := claudeCodeMCPServers(testMCPServers)
+	assert.Equal(t, "stdio", got["github"].(map[string]interface{})["type"])
+	assert.Equal(t, "sse", got["docs"].(map[string]interface{})["type"])
+	assert.Equal(t, "http", got["search"].(map[string]interface{})["type"])
+}
+
+func TestClaudeDesktopMCPServers(t *testing.T) {
+	got := claudeDesktopMCPServers(testMCPServers)
+	assert.Equal(t, map[string]interface{}{
+		"command": "npx",
+		"args":    []string{"-y", "mcp-remote", "https://mcp.example.com/sse", "--header", "Authorization: Bearer x"},
+	}, got["docs"])
+	assert.Equal(t, "npx", got["github"].(map[string]interface{})["command"])
+}
+
+func TestOpenCodeMCPServers(t *testing.T) {
+	got := openCodeMCPServers(testMCPServers)
+	assert.Equal(t, map[string]interface{}{
+		"type":        "local",
+		"command":     []string{"npx", "-y", "server-github"},
+		"environment": map[string]string{"GITHUB_TOKEN": "${GITHUB_TOKEN}"},
+		"enabled":     true,
+	}, got["github"])
+	assert.Equal(t, map[string]interface{}{"type": "remote", "url": "https://search.example.com/mcp", "enabled": true}, got["search"])
+}
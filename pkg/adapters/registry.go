@@ -0,0 +1,170 @@
+package adapters
+
+import (
+	"fmt"
+
+	"github.com/nexus/nexus/pkg/plugins"
+)
+
+// builtinDefinitions are the agents nexus supports out of the box, in
+// detection order
+var builtinDefinitions = []plugins.AgentDefinition{
+	{
+		Name:         "cursor",
+		Detect:       plugins.AgentDetect{Commands: []string{"cursor"}, Paths: []string{"~/.cursor"}},
+		RulesFormat:  "mdc",
+		RulesDir:     ".cursor/rules",
+		SkillsLayout: SkillRules, // Cursor has no skills; they become rules it attaches on demand
+		SkillsDir:    ".cursor/rules",
+		CommandsDir:  ".cursor/commands",
+		MCP:          []plugins.AgentMCPFile{{Path: ".cursor/mcp.json", Format: "mcpServers"}},
+		Gitignore:    []string{".cursor/"},
+	},
+	{
+		Name:         "opencode",
+		Detect:       plugins.AgentDetect{Commands: []string{"opencode"}},
+		Template:     ".nexus/agents/opencode/opencode.json.tpl",
+		Output:       "opencode.json",
+		RulesFormat:  "md",
+		RulesDir:     ".opencode/rules",
+		SkillsLayout: SkillDirs,
+		SkillsDir:    ".opencode/skills",
+		CommandsDir:  ".opencode/commands",
+		MCP:          []plugins.AgentMCPFile{{Path: "opencode.json", Format: "opencode", Always: true}},
+		Gitignore:    []string{"AGENTS.md"},
+	},
+	{
+		Name:      "claude-desktop",
+		Detect:    plugins.AgentDetect{Paths: []string{"~/Library/Application Support/Claude"}},
+		Template:  ".nexus/agents/claude-desktop/claude_desktop_config.json.tpl",
+		Output:    "claude_desktop_config.json",
+		MCP:       []plugins.AgentMCPFile{{Path: "claude_desktop_config.json", Format: "claude-desktop", Always: true}},
+		Gitignore: []string{"claude_desktop_config.json"},
+	},
+	{
+		Name:         "claude-code",
+		Detect:       plugins.AgentDetect{Commands: []string{"claude"}},
+		Template:     ".nexus/agents/claude-code/claude_code_config.json.tpl",
+		Output:       "claude_code_config.json",
+		SkillsLayout: SkillDirs,
+		SkillsDir:    ".claude/skills",
+		CommandsDir:  ".claude/commands",
+		MCP: []plugins.AgentMCPFile{
+			{Path: "claude_code_config.json", Format: "claude-code", Always: true},
+			// Claude Code reads project-scoped MCP servers from .mcp.json
+			{Path: ".mcp.json", Format: "claude-code"},
+		},
+		Gitignore: []string{"claude_code_config.json"},
+	},
+	{
+		Name:        "codex",
+		Detect:      plugins.AgentDetect{Commands: []string{"codex"}},
+		Template:    ".nexus/agents/codex/settings.json.tpl",
+		Output:      ".vscode/settings.json",
+		RulesFormat: "md",
+		RulesDir:    ".github/instructions",
+		Gitignore:   []string{".vscode/"},
+	},
+}
+
+// Registry holds the known agent adapters in registration order
+type Registry struct {
+	adapters map[string]AgentAdapter
+	order    []string
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{adapters: make(map[string]AgentAdapter)}
+}
+
+// Default returns a registry of the built-in adapters
+func Default() *Registry {
+	r := NewRegistry()
+	for _, def := range builtinDefinitions {
+		adapter, err := FromDefinition(def)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in agent: %v", err))
+		}
+		if err := r.Register(adapter); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register adds an adapter. Names must be unique.
+func (r *Registry) Register(adapter AgentAdapter) error {
+	name := adapter.Name()
+	if _, ok := r.adapters[name]; ok {
+		return fmt.Errorf("agent %s is already registered", name)
+	}
+	r.adapters[name] = adapter
+	r.order = append(r.order, name)
+	return nil
+}
+
+// RegisterDefinitions adds the agents declared in the named plugin's manifest
+func (r *Registry) RegisterDefinitions(plugin string, defs []plugins.AgentDefinition) error {
+	for _, def := range defs {
+		adapter, err := FromDefinition(def)
+		if err != nil {
+			return fmt.Errorf("plugin %s: %w", plugin, err)
+		}
+		if err := r.Register(adapter); err != nil {
+			return fmt.Errorf("plugin %s: %w", plugin, err)
+		}
+	}
+	return nil
+}
+
+// Get returns the adapter with the given name
+func (r *Registry) Get(name string) (AgentAdapter, bool) {
+	adapter, ok := r.adapters[name]
+	return adapter, ok
+}
+
+// All returns every adapter in registration order
+func (r *Registry) All() []AgentAdapter {
+	result := make([]AgentAdapter, 0, len(r.order))
+	for _, name := range r.order {
+		result = append(result, r.adapters[name])
+	}
+	return result
+}
+
+// Detect returns the adapters of the agents installed on this machine
+func (r *Registry) Detect() []AgentAdapter {
+	var detected []AgentAdapter
+	for _, adapter := range r.All() {
+		if adapter.Detect() {
+			detected = append(detected, adapter)
+		}
+	}
+	return detected
+}
+
+// Named returns the adapters with the given names, in registration order.
+// Names the registry doesn't know are skipped.
+func (r *Registry) Named(names []string) []AgentAdapter {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var result []AgentAdapter
+	for _, adapter := range r.All() {
+		if wanted[adapter.Name()] {
+			result = append(result, adapter)
+		}
+	}
+	return result
+}
+
+// Names returns the names of adapters
+func Names(adapters []AgentAdapter) []string {
+	names := make([]string, 0, len(adapters))
+	for _, adapter := range adapters {
+		names = append(names, adapter.Name())
+	}
+	return names
+}
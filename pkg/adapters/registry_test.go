@@ -0,0 +1,122 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nexus/nexus/pkg/plugins"
+)
+
+func TestDefault(t *testing.T) {
+	registry := Default()
+	assert.Equal(t, []string{"cursor", "opencode", "claude-desktop", "claude-code", "codex"}, Names(registry.All()))
+
+	cursor, ok := registry.Get("cursor")
+	require.True(t, ok)
+	assert.Equal(t, Layout{
+		RulesFormat:  "mdc",
+		RulesDir:     ".cursor/rules",
+		SkillsLayout: SkillRules,
+		SkillsDir:    ".cursor/rules",
+		CommandsDir:  ".cursor/commands",
+		Gitignore:    []string{".cursor/"},
+	}, cursor.Layout())
+}
+
+func TestDefault_Detect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake commands are shell scripts")
+	}
+	home, bin := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", bin)
+
+	detected := func() []string {
+		return Names(Default().Detect())
+	}
+	install := func(command string) {
+		require.NoError(t, os.WriteFile(filepath.Join(bin, command), []byte("#!/bin/sh\n"), 0755))
+	}
+
+	assert.Empty(t, detected())
+
+	install("claude")
+	assert.Equal(t, []string{"claude-code"}, detected(), "the claude CLI is Claude Code, not the desktop app")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "Library", "Application Support", "Claude"), 0755))
+	assert.Equal(t, []string{"claude-desktop", "claude-code"}, detected())
+
+	install("codex")
+	assert.Equal(t, []string{"claude-desktop", "claude-code", "codex"}, detected())
+}
+
+func TestRegistry_RegisterDefinitions(t *testing.T) {
+	registry := Default()
+
+	err := registry.RegisterDefinitions("windsurf-support", []plugins.AgentDefinition{{
+		Name:        "windsurf",
+		Detect:      plugins.AgentDetect{Env: []string{"NEXUS_TEST_WINDSURF"}},
+		RulesFormat: "md",
+		RulesDir:    ".windsurf/rules",
+		SkillsDir:   ".windsurf/skills",
+		MCP:         []plugins.AgentMCPFile{{Path: ".windsurf/mcp.json", Format: "mcpServers"}},
+	}})
+	require.NoError(t, err)
+
+	windsurf, ok := registry.Get("windsurf")
+	require.True(t, ok)
+	assert.Equal(t, SkillDirs, windsurf.Layout().SkillsLayout)
+
+	assert.False(t, windsurf.Detect())
+	t.Setenv("NEXUS_TEST_WINDSURF", "1")
+	assert.True(t, windsurf.Detect())
+
+	err = registry.RegisterDefinitions("other", []plugins.AgentDefinition{{Name: "cursor"}})
+	assert.EqualError(t, err, "plugin other: agent cursor is already registered")
+}
+
+func TestFromDefinition_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		def  plugins.AgentDefinition
+		want string
+	}{
+		{"missing name", plugins.AgentDefinition{}, "name is required"},
+		{"template without output", plugins.AgentDefinition{Name: "a", Template: "a.tpl"}, "template and output must be set together"},
+		{"unknown rules format", plugins.AgentDefinition{Name: "a", RulesFormat: "txt", RulesDir: "r"}, `unknown rules_format "txt"`},
+		{"rules dir without format", plugins.AgentDefinition{Name: "a", RulesDir: "r"}, "rules_format and rules_dir must be set together"},
+		{"unknown MCP format", plugins.AgentDefinition{Name: "a", MCP: []plugins.AgentMCPFile{{Path: "m.json", Format: "toml"}}}, `unknown MCP format "toml"`},
+		{"path outside workspace", plugins.AgentDefinition{Name: "a", CommandsDir: "../outside"}, `path "../outside" must be relative to the workspace`},
+		{"absolute path", plugins.AgentDefinition{Name: "a", MCP: []plugins.AgentMCPFile{{Path: "/etc/mcp.json", Format: "mcpServers"}}}, `path "/etc/mcp.json" must be relative to the workspace`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromDefinition(tt.def)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestMCPFiles(t *testing.T) {
+	claudeCode, ok := Default().Get("claude-code")
+	require.True(t, ok)
+
+	files, err := claudeCode.MCPFiles(nil)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "claude_code_config.json", files[0].Path)
+	assert.JSONEq(t, `{"mcpServers": {}}`, string(files[0].Data))
+
+	files, err = claudeCode.MCPFiles(testMCPServers)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, ".mcp.json", files[1].Path)
+	assert.Contains(t, string(files[1].Data), `"type": "stdio"`)
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -63,7 +64,7 @@ func TestExecutorSessionCommand(t *testing.T) {
 		Action: "list",
 	}
 
-	result := executor.ExecuteSessionCommand(cmd)
+	result := executor.ExecuteSessionCommand(context.Background(), cmd)
 	assert.Equal(t, "success", result.Status)
 	assert.NotEmpty(t, result.Output)
 }
@@ -85,7 +86,7 @@ func TestExecutorServiceCommand(t *testing.T) {
 		Action: "list",
 	}
 
-	result := executor.ExecuteServiceCommand(cmd)
+	result := executor.ExecuteServiceCommand(context.Background(), cmd)
 	assert.Equal(t, "success", result.Status)
 	assert.NotEmpty(t, result.Output)
 }
@@ -107,7 +108,7 @@ func TestExecutorSystemCommand(t *testing.T) {
 		Action: "status",
 	}
 
-	result := executor.ExecuteSystemCommand(cmd)
+	result := executor.ExecuteSystemCommand(context.Background(), cmd)
 	assert.Equal(t, "success", result.Status)
 	assert.NotEmpty(t, result.Output)
 }
@@ -50,6 +50,13 @@ func LoadConfig(path string) (NodeConfig, error) {
 	if provider := os.Getenv("NEXUS_PROVIDER"); provider != "" {
 		config.Provider = provider
 	}
+	if level := os.Getenv("NEXUS_LOG_LEVEL"); level != "" {
+		config.Logging.Level = level
+	}
+	if config.Logging.Level == "" {
+		config.Logging.Level = config.LogLevel
+	}
+	config.Tracing.ApplyEnv()
 
 	return config, nil
 }
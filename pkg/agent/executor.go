@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/nexus/nexus/pkg/provider"
@@ -25,7 +25,7 @@ func NewExecutor(agent *Agent) *Executor {
 }
 
 // ExecuteSessionCommand executes session-related commands
-func (e *Executor) ExecuteSessionCommand(cmd Command) CommandResult {
+func (e *Executor) ExecuteSessionCommand(ctx context.Context, cmd Command) CommandResult {
 	start := time.Now()
 	result := CommandResult{
 		ID:      cmd.ID,
@@ -41,17 +41,17 @@ func (e *Executor) ExecuteSessionCommand(cmd Command) CommandResult {
 
 	switch cmd.Action {
 	case "create":
-		return e.createSession(cmd, result)
+		return e.createSession(ctx, cmd, result)
 	case "start":
-		return e.startSession(cmd, result)
+		return e.startSession(ctx, cmd, result)
 	case "stop":
-		return e.stopSessionFunc(cmd, result)
+		return e.stopSessionFunc(ctx, cmd, result)
 	case "destroy":
-		return e.destroySessionFunc(cmd, result)
+		return e.destroySessionFunc(ctx, cmd, result)
 	case "list":
-		return e.listSessionsFunc(cmd, result)
+		return e.listSessionsFunc(ctx, cmd, result)
 	case "exec":
-		return e.execInSessionFunc(cmd, result)
+		return e.execInSessionFunc(ctx, cmd, result)
 	default:
 		result.Status = "failed"
 		result.Error = fmt.Sprintf("unknown session command: %s", cmd.Action)
@@ -60,7 +60,7 @@ func (e *Executor) ExecuteSessionCommand(cmd Command) CommandResult {
 }
 
 // createSession creates a new session
-func (e *Executor) createSession(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) createSession(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	sessionID, ok := cmd.Params["session_id"].(string)
 	if !ok {
 		result.Status = "failed"
@@ -87,7 +87,7 @@ func (e *Executor) createSession(cmd Command, result CommandResult) CommandResul
 		return result
 	}
 
-	session, err := provider.Create(context.Background(), sessionID, workspacePath, nil)
+	session, err := provider.Create(ctx, sessionID, workspacePath, nil)
 	if err != nil {
 		result.Status = "failed"
 		result.Error = fmt.Sprintf("failed to create session: %v", err)
@@ -103,7 +103,7 @@ func (e *Executor) createSession(cmd Command, result CommandResult) CommandResul
 	return result
 }
 
-func (e *Executor) stopSessionFunc(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) stopSessionFunc(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	sessionID, ok := cmd.Params["session_id"].(string)
 	if !ok {
 		result.Status = "failed"
@@ -128,7 +128,7 @@ func (e *Executor) stopSessionFunc(cmd Command, result CommandResult) CommandRes
 		return result
 	}
 
-	if err := provider.Stop(context.Background(), sessionID); err != nil {
+	if err := provider.Stop(ctx, sessionID); err != nil {
 		result.Status = "failed"
 		result.Error = fmt.Sprintf("failed to stop session: %v", err)
 		return result
@@ -144,7 +144,7 @@ func (e *Executor) stopSessionFunc(cmd Command, result CommandResult) CommandRes
 	return result
 }
 
-func (e *Executor) destroySessionFunc(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) destroySessionFunc(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	sessionID, ok := cmd.Params["session_id"].(string)
 	if !ok {
 		result.Status = "failed"
@@ -169,7 +169,7 @@ func (e *Executor) destroySessionFunc(cmd Command, result CommandResult) Command
 		return result
 	}
 
-	if err := provider.Destroy(context.Background(), sessionID); err != nil {
+	if err := provider.Destroy(ctx, sessionID); err != nil {
 		result.Status = "failed"
 		result.Error = fmt.Sprintf("failed to destroy session: %v", err)
 		return result
@@ -185,7 +185,7 @@ func (e *Executor) destroySessionFunc(cmd Command, result CommandResult) Command
 	return result
 }
 
-func (e *Executor) listSessionsFunc(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) listSessionsFunc(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	e.agent.mu.RLock()
 	sessions := e.agent.sessions
 	e.agent.mu.RUnlock()
@@ -205,7 +205,7 @@ func (e *Executor) listSessionsFunc(cmd Command, result CommandResult) CommandRe
 	return result
 }
 
-func (e *Executor) execInSessionFunc(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) execInSessionFunc(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	sessionID, ok := cmd.Params["session_id"].(string)
 	if !ok {
 		result.Status = "failed"
@@ -241,7 +241,7 @@ func (e *Executor) execInSessionFunc(cmd Command, result CommandResult) CommandR
 		cmdParts = []string{commandStr}
 	}
 
-	log.Printf("Executing command %v in session %s", cmdParts, sessionID)
+	slog.InfoContext(ctx, "Executing command", "session_id", sessionID, "command", cmdParts)
 
 	result.Status = "success"
 	result.Output = fmt.Sprintf("Command executed in session %s", sessionID)
@@ -249,7 +249,7 @@ func (e *Executor) execInSessionFunc(cmd Command, result CommandResult) CommandR
 }
 
 // startSession starts an existing session
-func (e *Executor) startSession(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) startSession(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	sessionID, ok := cmd.Params["session_id"].(string)
 	if !ok {
 		result.Status = "failed"
@@ -279,7 +279,7 @@ func (e *Executor) startSession(cmd Command, result CommandResult) CommandResult
 }
 
 // ExecuteServiceCommand executes service-related commands
-func (e *Executor) ExecuteServiceCommand(cmd Command) CommandResult {
+func (e *Executor) ExecuteServiceCommand(ctx context.Context, cmd Command) CommandResult {
 	start := time.Now()
 	result := CommandResult{
 		ID:      cmd.ID,
@@ -295,9 +295,9 @@ func (e *Executor) ExecuteServiceCommand(cmd Command) CommandResult {
 
 	switch cmd.Action {
 	case "list":
-		return e.listServices(cmd, result)
+		return e.listServices(ctx, cmd, result)
 	case "status":
-		return e.getServiceStatus(cmd, result)
+		return e.getServiceStatus(ctx, cmd, result)
 	default:
 		result.Status = "failed"
 		result.Error = fmt.Sprintf("unknown service command: %s", cmd.Action)
@@ -306,7 +306,7 @@ func (e *Executor) ExecuteServiceCommand(cmd Command) CommandResult {
 }
 
 // listServices lists all services
-func (e *Executor) listServices(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) listServices(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	e.agent.mu.RLock()
 	services := e.agent.services
 	e.agent.mu.RUnlock()
@@ -317,7 +317,7 @@ func (e *Executor) listServices(cmd Command, result CommandResult) CommandResult
 }
 
 // getServiceStatus gets the status of a specific service
-func (e *Executor) getServiceStatus(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) getServiceStatus(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	serviceName, ok := cmd.Params["service"].(string)
 	if !ok {
 		result.Status = "failed"
@@ -341,7 +341,7 @@ func (e *Executor) getServiceStatus(cmd Command, result CommandResult) CommandRe
 }
 
 // ExecuteSystemCommand executes system-related commands
-func (e *Executor) ExecuteSystemCommand(cmd Command) CommandResult {
+func (e *Executor) ExecuteSystemCommand(ctx context.Context, cmd Command) CommandResult {
 	start := time.Now()
 	result := CommandResult{
 		ID:      cmd.ID,
@@ -357,11 +357,11 @@ func (e *Executor) ExecuteSystemCommand(cmd Command) CommandResult {
 
 	switch cmd.Action {
 	case "status":
-		return e.getSystemStatus(cmd, result)
+		return e.getSystemStatus(ctx, cmd, result)
 	case "info":
-		return e.getSystemInfo(cmd, result)
+		return e.getSystemInfo(ctx, cmd, result)
 	case "health":
-		return e.getSystemHealth(cmd, result)
+		return e.getSystemHealth(ctx, cmd, result)
 	default:
 		result.Status = "failed"
 		result.Error = fmt.Sprintf("unknown system command: %s", cmd.Action)
@@ -370,12 +370,14 @@ func (e *Executor) ExecuteSystemCommand(cmd Command) CommandResult {
 }
 
 // getSystemStatus gets the system status
-func (e *Executor) getSystemStatus(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) getSystemStatus(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	e.agent.mu.RLock()
 	nodeCopy := *e.agent.node
 	nodeCopy.LastSeen = time.Now()
 	e.agent.mu.RUnlock()
 
+	telemetry, _ := e.agent.sampleTelemetry(ctx, sampleStatus)
+
 	status := map[string]interface{}{
 		"node_id":      nodeCopy.ID,
 		"status":       nodeCopy.Status,
@@ -386,6 +388,7 @@ func (e *Executor) getSystemStatus(cmd Command, result CommandResult) CommandRes
 		"uptime":       time.Since(nodeCopy.CreatedAt).String(),
 		"sessions":     len(e.agent.sessions),
 		"services":     len(e.agent.services),
+		"telemetry":    telemetry,
 	}
 
 	result.Status = "success"
@@ -394,7 +397,7 @@ func (e *Executor) getSystemStatus(cmd Command, result CommandResult) CommandRes
 }
 
 // getSystemInfo gets system information
-func (e *Executor) getSystemInfo(cmd Command, result CommandResult) CommandResult {
+func (e *Executor) getSystemInfo(ctx context.Context, cmd Command, result CommandResult) CommandResult {
 	e.agent.mu.RLock()
 	info := e.agent.node.Metadata
 	e.agent.mu.RUnlock()
@@ -405,23 +408,8 @@ func (e *Executor) getSystemInfo(cmd Command, result CommandResult) CommandResul
 }
 
 // getSystemHealth gets system health information
-func (e *Executor) getSystemHealth(cmd Command, result CommandResult) CommandResult {
-	health := map[string]interface{}{
-		"status":     "healthy",
-		"timestamp":  time.Now(),
-		"node_id":    e.agent.node.ID,
-		"provider":   e.agent.node.Provider,
-		"cpu_usage":  "low",
-		"memory":     "available",
-		"disk_space": "available",
-		"network":    "connected",
-	}
-
-	providersHealth := make(map[string]string)
-	for name := range e.providers {
-		providersHealth[name] = "available"
-	}
-	health["providers"] = providersHealth
+func (e *Executor) getSystemHealth(ctx context.Context, cmd Command, result CommandResult) CommandResult {
+	health := e.agent.healthReport(ctx, sampleStatus)
 
 	result.Status = "success"
 	result.Output = fmt.Sprintf("%+v", health)
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 // HeartbeatService handles periodic heartbeat to coordination server
@@ -20,7 +22,8 @@ func NewHeartbeatService(agent *Agent) *HeartbeatService {
 	return &HeartbeatService{
 		agent: agent,
 		client: &http.Client{
-			Timeout: agent.config.Heartbeat.Timeout,
+			Timeout:   agent.config.Heartbeat.Timeout,
+			Transport: tracing.Transport(nil),
 		},
 	}
 }
@@ -36,7 +39,7 @@ func (h *HeartbeatService) Start(ctx context.Context) {
 			return
 		case <-ticker.C:
 			if err := h.sendHeartbeat(); err != nil {
-				log.Printf("Heartbeat failed: %v", err)
+				slog.Warn("Heartbeat failed", "error", err)
 			}
 		}
 	}
@@ -57,12 +60,16 @@ func (h *HeartbeatService) sendHeartbeat() error {
 	}
 	h.agent.mu.RUnlock()
 
+	telemetry, _ := h.agent.sampleTelemetry(context.Background(), sampleHeartbeat)
+
 	heartbeatData := map[string]interface{}{
-		"last_seen": nodeCopy.LastSeen,
-		"status":    nodeCopy.Status,
-		"services":  servicesCopy,
-		"version":   nodeCopy.Version,
-		"uptime":    time.Since(nodeCopy.CreatedAt).String(),
+		"last_seen":    nodeCopy.LastSeen,
+		"status":       nodeCopy.Status,
+		"services":     servicesCopy,
+		"version":      nodeCopy.Version,
+		"uptime":       time.Since(nodeCopy.CreatedAt).String(),
+		"capabilities": h.agent.capabilities(nodeCopy, telemetry),
+		"metadata":     map[string]interface{}{"telemetry": telemetry},
 	}
 
 	url := fmt.Sprintf("%s/api/v1/nodes/%s/heartbeat", h.agent.config.CoordinationURL, h.agent.node.ID)
@@ -77,7 +84,7 @@ func (h *HeartbeatService) sendHeartbeat() error {
 	}
 
 	// For now, we'll simulate the heartbeat call
-	log.Printf("Heartbeat: %+v", heartbeatData)
+	slog.Debug("Heartbeat", "data", heartbeatData)
 
 	// In a real implementation:
 	// req.Body = io.NopCloser(bytes.NewReader(data))
@@ -98,7 +105,8 @@ func NewStatusReporter(agent *Agent) *StatusReporter {
 	return &StatusReporter{
 		agent: agent,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: tracing.Transport(nil),
 		},
 	}
 }
@@ -125,7 +133,7 @@ func (s *StatusReporter) ReportStatus(status string, message string) error {
 		return fmt.Errorf("failed to marshal status data: %w", err)
 	}
 
-	log.Printf("Status update: %s - %s", status, message)
+	slog.Info("Status update", "status", status, "message", message)
 
 	// In a real implementation, send to coordination server
 	_ = data
@@ -144,7 +152,8 @@ func NewHealthChecker(agent *Agent) *HealthChecker {
 	return &HealthChecker{
 		agent: agent,
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: tracing.Transport(nil),
 		},
 	}
 }
@@ -166,24 +175,35 @@ func (h *HealthChecker) Start(ctx context.Context) {
 
 // checkHealth performs health checks on node and services
 func (h *HealthChecker) checkHealth() {
-	health := map[string]interface{}{
-		"status":     "healthy",
-		"timestamp":  time.Now(),
-		"node_id":    h.agent.node.ID,
-		"provider":   h.agent.node.Provider,
-		"cpu_usage":  "low",
-		"memory":     "available",
-		"disk_space": "available",
-		"network":    "connected",
-	}
+	health := h.agent.healthReport(context.Background(), sampleHealth)
+	slog.Debug("Health check", "health", health)
+
+	// In a real implementation, send to coordination server
+}
+
+// healthReport describes the node's health from a fresh telemetry sample
+// taken for caller
+func (a *Agent) healthReport(ctx context.Context, caller string) map[string]interface{} {
+	telemetry, err := a.sampleTelemetry(ctx, caller)
 
 	providersHealth := make(map[string]string)
-	for name := range h.agent.providers {
+	for name := range a.providers {
 		providersHealth[name] = "available"
+		if _, failed := telemetry.ProviderErrors[name]; failed {
+			providersHealth[name] = "unavailable"
+		}
 	}
-	health["providers"] = providersHealth
-
-	log.Printf("Health check: %+v", health)
 
-	// In a real implementation, send to coordination server
+	health := map[string]interface{}{
+		"status":    telemetry.Health(),
+		"timestamp": telemetry.SampledAt,
+		"node_id":   a.node.ID,
+		"provider":  a.node.Provider,
+		"telemetry": telemetry,
+		"providers": providersHealth,
+	}
+	if err != nil {
+		health["error"] = err.Error()
+	}
+	return health
 }
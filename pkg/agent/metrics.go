@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/nexus/nexus/pkg/prom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// createBuckets suit creating a workspace container, in seconds
+var createBuckets = []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60}
+
+// handlerMetrics are the Prometheus metrics the workspace HTTP handler
+// exposes on /metrics
+type handlerMetrics struct {
+	registry *prometheus.Registry
+	http     *prom.HTTPMetrics
+	create   *prometheus.HistogramVec
+}
+
+func newHandlerMetrics(manager *WorkspaceManager) *handlerMetrics {
+	registry := prometheus.NewRegistry()
+	m := &handlerMetrics{
+		registry: registry,
+		http:     prom.NewHTTPMetrics(registry, "nexus_agent"),
+		create: promauto.With(registry).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "nexus_agent_workspace_create_duration_seconds",
+			Help:    "Time spent creating workspaces, by provider and outcome",
+			Buckets: createBuckets,
+		}, []string{"provider", "outcome"}),
+	}
+
+	registry.MustRegister(prom.NewGaugeFunc("nexus_agent_workspaces", "Workspaces on this node, by status and provider",
+		[]string{"status", "provider"}, func(set func(float64, ...string)) {
+			counts := make(map[[2]string]int)
+			manager.mu.RLock()
+			for _, ws := range manager.workspaces {
+				ws.mu.RLock()
+				counts[[2]string{string(ws.Status), ws.Command.Provider}]++
+				ws.mu.RUnlock()
+			}
+			manager.mu.RUnlock()
+			for key, count := range counts {
+				set(float64(count), key[0], key[1])
+			}
+		}))
+
+	return m
+}
+
+// observeCreate records how long creating a workspace took since start
+func (m *handlerMetrics) observeCreate(provider string, start time.Time, failed bool) {
+	outcome := "success"
+	if failed {
+		outcome = "error"
+	}
+	m.create.WithLabelValues(provider, outcome).Observe(time.Since(start).Seconds())
+}
@@ -4,9 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -14,10 +15,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/nexus/nexus/pkg/logging"
 	"github.com/nexus/nexus/pkg/provider"
 	"github.com/nexus/nexus/pkg/provider/docker"
 	"github.com/nexus/nexus/pkg/provider/lxc"
 	"github.com/nexus/nexus/pkg/provider/qemu"
+	"github.com/nexus/nexus/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Node represents the agent running on a remote machine
@@ -58,7 +62,10 @@ type NodeConfig struct {
 	RetryPolicy     RetryConfig     `yaml:"retry_policy" json:"retry_policy"`
 	OfflineMode     bool            `yaml:"offline_mode" json:"offline_mode"`
 	CacheDir        string          `yaml:"cache_dir" json:"cache_dir"`
-	LogLevel        string          `yaml:"log_level" json:"log_level"`
+	// LogLevel is kept for older configs; Logging.Level takes precedence
+	LogLevel string         `yaml:"log_level" json:"log_level"`
+	Logging  logging.Config `yaml:"logging,omitempty" json:"logging,omitempty"`
+	Tracing  tracing.Config `yaml:"tracing,omitempty" json:"tracing,omitempty"`
 }
 
 type HeartbeatConfig struct {
@@ -83,6 +90,9 @@ type Command struct {
 	Workspace string                 `json:"workspace,omitempty"`
 	Timeout   time.Duration          `json:"timeout,omitempty"`
 	Created   time.Time              `json:"created"`
+	// TraceContext carries the W3C trace context of the request that sent
+	// the command
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 // CommandResult represents the result of command execution
@@ -103,6 +113,7 @@ type Agent struct {
 	config    NodeConfig
 	providers map[string]provider.Provider
 	client    *http.Client
+	telemetry *TelemetrySampler
 
 	// Runtime state
 	running  bool
@@ -143,13 +154,21 @@ func NewAgent(config NodeConfig) (*Agent, error) {
 		node.Provider = config.Provider
 	}
 
+	// Report disk space where workspaces are cached, or of the root filesystem
+	diskPath := config.CacheDir
+	if diskPath == "" {
+		diskPath = "/"
+	}
+
 	agent := &Agent{
 		node:      node,
 		config:    config,
 		providers: make(map[string]provider.Provider),
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: tracing.Transport(nil),
 		},
+		telemetry: NewTelemetrySampler(diskPath),
 		sessions:  make(map[string]*provider.Session),
 		services:  make(map[string]Service),
 		commandCh: make(chan Command, 100),
@@ -169,21 +188,21 @@ func (a *Agent) initProviders() error {
 	if dockerProv, err := docker.NewDockerProvider(); err == nil {
 		a.providers["docker"] = dockerProv
 	} else {
-		log.Printf("Docker provider not available: %v", err)
+		slog.Info("Provider not available", "provider", "docker", "error", err)
 	}
 
 	// Initialize LXC provider
 	if lxcProv, err := lxc.NewLXCProvider(); err == nil {
 		a.providers["lxc"] = lxcProv
 	} else {
-		log.Printf("LXC provider not available: %v", err)
+		slog.Info("Provider not available", "provider", "lxc", "error", err)
 	}
 
 	// Initialize QEMU provider
 	if qemuProv, err := qemu.NewQEMUProvider(); err == nil {
 		a.providers["qemu"] = qemuProv
 	} else {
-		log.Printf("QEMU provider not available: %v", err)
+		slog.Info("Provider not available", "provider", "qemu", "error", err)
 	}
 
 	if len(a.providers) == 0 {
@@ -219,15 +238,15 @@ func (a *Agent) Start(ctx context.Context) error {
 	a.node.Status = "active"
 	a.mu.Unlock()
 
-	log.Printf("Starting node agent %s on %s:%d", a.node.ID, a.node.Host, a.node.Port)
+	slog.Info("Starting node agent", "node_id", a.node.ID, "host", a.node.Host, "port", a.node.Port)
 
 	// Register with coordination server
 	if err := a.registerWithServer(); err != nil {
-		log.Printf("Failed to register with coordination server: %v", err)
+		slog.Warn("Failed to register with coordination server", "error", err)
 		if !a.config.OfflineMode {
 			return fmt.Errorf("registration failed: %w", err)
 		}
-		log.Println("Continuing in offline mode")
+		slog.Info("Continuing in offline mode")
 	}
 
 	// Start background processes
@@ -235,7 +254,7 @@ func (a *Agent) Start(ctx context.Context) error {
 	go a.commandProcessor(ctx)
 	go a.serviceMonitor(ctx)
 
-	log.Printf("Node agent started successfully")
+	slog.Info("Node agent started")
 	return nil
 }
 
@@ -250,21 +269,21 @@ func (a *Agent) Stop(ctx context.Context) error {
 	a.node.Status = "stopped"
 	a.mu.Unlock()
 
-	log.Printf("Stopping node agent %s", a.node.ID)
+	slog.Info("Stopping node agent", "node_id", a.node.ID)
 
 	// Stop all sessions
 	for sessionID := range a.sessions {
 		if err := a.stopSession(sessionID); err != nil {
-			log.Printf("Error stopping session %s: %v", sessionID, err)
+			slog.Warn("Failed to stop session", "session_id", sessionID, "error", err)
 		}
 	}
 
 	// Unregister from coordination server
 	if err := a.unregisterFromServer(); err != nil {
-		log.Printf("Failed to unregister from server: %v", err)
+		slog.Warn("Failed to unregister from coordination server", "error", err)
 	}
 
-	log.Printf("Node agent stopped")
+	slog.Info("Node agent stopped")
 	return nil
 }
 
@@ -301,7 +320,7 @@ func (a *Agent) registerWithServer() error {
 		return fmt.Errorf("registration failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	log.Printf("Successfully registered with coordination server")
+	slog.Info("Registered with coordination server")
 	return nil
 }
 
@@ -332,7 +351,7 @@ func (a *Agent) unregisterFromServer() error {
 		return fmt.Errorf("unregistration failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	log.Printf("Successfully unregistered from coordination server")
+	slog.Info("Unregistered from coordination server")
 	return nil
 }
 
@@ -347,7 +366,7 @@ func (a *Agent) heartbeatLoop(ctx context.Context) {
 			return
 		case <-ticker.C:
 			if err := a.sendHeartbeat(); err != nil {
-				log.Printf("Failed to send heartbeat: %v", err)
+				slog.Warn("Failed to send heartbeat", "error", err)
 			}
 		}
 	}
@@ -364,10 +383,15 @@ func (a *Agent) sendHeartbeat() error {
 	nodeCopy.LastSeen = time.Now()
 	a.mu.RUnlock()
 
+	// Send what could be sampled; a heartbeat matters more than telemetry
+	telemetry, _ := a.sampleTelemetry(context.Background(), sampleHeartbeat)
+
 	data, err := json.Marshal(map[string]interface{}{
-		"last_seen": nodeCopy.LastSeen,
-		"status":    nodeCopy.Status,
-		"services":  a.services,
+		"last_seen":    nodeCopy.LastSeen,
+		"status":       nodeCopy.Status,
+		"services":     a.services,
+		"capabilities": a.capabilities(nodeCopy, telemetry),
+		"metadata":     map[string]interface{}{"telemetry": telemetry},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to marshal heartbeat data: %w", err)
@@ -398,6 +422,23 @@ func (a *Agent) sendHeartbeat() error {
 	return nil
 }
 
+// sampleTelemetry samples the node's resources and workspace usage, measuring
+// CPU since caller's previous sample
+func (a *Agent) sampleTelemetry(ctx context.Context, caller string) (NodeTelemetry, error) {
+	if a.telemetry == nil {
+		return NodeTelemetry{SampledAt: time.Now()}, fmt.Errorf("telemetry is not configured")
+	}
+	return a.telemetry.Sample(ctx, caller, a.providers)
+}
+
+// capabilities describes what the node offers workspaces: its providers and
+// the capacity in telemetry
+func (a *Agent) capabilities(node Node, telemetry NodeTelemetry) map[string]interface{} {
+	capabilities := telemetry.Capacity()
+	capabilities["providers"] = node.Capabilities
+	return capabilities
+}
+
 // commandProcessor processes incoming commands
 func (a *Agent) commandProcessor(ctx context.Context) {
 	for {
@@ -405,25 +446,36 @@ func (a *Agent) commandProcessor(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case cmd := <-a.commandCh:
-			result := a.executeCommand(cmd)
-			if err := a.sendCommandResult(result); err != nil {
-				log.Printf("Failed to send command result: %v", err)
+			// Commands continue the trace of the request that sent them
+			cmdCtx, span := tracing.Start(tracing.Extract(ctx, cmd.TraceContext),
+				fmt.Sprintf("agent.command %s.%s", cmd.Type, cmd.Action),
+				attribute.String("command.id", cmd.ID),
+				attribute.String("session.id", cmd.SessionID))
+			result := a.executeCommand(cmdCtx, cmd)
+			var cmdErr error
+			if result.Status == "failed" {
+				cmdErr = errors.New(result.Error)
 			}
+			if err := a.sendCommandResult(cmdCtx, result); err != nil {
+				slog.WarnContext(cmdCtx, "Failed to send command result", "error", err)
+				cmdErr = errors.Join(cmdErr, err)
+			}
+			tracing.End(span, cmdErr)
 		}
 	}
 }
 
 // executeCommand executes a command and returns the result
-func (a *Agent) executeCommand(cmd Command) CommandResult {
+func (a *Agent) executeCommand(ctx context.Context, cmd Command) CommandResult {
 	executor := NewExecutor(a)
 
 	switch cmd.Type {
 	case "session":
-		return executor.ExecuteSessionCommand(cmd)
+		return executor.ExecuteSessionCommand(ctx, cmd)
 	case "service":
-		return executor.ExecuteServiceCommand(cmd)
+		return executor.ExecuteServiceCommand(ctx, cmd)
 	case "system":
-		return executor.ExecuteSystemCommand(cmd)
+		return executor.ExecuteSystemCommand(ctx, cmd)
 	default:
 		return CommandResult{
 			ID:       cmd.ID,
@@ -437,7 +489,7 @@ func (a *Agent) executeCommand(cmd Command) CommandResult {
 }
 
 // sendCommandResult sends the command result back to the coordination server
-func (a *Agent) sendCommandResult(result CommandResult) error {
+func (a *Agent) sendCommandResult(ctx context.Context, result CommandResult) error {
 	if a.config.CoordinationURL == "" {
 		return nil
 	}
@@ -448,7 +500,7 @@ func (a *Agent) sendCommandResult(result CommandResult) error {
 	}
 
 	url := fmt.Sprintf("%s/api/v1/commands/%s/result", a.config.CoordinationURL, result.ID)
-	req, err := http.NewRequest("POST", url, io.NopCloser(bytes.NewReader(data)))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, io.NopCloser(bytes.NewReader(data)))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -502,7 +554,7 @@ func (a *Agent) updateServiceHealth() {
 		// Check if session is still running by listing sessions
 		sessions, err := provider.List(context.Background())
 		if err != nil {
-			log.Printf("Failed to list sessions for provider %s: %v", session.Provider, err)
+			slog.Warn("Failed to list sessions", "provider", session.Provider, "error", err)
 			continue
 		}
 
@@ -570,13 +622,13 @@ func (a *Agent) stopSession(sessionID string) error {
 	}
 
 	if err := provider.Stop(context.Background(), sessionID); err != nil {
-		log.Printf("Failed to stop session %s: %v", sessionID, err)
+		slog.Warn("Failed to stop session", "session_id", sessionID, "error", err)
 	}
 
 	delete(a.sessions, sessionID)
 	delete(a.services, sessionID)
 
-	log.Printf("Stopped session %s", sessionID)
+	slog.Info("Stopped session", "session_id", sessionID)
 	return nil
 }
 
@@ -787,7 +839,7 @@ func (a *Agent) listSessions(cmd Command, result CommandResult) CommandResult {
 
 func (a *Agent) execInSession(cmd Command, result CommandResult) CommandResult {
 	executor := NewExecutor(a)
-	return executor.execInSessionFunc(cmd, result)
+	return executor.execInSessionFunc(context.Background(), cmd, result)
 }
 
 func (a *Agent) listServices(cmd Command, result CommandResult) CommandResult {
@@ -833,6 +885,8 @@ func (a *Agent) getSystemStatus(cmd Command, result CommandResult) CommandResult
 	nodeCopy.LastSeen = time.Now()
 	a.mu.RUnlock()
 
+	telemetry, _ := a.sampleTelemetry(context.Background(), sampleStatus)
+
 	status := map[string]interface{}{
 		"node_id":      nodeCopy.ID,
 		"status":       nodeCopy.Status,
@@ -843,6 +897,7 @@ func (a *Agent) getSystemStatus(cmd Command, result CommandResult) CommandResult
 		"uptime":       time.Since(nodeCopy.CreatedAt).String(),
 		"sessions":     len(a.sessions),
 		"services":     len(a.services),
+		"telemetry":    telemetry,
 	}
 
 	data, _ := json.Marshal(status)
@@ -863,23 +918,7 @@ func (a *Agent) getSystemInfo(cmd Command, result CommandResult) CommandResult {
 }
 
 func (a *Agent) getSystemHealth(cmd Command, result CommandResult) CommandResult {
-	health := map[string]interface{}{
-		"status":     "healthy",
-		"timestamp":  time.Now(),
-		"node_id":    a.node.ID,
-		"provider":   a.node.Provider,
-		"cpu_usage":  "low",
-		"memory":     "available",
-		"disk_space": "available",
-		"network":    "connected",
-	}
-
-	// Check providers health
-	providersHealth := make(map[string]string)
-	for name := range a.providers {
-		providersHealth[name] = "available"
-	}
-	health["providers"] = providersHealth
+	health := a.healthReport(context.Background(), sampleStatus)
 
 	data, _ := json.Marshal(health)
 	result.Status = "success"
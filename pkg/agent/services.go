@@ -3,10 +3,12 @@ package agent
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 // ServiceManager handles service management on the node
@@ -33,7 +35,7 @@ func (sm *ServiceManager) RegisterService(service Service) error {
 	service.Health = "unknown"
 	sm.services[service.Name] = service
 
-	log.Printf("Registered service: %s", service.Name)
+	slog.Info("Registered service", "service", service.Name)
 
 	return sm.updateServiceInAgent(service.Name, service)
 }
@@ -51,7 +53,7 @@ func (sm *ServiceManager) UnregisterService(name string) error {
 	service.Status = "stopped"
 	service.Health = "stopped"
 
-	log.Printf("Unregistered service: %s", name)
+	slog.Info("Unregistered service", "service", name)
 
 	delete(sm.services, name)
 	return sm.updateServiceInAgent(name, service)
@@ -68,7 +70,7 @@ func (sm *ServiceManager) UpdateService(name string, service Service) error {
 	}
 
 	sm.services[name] = service
-	log.Printf("Updated service: %s", name)
+	slog.Info("Updated service", "service", name)
 
 	return sm.updateServiceInAgent(name, service)
 }
@@ -112,10 +114,10 @@ func (sm *ServiceManager) StartService(name string) error {
 	sm.services[name] = service
 	sm.mu.Unlock()
 
-	log.Printf("Starting service: %s", name)
+	slog.Info("Starting service", "service", name)
 
 	if err := sm.updateServiceInAgent(name, service); err != nil {
-		log.Printf("Failed to update service %s in agent: %v", name, err)
+		slog.Warn("Failed to update service", "service", name, "error", err)
 	}
 
 	sm.mu.Lock()
@@ -140,7 +142,7 @@ func (sm *ServiceManager) StopService(name string) error {
 	sm.services[name] = service
 	sm.mu.Unlock()
 
-	log.Printf("Stopping service: %s", name)
+	slog.Info("Stopping service", "service", name)
 
 	sm.mu.Lock()
 	service.Status = "stopped"
@@ -177,12 +179,12 @@ func (sm *ServiceManager) performHealthCheck(service Service) string {
 		return "no_port"
 	}
 
-	client := &http.Client{Timeout: 5 * time.Second}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(nil)}
 	url := fmt.Sprintf("http://localhost:%d/health", service.Port)
 
 	resp, err := client.Get(url)
 	if err != nil {
-		log.Printf("Health check failed for %s: %v", service.Name, err)
+		slog.Warn("Health check failed", "service", service.Name, "error", err)
 		return "unhealthy"
 	}
 	defer resp.Body.Close()
@@ -237,11 +239,11 @@ func (sd *ServiceDiscovery) DiscoverServices() error {
 		}
 
 		if err := sd.manager.RegisterService(service); err != nil {
-			log.Printf("Failed to register discovered service %s: %v", sessionID, err)
+			slog.Warn("Failed to register discovered service", "session_id", sessionID, "error", err)
 		}
 	}
 
-	log.Printf("Service discovery completed")
+	slog.Info("Service discovery completed")
 	return nil
 }
 
@@ -264,7 +266,7 @@ func (sd *ServiceDiscovery) MonitorServices(ctx context.Context) {
 
 			for name := range services {
 				if err := sd.manager.CheckServiceHealth(name); err != nil {
-					log.Printf("Health check failed for service %s: %v", name, err)
+					slog.Warn("Health check failed", "service", name, "error", err)
 				}
 			}
 		}
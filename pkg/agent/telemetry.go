@@ -0,0 +1,437 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nexus/nexus/pkg/provider"
+)
+
+// Thresholds below which a node reports itself degraded
+const (
+	lowMemoryFraction = 0.10
+	lowDiskFraction   = 0.10
+)
+
+// workspaceStatsTimeout bounds how long providers may take to report usage
+const workspaceStatsTimeout = 5 * time.Second
+
+// NodeTelemetry is a sample of what a node has and is using
+type NodeTelemetry struct {
+	SampledAt  time.Time        `json:"sampled_at"`
+	CPU        CPUTelemetry     `json:"cpu"`
+	Memory     MemoryTelemetry  `json:"memory"`
+	Disk       DiskTelemetry    `json:"disk"`
+	Load       LoadAverage      `json:"load"`
+	Workspaces []WorkspaceUsage `json:"workspaces,omitempty"`
+	// ProviderErrors holds why providers failed to report workspace usage
+	ProviderErrors map[string]string `json:"provider_errors,omitempty"`
+}
+
+// CPUTelemetry is the CPU the node may use and how busy it is
+type CPUTelemetry struct {
+	// Cores is the number of CPUs, or the cgroup quota when that is lower
+	Cores float64 `json:"cores"`
+	// Percent is the share of all CPUs in use since the previous sample
+	Percent float64 `json:"percent"`
+}
+
+// MemoryTelemetry is the node's memory, bounded by its cgroup limit if any
+type MemoryTelemetry struct {
+	TotalBytes     uint64 `json:"total_bytes"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	AvailableBytes uint64 `json:"available_bytes"`
+}
+
+// DiskTelemetry is the space on the filesystem holding Path
+type DiskTelemetry struct {
+	Path       string `json:"path"`
+	TotalBytes uint64 `json:"total_bytes"`
+	FreeBytes  uint64 `json:"free_bytes"`
+}
+
+// LoadAverage is the run queue length averaged over 1, 5 and 15 minutes
+type LoadAverage struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// WorkspaceUsage is what one workspace is using
+type WorkspaceUsage struct {
+	provider.ResourceUsage
+	// CPUPercent is CPU time since the previous sample as a percentage of
+	// one core, like docker stats reports it
+	CPUPercent float64 `json:"cpu_percent"`
+}
+
+// Health is "degraded" when the node is short of memory or disk, and
+// "healthy" otherwise
+func (t NodeTelemetry) Health() string {
+	if t.Memory.TotalBytes > 0 && float64(t.Memory.AvailableBytes) < lowMemoryFraction*float64(t.Memory.TotalBytes) {
+		return "degraded"
+	}
+	if t.Disk.TotalBytes > 0 && float64(t.Disk.FreeBytes) < lowDiskFraction*float64(t.Disk.TotalBytes) {
+		return "degraded"
+	}
+	return "healthy"
+}
+
+// Capacity summarises what the node can offer workspaces, for schedulers
+func (t NodeTelemetry) Capacity() map[string]interface{} {
+	return map[string]interface{}{
+		"cpu_cores":              t.CPU.Cores,
+		"memory_bytes":           t.Memory.TotalBytes,
+		"memory_available_bytes": t.Memory.AvailableBytes,
+		"disk_bytes":             t.Disk.TotalBytes,
+		"disk_free_bytes":        t.Disk.FreeBytes,
+	}
+}
+
+// Callers of Sample. Each keeps its own CPU baseline, so a status command
+// doesn't shorten the interval the next heartbeat measures over.
+const (
+	sampleHeartbeat = "heartbeat"
+	sampleHealth    = "health"
+	sampleStatus    = "status"
+)
+
+// TelemetrySampler samples node resources from /proc and cgroups, and
+// workspace resources from the providers that report them. CPU utilisation
+// is measured between successive samples taken for the same caller; the
+// first covers the time since boot for the node and is zero for workspaces.
+type TelemetrySampler struct {
+	procRoot   string
+	cgroupRoot string
+	diskPath   string
+
+	// mu guards baselines only; readings are taken without it, so a slow
+	// provider doesn't hold up other callers
+	mu        sync.Mutex
+	baselines map[string]*sampleBaseline
+}
+
+// sampleBaseline is the previous sample taken for one caller
+type sampleBaseline struct {
+	cpu        cpuTimes
+	workspaces map[string]workspaceSample
+}
+
+type cpuTimes struct {
+	busy, total uint64
+}
+
+type workspaceSample struct {
+	// provider is the key of the provider that reported the workspace
+	provider   string
+	cpuSeconds float64
+	at         time.Time
+}
+
+// NewTelemetrySampler creates a sampler that reports disk space for the
+// filesystem holding diskPath
+func NewTelemetrySampler(diskPath string) *TelemetrySampler {
+	return newTelemetrySampler("/proc", "/sys/fs/cgroup", diskPath)
+}
+
+func newTelemetrySampler(procRoot, cgroupRoot, diskPath string) *TelemetrySampler {
+	return &TelemetrySampler{
+		procRoot:   procRoot,
+		cgroupRoot: cgroupRoot,
+		diskPath:   diskPath,
+		baselines:  make(map[string]*sampleBaseline),
+	}
+}
+
+// Sample reads the node's resources and the usage of workspaces run by
+// providers. CPU utilisation covers the time since the previous sample for
+// the same caller. Node resources that can't be read are left zero and
+// reported in the error; providers that fail are recorded in ProviderErrors.
+func (s *TelemetrySampler) Sample(ctx context.Context, caller string, providers map[string]provider.Provider) (NodeTelemetry, error) {
+	t := NodeTelemetry{SampledAt: time.Now()}
+	var errs []error
+
+	cores, times, cpuErr := s.readCPU()
+	if cpuErr != nil {
+		errs = append(errs, cpuErr)
+	}
+	stats, providerErrors := s.readWorkspaces(ctx, providers)
+
+	s.mu.Lock()
+	base, ok := s.baselines[caller]
+	if !ok {
+		base = &sampleBaseline{workspaces: make(map[string]workspaceSample)}
+		s.baselines[caller] = base
+	}
+	var percent float64
+	if cpuErr == nil {
+		percent = base.cpuPercent(times)
+	}
+	t.Workspaces = base.workspaceUsage(stats, providerErrors, t.SampledAt)
+	s.mu.Unlock()
+
+	t.CPU = CPUTelemetry{Cores: cores, Percent: percent}
+	t.ProviderErrors = providerErrors
+
+	var err error
+	if t.Memory, err = s.sampleMemory(); err != nil {
+		errs = append(errs, err)
+	}
+	if t.Load, err = s.sampleLoad(); err != nil {
+		errs = append(errs, err)
+	}
+	if t.Disk, err = diskUsage(s.diskPath); err != nil {
+		errs = append(errs, err)
+	}
+	return t, errors.Join(errs...)
+}
+
+// readCPU returns the CPUs the node may use and its CPU times so far
+func (s *TelemetrySampler) readCPU() (float64, cpuTimes, error) {
+	cores := float64(runtime.NumCPU())
+	if quota := s.cgroupCPUQuota(); quota > 0 && quota < cores {
+		cores = quota
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.procRoot, "stat"))
+	if err != nil {
+		return cores, cpuTimes{}, fmt.Errorf("failed to read CPU times: %w", err)
+	}
+	times, err := parseCPUTimes(string(data))
+	if err != nil {
+		return cores, cpuTimes{}, err
+	}
+	return cores, times, nil
+}
+
+// cpuPercent is the node's CPU utilisation since the baseline, which it
+// then moves to times
+func (base *sampleBaseline) cpuPercent(times cpuTimes) float64 {
+	var percent float64
+	if total := times.total - base.cpu.total; total > 0 && times.total > base.cpu.total {
+		percent = 100 * float64(times.busy-base.cpu.busy) / float64(total)
+	}
+	base.cpu = times
+	return round(percent)
+}
+
+// parseCPUTimes reads the aggregate cpu line of /proc/stat. Idle and iowait
+// count as idle time.
+func parseCPUTimes(stat string) (cpuTimes, error) {
+	for _, line := range strings.Split(stat, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		var times cpuTimes
+		for i, field := range fields[1:] {
+			// guest and guest_nice are already counted in user and nice
+			if i >= 8 {
+				break
+			}
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return cpuTimes{}, fmt.Errorf("malformed CPU times %q: %w", line, err)
+			}
+			times.total += value
+			if i != 3 && i != 4 {
+				times.busy += value
+			}
+		}
+		return times, nil
+	}
+	return cpuTimes{}, fmt.Errorf("no cpu line in /proc/stat")
+}
+
+func (s *TelemetrySampler) sampleMemory() (MemoryTelemetry, error) {
+	data, err := os.ReadFile(filepath.Join(s.procRoot, "meminfo"))
+	if err != nil {
+		return MemoryTelemetry{}, fmt.Errorf("failed to read memory info: %w", err)
+	}
+	meminfo := parseMeminfo(string(data))
+	total, available := meminfo["MemTotal"], meminfo["MemAvailable"]
+	mem := MemoryTelemetry{TotalBytes: total, UsedBytes: total - min(available, total)}
+
+	// Inside a container the cgroup limit, not the host, bounds what we get
+	if limit, usage, ok := s.cgroupMemory(); ok && limit < total {
+		mem.TotalBytes = limit
+		mem.UsedBytes = min(usage, limit)
+	}
+	mem.AvailableBytes = mem.TotalBytes - mem.UsedBytes
+	return mem, nil
+}
+
+// parseMeminfo reads /proc/meminfo into bytes by field name
+func parseMeminfo(meminfo string) map[string]uint64 {
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(meminfo, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		values[name] = n
+	}
+	return values
+}
+
+func (s *TelemetrySampler) sampleLoad() (LoadAverage, error) {
+	data, err := os.ReadFile(filepath.Join(s.procRoot, "loadavg"))
+	if err != nil {
+		return LoadAverage{}, fmt.Errorf("failed to read load average: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return LoadAverage{}, fmt.Errorf("malformed load average %q", data)
+	}
+	var load [3]float64
+	for i := range load {
+		if load[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return LoadAverage{}, fmt.Errorf("malformed load average %q: %w", data, err)
+		}
+	}
+	return LoadAverage{Load1: load[0], Load5: load[1], Load15: load[2]}, nil
+}
+
+// cgroupCPUQuota returns the CPUs the agent's cgroup may use, or 0 when it
+// is not limited. cgroup v2 is tried before v1.
+func (s *TelemetrySampler) cgroupCPUQuota() float64 {
+	if data, err := os.ReadFile(filepath.Join(s.cgroupRoot, "cpu.max")); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 && fields[0] != "max" {
+			quota, _ := strconv.ParseFloat(fields[0], 64)
+			period, _ := strconv.ParseFloat(fields[1], 64)
+			if period > 0 {
+				return quota / period
+			}
+		}
+		return 0
+	}
+
+	quota, errQuota := readCgroupInt(filepath.Join(s.cgroupRoot, "cpu", "cpu.cfs_quota_us"))
+	period, errPeriod := readCgroupInt(filepath.Join(s.cgroupRoot, "cpu", "cpu.cfs_period_us"))
+	if errQuota != nil || errPeriod != nil || quota <= 0 || period <= 0 {
+		return 0
+	}
+	return float64(quota) / float64(period)
+}
+
+// cgroupMemory returns the memory limit and usage of the agent's cgroup, and
+// whether it has a limit
+func (s *TelemetrySampler) cgroupMemory() (uint64, uint64, bool) {
+	limitFile, usageFile := filepath.Join(s.cgroupRoot, "memory.max"), filepath.Join(s.cgroupRoot, "memory.current")
+	if _, err := os.Stat(limitFile); err != nil {
+		limitFile = filepath.Join(s.cgroupRoot, "memory", "memory.limit_in_bytes")
+		usageFile = filepath.Join(s.cgroupRoot, "memory", "memory.usage_in_bytes")
+	}
+
+	// v2 writes "max" and v1 a huge number when there is no limit; the
+	// caller ignores limits above physical memory
+	limit, err := readCgroupInt(limitFile)
+	if err != nil || limit <= 0 {
+		return 0, 0, false
+	}
+	usage, err := readCgroupInt(usageFile)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint64(limit), uint64(max(usage, 0)), true
+}
+
+func readCgroupInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// providerStats are the workspaces one provider reported
+type providerStats struct {
+	provider  string
+	resources []provider.ResourceUsage
+}
+
+// readWorkspaces asks each provider that reports stats for its workspaces,
+// in provider order, and returns the errors of those that failed
+func (s *TelemetrySampler) readWorkspaces(ctx context.Context, providers map[string]provider.Provider) ([]providerStats, map[string]string) {
+	ctx, cancel := context.WithTimeout(ctx, workspaceStatsTimeout)
+	defer cancel()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var stats []providerStats
+	var providerErrors map[string]string
+	for _, name := range names {
+		sp, ok := providers[name].(provider.StatsProvider)
+		if !ok {
+			continue
+		}
+		resources, err := sp.Stats(ctx)
+		if err != nil {
+			if providerErrors == nil {
+				providerErrors = make(map[string]string)
+			}
+			providerErrors[name] = err.Error()
+			continue
+		}
+		stats = append(stats, providerStats{provider: name, resources: resources})
+	}
+	return stats, providerErrors
+}
+
+// workspaceUsage is the usage of the workspaces in stats, with their CPU
+// utilisation since the baseline, which it then moves to now. Workspaces
+// that are gone are forgotten, but those of providers that failed keep their
+// baseline for the next sample.
+func (base *sampleBaseline) workspaceUsage(stats []providerStats, providerErrors map[string]string, now time.Time) []WorkspaceUsage {
+	var usage []WorkspaceUsage
+	seen := make(map[string]workspaceSample)
+	for _, ps := range stats {
+		for _, r := range ps.resources {
+			key := r.Provider + "/" + r.SessionID
+			w := WorkspaceUsage{ResourceUsage: r}
+			if last, ok := base.workspaces[key]; ok && now.After(last.at) && r.CPUSeconds >= last.cpuSeconds {
+				w.CPUPercent = round(100 * (r.CPUSeconds - last.cpuSeconds) / now.Sub(last.at).Seconds())
+			}
+			seen[key] = workspaceSample{provider: ps.provider, cpuSeconds: r.CPUSeconds, at: now}
+			usage = append(usage, w)
+		}
+	}
+	for key, last := range base.workspaces {
+		if _, failed := providerErrors[last.provider]; failed {
+			seen[key] = last
+		}
+	}
+	base.workspaces = seen
+	return usage
+}
+
+// round rounds to two decimal places
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
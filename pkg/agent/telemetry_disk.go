@@ -0,0 +1,20 @@
+//go:build !windows
+
+package agent
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func diskUsage(path string) (DiskTelemetry, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return DiskTelemetry{Path: path}, fmt.Errorf("failed to stat filesystem of %s: %w", path, err)
+	}
+	return DiskTelemetry{
+		Path:       path,
+		TotalBytes: fs.Blocks * uint64(fs.Bsize),
+		FreeBytes:  fs.Bavail * uint64(fs.Bsize),
+	}, nil
+}
@@ -0,0 +1,7 @@
+package agent
+
+import "fmt"
+
+func diskUsage(path string) (DiskTelemetry, error) {
+	return DiskTelemetry{Path: path}, fmt.Errorf("disk usage is not supported on windows")
+}
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nexus/nexus/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsProvider is a provider that reports fixed workspace usage
+type statsProvider struct {
+	provider.Provider
+	usage []provider.ResourceUsage
+	err   error
+}
+
+func (p *statsProvider) Stats(ctx context.Context) ([]provider.ResourceUsage, error) {
+	return p.usage, p.err
+}
+
+func writeTelemetryFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestTelemetrySampler_Sample(t *testing.T) {
+	procRoot, cgroupRoot := t.TempDir(), t.TempDir()
+	writeTelemetryFile(t, filepath.Join(procRoot, "stat"), "cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 1 0 1 7 1 0 0 0 0 0\n")
+	writeTelemetryFile(t, filepath.Join(procRoot, "meminfo"), "MemTotal:       8000000 kB\nMemFree:        1000000 kB\nMemAvailable:   6000000 kB\n")
+	writeTelemetryFile(t, filepath.Join(procRoot, "loadavg"), "0.50 0.25 0.10 1/200 12345\n")
+	writeTelemetryFile(t, filepath.Join(cgroupRoot, "cpu.max"), "50000 100000\n")
+	writeTelemetryFile(t, filepath.Join(cgroupRoot, "memory.max"), "max\n")
+
+	workspaces := &statsProvider{usage: []provider.ResourceUsage{
+		{SessionID: "ws1", Provider: "docker", CPUSeconds: 10, MemoryBytes: 1 << 20},
+	}}
+	providers := map[string]provider.Provider{
+		"docker": workspaces,
+		"lxc":    &statsProvider{err: fmt.Errorf("lxc not running")},
+	}
+
+	s := newTelemetrySampler(procRoot, cgroupRoot, t.TempDir())
+	first, err := s.Sample(context.Background(), sampleHeartbeat, providers)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.5, first.CPU.Cores)
+	assert.Equal(t, 20.0, first.CPU.Percent)
+	assert.Equal(t, uint64(8000000*1024), first.Memory.TotalBytes)
+	assert.Equal(t, uint64(6000000*1024), first.Memory.AvailableBytes)
+	assert.Equal(t, uint64(2000000*1024), first.Memory.UsedBytes)
+	assert.Equal(t, LoadAverage{Load1: 0.5, Load5: 0.25, Load15: 0.1}, first.Load)
+	assert.NotZero(t, first.Disk.TotalBytes)
+	require.Len(t, first.Workspaces, 1)
+	assert.Equal(t, "ws1", first.Workspaces[0].SessionID)
+	assert.Zero(t, first.Workspaces[0].CPUPercent)
+	assert.Equal(t, map[string]string{"lxc": "lxc not running"}, first.ProviderErrors)
+	assert.Equal(t, "healthy", first.Health())
+
+	// The second sample measures CPU since the first
+	writeTelemetryFile(t, filepath.Join(procRoot, "stat"), "cpu  250 0 150 800 100 0 0 0 0 0\n")
+	workspaces.usage[0].CPUSeconds = 1e6
+	second, err := s.Sample(context.Background(), sampleHeartbeat, providers)
+	require.NoError(t, err)
+	assert.Equal(t, 66.67, second.CPU.Percent)
+	assert.Greater(t, second.Workspaces[0].CPUPercent, 0.0)
+
+	// Another caller has its own baseline, and sampling for it leaves the
+	// heartbeat's alone
+	status, err := s.Sample(context.Background(), sampleStatus, providers)
+	require.NoError(t, err)
+	assert.Equal(t, 30.77, status.CPU.Percent, "measured since boot")
+	assert.Zero(t, status.Workspaces[0].CPUPercent)
+
+	writeTelemetryFile(t, filepath.Join(procRoot, "stat"), "cpu  350 0 150 900 100 0 0 0 0 0\n")
+	third, err := s.Sample(context.Background(), sampleHeartbeat, providers)
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, third.CPU.Percent)
+
+	// A provider that fails for a round keeps its workspaces' baselines
+	workspaces.err = fmt.Errorf("docker not responding")
+	failed, err := s.Sample(context.Background(), sampleHeartbeat, providers)
+	require.NoError(t, err)
+	assert.Empty(t, failed.Workspaces)
+	workspaces.err = nil
+	workspaces.usage[0].CPUSeconds = 2e6
+	recovered, err := s.Sample(context.Background(), sampleHeartbeat, providers)
+	require.NoError(t, err)
+	require.Len(t, recovered.Workspaces, 1)
+	assert.Greater(t, recovered.Workspaces[0].CPUPercent, 0.0)
+}
+
+// blockingProvider closes called when asked for stats, then reports no
+// workspaces until release is closed
+type blockingProvider struct {
+	provider.Provider
+	called, release chan struct{}
+}
+
+func (p *blockingProvider) Stats(ctx context.Context) ([]provider.ResourceUsage, error) {
+	close(p.called)
+	<-p.release
+	return nil, nil
+}
+
+func TestTelemetrySampler_SlowProviderDoesNotBlockOtherCallers(t *testing.T) {
+	procRoot := t.TempDir()
+	writeTelemetryFile(t, filepath.Join(procRoot, "stat"), "cpu  100 0 100 700 100 0 0 0 0 0\n")
+	writeTelemetryFile(t, filepath.Join(procRoot, "meminfo"), "MemTotal:       8000000 kB\nMemAvailable:   6000000 kB\n")
+	writeTelemetryFile(t, filepath.Join(procRoot, "loadavg"), "0.50 0.25 0.10 1/200 12345\n")
+	s := newTelemetrySampler(procRoot, t.TempDir(), t.TempDir())
+
+	slow := &blockingProvider{called: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Sample(context.Background(), sampleHeartbeat, map[string]provider.Provider{"docker": slow})
+	}()
+	<-slow.called
+
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		s.Sample(context.Background(), sampleStatus, nil)
+	}()
+	select {
+	case <-sampled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("status sample waited for the heartbeat's provider")
+	}
+
+	close(slow.release)
+	<-done
+}
+
+func TestTelemetrySampler_CgroupV1Memory(t *testing.T) {
+	procRoot, cgroupRoot := t.TempDir(), t.TempDir()
+	writeTelemetryFile(t, filepath.Join(procRoot, "meminfo"), "MemTotal: 8000000 kB\nMemAvailable: 6000000 kB\n")
+	writeTelemetryFile(t, filepath.Join(cgroupRoot, "memory", "memory.limit_in_bytes"), "1000000\n")
+	writeTelemetryFile(t, filepath.Join(cgroupRoot, "memory", "memory.usage_in_bytes"), "950000\n")
+	writeTelemetryFile(t, filepath.Join(cgroupRoot, "cpu", "cpu.cfs_quota_us"), "-1\n")
+	writeTelemetryFile(t, filepath.Join(cgroupRoot, "cpu", "cpu.cfs_period_us"), "100000\n")
+
+	s := newTelemetrySampler(procRoot, cgroupRoot, t.TempDir())
+	telemetry, err := s.Sample(context.Background(), sampleHealth, nil)
+	// /proc/stat and loadavg are missing
+	assert.Error(t, err)
+
+	assert.Equal(t, MemoryTelemetry{TotalBytes: 1000000, UsedBytes: 950000, AvailableBytes: 50000}, telemetry.Memory)
+	assert.Zero(t, s.cgroupCPUQuota())
+	assert.Equal(t, "degraded", telemetry.Health())
+}
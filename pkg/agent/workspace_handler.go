@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/nexus/nexus/pkg/prom"
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 type WorkspaceHTTPHandler struct {
@@ -17,6 +20,7 @@ type WorkspaceHTTPHandler struct {
 	mux      *http.ServeMux
 	server   *http.Server
 	listener net.Listener
+	metrics  *handlerMetrics
 	mu       sync.RWMutex
 }
 
@@ -24,13 +28,14 @@ func NewWorkspaceHTTPHandler(manager *WorkspaceManager, port int) *WorkspaceHTTP
 	h := &WorkspaceHTTPHandler{
 		manager: manager,
 		mux:     http.NewServeMux(),
+		metrics: newHandlerMetrics(manager),
 	}
 
 	h.registerRoutes()
 
 	h.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      h.mux,
+		Handler:      tracing.Middleware(h.mux, h.metrics.http.Middleware(h.mux, h.mux)),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -45,6 +50,7 @@ func (h *WorkspaceHTTPHandler) registerRoutes() {
 	h.mux.HandleFunc("/api/v1/workspaces/", h.handleWorkspaceAction)
 	h.mux.HandleFunc("/api/v1/workspaces/status/", h.handleGetWorkspaceStatus)
 	h.mux.HandleFunc("/api/v1/health", h.handleHealth)
+	h.mux.Handle("/metrics", prom.Handler(h.metrics.registry))
 }
 
 func (h *WorkspaceHTTPHandler) Start(ctx context.Context) error {
@@ -59,11 +65,11 @@ func (h *WorkspaceHTTPHandler) Start(ctx context.Context) error {
 
 	go func() {
 		if err := h.server.Serve(listener); err != nil && err != http.ErrServerClosed {
-			log.Printf("Workspace HTTP server error: %v", err)
+			slog.Error("Workspace HTTP server failed", "error", err)
 		}
 	}()
 
-	log.Printf("Workspace HTTP handler listening on %s", h.server.Addr)
+	slog.Info("Workspace HTTP handler listening", "addr", h.server.Addr)
 	return nil
 }
 
@@ -98,7 +104,9 @@ func (h *WorkspaceHTTPHandler) handleCreateWorkspace(w http.ResponseWriter, r *h
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
+	start := time.Now()
 	result, err := h.manager.CreateWorkspace(ctx, &cmd)
+	h.metrics.observeCreate(cmd.Provider, start, err != nil || result.Status == WorkspaceStatusError)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to create workspace: %v", err), http.StatusInternalServerError)
 		return
@@ -112,7 +120,7 @@ func (h *WorkspaceHTTPHandler) handleCreateWorkspace(w http.ResponseWriter, r *h
 	}
 
 	if err := json.NewEncoder(w).Encode(result); err != nil {
-		log.Printf("Failed to encode response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode response", "error", err)
 	}
 }
 
@@ -139,7 +147,7 @@ func (h *WorkspaceHTTPHandler) handleListWorkspaces(w http.ResponseWriter, r *ht
 		"workspaces": workspaces,
 		"count":      len(workspaces),
 	}); err != nil {
-		log.Printf("Failed to encode response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode response", "error", err)
 	}
 }
 
@@ -180,7 +188,7 @@ func (h *WorkspaceHTTPHandler) handleGetWorkspaceStatus(w http.ResponseWriter, r
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(status); err != nil {
-		log.Printf("Failed to encode response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode response", "error", err)
 	}
 }
 
@@ -198,7 +206,7 @@ func (h *WorkspaceHTTPHandler) handleStartServices(w http.ResponseWriter, r *htt
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(statusUpdate); err != nil {
-		log.Printf("Failed to encode response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode response", "error", err)
 	}
 }
 
@@ -216,7 +224,7 @@ func (h *WorkspaceHTTPHandler) handleStopWorkspace(w http.ResponseWriter, r *htt
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(status); err != nil {
-		log.Printf("Failed to encode response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode response", "error", err)
 	}
 }
 
@@ -250,6 +258,6 @@ func (h *WorkspaceHTTPHandler) handleHealth(w http.ResponseWriter, r *http.Reque
 		"timestamp":  time.Now().Format(time.RFC3339),
 		"workspaces": workspaceCount,
 	}); err != nil {
-		log.Printf("Failed to encode response: %v", err)
+		slog.WarnContext(r.Context(), "Failed to encode response", "error", err)
 	}
 }
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspaceHTTPHandler_Metrics(t *testing.T) {
+	wm := createTestWorkspaceManager()
+	wm.workspaces["ws-1"] = &ManagedWorkspace{
+		Command: &CreateWorkspaceCommand{WorkspaceID: "ws-1", Provider: "docker"},
+		Status:  WorkspaceStatusRunning,
+	}
+	h := NewWorkspaceHTTPHandler(wm, 0)
+
+	w := httptest.NewRecorder()
+	h.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	h.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `nexus_agent_workspaces{provider="docker",status="running"} 1`)
+	assert.Contains(t, body, `nexus_agent_http_requests_total{method="GET",route="/api/v1/health",status="200"} 1`)
+}
@@ -3,7 +3,7 @@ package agent
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -241,7 +241,7 @@ echo "SSH configured successfully"
 		Cmd: []string{"netstat", "-tlnp"},
 	}
 	if err := prov.Exec(ctx, workspaceID, verifyCmd); err != nil {
-		log.Printf("Warning: Could not verify SSH is listening, but continuing: %v", err)
+		slog.WarnContext(ctx, "Could not verify SSH is listening, continuing", "workspace_id", workspaceID, "error", err)
 	}
 
 	return nil
@@ -298,7 +298,7 @@ func (wm *WorkspaceManager) StartServices(ctx context.Context, workspaceID strin
 			serviceStatus[svc.Name] = "error"
 			managedSvc.Status = ServiceStatusError
 			managedSvc.ErrorMessage = err.Error()
-			log.Printf("Error starting service %s: %v", svc.Name, err)
+			slog.WarnContext(ctx, "Failed to start service", "workspace_id", workspaceID, "service", svc.Name, "error", err)
 			continue
 		}
 
@@ -350,7 +350,7 @@ func (wm *WorkspaceManager) startServiceWithRetry(ctx context.Context, prov prov
 		}
 
 		lastErr = err
-		log.Printf("Service %s start attempt %d failed: %v", svc.Name, attempt+1, err)
+		slog.DebugContext(ctx, "Service start attempt failed", "workspace_id", workspaceID, "service", svc.Name, "attempt", attempt+1, "error", err)
 	}
 
 	return fmt.Errorf("failed to start service %s after %d attempts: %w", svc.Name, maxRetries, lastErr)
@@ -15,6 +15,8 @@ import (
 	"github.com/go-jose/go-jose/v4"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/oauth2"
+
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 // Authgear OIDC configuration constants
@@ -91,6 +93,7 @@ func NewOIDCProvider(ctx context.Context, config *Config) (*OIDCProvider, error)
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 		}
 	}
+	httpClient.Transport = tracing.Transport(httpClient.Transport)
 
 	provider, err := oidc.NewProvider(ctx, config.Issuer)
 	if err != nil {
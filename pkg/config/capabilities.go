@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nexus/nexus/pkg/adapters"
+	"github.com/nexus/nexus/pkg/generated"
+	"github.com/nexus/nexus/pkg/templates"
+)
+
+// layoutCommand writes commands to <dir>/<plugin>/<name>.md. Skills use the
+// adapters.SkillDirs and adapters.SkillRules layouts.
+const layoutCommand = "command"
+
+// capabilityBodyKeys hold the prompt of a YAML skill or command, in order of preference
+var capabilityBodyKeys = []string{"content", "prompt", "instructions"}
+
+// namespacedTemplates collects the templates selected by kind from each of
+// pluginNames, keyed by "<plugin>/<name>" so plugins cannot collide
+func namespacedTemplates(merged *templates.TemplateData, pluginNames []string, kind func(*templates.PluginData) map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	if merged == nil {
+		return result
+	}
+	for _, pluginName := range pluginNames {
+		plugin, ok := merged.Plugins[pluginName]
+		if !ok || plugin == nil {
+			continue
+		}
+		for name, data := range kind(plugin) {
+			result[pluginName+"/"+name] = data
+		}
+	}
+	return result
+}
+
+// generateAgentCapabilities plans skills or commands, keyed by
+// "<plugin>/<name>", to dir in the given layout
+func generateAgentCapabilities(plan *generated.Plan, worktreePath, dir, layout string, items map[string]interface{}) error {
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data, ok := items[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pluginName, itemName := name, name
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			pluginName, itemName = name[:i], name[i+1:]
+		}
+
+		var outputPath string
+		var frontmatter [][2]string
+		description := capabilityDescription(data, itemName, pluginName)
+		switch layout {
+		case adapters.SkillDirs:
+			skillName := strings.ReplaceAll(pluginName, "/", "-") + "-" + itemName
+			outputPath = filepath.Join(worktreePath, dir, skillName, "SKILL.md")
+			frontmatter = [][2]string{{"name", skillName}, {"description", description}}
+		case adapters.SkillRules:
+			outputPath = filepath.Join(worktreePath, dir, pluginName, itemName+".mdc")
+			frontmatter = [][2]string{
+				{"description", description},
+				{"globs", capabilityGlobs(data["globs"])},
+				{"alwaysApply", "false"},
+			}
+		default:
+			outputPath = filepath.Join(worktreePath, dir, pluginName, itemName+".md")
+			frontmatter = [][2]string{{"description", description}}
+			if hint, ok := data["argument-hint"].(string); ok {
+				frontmatter = append(frontmatter, [2]string{"argument-hint", hint})
+			}
+		}
+
+		body, err := capabilityBody(data)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", name, err)
+		}
+
+		var builder strings.Builder
+		builder.WriteString("---\n")
+		for _, field := range frontmatter {
+			builder.WriteString(fmt.Sprintf("%s: %s\n", field[0], field[1]))
+		}
+		builder.WriteString("---\n")
+		builder.WriteString(body)
+
+		plan.Write(outputPath, []byte(builder.String()))
+	}
+
+	return nil
+}
+
+// capabilityDescription returns the single-line description of a skill or
+// command, which agents use to decide when to load it, quoted for frontmatter
+func capabilityDescription(data map[string]interface{}, name, pluginName string) string {
+	description, _ := data["description"].(string)
+	description = strings.Join(strings.Fields(description), " ")
+	if description == "" {
+		description = fmt.Sprintf("%s from the %s plugin", name, pluginName)
+	}
+	return strconv.Quote(description)
+}
+
+// capabilityGlobs renders a globs field, given as a string or a list, in the
+// comma-separated form Cursor expects
+func capabilityGlobs(value interface{}) string {
+	switch globs := value.(type) {
+	case string:
+		return globs
+	case []interface{}:
+		parts := make([]string, 0, len(globs))
+		for _, glob := range globs {
+			parts = append(parts, fmt.Sprint(glob))
+		}
+		return strings.Join(parts, ",")
+	}
+	return ""
+}
+
+// capabilityBody returns the markdown body of a skill or command. Markdown
+// templates keep their content. YAML definitions use their prompt, or else
+// describe their steps or, failing that, embed the definition itself.
+func capabilityBody(data map[string]interface{}) (string, error) {
+	for _, key := range capabilityBodyKeys {
+		if body, ok := data[key].(string); ok {
+			return stripFrontmatter(body), nil
+		}
+	}
+
+	var builder strings.Builder
+	if description, ok := data["description"].(string); ok && description != "" {
+		builder.WriteString(description + "\n\n")
+	}
+
+	if steps, ok := data["steps"].([]interface{}); ok && len(steps) > 0 {
+		builder.WriteString("Run these steps in order:\n\n")
+		for i, raw := range steps {
+			step, ok := raw.(map[string]interface{})
+			if !ok {
+				builder.WriteString(fmt.Sprintf("%d. %v\n", i+1, raw))
+				continue
+			}
+			title, _ := step["name"].(string)
+			command, _ := step["command"].(string)
+			if title == "" {
+				title = command
+			}
+			builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, title))
+			if command != "" {
+				builder.WriteString(fmt.Sprintf("   ```sh\n   %s\n   ```\n", command))
+			}
+			if condition, ok := step["condition"].(string); ok && condition != "" {
+				builder.WriteString(fmt.Sprintf("   Only when: `%s`\n", condition))
+			}
+		}
+		return builder.String(), nil
+	}
+
+	definition := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if k != "name" && k != "description" {
+			definition[k] = v
+		}
+	}
+	if len(definition) > 0 {
+		out, err := yaml.Marshal(definition)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString("Definition:\n\n```yaml\n")
+		builder.Write(out)
+		builder.WriteString("```\n")
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nexus/nexus/pkg/adapters"
+	"github.com/nexus/nexus/pkg/generated"
+	"github.com/nexus/nexus/pkg/templates"
+)
+
+func TestNamespacedTemplates(t *testing.T) {
+	merged := &templates.TemplateData{
+		Plugins: map[string]*templates.PluginData{
+			"base":   {Commands: map[string]interface{}{"build": map[string]interface{}{"content": "base"}}},
+			"golang": {Commands: map[string]interface{}{"build": map[string]interface{}{"content": "go"}}},
+			"python": {Commands: map[string]interface{}{"lint": map[string]interface{}{"content": "ruff"}}},
+		},
+	}
+
+	commands := namespacedTemplates(merged, []string{"base", "golang"}, func(p *templates.PluginData) map[string]interface{} {
+		return p.Commands
+	})
+
+	assert.Len(t, commands, 2)
+	assert.Contains(t, commands, "base/build")
+	assert.Contains(t, commands, "golang/build")
+	assert.Empty(t, namespacedTemplates(nil, []string{"base"}, func(p *templates.PluginData) map[string]interface{} {
+		return p.Commands
+	}))
+}
+
+func TestGenerateAgentCapabilities(t *testing.T) {
+	items := map[string]interface{}{
+		"golang/review": map[string]interface{}{
+			"description":   "Review Go code",
+			"argument-hint": "[file]",
+			"content":       "Review $ARGUMENTS for idiomatic Go.",
+		},
+		"base/build": map[string]interface{}{
+			"name":        "build",
+			"description": "Build the project",
+			"steps": []interface{}{
+				map[string]interface{}{"name": "Compile", "command": "go build ./...", "condition": "file_exists go.mod"},
+			},
+		},
+		"base/web-search": map[string]interface{}{
+			"name":    "web-search",
+			"globs":   []interface{}{"**/*.go", "go.mod"},
+			"execute": map[string]interface{}{"type": "http"},
+		},
+	}
+
+	t.Run("commands", func(t *testing.T) {
+		dir := t.TempDir()
+		plan := generated.NewPlan(dir)
+		require.NoError(t, generateAgentCapabilities(plan, dir, ".claude/commands", layoutCommand, items))
+
+		review, err := plan.ReadFile(filepath.Join(dir, ".claude/commands/golang/review.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "---\ndescription: \"Review Go code\"\nargument-hint: [file]\n---\nReview $ARGUMENTS for idiomatic Go.", string(review))
+
+		build, err := plan.ReadFile(filepath.Join(dir, ".claude/commands/base/build.md"))
+		require.NoError(t, err)
+		assert.Contains(t, string(build), "1. Compile\n   ```sh\n   go build ./...\n   ```\n")
+		assert.Contains(t, string(build), "Only when: `file_exists go.mod`")
+	})
+
+	t.Run("skills", func(t *testing.T) {
+		dir := t.TempDir()
+		plan := generated.NewPlan(dir)
+		require.NoError(t, generateAgentCapabilities(plan, dir, ".claude/skills", adapters.SkillDirs, items))
+
+		skill, err := plan.ReadFile(filepath.Join(dir, ".claude/skills/base-web-search/SKILL.md"))
+		require.NoError(t, err)
+		assert.Contains(t, string(skill), "name: base-web-search\n")
+		assert.Contains(t, string(skill), "description: \"web-search from the base plugin\"\n")
+		assert.Contains(t, string(skill), "```yaml\nexecute:\n    type: http\n")
+	})
+
+	t.Run("cursor rules", func(t *testing.T) {
+		dir := t.TempDir()
+		plan := generated.NewPlan(dir)
+		require.NoError(t, generateAgentCapabilities(plan, dir, ".cursor/rules", adapters.SkillRules, items))
+
+		rule, err := plan.ReadFile(filepath.Join(dir, ".cursor/rules/base/web-search.mdc"))
+		require.NoError(t, err)
+		assert.Contains(t, string(rule), "globs: **/*.go,go.mod\nalwaysApply: false\n")
+	})
+}
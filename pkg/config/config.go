@@ -2,15 +2,20 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
-	"text/template"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/nexus/nexus/pkg/adapters"
+	"github.com/nexus/nexus/pkg/generated"
+	"github.com/nexus/nexus/pkg/lock"
+	"github.com/nexus/nexus/pkg/paths"
+	"github.com/nexus/nexus/pkg/plugins"
 	"github.com/nexus/nexus/pkg/templates"
 )
 
@@ -46,12 +51,46 @@ type TemplateRepo struct {
 }
 
 type PluginManifest struct {
-	Name        string `yaml:"name"`
-	Version     string `yaml:"version,omitempty"`
-	Description string `yaml:"description,omitempty"`
-	Conditions  []struct {
-		File string `yaml:"file"`
-	} `yaml:"conditions,omitempty"`
+	Name        string             `yaml:"name"`
+	Version     string             `yaml:"version,omitempty"`
+	Description string             `yaml:"description,omitempty"`
+	Conditions  plugins.Conditions `yaml:"conditions,omitempty"`
+}
+
+// CloneConfig controls how a repository is cloned into a workspace
+type CloneConfig struct {
+	// Depth limits history to the last N commits. 0 keeps the default shallow
+	// clone and a negative value fetches the full history.
+	Depth int `yaml:"depth,omitempty" json:"depth,omitempty"`
+	// Ref is a tag, branch or commit SHA to check out instead of the branch tip
+	Ref        string   `yaml:"ref,omitempty" json:"ref,omitempty"`
+	Submodules bool     `yaml:"submodules,omitempty" json:"submodules,omitempty"`
+	LFS        bool     `yaml:"lfs,omitempty" json:"lfs,omitempty"`
+	Sparse     []string `yaml:"sparse,omitempty" json:"sparse,omitempty"`
+	// Filter is a partial clone filter such as "blob:none" or "tree:0"
+	Filter string `yaml:"filter,omitempty" json:"filter,omitempty"`
+}
+
+// UsageConfig controls how long the usage metrics in .nexus/logs/usage are
+// kept
+type UsageConfig struct {
+	// RetentionDays drops usage entries older than this many days; 0 keeps
+	// them all
+	RetentionDays int `yaml:"retention_days,omitempty" json:"retention_days,omitempty"`
+	// CompactAfterDays gzips days of entries older than this. 0 means the
+	// default of a week and a negative value never compacts.
+	CompactAfterDays int `yaml:"compact_after_days,omitempty" json:"compact_after_days,omitempty"`
+	// Team is where members who opt in share their usage
+	Team UsageTeamConfig `yaml:"team,omitempty" json:"team,omitempty"`
+}
+
+// UsageTeamConfig names the coordination server and project team usage is
+// shared under
+type UsageTeamConfig struct {
+	// Server is the coordination server URL; empty means $NEXUS_COORD_URL
+	Server string `yaml:"server,omitempty" json:"server,omitempty"`
+	// Project groups the team's usage; empty means the config's name
+	Project string `yaml:"project,omitempty" json:"project,omitempty"`
 }
 
 type Remote struct {
@@ -67,6 +106,10 @@ type Config struct {
 	Services map[string]Service `yaml:"services"`
 	Extends  []interface{}      `yaml:"extends,omitempty"`
 	Plugins  []interface{}      `yaml:"plugins,omitempty"`
+	// PluginIndex is the catalog searched by nexus plugin search and add
+	PluginIndex string      `yaml:"plugin_index,omitempty"`
+	Clone       CloneConfig `yaml:"clone,omitempty"`
+	Usage       UsageConfig `yaml:"usage,omitempty"`
 
 	Docker struct {
 		Image string   `yaml:"image"`
@@ -106,24 +149,25 @@ func LoadConfig(path string) (*Config, error) {
 	return &cfg, err
 }
 
-func DetectInstalledAgents() []string {
-	var agents []string
-	if _, err := exec.LookPath("cursor"); err == nil {
-		agents = append(agents, "cursor")
-	} else if _, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".cursor")); err == nil {
-		agents = append(agents, "cursor")
-	}
-
-	if _, err := exec.LookPath("opencode"); err == nil {
-		agents = append(agents, "opencode")
-	}
-
-	if _, err := exec.LookPath("claude"); err == nil {
-		agents = append(agents, "claude-desktop")
-		agents = append(agents, "claude-code")
+// ConditionEnvironment detects the installed agents once, so that plugin
+// conditions can be evaluated for every workspace of a run without detecting
+// them again. Agents declared by any plugin in config.yaml count, whether or
+// not that plugin's own conditions match.
+func (c *Config) ConditionEnvironment(baseDir string) (plugins.Environment, error) {
+	registry := adapters.Default()
+	for _, ref := range c.PluginRefs() {
+		manifest, err := findPluginManifest(baseDir, ref.Name)
+		if err != nil {
+			return plugins.Environment{}, err
+		}
+		if manifest == nil {
+			continue
+		}
+		if err := registry.RegisterDefinitions(ref.Name, manifest.Plugin.Agents); err != nil {
+			return plugins.Environment{}, err
+		}
 	}
-
-	return agents
+	return plugins.Environment{Root: baseDir, Agents: adapters.Names(registry.Detect())}, nil
 }
 
 // TODO: Implement plugin initialization
@@ -148,24 +192,88 @@ func DetectInstalledAgents() []string {
 
 // RenderData holds data for template rendering
 type RenderData struct {
-	AuthToken      string
-	ProjectName    string
+	AuthToken   string
+	ProjectName string
+	// Facts describes the workspace the templates are rendered for
+	Facts ProjectFacts
+	// Rules, Skills and Commands are keyed by name; toJson serializes them
+	Rules      map[string]interface{}
+	Skills     map[string]interface{}
+	Commands   map[string]interface{}
+	MCPServers map[string]plugins.MCPServer
+
 	RulesConfig    string // JSON
 	SkillsConfig   string // JSON
 	CommandsConfig string // JSON
+	// MCPServersConfig maps server names to the MCP servers of active plugins
+	MCPServersConfig string // JSON
 }
 
-// GetMergedTemplates returns merged template data from all sources
-func (c *Config) GetMergedTemplates(baseDir string) (*templates.TemplateData, error) {
+// partialsDir holds the partials templates include by name
+var partialsDir = filepath.Join(".nexus", "partials")
+
+// renderTemplatedContent returns a copy of merged in which the rules, skills
+// and commands loaded from .tpl files are rendered with data
+func renderTemplatedContent(renderer *templates.Renderer, merged *templates.TemplateData, data *RenderData) (*templates.TemplateData, error) {
+	if merged == nil {
+		return nil, nil
+	}
+	result := &templates.TemplateData{Plugins: make(map[string]*templates.PluginData, len(merged.Plugins))}
+	for name, plugin := range merged.Plugins {
+		if plugin == nil {
+			continue
+		}
+		rendered := &templates.PluginData{}
+		var err error
+		if rendered.Rules, err = renderTemplatedItems(renderer, plugin.Rules, data); err != nil {
+			return nil, err
+		}
+		if rendered.Skills, err = renderTemplatedItems(renderer, plugin.Skills, data); err != nil {
+			return nil, err
+		}
+		if rendered.Commands, err = renderTemplatedItems(renderer, plugin.Commands, data); err != nil {
+			return nil, err
+		}
+		result.Plugins[name] = rendered
+	}
+	return result, nil
+}
+
+func renderTemplatedItems(renderer *templates.Renderer, items map[string]interface{}, data *RenderData) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(items))
+	for name, raw := range items {
+		item, ok := raw.(map[string]interface{})
+		source, isTemplate := item[templates.TemplateKey].(templates.Source)
+		if !ok || !isTemplate {
+			result[name] = raw
+			continue
+		}
+		content, _ := item["content"].(string)
+		rendered, err := renderer.Render(source, content, data)
+		if err != nil {
+			return nil, err
+		}
+		copied := make(map[string]interface{}, len(item))
+		for k, v := range item {
+			copied[k] = v
+		}
+		delete(copied, templates.TemplateKey)
+		copied["content"] = rendered
+		result[name] = copied
+	}
+	return result, nil
+}
+
+// GetMergedTemplates returns merged template data from all sources. Plugins
+// whose activation conditions do not match baseDir in env are left out.
+func (c *Config) GetMergedTemplates(baseDir string, env plugins.Environment) (*templates.TemplateData, error) {
 	nexusDir := filepath.Join(baseDir, ".nexus")
 	manager := templates.NewManager(nexusDir)
 
 	var enabledPlugins []string
-	for _, p := range c.Plugins {
-		if name, ok := p.(string); ok {
-			if c.isPluginEnabled(baseDir, name) {
-				enabledPlugins = append(enabledPlugins, name)
-			}
+	for _, ref := range c.PluginRefs() {
+		if c.isPluginEnabled(env, baseDir, ref.Name) {
+			enabledPlugins = append(enabledPlugins, ref.Name)
 		}
 	}
 
@@ -176,18 +284,128 @@ func (c *Config) GetMergedTemplates(baseDir string) (*templates.TemplateData, er
 		}
 	}
 
-	return manager.Merge(nexusDir, enabledPlugins, extends)
+	merged, err := manager.Merge(nexusDir, enabledPlugins, extends)
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range merged.Plugins {
+		if name != "base" && name != "override" && !c.isPluginEnabled(env, baseDir, name) {
+			delete(merged.Plugins, name)
+		}
+	}
+
+	return merged, nil
 }
 
-func (c *Config) isPluginEnabled(baseDir, name string) bool {
-	switch name {
-	case "golang":
-		return fileExists(filepath.Join(baseDir, "go.mod")) || fileExists(filepath.Join(baseDir, "go.sum"))
-	case "node":
-		return fileExists(filepath.Join(baseDir, "package.json"))
-	default:
+// isPluginEnabled evaluates the activation conditions of the named plugin
+// against the workspace at baseDir, with the agents detected in env. Plugins
+// without conditions are enabled.
+func (c *Config) isPluginEnabled(env plugins.Environment, baseDir, name string) bool {
+	conditions, err := pluginConditions(baseDir, name)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: plugin %s disabled: %v\n", name, err)
+		return false
+	}
+	if len(conditions) == 0 {
 		return true
 	}
+
+	env.Root = baseDir
+	enabled, err := conditions.Match(env)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: plugin %s disabled: %v\n", name, err)
+		return false
+	}
+	return enabled
+}
+
+// pluginConditions returns the conditions in the named plugin's manifest, or
+// the built-in conditions of well-known plugins when the manifest has none
+func pluginConditions(baseDir, name string) (plugins.Conditions, error) {
+	manifest, err := findPluginManifest(baseDir, name)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil && len(manifest.Plugin.Conditions) > 0 {
+		return manifest.Plugin.Conditions, nil
+	}
+	return plugins.BuiltinConditions[name], nil
+}
+
+// findPluginManifest returns the plugin.yaml of the named plugin, looked up
+// among local plugins, remote repositories and plugins installed from
+// nexus.lock, or nil when the plugin has none
+func findPluginManifest(baseDir, name string) (*plugins.PluginManifest, error) {
+	nexusDir := filepath.Join(baseDir, ".nexus")
+	candidates := []string{
+		filepath.Join(nexusDir, "plugins", name, "plugin.yaml"),
+		filepath.Join(nexusDir, "remotes", name, "plugin.yaml"),
+	}
+	if matches, err := filepath.Glob(filepath.Join(nexusDir, "remotes", "*", name, "plugin.yaml")); err == nil {
+		candidates = append(candidates, matches...)
+	}
+	if lockfile, err := lock.NewManager(baseDir).LoadLockfile(); err == nil {
+		if entry, ok := lockfile.Plugins[name]; ok && entry.Repository != "" {
+			dir := lock.CachePath(paths.GetPluginsCacheDir(baseDir), name, entry)
+			candidates = append(candidates, filepath.Join(dir, "plugin.yaml"))
+		}
+	}
+
+	for _, path := range candidates {
+		manifest, err := plugins.LoadManifest(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		return manifest, nil
+	}
+	return nil, nil
+}
+
+// MCPServers returns the MCP servers declared by the enabled plugins in
+// config.yaml, merged in config order. Plugins declaring the same server
+// name differently are an error.
+func (c *Config) MCPServers(baseDir string, env plugins.Environment) (map[string]plugins.MCPServer, error) {
+	var contributions []plugins.MCPContribution
+	for _, ref := range c.PluginRefs() {
+		if !c.isPluginEnabled(env, baseDir, ref.Name) {
+			continue
+		}
+		manifest, err := findPluginManifest(baseDir, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		if manifest == nil || len(manifest.Plugin.MCPServers) == 0 {
+			continue
+		}
+		contributions = append(contributions, plugins.MCPContribution{Plugin: ref.Name, Servers: manifest.Plugin.MCPServers})
+	}
+	return plugins.MergeMCPServers(contributions)
+}
+
+// AgentRegistry returns the built-in agent adapters plus those declared by the
+// enabled plugins in config.yaml
+func (c *Config) AgentRegistry(baseDir string, env plugins.Environment) (*adapters.Registry, error) {
+	registry := adapters.Default()
+	for _, ref := range c.PluginRefs() {
+		if !c.isPluginEnabled(env, baseDir, ref.Name) {
+			continue
+		}
+		manifest, err := findPluginManifest(baseDir, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		if manifest == nil {
+			continue
+		}
+		if err := registry.RegisterDefinitions(ref.Name, manifest.Plugin.Agents); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
 }
 
 func fileExists(path string) bool {
@@ -195,45 +413,26 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-func (c *Config) GenerateAgentConfigs(worktreePath string, merged *templates.TemplateData) error {
-	// Agent configurations mapping
-	agentConfigs := map[string]struct {
-		templatePath string
-		outputPath   string
-		gitignore    string
-		rulesFormat  string
-		rulesDir     string
-	}{
-		"opencode": {
-			templatePath: ".nexus/agents/opencode/opencode.json.tpl",
-			outputPath:   "opencode.json",
-			gitignore:    "AGENTS.md",
-			rulesFormat:  "md",
-			rulesDir:     ".opencode/rules",
-		},
-		"claude-desktop": {
-			templatePath: ".nexus/agents/claude-desktop/claude_desktop_config.json.tpl",
-			outputPath:   "claude_desktop_config.json",
-			gitignore:    "claude_desktop_config.json",
-		},
-		"claude-code": {
-			templatePath: ".nexus/agents/claude-code/claude_code_config.json.tpl",
-			outputPath:   "claude_code_config.json",
-			gitignore:    "claude_code_config.json",
-		},
-		"codex": {
-			templatePath: ".nexus/agents/codex/settings.json.tpl",
-			outputPath:   ".vscode/settings.json",
-			gitignore:    ".vscode/",
-			rulesFormat:  "md",
-			rulesDir:     ".github/instructions",
-		},
-		"cursor": {
-			// MCP removed - cursor only generates rules now
-			gitignore:   ".cursor/",
-			rulesFormat: "mdc",
-			rulesDir:    ".cursor/rules",
-		},
+// GenerateAgentConfigs plans the agent configs, rules, skills and commands of
+// the agents detected in env in the workspace at worktreePath. Nothing is written
+// until the plan is applied.
+func (c *Config) GenerateAgentConfigs(plan *generated.Plan, worktreePath string, merged *templates.TemplateData, env plugins.Environment) error {
+	mcpServers, mcpErr := c.MCPServers(worktreePath, env)
+	if mcpErr != nil {
+		return fmt.Errorf("failed to merge plugin MCP servers: %w", mcpErr)
+	}
+
+	renderData := RenderData{
+		AuthToken:   generateAuthToken(),
+		ProjectName: c.Name,
+		Facts:       c.Facts(worktreePath),
+		MCPServers:  mcpServers,
+	}
+	renderer := templates.NewRenderer(partialsDir)
+
+	merged, renderErr := renderTemplatedContent(renderer, merged, &renderData)
+	if renderErr != nil {
+		return fmt.Errorf("failed to render templates: %w", renderErr)
 	}
 
 	// Collect merged data from specified plugins
@@ -242,10 +441,8 @@ func (c *Config) GenerateAgentConfigs(worktreePath string, merged *templates.Tem
 	finalCommands := make(map[string]interface{})
 
 	relevantPlugins := []string{"base", "override"}
-	for _, plugin := range c.Plugins {
-		if name, ok := plugin.(string); ok {
-			relevantPlugins = append(relevantPlugins, name)
-		}
+	for _, ref := range c.PluginRefs() {
+		relevantPlugins = append(relevantPlugins, ref.Name)
 	}
 
 	if merged != nil {
@@ -254,6 +451,12 @@ func (c *Config) GenerateAgentConfigs(worktreePath string, merged *templates.Tem
 				for k, v := range plugin.Rules {
 					finalRules[k] = v
 				}
+				for k, v := range plugin.Skills {
+					finalSkills[k] = v
+				}
+				for k, v := range plugin.Commands {
+					finalCommands[k] = v
+				}
 			}
 		}
 	}
@@ -263,13 +466,15 @@ func (c *Config) GenerateAgentConfigs(worktreePath string, merged *templates.Tem
 	skillsJSON, _ := json.Marshal(finalSkills)
 	commandsJSON, _ := json.Marshal(finalCommands)
 
-	renderData := RenderData{
-		AuthToken:      generateAuthToken(),
-		ProjectName:    c.Name,
-		RulesConfig:    string(rulesJSON),
-		SkillsConfig:   string(skillsJSON),
-		CommandsConfig: string(commandsJSON),
-	}
+	mcpJSON, _ := json.Marshal(mcpServers)
+
+	renderData.Rules = finalRules
+	renderData.Skills = finalSkills
+	renderData.Commands = finalCommands
+	renderData.RulesConfig = string(rulesJSON)
+	renderData.SkillsConfig = string(skillsJSON)
+	renderData.CommandsConfig = string(commandsJSON)
+	renderData.MCPServersConfig = string(mcpJSON)
 
 	var gitignorePatterns []string
 
@@ -282,50 +487,31 @@ func (c *Config) GenerateAgentConfigs(worktreePath string, merged *templates.Tem
 				},
 			},
 		}
-		if err := c.generateAgentRules(worktreePath, "agents.md", "", tempMerged); err != nil {
+		if err := c.generateAgentRules(plan, worktreePath, "agents.md", "", tempMerged); err != nil {
 			return fmt.Errorf("failed to generate AGENTS.md: %w", err)
 		}
 		gitignorePatterns = append(gitignorePatterns, "AGENTS.md")
 	}
 
-	detectedAgents := DetectInstalledAgents()
-	for _, agentName := range detectedAgents {
-		cfg, ok := agentConfigs[agentName]
-		if !ok {
-			continue
-		}
-
-		// Skip agents that don't have a template (like cursor which only generates rules)
-		if cfg.templatePath == "" {
-			continue
-		}
-
-		templateContent, err := os.ReadFile(cfg.templatePath)
-		if err != nil {
-			return fmt.Errorf("failed to read template %s: %w", cfg.templatePath, err)
-		}
-
-		tmpl, err := template.New("template").Parse(string(templateContent))
-		if err != nil {
-			return fmt.Errorf("failed to parse template for %s: %w", agentName, err)
-		}
-
-		var result strings.Builder
-		if err := tmpl.Execute(&result, renderData); err != nil {
-			return fmt.Errorf("failed to execute template for %s: %w", agentName, err)
-		}
-		rendered := result.String()
-
-		outputPath := filepath.Join(worktreePath, cfg.outputPath)
-		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-			return fmt.Errorf("failed to create dir for %s: %w", outputPath, err)
-		}
+	registry, registryErr := c.AgentRegistry(worktreePath, env)
+	if registryErr != nil {
+		return fmt.Errorf("failed to load agent adapters: %w", registryErr)
+	}
+	for _, adapter := range registry.Named(env.Agents) {
+		agentName := adapter.Name()
+		layout := adapter.Layout()
+
+		// Agents without a template (like cursor) only get rules, skills and commands
+		if layout.TemplatePath != "" {
+			rendered, err := renderer.RenderFile(layout.TemplatePath, renderData)
+			if err != nil {
+				return fmt.Errorf("failed to render template for %s: %w", agentName, err)
+			}
 
-		if err := os.WriteFile(outputPath, []byte(rendered), 0644); err != nil {
-			return fmt.Errorf("failed to write config for %s: %w", agentName, err)
+			plan.Write(filepath.Join(worktreePath, layout.OutputPath), []byte(rendered))
 		}
 
-		if cfg.rulesFormat != "" {
+		if layout.RulesFormat != "" {
 			agentRules := make(map[string]interface{})
 
 			// Merge rules from relevant plugins
@@ -378,44 +564,55 @@ func (c *Config) GenerateAgentConfigs(worktreePath string, merged *templates.Tem
 						},
 					},
 				}
-				if err := c.generateAgentRules(worktreePath, cfg.rulesFormat, cfg.rulesDir, tempAgentMerged); err != nil {
+				if err := c.generateAgentRules(plan, worktreePath, layout.RulesFormat, layout.RulesDir, tempAgentMerged); err != nil {
 					return fmt.Errorf("failed to generate rules for %s: %w", agentName, err)
 				}
 			}
 		}
 
-		gitignorePatterns = append(gitignorePatterns, cfg.gitignore)
+		if layout.SkillsDir != "" {
+			agentSkills := namespacedTemplates(merged, relevantPlugins, func(p *templates.PluginData) map[string]interface{} { return p.Skills })
+			if err := generateAgentCapabilities(plan, worktreePath, layout.SkillsDir, layout.SkillsLayout, agentSkills); err != nil {
+				return fmt.Errorf("failed to generate skills for %s: %w", agentName, err)
+			}
+		}
+		if layout.CommandsDir != "" {
+			agentCommands := namespacedTemplates(merged, relevantPlugins, func(p *templates.PluginData) map[string]interface{} { return p.Commands })
+			if err := generateAgentCapabilities(plan, worktreePath, layout.CommandsDir, layoutCommand, agentCommands); err != nil {
+				return fmt.Errorf("failed to generate commands for %s: %w", agentName, err)
+			}
+		}
+
+		gitignorePatterns = append(gitignorePatterns, layout.Gitignore...)
 	}
 
 	// Update .gitignore with generated patterns
-	if err := updateGitignore(gitignorePatterns); err != nil {
-		return fmt.Errorf("failed to update .gitignore: %w", err)
-	}
+	plan.Gitignore(gitignorePatterns...)
 
 	return nil
 }
 
-func (c *Config) generateAgentRules(worktreePath, format, rulesDir string, merged *templates.TemplateData) error {
+func (c *Config) generateAgentRules(plan *generated.Plan, worktreePath, format, rulesDir string, merged *templates.TemplateData) error {
 	if merged == nil || len(merged.Plugins) == 0 {
 		return nil
 	}
 
 	absRulesDir := filepath.Join(worktreePath, rulesDir)
-	if rulesDir != "" {
-		if err := os.MkdirAll(absRulesDir, 0755); err != nil {
-			return err
-		}
+
+	// Plugins and rules are visited in sorted order so output is stable
+	pluginNames := make([]string, 0, len(merged.Plugins))
+	for name := range merged.Plugins {
+		pluginNames = append(pluginNames, name)
 	}
+	sort.Strings(pluginNames)
 
 	switch format {
 	case "mdc", "md":
 		extension := "." + format
-		if format == "md" {
-			extension = ".md"
-		}
-		for _, plugin := range merged.Plugins {
-			for name, data := range plugin.Rules {
-				ruleMap, ok := data.(map[string]interface{})
+		for _, pluginName := range pluginNames {
+			plugin := merged.Plugins[pluginName]
+			for _, name := range sortedMapKeys(plugin.Rules) {
+				ruleMap, ok := plugin.Rules[name].(map[string]interface{})
 				if !ok {
 					continue
 				}
@@ -425,22 +622,16 @@ func (c *Config) generateAgentRules(worktreePath, format, rulesDir string, merge
 
 				var builder strings.Builder
 				builder.WriteString("---\n")
-				for k, v := range ruleMap {
+				for _, k := range sortedMapKeys(ruleMap) {
 					if k == "content" {
 						continue
 					}
-					builder.WriteString(fmt.Sprintf("%s: %v\n", k, v))
+					builder.WriteString(fmt.Sprintf("%s: %v\n", k, ruleMap[k]))
 				}
 				builder.WriteString("---\n")
 				builder.WriteString(content)
 
-				outputPath := filepath.Join(absRulesDir, name+extension)
-				if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-					return err
-				}
-				if err := os.WriteFile(outputPath, []byte(builder.String()), 0644); err != nil {
-					return err
-				}
+				plan.Write(filepath.Join(absRulesDir, name+extension), []byte(builder.String()))
 			}
 		}
 	case "agents.md":
@@ -448,9 +639,10 @@ func (c *Config) generateAgentRules(worktreePath, format, rulesDir string, merge
 		builder.WriteString("# PROJECT KNOWLEDGE BASE\n\n")
 		builder.WriteString(fmt.Sprintf("**Generated:** %s\n", strings.ToUpper(c.Name)))
 		builder.WriteString("\n")
-		for _, plugin := range merged.Plugins {
-			for _, data := range plugin.Rules {
-				ruleMap, ok := data.(map[string]interface{})
+		for _, pluginName := range pluginNames {
+			plugin := merged.Plugins[pluginName]
+			for _, name := range sortedMapKeys(plugin.Rules) {
+				ruleMap, ok := plugin.Rules[name].(map[string]interface{})
 				if !ok {
 					continue
 				}
@@ -459,14 +651,20 @@ func (c *Config) generateAgentRules(worktreePath, format, rulesDir string, merge
 				builder.WriteString("\n\n")
 			}
 		}
-		outputPath := filepath.Join(worktreePath, "AGENTS.md")
-		if err := os.WriteFile(outputPath, []byte(builder.String()), 0644); err != nil {
-			return err
-		}
+		plan.Write(filepath.Join(worktreePath, "AGENTS.md"), []byte(builder.String()))
 	}
 	return nil
 }
 
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // stripFrontmatter removes YAML frontmatter delimiters from markdown content
 func stripFrontmatter(content string) string {
 	if !strings.HasPrefix(content, "---\n") && !strings.HasPrefix(content, "---\r\n") {
@@ -485,42 +683,6 @@ func generateAuthToken() string {
 	return "random-token-12345" // Simplified for now
 }
 
-// updateGitignore adds patterns to .gitignore if not already present
-func updateGitignore(patterns []string) error {
-	gitignorePath := ".gitignore"
-	var existing []string
-
-	if content, err := os.ReadFile(gitignorePath); err == nil {
-		existing = strings.Split(strings.TrimSpace(string(content)), "\n")
-	}
-
-	var cleaned []string
-	for _, line := range existing {
-		if strings.TrimSpace(line) != "" {
-			cleaned = append(cleaned, line)
-		}
-	}
-	existing = cleaned
-
-	// Add missing patterns
-	for _, pattern := range patterns {
-		found := false
-		for _, line := range existing {
-			if strings.TrimSpace(line) == pattern {
-				found = true
-				break
-			}
-		}
-		if !found {
-			existing = append(existing, pattern)
-		}
-	}
-
-	// Write back
-	newContent := strings.Join(existing, "\n") + "\n"
-	return os.WriteFile(gitignorePath, []byte(newContent), 0644)
-}
-
 // GenerateJSONSchema generates a JSON schema for the Config struct
 func GenerateJSONSchema() (string, error) {
 	schema := map[string]interface{}{
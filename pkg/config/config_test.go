@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/nexus/nexus/pkg/generated"
+	"github.com/nexus/nexus/pkg/plugins"
 	"github.com/nexus/nexus/pkg/templates"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -148,7 +150,7 @@ rules:
 
 	config := &Config{}
 
-	_, _ = config.GetMergedTemplates(tempDir)
+	_, _ = config.GetMergedTemplates(tempDir, plugins.Environment{})
 }
 
 func TestConfig_GenerateAgentConfigs(t *testing.T) {
@@ -165,39 +167,10 @@ func TestConfig_GenerateAgentConfigs(t *testing.T) {
 
 	mergedTemplates := &templates.TemplateData{}
 
-	err = config.GenerateAgentConfigs(worktreeDir, mergedTemplates)
+	err = config.GenerateAgentConfigs(generated.NewPlan(tempDir), worktreeDir, mergedTemplates, plugins.Environment{})
 	_ = err
 }
 
-func TestUpdateGitignore(t *testing.T) {
-	tempDir, err := os.MkdirTemp("", "gitignore-test-")
-	require.NoError(t, err)
-	defer os.RemoveAll(tempDir)
-
-	oldDir, err := os.Getwd()
-	require.NoError(t, err)
-	defer os.Chdir(oldDir)
-
-	err = os.Chdir(tempDir)
-	require.NoError(t, err)
-
-	err = updateGitignore([]string{".cursor/"})
-	assert.NoError(t, err)
-
-	content, err := os.ReadFile(".gitignore")
-	require.NoError(t, err)
-	assert.Contains(t, string(content), ".cursor/")
-
-	require.NoError(t, os.WriteFile(".gitignore", []byte("node_modules/\n"), 0644))
-	err = updateGitignore([]string{".opencode/"})
-	assert.NoError(t, err)
-
-	content, err = os.ReadFile(".gitignore")
-	require.NoError(t, err)
-	assert.Contains(t, string(content), "node_modules/")
-	assert.Contains(t, string(content), ".opencode/")
-}
-
 func TestConfigYAMLMarshalling(t *testing.T) {
 	config := &Config{
 		Name:     "test-project",
@@ -244,11 +217,79 @@ func TestIsPluginEnabled(t *testing.T) {
 
 	config := &Config{}
 
-	assert.True(t, config.isPluginEnabled(tempDir1, "golang"))
-	assert.True(t, config.isPluginEnabled(tempDir2, "node"))
-	assert.False(t, config.isPluginEnabled(tempDir3, "golang"))
-	assert.False(t, config.isPluginEnabled(tempDir3, "node"))
-	assert.True(t, config.isPluginEnabled(tempDir3, "unknown"))
+	assert.True(t, config.isPluginEnabled(plugins.Environment{}, tempDir1, "golang"))
+	assert.True(t, config.isPluginEnabled(plugins.Environment{}, tempDir2, "node"))
+	assert.False(t, config.isPluginEnabled(plugins.Environment{}, tempDir3, "golang"))
+	assert.False(t, config.isPluginEnabled(plugins.Environment{}, tempDir3, "node"))
+	assert.True(t, config.isPluginEnabled(plugins.Environment{}, tempDir3, "unknown"))
+}
+
+func TestIsPluginEnabled_Manifest(t *testing.T) {
+	root := t.TempDir()
+	pluginDir := filepath.Join(root, ".nexus", "plugins", "react")
+	require.NoError(t, os.MkdirAll(pluginDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.yaml"), []byte(`plugin:
+  name: react
+  conditions:
+    - content: {file: package.json, pattern: '"react"'}
+`), 0644))
+
+	config := &Config{}
+	assert.False(t, config.isPluginEnabled(plugins.Environment{}, root, "react"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"dependencies": {"react": "18"}}`), 0644))
+	assert.True(t, config.isPluginEnabled(plugins.Environment{}, root, "react"))
+
+	// A manifest with invalid conditions disables the plugin
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.yaml"), []byte(`plugin:
+  name: react
+  conditions:
+    - content: {file: package.json, pattern: '('}
+`), 0644))
+	assert.False(t, config.isPluginEnabled(plugins.Environment{}, root, "react"))
+}
+
+func TestConfig_MCPServers(t *testing.T) {
+	root := t.TempDir()
+	writeManifest := func(name, manifest string) {
+		dir := filepath.Join(root, ".nexus", "plugins", name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(manifest), 0644))
+	}
+
+	writeManifest("github", `plugin:
+  name: github
+  mcp_servers:
+    github:
+      command: npx
+      args: ["-y", "@modelcontextprotocol/server-github"]
+`)
+	writeManifest("docs", `plugin:
+  name: docs
+  mcp_servers:
+    docs:
+      url: https://mcp.example.com/sse
+`)
+	writeManifest("rust", `plugin:
+  name: rust
+  conditions:
+    - file: Cargo.toml
+  mcp_servers:
+    github:
+      command: other-github
+`)
+
+	config := &Config{Plugins: []interface{}{"github", "docs", "rust"}}
+	servers, err := config.MCPServers(root, plugins.Environment{})
+	require.NoError(t, err)
+	assert.Len(t, servers, 2, "inactive plugins contribute no servers")
+	assert.Equal(t, "npx", servers["github"].Command)
+	assert.Equal(t, "https://mcp.example.com/sse", servers["docs"].URL)
+
+	// Once active, the rust plugin's github server conflicts
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Cargo.toml"), []byte(""), 0644))
+	_, err = config.MCPServers(root, plugins.Environment{})
+	assert.ErrorContains(t, err, "declared differently by plugins github and rust")
 }
 
 func TestFileExists(t *testing.T) {
@@ -277,7 +318,7 @@ func TestGenerateAgentRules(t *testing.T) {
 		},
 	}
 
-	err := config.generateAgentRules(worktreePath, "cursor", ".cursor/rules", templates)
+	err := config.generateAgentRules(generated.NewPlan(worktreePath), worktreePath, "cursor", ".cursor/rules", templates)
 	require.NoError(t, err)
 }
 
@@ -298,3 +339,103 @@ func TestGenerateJSONSchema(t *testing.T) {
 	assert.Contains(t, schemaStr, "provider")
 	assert.Contains(t, schemaStr, "services")
 }
+
+func TestConfig_AgentRegistry(t *testing.T) {
+	baseDir := t.TempDir()
+	pluginDir := filepath.Join(baseDir, ".nexus", "plugins", "gemini")
+	require.NoError(t, os.MkdirAll(pluginDir, 0755))
+	manifest := `plugin:
+  name: gemini
+  version: 1.0.0
+  agents:
+    - name: gemini-cli
+      detect: {commands: [gemini]}
+      commands_dir: .gemini/commands
+      mcp: [{path: .gemini/settings.json, format: mcpServers}]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.yaml"), []byte(manifest), 0644))
+
+	cfg := &Config{Plugins: []interface{}{"gemini"}}
+	registry, err := cfg.AgentRegistry(baseDir, plugins.Environment{})
+	require.NoError(t, err)
+
+	adapter, ok := registry.Get("gemini-cli")
+	require.True(t, ok)
+	assert.Equal(t, ".gemini/commands", adapter.Layout().CommandsDir)
+	_, ok = registry.Get("cursor")
+	assert.True(t, ok)
+}
+
+func TestConfig_ConditionEnvironment(t *testing.T) {
+	baseDir := t.TempDir()
+	writePlugin := func(name, manifest string) {
+		dir := filepath.Join(baseDir, ".nexus", "plugins", name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(manifest), 0644))
+	}
+	// gemini-cli is only known from a plugin, and the plugin declaring it
+	// has a condition of its own that doesn't match
+	writePlugin("gemini", `plugin:
+  name: gemini
+  conditions:
+    - file: GEMINI.md
+  agents:
+    - name: gemini-cli
+      detect: {env: [NEXUS_TEST_GEMINI]}
+      commands_dir: .gemini/commands
+`)
+	writePlugin("gemini-rules", `plugin:
+  name: gemini-rules
+  conditions:
+    - agent: gemini-cli
+`)
+	t.Setenv("NEXUS_TEST_GEMINI", "1")
+
+	cfg := &Config{Plugins: []interface{}{"gemini", "gemini-rules"}}
+	env, err := cfg.ConditionEnvironment(baseDir)
+	require.NoError(t, err)
+	assert.Contains(t, env.Agents, "gemini-cli")
+	assert.True(t, cfg.isPluginEnabled(env, baseDir, "gemini-rules"))
+	assert.False(t, cfg.isPluginEnabled(env, baseDir, "gemini"))
+
+	t.Setenv("NEXUS_TEST_GEMINI", "")
+	assert.True(t, cfg.isPluginEnabled(env, baseDir, "gemini-rules"), "agents are detected once per environment")
+}
+
+func TestRenderTemplatedContent(t *testing.T) {
+	partials := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(partials, "footer.md"), []byte("-- {{ .ProjectName }}"), 0644))
+
+	plain := map[string]interface{}{"content": "Keep {{ braces }}"}
+	merged := &templates.TemplateData{
+		Plugins: map[string]*templates.PluginData{
+			"base": {
+				Rules: map[string]interface{}{
+					"plain": plain,
+					"testing": map[string]interface{}{
+						"description":         "Tests",
+						"content":             "Run {{ index .Facts.TestCommands 0 }}\n{{ include \"footer.md\" . }}",
+						templates.TemplateKey: templates.Source{File: "rules/testing.md.tpl", Line: 4},
+					},
+				},
+			},
+		},
+	}
+	data := &RenderData{ProjectName: "app", Facts: ProjectFacts{TestCommands: []string{"go test ./..."}}}
+
+	rendered, err := renderTemplatedContent(templates.NewRenderer(partials), merged, data)
+	require.NoError(t, err)
+
+	rules := rendered.Plugins["base"].Rules
+	assert.Equal(t, plain, rules["plain"])
+	rule := rules["testing"].(map[string]interface{})
+	assert.Equal(t, "Run go test ./...\n-- app", rule["content"])
+	assert.Equal(t, "Tests", rule["description"])
+	assert.NotContains(t, rule, templates.TemplateKey)
+	// The loaded templates are left as they were
+	assert.Contains(t, merged.Plugins["base"].Rules["testing"], templates.TemplateKey)
+
+	merged.Plugins["base"].Rules["testing"].(map[string]interface{})["content"] = "ok\n{{ .Missing.Field }}"
+	_, err = renderTemplatedContent(templates.NewRenderer(partials), merged, data)
+	assert.ErrorContains(t, err, "rules/testing.md.tpl:5:")
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// ProjectFacts describes a workspace for templates, as .Facts
+type ProjectFacts struct {
+	// Languages are detected from manifest files, e.g. "go" or "typescript"
+	Languages []string
+	// PackageManagers are detected from manifests and lockfiles, e.g. "pnpm"
+	PackageManagers []string
+	// TestCommands run the project's tests, e.g. "go test ./..."
+	TestCommands []string
+	// Ports maps services in config.yaml to the ports they listen on
+	Ports map[string]int
+	// GitRemote is the URL of the origin remote, if any
+	GitRemote string
+}
+
+// languageMarkers maps files at the workspace root to the language they
+// indicate, in the order languages are reported
+var languageMarkers = []struct {
+	files    []string
+	language string
+}{
+	{[]string{"go.mod"}, "go"},
+	{[]string{"tsconfig.json"}, "typescript"},
+	{[]string{"package.json"}, "javascript"},
+	{[]string{"pyproject.toml", "setup.py", "requirements.txt", "Pipfile"}, "python"},
+	{[]string{"Cargo.toml"}, "rust"},
+	{[]string{"pom.xml", "build.gradle", "build.gradle.kts"}, "java"},
+	{[]string{"Gemfile"}, "ruby"},
+	{[]string{"composer.json"}, "php"},
+}
+
+// packageManagerMarkers maps files at the workspace root to the package
+// manager they indicate. Lockfiles come before the manifests they belong to.
+var packageManagerMarkers = []struct {
+	file    string
+	manager string
+}{
+	{"go.mod", "go"},
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"bun.lockb", "bun"},
+	{"package-lock.json", "npm"},
+	{"uv.lock", "uv"},
+	{"poetry.lock", "poetry"},
+	{"Pipfile", "pipenv"},
+	{"requirements.txt", "pip"},
+	{"Cargo.toml", "cargo"},
+	{"pom.xml", "maven"},
+	{"build.gradle", "gradle"},
+	{"build.gradle.kts", "gradle"},
+	{"Gemfile", "bundler"},
+	{"composer.json", "composer"},
+}
+
+// jsPackageManagers are the package managers that run package.json scripts
+var jsPackageManagers = []string{"pnpm", "yarn", "bun", "npm"}
+
+// Facts detects the languages, package managers, test commands and git
+// remote of the workspace at root, and the service ports in config.yaml
+func (c *Config) Facts(root string) ProjectFacts {
+	facts := ProjectFacts{Ports: make(map[string]int)}
+	exists := func(name string) bool {
+		return fileExists(filepath.Join(root, name))
+	}
+
+	for _, marker := range languageMarkers {
+		for _, file := range marker.files {
+			if exists(file) {
+				facts.Languages = appendUnique(facts.Languages, marker.language)
+				break
+			}
+		}
+	}
+	// TypeScript projects also have a package.json; report them once
+	if containsFact(facts.Languages, "typescript") {
+		facts.Languages = removeFact(facts.Languages, "javascript")
+	}
+
+	for _, marker := range packageManagerMarkers {
+		if exists(marker.file) {
+			facts.PackageManagers = appendUnique(facts.PackageManagers, marker.manager)
+		}
+	}
+	if exists("package.json") && !containsAnyFact(facts.PackageManagers, jsPackageManagers) {
+		facts.PackageManagers = append(facts.PackageManagers, "npm")
+	}
+
+	facts.TestCommands = detectTestCommands(root, facts.PackageManagers)
+
+	for name, svc := range c.Services {
+		if svc.Port > 0 {
+			facts.Ports[name] = svc.Port
+		}
+	}
+
+	facts.GitRemote = gitRemoteURL(root)
+	return facts
+}
+
+// detectTestCommands returns the commands that run the tests of the
+// workspace at root
+func detectTestCommands(root string, managers []string) []string {
+	var commands []string
+	exists := func(name string) bool {
+		return fileExists(filepath.Join(root, name))
+	}
+
+	if hasMakeTarget(filepath.Join(root, "Makefile"), "test") {
+		commands = append(commands, "make test")
+	}
+	if exists("go.mod") {
+		commands = append(commands, "go test ./...")
+	}
+	if hasPackageScript(filepath.Join(root, "package.json"), "test") {
+		for _, manager := range jsPackageManagers {
+			if containsFact(managers, manager) {
+				commands = append(commands, manager+" test")
+				break
+			}
+		}
+	}
+	if exists("pytest.ini") || exists("conftest.py") || fileContains(filepath.Join(root, "pyproject.toml"), "[tool.pytest") {
+		switch {
+		case containsFact(managers, "uv"):
+			commands = append(commands, "uv run pytest")
+		case containsFact(managers, "poetry"):
+			commands = append(commands, "poetry run pytest")
+		default:
+			commands = append(commands, "pytest")
+		}
+	}
+	if exists("Cargo.toml") {
+		commands = append(commands, "cargo test")
+	}
+	if exists("pom.xml") {
+		commands = append(commands, "mvn test")
+	}
+	if exists("build.gradle") || exists("build.gradle.kts") {
+		if exists("gradlew") {
+			commands = append(commands, "./gradlew test")
+		} else {
+			commands = append(commands, "gradle test")
+		}
+	}
+	return commands
+}
+
+// hasPackageScript reports whether the package.json at path defines script
+func hasPackageScript(path, script string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return false
+	}
+	_, ok := pkg.Scripts[script]
+	return ok
+}
+
+// hasMakeTarget reports whether the Makefile at path defines target
+func hasMakeTarget(path, target string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, target+":") {
+			return true
+		}
+	}
+	return false
+}
+
+func fileContains(path, substr string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(data), substr)
+}
+
+// gitRemoteURL returns the origin URL of the repository containing root
+func gitRemoteURL(root string) string {
+	repo, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return ""
+	}
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return ""
+	}
+	return remote.Config().URLs[0]
+}
+
+func appendUnique(list []string, s string) []string {
+	if containsFact(list, s) {
+		return list
+	}
+	return append(list, s)
+}
+
+func containsFact(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAnyFact(list, candidates []string) bool {
+	for _, candidate := range candidates {
+		if containsFact(list, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFact(list []string, s string) []string {
+	result := list[:0]
+	for _, item := range list {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+	return result
+}
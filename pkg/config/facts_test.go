@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Facts(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module example.com/app\n",
+		"package.json":   `{"scripts": {"test": "vitest"}}`,
+		"tsconfig.json":  "{}",
+		"pnpm-lock.yaml": "",
+		"pyproject.toml": "[tool.pytest.ini_options]\n",
+		"uv.lock":        "",
+		"Makefile":       "build:\n\tgo build\ntest:\n\tgo test\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+	}
+
+	repo, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{"git@github.com:example/app.git"}})
+	require.NoError(t, err)
+
+	cfg := &Config{Services: map[string]Service{
+		"api":    {Command: "go run .", Port: 8080},
+		"worker": {Command: "go run ./worker"},
+	}}
+	facts := cfg.Facts(root)
+
+	assert.Equal(t, []string{"go", "typescript", "python"}, facts.Languages)
+	assert.Equal(t, []string{"go", "pnpm", "uv"}, facts.PackageManagers)
+	assert.Equal(t, []string{"make test", "go test ./...", "pnpm test", "uv run pytest"}, facts.TestCommands)
+	assert.Equal(t, map[string]int{"api": 8080}, facts.Ports)
+	assert.Equal(t, "git@github.com:example/app.git", facts.GitRemote)
+}
+
+func TestConfig_Facts_Empty(t *testing.T) {
+	facts := (&Config{}).Facts(t.TempDir())
+
+	assert.Empty(t, facts.Languages)
+	assert.Empty(t, facts.PackageManagers)
+	assert.Empty(t, facts.TestCommands)
+	assert.Empty(t, facts.Ports)
+	assert.Empty(t, facts.GitRemote)
+}
+
+func TestConfig_Facts_PackageJSONWithoutLockfile(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"scripts": {"test": "jest"}}`), 0644))
+
+	facts := (&Config{}).Facts(root)
+
+	assert.Equal(t, []string{"javascript"}, facts.Languages)
+	assert.Equal(t, []string{"npm"}, facts.PackageManagers)
+	assert.Equal(t, []string{"npm test"}, facts.TestCommands)
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PluginRef is an entry of the plugins list in config.yaml. Entries are
+// either a plain name or a map:
+//
+//	plugins:
+//	  - golang
+//	  - name: golang-base
+//	    version: ^1.2
+//	    repository: https://github.com/acme/nexus-plugins.git
+//	    path: golang/base
+type PluginRef struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version,omitempty"`
+	Repository string `yaml:"repository,omitempty"`
+	Path       string `yaml:"path,omitempty"`
+}
+
+// PluginRefs returns the entries of the plugins list, skipping entries without a name
+func (c *Config) PluginRefs() []PluginRef {
+	var refs []PluginRef
+	for _, entry := range c.Plugins {
+		switch p := entry.(type) {
+		case string:
+			refs = append(refs, PluginRef{Name: p})
+		case map[string]interface{}:
+			ref := PluginRef{}
+			ref.Name, _ = p["name"].(string)
+			ref.Version, _ = p["version"].(string)
+			ref.Repository, _ = p["repository"].(string)
+			ref.Path, _ = p["path"].(string)
+			if ref.Name != "" {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// AddPlugin adds ref to the plugins list of the config file at path, replacing
+// an existing entry with the same name. Comments and the order of other
+// entries are kept.
+func AddPlugin(path string, ref PluginRef) error {
+	doc, err := loadConfigNode(path)
+	if err != nil {
+		return err
+	}
+
+	var entry yaml.Node
+	if err := entry.Encode(ref); err != nil {
+		return fmt.Errorf("failed to encode plugin %s: %w", ref.Name, err)
+	}
+
+	list := pluginsNode(doc, true)
+	if i := pluginIndex(list, ref.Name); i >= 0 {
+		list.Content[i] = &entry
+	} else {
+		list.Content = append(list.Content, &entry)
+	}
+
+	return saveConfigNode(path, doc)
+}
+
+// RemovePlugin removes the entry named name from the plugins list of the
+// config file at path. It reports whether an entry was removed.
+func RemovePlugin(path, name string) (bool, error) {
+	doc, err := loadConfigNode(path)
+	if err != nil {
+		return false, err
+	}
+
+	list := pluginsNode(doc, false)
+	i := pluginIndex(list, name)
+	if i < 0 {
+		return false, nil
+	}
+	list.Content = append(list.Content[:i], list.Content[i+1:]...)
+
+	return true, saveConfigNode(path, doc)
+}
+
+func loadConfigNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		// Empty file
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse %s: top level is not a map", path)
+	}
+	return &doc, nil
+}
+
+func saveConfigNode(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), info.Mode().Perm())
+}
+
+// pluginsNode returns the sequence node of the plugins key, creating it when
+// create is set. It returns nil when the key is missing and create is not set.
+func pluginsNode(doc *yaml.Node, create bool) *yaml.Node {
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "plugins" {
+			value := root.Content[i+1]
+			if value.Kind != yaml.SequenceNode {
+				// "plugins:" with no entries parses as null
+				*value = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			}
+			return value
+		}
+	}
+	if !create {
+		return nil
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "plugins"}
+	value := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	root.Content = append(root.Content, key, value)
+	return value
+}
+
+// pluginIndex returns the position of the entry named name in list, or -1
+func pluginIndex(list *yaml.Node, name string) int {
+	if list == nil {
+		return -1
+	}
+	for i, item := range list.Content {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			if item.Value == name {
+				return i
+			}
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(item.Content); j += 2 {
+				if item.Content[j].Value == "name" && item.Content[j+1].Value == name {
+					return i
+				}
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginRefs(t *testing.T) {
+	cfg := &Config{Plugins: []interface{}{
+		"golang",
+		map[string]interface{}{"name": "golang-base", "version": "^1.2", "repository": "https://example.com/p.git", "path": "go"},
+		map[string]interface{}{"version": "1.0"},
+	}}
+
+	assert.Equal(t, []PluginRef{
+		{Name: "golang"},
+		{Name: "golang-base", Version: "^1.2", Repository: "https://example.com/p.git", Path: "go"},
+	}, cfg.PluginRefs())
+}
+
+func TestAddAndRemovePlugin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := `# Project settings
+name: demo
+plugins:
+  - golang # detected from go.mod
+services: {}
+`
+	require.NoError(t, os.WriteFile(path, []byte(original), 0644))
+
+	ref := PluginRef{Name: "golang-base", Version: "^1.2", Repository: "https://example.com/p.git"}
+	require.NoError(t, AddPlugin(path, ref))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Project settings")
+	assert.Contains(t, string(data), "# detected from go.mod")
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []PluginRef{{Name: "golang"}, ref}, cfg.PluginRefs())
+
+	// Adding the same plugin again replaces its entry
+	ref.Version = "^2"
+	require.NoError(t, AddPlugin(path, ref))
+	cfg, err = LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []PluginRef{{Name: "golang"}, ref}, cfg.PluginRefs())
+
+	removed, err := RemovePlugin(path, "golang-base")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = RemovePlugin(path, "golang-base")
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	cfg, err = LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []PluginRef{{Name: "golang"}}, cfg.PluginRefs())
+	assert.Equal(t, "demo", cfg.Name)
+}
+
+func TestAddPluginCreatesList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("name: demo\n"), 0644))
+
+	require.NoError(t, AddPlugin(path, PluginRef{Name: "node-base", Repository: "https://example.com/n.git"}))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []PluginRef{{Name: "node-base", Repository: "https://example.com/n.git"}}, cfg.PluginRefs())
+}
@@ -18,14 +18,31 @@ type UserConfig struct {
 		KeyPath   string `yaml:"key_path,omitempty"`
 		PublicKey string `yaml:"public_key,omitempty"`
 	} `yaml:"ssh,omitempty"`
-	Editor     string `yaml:"editor,omitempty"`
-	Workspaces []struct {
+	Editor string `yaml:"editor,omitempty"`
+	// UsageSharing is this user's consent to upload usage metrics to team
+	// coordination servers; nothing is uploaded until Enabled is set
+	UsageSharing UsageSharing `yaml:"usage_sharing,omitempty"`
+	Workspaces   []struct {
 		Name   string `yaml:"name"`
 		ID     string `yaml:"id"`
 		Status string `yaml:"status"`
 	} `yaml:"workspaces,omitempty"`
 }
 
+// UsageSharing controls what nexus usage upload sends
+type UsageSharing struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Anonymize uploads under a per-project pseudonym instead of the user's
+	// name
+	Anonymize bool `yaml:"anonymize,omitempty"`
+	// PseudonymKey is the secret pseudonyms are derived from; it is never
+	// uploaded
+	PseudonymKey string `yaml:"pseudonym_key,omitempty"`
+	// IncludeContext uploads task text, touched files and session IDs, which
+	// are otherwise stripped
+	IncludeContext bool `yaml:"include_context,omitempty"`
+}
+
 func LoadUserConfig(path string) (*UserConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -0,0 +1,203 @@
+package coordination
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Audit results, from the status the handler responded with
+const (
+	AuditSuccess = "success"
+	// AuditDenied is a request refused for want of authorization
+	AuditDenied  = "denied"
+	AuditFailure = "failure"
+)
+
+// auditTimeFormat is fixed-width so stored timestamps sort as strings
+const auditTimeFormat = "2006-01-02T15:04:05.000000Z"
+
+// AuditEvent records one privileged action: who did what to which target,
+// from where, and how it went. Actor is the credential the request
+// authenticated with; ClaimedUser is the user the request said it acted
+// for, which the server doesn't verify.
+type AuditEvent struct {
+	ID          int64     `json:"id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Actor       string    `json:"actor"`
+	ClaimedUser string    `json:"claimed_user,omitempty"`
+	Action      string    `json:"action"`
+	Target      string    `json:"target,omitempty"`
+	SourceIP    string    `json:"source_ip"`
+	Result      string    `json:"result"`
+	Status      int       `json:"status"`
+	RequestID   string    `json:"request_id,omitempty"`
+}
+
+// AuditFilter selects audit events; zero fields match everything. Action
+// also matches the actions under it, so "workspace" matches
+// "workspace.create".
+type AuditFilter struct {
+	Actor       string
+	ClaimedUser string
+	Action      string
+	Target      string
+	Result      string
+	Since       time.Time
+	Until       time.Time
+	// Limit caps how many of the newest matching events are returned
+	Limit int
+}
+
+// Matches reports whether event passes the filter, ignoring Limit
+func (f AuditFilter) Matches(event AuditEvent) bool {
+	if f.Actor != "" && event.Actor != f.Actor {
+		return false
+	}
+	if f.ClaimedUser != "" && event.ClaimedUser != f.ClaimedUser {
+		return false
+	}
+	if f.Action != "" && event.Action != f.Action && !strings.HasPrefix(event.Action, f.Action+".") {
+		return false
+	}
+	if f.Target != "" && event.Target != f.Target {
+		return false
+	}
+	if f.Result != "" && event.Result != f.Result {
+		return false
+	}
+	if !f.Since.IsZero() && event.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// AuditStore is the append-only audit trail; events can be recorded and
+// queried but never changed or removed
+type AuditStore interface {
+	// RecordAudit appends event and sets its ID
+	RecordAudit(event *AuditEvent) error
+	// QueryAudit returns the events that match filter, newest first
+	QueryAudit(filter AuditFilter) ([]AuditEvent, error)
+}
+
+type InMemoryAuditStore struct {
+	events []AuditEvent
+	mu     sync.RWMutex
+}
+
+func NewInMemoryAuditStore() AuditStore {
+	return &InMemoryAuditStore{}
+}
+
+func (s *InMemoryAuditStore) RecordAudit(event *AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = int64(len(s.events) + 1)
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *InMemoryAuditStore) QueryAudit(filter AuditFilter) ([]AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []AuditEvent
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(results) >= filter.Limit {
+			break
+		}
+		if filter.Matches(s.events[i]) {
+			results = append(results, s.events[i])
+		}
+	}
+	return results, nil
+}
+
+// SQLiteAuditStore keeps audit events in the coordination database's
+// audit_log table, whose triggers refuse updates and deletes
+type SQLiteAuditStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteAuditStore(db *sql.DB) AuditStore {
+	return &SQLiteAuditStore{db: db}
+}
+
+func (s *SQLiteAuditStore) RecordAudit(event *AuditEvent) error {
+	result, err := s.db.Exec(`
+		INSERT INTO audit_log (timestamp, actor, claimed_user, action, target, source_ip, result, status, request_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, event.Timestamp.UTC().Format(auditTimeFormat), event.Actor, event.ClaimedUser, event.Action, event.Target,
+		event.SourceIP, event.Result, event.Status, event.RequestID)
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	event.ID, _ = result.LastInsertId()
+	return nil
+}
+
+func (s *SQLiteAuditStore) QueryAudit(filter AuditFilter) ([]AuditEvent, error) {
+	query := "SELECT id, timestamp, actor, claimed_user, action, target, source_ip, result, status, request_id FROM audit_log WHERE 1 = 1"
+	var args []interface{}
+	if filter.Actor != "" {
+		query += " AND actor = ?"
+		args = append(args, filter.Actor)
+	}
+	if filter.ClaimedUser != "" {
+		query += " AND claimed_user = ?"
+		args = append(args, filter.ClaimedUser)
+	}
+	if filter.Action != "" {
+		query += " AND (action = ? OR substr(action, 1, ?) = ?)"
+		args = append(args, filter.Action, len(filter.Action)+1, filter.Action+".")
+	}
+	if filter.Target != "" {
+		query += " AND target = ?"
+		args = append(args, filter.Target)
+	}
+	if filter.Result != "" {
+		query += " AND result = ?"
+		args = append(args, filter.Result)
+	}
+	if !filter.Since.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, filter.Since.UTC().Format(auditTimeFormat))
+	}
+	if !filter.Until.IsZero() {
+		query += " AND timestamp <= ?"
+		args = append(args, filter.Until.UTC().Format(auditTimeFormat))
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var results []AuditEvent
+	for rows.Next() {
+		var event AuditEvent
+		var timestamp string
+		if scanErr := rows.Scan(&event.ID, &timestamp, &event.Actor, &event.ClaimedUser, &event.Action, &event.Target,
+			&event.SourceIP, &event.Result, &event.Status, &event.RequestID); scanErr != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", scanErr)
+		}
+		if event.Timestamp, err = time.Parse(auditTimeFormat, timestamp); err != nil {
+			return nil, fmt.Errorf("failed to parse audit timestamp %q: %w", timestamp, err)
+		}
+		results = append(results, event)
+	}
+	return results, rows.Err()
+}
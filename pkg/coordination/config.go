@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/nexus/nexus/pkg/logging"
+	"github.com/nexus/nexus/pkg/tracing"
 	"gopkg.in/yaml.v3"
 )
 
@@ -41,16 +43,51 @@ type Config struct {
 		JWTSecret   string   `yaml:"jwt_secret,omitempty"`
 		TokenExpiry string   `yaml:"token_expiry,omitempty"`
 		AllowedIPs  []string `yaml:"allowed_ips,omitempty"`
+		// AdminToken is the bearer token for admin-only endpoints such as
+		// the audit log, which stay closed while it's empty
+		AdminToken string `yaml:"admin_token,omitempty"`
 	} `yaml:"auth,omitempty"`
 
-	Logging struct {
-		Level      string `yaml:"level,omitempty"`
-		Format     string `yaml:"format,omitempty"`
-		Output     string `yaml:"output,omitempty"`
-		MaxSize    int    `yaml:"max_size,omitempty"`
-		MaxBackups int    `yaml:"max_backups,omitempty"`
-		MaxAge     int    `yaml:"max_age,omitempty"`
-	} `yaml:"logging,omitempty"`
+	// Logging configures the server's slog output; a file Output is rotated
+	// at MaxSize megabytes
+	Logging logging.Config `yaml:"logging,omitempty"`
+
+	// Tracing exports OpenTelemetry traces over OTLP; off by default
+	Tracing tracing.Config `yaml:"tracing,omitempty"`
+
+	GitHub struct {
+		WebhookSecret string `yaml:"webhook_secret,omitempty"`
+		// DeployKeys maps "owner/repo" to a private key path used for SSH clones
+		DeployKeys   map[string]string `yaml:"deploy_keys,omitempty"`
+		PullRequests struct {
+			Enabled  bool   `yaml:"enabled,omitempty"`
+			Provider string `yaml:"provider,omitempty"`
+			Image    string `yaml:"image,omitempty"`
+			Comment  bool   `yaml:"comment,omitempty"`
+			// AllowForks provisions workspaces for PRs from forks too. Fork
+			// PRs run code from outside the repository, so they are skipped
+			// unless this is set.
+			AllowForks bool `yaml:"allow_forks,omitempty"`
+		} `yaml:"pull_requests,omitempty"`
+	} `yaml:"github,omitempty"`
+
+	Usage struct {
+		// HideUsers leaves each member's share out of team usage reports and
+		// refuses per-user queries, so reports only show team totals
+		HideUsers bool `yaml:"hide_users,omitempty"`
+		// MemberTokens maps each member to the token they upload usage
+		// with. With auth enabled, uploads must carry a member token and are
+		// recorded under that member or their pseudonym.
+		MemberTokens map[string]string `yaml:"member_tokens,omitempty"`
+	} `yaml:"usage,omitempty"`
+
+	Git struct {
+		// MirrorDir holds bare mirrors that full-history clones borrow
+		// objects from via --reference. A relative path is under the
+		// server's data directory. Empty, the default, disables the mirror
+		// cache.
+		MirrorDir string `yaml:"mirror_dir,omitempty"`
+	} `yaml:"git,omitempty"`
 
 	Provider struct {
 		Type string `yaml:"type,omitempty"`
@@ -133,6 +170,18 @@ func LoadConfig(path string) (*Config, error) {
 		cfg.Server.JWTSecret = jwtSecret
 	}
 
+	if adminToken := os.Getenv("NEXUS_ADMIN_TOKEN"); adminToken != "" {
+		cfg.Auth.AdminToken = adminToken
+	}
+
+	if webhookSecret := os.Getenv("GITHUB_WEBHOOK_SECRET"); webhookSecret != "" {
+		cfg.GitHub.WebhookSecret = webhookSecret
+	}
+	if level := os.Getenv("NEXUS_LOG_LEVEL"); level != "" {
+		cfg.Logging.Level = level
+	}
+	cfg.Tracing.ApplyEnv()
+
 	return cfg, nil
 }
 
@@ -388,6 +388,7 @@ func TestConfig(t *testing.T) {
 				JWTSecret   string   `yaml:"jwt_secret,omitempty"`
 				TokenExpiry string   `yaml:"token_expiry,omitempty"`
 				AllowedIPs  []string `yaml:"allowed_ips,omitempty"`
+				AdminToken  string   `yaml:"admin_token,omitempty"`
 			}{
 				Enabled: true,
 				// JWTSecret is empty
@@ -1,7 +1,7 @@
 package coordination
 
 const (
-	DBVersion = 1
+	DBVersion = 5
 )
 
 type Migration struct {
@@ -93,6 +93,83 @@ CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 CREATE INDEX IF NOT EXISTS idx_workspaces_user_id ON workspaces(user_id);
 CREATE INDEX IF NOT EXISTS idx_workspaces_status ON workspaces(status);
 CREATE INDEX IF NOT EXISTS idx_services_workspace_id ON services(workspace_id);
+`,
+	},
+	{
+		Version: 2,
+		Name:    "team_usage",
+		SQL: `
+CREATE TABLE IF NOT EXISTS usage_logs (
+	user_id TEXT NOT NULL,
+	entry_id TEXT NOT NULL,
+	project TEXT NOT NULL,
+	timestamp TEXT NOT NULL,
+	entry TEXT NOT NULL,
+	uploaded_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(user_id, entry_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_logs_project_timestamp ON usage_logs(project, timestamp);
+`,
+	},
+	{
+		Version: 3,
+		Name:    "audit_log",
+		SQL: `
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	action TEXT NOT NULL,
+	target TEXT NOT NULL DEFAULT '',
+	source_ip TEXT NOT NULL DEFAULT '',
+	result TEXT NOT NULL,
+	status INTEGER NOT NULL,
+	request_id TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, timestamp);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+	SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+`,
+	},
+	{
+		Version: 4,
+		Name:    "audit_log_claimed_user",
+		SQL: `
+ALTER TABLE audit_log ADD COLUMN claimed_user TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_claimed_user ON audit_log(claimed_user, timestamp);
+`,
+	},
+	{
+		Version: 5,
+		Name:    "github_user_token",
+		SQL: `
+ALTER TABLE github_installations ADD COLUMN user_token TEXT NOT NULL DEFAULT '';
+`,
+	},
+	{
+		Version: 6,
+		Name:    "usage_pseudonyms",
+		SQL: `
+CREATE TABLE IF NOT EXISTS usage_pseudonyms (
+	member TEXT NOT NULL,
+	project TEXT NOT NULL,
+	pseudonym TEXT NOT NULL,
+	bound_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY(member, project),
+	UNIQUE(project, pseudonym)
+);
 `,
 	},
 }
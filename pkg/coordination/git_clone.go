@@ -0,0 +1,298 @@
+package coordination
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nexus/nexus/pkg/config"
+	"github.com/nexus/nexus/pkg/github"
+)
+
+// commitSHAPattern matches full and abbreviated commit SHAs, which are
+// fetched and checked out rather than passed to --branch
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// mirrorScope keys the mirror cache by whose credentials fill it: the GitHub
+// App installation, or the user for token-only installations
+func mirrorScope(installation *GitHubInstallation) string {
+	if installation.InstallationID != 0 {
+		return "installation:" + strconv.FormatInt(installation.InstallationID, 10)
+	}
+	return "user:" + installation.UserID
+}
+
+// cloneRepository clones repo into workspaceDir. mirrorScope picks which of
+// the node's mirrors a full-history clone may borrow objects from.
+func (s *Server) cloneRepository(ctx context.Context, repo M4Repository, mirrorScope, githubToken, workspaceDir string) error {
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		return fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+
+	auth := s.cloneAuth(repo, githubToken)
+	env := auth.GitEnv(repo.URL)
+
+	// Shallow clones gain little from a local mirror, so only full-history
+	// clones use it
+	reference := ""
+	if s.mirrorCache != nil && repo.Clone.Depth < 0 {
+		mirror, mirrorErr := s.mirrorCache.Update(ctx, mirrorScope, repo.URL, env)
+		if mirrorErr != nil {
+			slog.WarnContext(ctx, "Mirror cache unavailable", "error", auth.Redact(mirrorErr.Error()))
+		} else {
+			reference = mirror
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", cloneArgs(repo, workspaceDir, reference)...)
+	cmd.Env = env
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		out := auth.Redact(string(output))
+		if isGitAuthFailure(out) {
+			// Clear the partial checkout so a retry with a fresh token can clone into it
+			os.RemoveAll(workspaceDir)
+			return fmt.Errorf("git clone failed: %w: %w\nOutput: %s", github.ErrUnauthorized, err, out)
+		}
+		return fmt.Errorf("git clone failed: %w\nOutput: %s", err, out)
+	}
+
+	if commit := targetCommit(repo); commit != "" {
+		if err := checkoutRef(ctx, workspaceDir, commit, env, auth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cloneArgs builds the git clone command line for a repository. reference is
+// a local mirror to borrow objects from, or empty.
+func cloneArgs(repo M4Repository, workspaceDir, reference string) []string {
+	branch := repo.Branch
+	if repo.Clone.Ref != "" && !commitSHAPattern.MatchString(repo.Clone.Ref) {
+		// --branch accepts tags as well as branches
+		branch = repo.Clone.Ref
+	}
+	if branch == "" {
+		branch = "main"
+	}
+
+	args := []string{"clone", "--branch", branch}
+
+	switch {
+	case repo.Clone.Depth > 0:
+		args = append(args, "--depth", strconv.Itoa(repo.Clone.Depth))
+	case repo.Clone.Depth == 0:
+		args = append(args, "--depth", "1")
+	}
+
+	if reference != "" {
+		// --dissociate copies the borrowed objects so the workspace keeps
+		// working inside the container, where the mirror is not mounted
+		args = append(args, "--reference", reference, "--dissociate")
+	}
+	if repo.Clone.Filter != "" {
+		args = append(args, "--filter="+repo.Clone.Filter)
+	}
+	if len(repo.Clone.Sparse) > 0 {
+		args = append(args, "--sparse")
+	}
+
+	return append(args, repo.URL, workspaceDir)
+}
+
+// targetCommit returns the commit a clone must end up at, if one was pinned
+func targetCommit(repo M4Repository) string {
+	if repo.Commit != "" {
+		return repo.Commit
+	}
+	if commitSHAPattern.MatchString(repo.Clone.Ref) {
+		return repo.Clone.Ref
+	}
+	return ""
+}
+
+// mergeCloneConfig combines the clone options from the create request with
+// those in the repository's .nexus/config.yaml. Request options take priority.
+func mergeCloneConfig(repo M4Repository, fromConfig config.CloneConfig) config.CloneConfig {
+	merged := repo.Clone
+	if merged.Depth == 0 {
+		merged.Depth = fromConfig.Depth
+	}
+	if merged.Ref == "" && repo.Commit == "" {
+		merged.Ref = fromConfig.Ref
+	}
+	merged.Submodules = merged.Submodules || fromConfig.Submodules
+	merged.LFS = merged.LFS || fromConfig.LFS
+	if len(merged.Sparse) == 0 {
+		merged.Sparse = fromConfig.Sparse
+	}
+	return merged
+}
+
+// applyCloneConfig brings an existing checkout in line with clone options that
+// cannot be expressed on the clone command line, or that were only known once
+// .nexus/config.yaml had been read: ref, history depth, sparse paths,
+// submodules and LFS objects
+func (s *Server) applyCloneConfig(ctx context.Context, repo M4Repository, opts config.CloneConfig, githubToken, workspaceDir string) error {
+	auth := s.cloneAuth(repo, githubToken)
+	env := auth.GitEnv(repo.URL)
+
+	if opts.Ref != "" {
+		if err := checkoutRef(ctx, workspaceDir, opts.Ref, env, auth); err != nil {
+			return err
+		}
+	}
+
+	if isShallowClone(ctx, workspaceDir) {
+		switch {
+		case opts.Depth < 0:
+			if err := runGit(ctx, workspaceDir, env, auth, "fetch", "--unshallow", "origin"); err != nil {
+				return err
+			}
+		case opts.Depth > 0:
+			if err := deepenClone(ctx, workspaceDir, opts.Depth, env, auth); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(opts.Sparse) > 0 {
+		args := append([]string{"sparse-checkout", "set"}, opts.Sparse...)
+		if err := runGit(ctx, workspaceDir, env, auth, args...); err != nil {
+			return err
+		}
+	}
+
+	if opts.Submodules {
+		args := []string{"submodule", "update", "--init", "--recursive"}
+		if isShallowClone(ctx, workspaceDir) {
+			args = append(args, "--depth", "1")
+		}
+		if err := runGit(ctx, workspaceDir, env, auth, args...); err != nil {
+			return err
+		}
+	}
+
+	if opts.LFS {
+		if err := runGit(ctx, workspaceDir, env, auth, "lfs", "install", "--local"); err != nil {
+			return err
+		}
+		if err := runGit(ctx, workspaceDir, env, auth, "lfs", "pull"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cloneAuth picks credentials for a repository: a configured deploy key for
+// SSH remotes, otherwise the GitHub token for HTTPS remotes
+func (s *Server) cloneAuth(repo M4Repository, githubToken string) github.CloneAuth {
+	auth := github.CloneAuth{Token: githubToken}
+	if repo.Owner != "" && repo.Name != "" {
+		auth.SSHKeyPath = s.config.GitHub.DeployKeys[repo.Owner+"/"+repo.Name]
+	}
+	return auth
+}
+
+// checkoutRef fetches a commit, tag or branch into the clone and detaches HEAD at it
+func checkoutRef(ctx context.Context, workspaceDir, ref string, env []string, auth github.CloneAuth) error {
+	head, err := resolveHeadCommit(ctx, workspaceDir)
+	if err == nil {
+		if head == ref {
+			return nil
+		}
+		resolved, resolveErr := exec.CommandContext(ctx, "git", "-C", workspaceDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}").Output()
+		if resolveErr == nil && strings.TrimSpace(string(resolved)) == head {
+			return nil
+		}
+	}
+
+	// Remotes only resolve full SHAs, so an abbreviated one is looked up in
+	// history fetched from the remote instead
+	if commitSHAPattern.MatchString(ref) && len(ref) < 40 {
+		fetchArgs := []string{"fetch", "origin"}
+		if isShallowClone(ctx, workspaceDir) {
+			fetchArgs = []string{"fetch", "--unshallow", "origin"}
+		}
+		if err := runGit(ctx, workspaceDir, env, auth, fetchArgs...); err != nil {
+			return fmt.Errorf("git fetch for %s failed: %w", ref, err)
+		}
+		if err := runGit(ctx, workspaceDir, env, auth, "checkout", "--detach", ref); err != nil {
+			return fmt.Errorf("git checkout %s failed: %w", ref, err)
+		}
+		return nil
+	}
+
+	fetchArgs := []string{"fetch"}
+	if isShallowClone(ctx, workspaceDir) {
+		fetchArgs = append(fetchArgs, "--depth", "1")
+	}
+	fetchArgs = append(fetchArgs, "origin", ref)
+	if err := runGit(ctx, workspaceDir, env, auth, fetchArgs...); err != nil {
+		return fmt.Errorf("git fetch %s failed: %w", ref, err)
+	}
+
+	if err := runGit(ctx, workspaceDir, env, auth, "checkout", "--detach", "FETCH_HEAD"); err != nil {
+		return fmt.Errorf("git checkout %s failed: %w", ref, err)
+	}
+
+	return nil
+}
+
+// deepenClone fetches history until HEAD has at least depth commits, for a
+// depth that was only known once .nexus/config.yaml had been read
+func deepenClone(ctx context.Context, workspaceDir string, depth int, env []string, auth github.CloneAuth) error {
+	output, err := exec.CommandContext(ctx, "git", "-C", workspaceDir, "rev-list", "--count", "HEAD").Output()
+	if err != nil {
+		return fmt.Errorf("git rev-list HEAD failed: %w", err)
+	}
+	have, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return fmt.Errorf("unexpected git rev-list output %q: %w", output, err)
+	}
+	if have >= depth {
+		return nil
+	}
+	return runGit(ctx, workspaceDir, env, auth, "fetch", "--deepen", strconv.Itoa(depth-have), "origin")
+}
+
+// runGit runs a git subcommand in workspaceDir, redacting credentials from its output
+func runGit(ctx context.Context, workspaceDir string, env []string, auth github.CloneAuth, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", workspaceDir}, args...)...)
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w\nOutput: %s", strings.Join(args, " "), err, auth.Redact(string(output)))
+	}
+	return nil
+}
+
+// isShallowClone reports whether the clone in workspaceDir has truncated history
+func isShallowClone(ctx context.Context, workspaceDir string) bool {
+	output, err := exec.CommandContext(ctx, "git", "-C", workspaceDir, "rev-parse", "--is-shallow-repository").Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// resolveHeadCommit returns the commit SHA checked out in a workspace
+func resolveHeadCommit(ctx context.Context, workspaceDir string) (string, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", workspaceDir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse HEAD failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// isGitAuthFailure reports whether git output indicates the remote rejected our credentials
+func isGitAuthFailure(output string) bool {
+	return strings.Contains(output, "Authentication failed") ||
+		strings.Contains(output, "returned error: 401") ||
+		strings.Contains(output, "Invalid username or password")
+}
@@ -0,0 +1,180 @@
+package coordination
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus/nexus/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		repo      M4Repository
+		reference string
+		want      []string
+	}{
+		{
+			name: "defaults to shallow clone of main",
+			repo: M4Repository{URL: "https://github.com/org/repo.git"},
+			want: []string{"clone", "--branch", "main", "--depth", "1", "https://github.com/org/repo.git", "/ws"},
+		},
+		{
+			name: "explicit depth and tag",
+			repo: M4Repository{URL: "u", Branch: "dev", Clone: config.CloneConfig{Depth: 10, Ref: "v1.2.0"}},
+			want: []string{"clone", "--branch", "v1.2.0", "--depth", "10", "u", "/ws"},
+		},
+		{
+			name: "commit ref keeps branch",
+			repo: M4Repository{URL: "u", Branch: "dev", Clone: config.CloneConfig{Ref: strings.Repeat("a", 40)}},
+			want: []string{"clone", "--branch", "dev", "--depth", "1", "u", "/ws"},
+		},
+		{
+			name: "full history with filter and sparse",
+			repo: M4Repository{URL: "u", Clone: config.CloneConfig{Depth: -1, Filter: "blob:none", Sparse: []string{"docs"}}},
+			want: []string{"clone", "--branch", "main", "--filter=blob:none", "--sparse", "u", "/ws"},
+		},
+		{
+			name: "short commit ref keeps branch",
+			repo: M4Repository{URL: "u", Clone: config.CloneConfig{Ref: "abc1234"}},
+			want: []string{"clone", "--branch", "main", "--depth", "1", "u", "/ws"},
+		},
+		{
+			name:      "mirror reference for full history",
+			repo:      M4Repository{URL: "u", Clone: config.CloneConfig{Depth: -1}},
+			reference: "/mirrors/abc.git",
+			want:      []string{"clone", "--branch", "main", "--reference", "/mirrors/abc.git", "--dissociate", "u", "/ws"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cloneArgs(tt.repo, "/ws", tt.reference))
+		})
+	}
+}
+
+func TestMergeCloneConfig(t *testing.T) {
+	fromConfig := config.CloneConfig{Depth: 50, Ref: "v1", Submodules: true, Sparse: []string{"src"}, Filter: "blob:none"}
+
+	merged := mergeCloneConfig(M4Repository{Clone: config.CloneConfig{LFS: true}}, fromConfig)
+	assert.Equal(t, 50, merged.Depth)
+	assert.Equal(t, "v1", merged.Ref)
+	assert.True(t, merged.Submodules)
+	assert.True(t, merged.LFS)
+	assert.Equal(t, []string{"src"}, merged.Sparse)
+	assert.Empty(t, merged.Filter)
+
+	merged = mergeCloneConfig(M4Repository{Commit: "abc", Clone: config.CloneConfig{Depth: -1, Sparse: []string{"docs"}}}, fromConfig)
+	assert.Equal(t, -1, merged.Depth)
+	assert.Empty(t, merged.Ref, "a pinned commit must not be overridden by the config ref")
+	assert.Equal(t, []string{"docs"}, merged.Sparse)
+}
+
+// newTestGitRepo creates a repository with two commits on main, tagging the first as v1
+func newTestGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	git("init", "--initial-branch", "main")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "README.md"), []byte("v1"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main"), 0644))
+	git("add", "-A")
+	git("commit", "-m", "first")
+	git("tag", "v1")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "README.md"), []byte("v2"), 0644))
+	git("commit", "-am", "second")
+
+	return dir
+}
+
+func TestCloneRepository_RefAndSparse(t *testing.T) {
+	source := newTestGitRepo(t)
+	server := NewServer(&Config{})
+	ctx := context.Background()
+
+	repo := M4Repository{URL: "file://" + source, Clone: config.CloneConfig{Ref: "v1", Sparse: []string{"docs"}}}
+	workspaceDir := filepath.Join(t.TempDir(), "ws")
+	require.NoError(t, server.cloneRepository(ctx, repo, "", "", workspaceDir))
+	require.NoError(t, server.applyCloneConfig(ctx, repo, repo.Clone, "", workspaceDir))
+
+	content, err := os.ReadFile(filepath.Join(workspaceDir, "docs", "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(content))
+	assert.NoFileExists(t, filepath.Join(workspaceDir, "src", "main.go"))
+	assert.True(t, isShallowClone(ctx, workspaceDir))
+}
+
+func TestCloneRepository_ShortCommitAndConfigDepth(t *testing.T) {
+	source := newTestGitRepo(t)
+	server := NewServer(&Config{})
+	ctx := context.Background()
+
+	output, err := exec.Command("git", "-C", source, "rev-parse", "v1").Output()
+	require.NoError(t, err)
+	first := strings.TrimSpace(string(output))
+
+	repo := M4Repository{URL: "file://" + source, Clone: config.CloneConfig{Ref: first[:7]}}
+	workspaceDir := filepath.Join(t.TempDir(), "ws")
+	require.NoError(t, server.cloneRepository(ctx, repo, "", "", workspaceDir))
+	head, err := resolveHeadCommit(ctx, workspaceDir)
+	require.NoError(t, err)
+	assert.Equal(t, first, head)
+
+	// depth from .nexus/config.yaml deepens the default shallow clone
+	repo = M4Repository{URL: "file://" + source}
+	workspaceDir = filepath.Join(t.TempDir(), "deep")
+	require.NoError(t, server.cloneRepository(ctx, repo, "", "", workspaceDir))
+	require.NoError(t, server.applyCloneConfig(ctx, repo, config.CloneConfig{Depth: 2}, "", workspaceDir))
+	output, err = exec.Command("git", "-C", workspaceDir, "rev-list", "--count", "HEAD").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "2", strings.TrimSpace(string(output)))
+}
+
+func TestCloneRepository_MirrorCache(t *testing.T) {
+	source := newTestGitRepo(t)
+	server := NewServer(&Config{})
+	dataDir := t.TempDir()
+	server.mirrorCache = NewMirrorCache("mirrors", dataDir)
+	ctx := context.Background()
+
+	// The default shallow clone doesn't touch the mirror
+	repo := M4Repository{URL: "file://" + source}
+	require.NoError(t, server.cloneRepository(ctx, repo, "installation:1", "", filepath.Join(t.TempDir(), "shallow")))
+	assert.NoDirExists(t, filepath.Join(dataDir, "mirrors"))
+
+	repo.Clone.Depth = -1
+	for _, name := range []string{"first", "second"} {
+		workspaceDir := filepath.Join(t.TempDir(), name)
+		require.NoError(t, server.cloneRepository(ctx, repo, "installation:1", "", workspaceDir))
+
+		assert.False(t, isShallowClone(ctx, workspaceDir), "mirror-backed clones carry full history")
+		assert.NoFileExists(t, filepath.Join(workspaceDir, ".git", "objects", "info", "alternates"),
+			"workspace must not depend on the mirror")
+	}
+
+	assert.DirExists(t, server.mirrorCache.Path("installation:1", repo.URL))
+	assert.NotEqual(t, server.mirrorCache.Path("installation:1", repo.URL), server.mirrorCache.Path("installation:2", repo.URL))
+	info, err := os.Stat(filepath.Join(dataDir, "mirrors"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
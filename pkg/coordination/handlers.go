@@ -4,13 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/nexus/nexus/pkg/github"
+	"github.com/nexus/nexus/pkg/logging"
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 // handleRegisterNode handles node registration
@@ -39,6 +41,7 @@ func (s *Server) handleRegisterNode(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
+	audit(r, "", node.ID)
 
 	if err := s.registry.Register(&node); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to register node: %v", err), http.StatusInternalServerError)
@@ -132,6 +135,58 @@ func (s *Server) handleUpdateNode(w http.ResponseWriter, r *http.Request, nodeID
 	json.NewEncoder(w).Encode(node)
 }
 
+// handleNodeHeartbeat records that a node is alive
+func (s *Server) handleNodeHeartbeat(w http.ResponseWriter, r *http.Request, nodeID string) {
+	var heartbeat struct {
+		Status       string                 `json:"status"`
+		Capabilities map[string]interface{} `json:"capabilities"`
+		Metadata     map[string]interface{} `json:"metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&heartbeat); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	node, err := s.registry.Get(nodeID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Node not found: %v", err), http.StatusNotFound)
+		return
+	}
+
+	// Update refreshes last_seen even when nothing else changed
+	updates := make(map[string]interface{})
+	if heartbeat.Status != "" {
+		updates["status"] = heartbeat.Status
+	}
+	// Heartbeats carry fresh capacity and telemetry; keep what the node
+	// registered with and didn't resend
+	if len(heartbeat.Capabilities) > 0 {
+		updates["capabilities"] = mergeFields(node.Capabilities, heartbeat.Capabilities)
+	}
+	if len(heartbeat.Metadata) > 0 {
+		updates["metadata"] = mergeFields(node.Metadata, heartbeat.Metadata)
+	}
+	if err := s.registry.Update(nodeID, updates); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update node: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.metrics.heartbeats.WithLabelValues(nodeID).Inc()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mergeFields returns a copy of base with the fields in update set
+func mergeFields(base, update map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(update))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range update {
+		merged[k] = v
+	}
+	return merged
+}
+
 // handleUnregisterNode handles unregistering a node
 func (s *Server) handleUnregisterNode(w http.ResponseWriter, r *http.Request, nodeID string) {
 	if err := s.registry.Unregister(nodeID); err != nil {
@@ -159,6 +214,7 @@ func (s *Server) handleSendCommand(w http.ResponseWriter, r *http.Request, nodeI
 		command.ID = fmt.Sprintf("cmd_%d_%s", time.Now().Unix(), nodeID)
 	}
 	command.Created = time.Now()
+	command.TraceContext = tracing.Inject(r.Context())
 
 	// Verify node exists
 	_, err := s.registry.Get(nodeID)
@@ -183,7 +239,7 @@ func (s *Server) handleSendCommand(w http.ResponseWriter, r *http.Request, nodeI
 	select {
 	case s.commandCh <- result:
 	default:
-		log.Printf("Command result channel full, dropping result for command %s", command.ID)
+		slog.WarnContext(r.Context(), "Command result channel full, dropping result", "command_id", command.ID)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -203,11 +259,13 @@ func (s *Server) handleCommandResult(w http.ResponseWriter, r *http.Request, com
 		return
 	}
 
+	s.metrics.commands.WithLabelValues(result.Command.Type, result.Status).Observe(result.Duration.Seconds())
+
 	// Send result through channel for broadcasting
 	select {
 	case s.commandCh <- result:
 	default:
-		log.Printf("Command result channel full, dropping result for command %s", commandID)
+		slog.WarnContext(r.Context(), "Command result channel full, dropping result", "command_id", commandID)
 	}
 
 	w.WriteHeader(http.StatusAccepted)
@@ -269,7 +327,8 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(health)
 }
 
-// handleMetrics handles metrics requests
+// handleMetrics returns a JSON summary of nodes, services and clients.
+// Prometheus metrics are served on /metrics.
 func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	nodes, err := s.registry.List()
 	if err != nil {
@@ -340,11 +399,18 @@ func (s *Server) handleServerSentEvents(w http.ResponseWriter, r *http.Request)
 	// Create client channel
 	clientChan := make(chan Event, 10)
 
+	transport := "sse"
+	if r.Header.Get("Upgrade") == "websocket" {
+		transport = "websocket"
+	}
+	s.metrics.streamClients.WithLabelValues(transport).Inc()
+
 	s.clientsMu.Lock()
 	s.clients[clientChan] = true
 	s.clientsMu.Unlock()
 
 	defer func() {
+		s.metrics.streamClients.WithLabelValues(transport).Dec()
 		s.clientsMu.Lock()
 		delete(s.clients, clientChan)
 		s.clientsMu.Unlock()
@@ -389,6 +455,12 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// GitHub cannot send bearer tokens; webhook deliveries are verified by signature instead
+		if r.URL.Path == "/webhooks/github" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
 			http.Error(w, "Authorization header required", http.StatusUnauthorized)
@@ -403,34 +475,79 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 		}
 
 		token := parts[1]
-		if token != s.config.Server.AuthToken && token != s.config.Auth.JWTSecret {
+		credential := credentialService
+		member, isMember := s.usageMember(r)
+		switch {
+		case s.isAdmin(r):
+			credential = credentialAdmin
+		case isMember && strings.HasPrefix(r.URL.Path, "/api/v1/usage/"):
+			// Member tokens only reach the team usage endpoints
+			credential = credentialMember + member
+		case token != s.config.Server.AuthToken && token != s.config.Auth.JWTSecret:
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
+		auditCredential(r, credential)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// loggingMiddleware gives each request an ID, echoed in X-Request-ID and in
+// error responses, carries it in the request context and logs the request
+// once it's handled. Callers may send their own X-Request-ID to correlate
+// with their logs.
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = generateRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := logging.WithRequestID(r.Context(), requestID)
+
 		// Wrap response writer to capture status code
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
-		next.ServeHTTP(wrapped, r)
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
 
-		duration := time.Since(start)
-		log.Printf("%s %s %d %v", r.Method, r.URL.Path, wrapped.statusCode, duration)
+		level := slog.LevelInfo
+		switch {
+		case wrapped.statusCode >= 500:
+			level = slog.LevelError
+		case wrapped.statusCode >= 400:
+			level = slog.LevelWarn
+		}
+		slog.Log(ctx, level, "Handled request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", wrapped.statusCode,
+			"duration", time.Since(start))
 	})
 }
 
+// validRequestID reports whether a caller-supplied request ID is safe to log
+// and echo back
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -446,6 +563,7 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 func (s *Server) handleUsersRequest(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
+		audit(r, "user.register", "")
 		s.handleRegisterUser(w, r)
 	case http.MethodGet:
 		s.handleListUsers(w, r)
@@ -467,6 +585,7 @@ func (s *Server) handleUserRequest(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		s.handleGetUser(w, r, username)
 	case http.MethodDelete:
+		audit(r, "user.delete", username)
 		s.handleDeleteUser(w, r, username)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -512,6 +631,7 @@ func (s *Server) handleRegisterUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
+	audit(r, "", user.Username)
 
 	userRegistry := s.registry.GetUserRegistry()
 	if err := userRegistry.Register(&user); err != nil {
@@ -609,6 +729,13 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush lets server-sent events stream through the logging middleware
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // GitHubOAuthCallbackRequest is the OAuth callback query parameters
 type GitHubOAuthCallbackRequest struct {
 	Code  string `json:"code"`
@@ -632,6 +759,7 @@ func (s *Server) handleGitHubOAuthCallback(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	audit(r, "github.authorize", "")
 
 	code := r.URL.Query().Get("code")
 	state := r.URL.Query().Get("state")
@@ -639,7 +767,7 @@ func (s *Server) handleGitHubOAuthCallback(w http.ResponseWriter, r *http.Reques
 
 	if code == "" {
 		if errMsg != "" {
-			log.Printf("GitHub OAuth error: %s", errMsg)
+			slog.WarnContext(r.Context(), "GitHub OAuth error", "error", errMsg)
 			http.Redirect(w, r, "/workspace/auth-error?error="+errMsg, http.StatusSeeOther)
 		} else {
 			http.Error(w, "Missing authorization code", http.StatusBadRequest)
@@ -649,7 +777,7 @@ func (s *Server) handleGitHubOAuthCallback(w http.ResponseWriter, r *http.Reques
 
 	// Validate CSRF token
 	if !s.oauthStateStore.Validate(state) {
-		log.Printf("Invalid OAuth state token: %s", state)
+		slog.WarnContext(r.Context(), "Invalid OAuth state token", "state", state)
 		resp := GitHubOAuthCallbackResponse{
 			Success: false,
 			Message: "Invalid state token (possibly expired)",
@@ -664,7 +792,7 @@ func (s *Server) handleGitHubOAuthCallback(w http.ResponseWriter, r *http.Reques
 	// Exchange authorization code for access token
 	appConfig := s.appConfig
 	if appConfig == nil {
-		log.Printf("GitHub App configuration not initialized")
+		slog.ErrorContext(r.Context(), "GitHub App configuration not initialized")
 		http.Error(w, "GitHub App not configured", http.StatusInternalServerError)
 		return
 	}
@@ -674,7 +802,7 @@ func (s *Server) handleGitHubOAuthCallback(w http.ResponseWriter, r *http.Reques
 
 	installation, err := github.ExchangeCodeForToken(ctx, appConfig.ClientID, appConfig.ClientSecret, code)
 	if err != nil {
-		log.Printf("Failed to exchange OAuth code: %v", err)
+		slog.WarnContext(r.Context(), "Failed to exchange OAuth code", "error", err)
 		resp := GitHubOAuthCallbackResponse{
 			Success: false,
 			Message: fmt.Sprintf("Failed to authorize: %v", err),
@@ -686,6 +814,14 @@ func (s *Server) handleGitHubOAuthCallback(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Link the App installation so installation tokens can be minted and refreshed
+	if installationID, err := github.GetInstallationIDForUser(ctx, installation.AccessToken); err == nil {
+		installation.InstallationID = installationID
+	} else {
+		slog.InfoContext(r.Context(), "No GitHub App installation found, using user token",
+			"user_id", installation.GitHubUsername, "error", err)
+	}
+
 	// Store GitHub installation in database
 	// For now, we'll store it in memory via the workspace registry
 	// In a production system, this would be a database operation
@@ -696,13 +832,14 @@ func (s *Server) handleGitHubOAuthCallback(w http.ResponseWriter, r *http.Reques
 		GitHubUsername: installation.GitHubUsername,
 		Token:          installation.AccessToken,
 		TokenExpiresAt: installation.ExpiresAt,
+		UserToken:      installation.AccessToken,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
 
 	// Validate the installation
 	if err := gitHubInstallation.Validate(); err != nil {
-		log.Printf("Invalid GitHub installation data: %v", err)
+		slog.WarnContext(r.Context(), "Invalid GitHub installation data", "error", err)
 		resp := GitHubOAuthCallbackResponse{
 			Success: false,
 			Message: fmt.Sprintf("Invalid installation data: %v", err),
@@ -714,20 +851,23 @@ func (s *Server) handleGitHubOAuthCallback(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	auditClaimedUser(r, installation.GitHubUsername)
+	audit(r, "", installation.GitHubUsername)
+
 	s.gitHubInstallationsMu.Lock()
 	s.gitHubInstallations[installation.GitHubUsername] = gitHubInstallation
 	s.gitHubInstallationsMu.Unlock()
-	log.Printf("Stored GitHub installation in memory for user: %s", installation.GitHubUsername)
+	logCtx := logging.With(r.Context(), slog.String("user_id", installation.GitHubUsername))
+	slog.InfoContext(logCtx, "Stored GitHub installation in memory")
 
 	if sqliteReg, ok := s.registry.(*SQLiteRegistry); ok {
-		log.Printf("DEBUG: Registry is SQLiteRegistry, attempting to persist...")
 		if err := sqliteReg.StoreGitHubInstallation(gitHubInstallation); err != nil {
-			log.Printf("ERROR: Failed to persist GitHub installation to database: %v", err)
+			slog.ErrorContext(logCtx, "Failed to persist GitHub installation", "error", err)
 		} else {
-			log.Printf("SUCCESS: Persisted GitHub installation to database for user: %s", installation.GitHubUsername)
+			slog.InfoContext(logCtx, "Persisted GitHub installation")
 		}
 	} else {
-		log.Printf("WARNING: Registry is not SQLiteRegistry (type: %T), cannot persist to database", s.registry)
+		slog.WarnContext(logCtx, "Registry cannot persist GitHub installations", "registry", fmt.Sprintf("%T", s.registry))
 	}
 
 	userRegistry := s.registry.GetUserRegistry()
@@ -741,9 +881,9 @@ func (s *Server) handleGitHubOAuthCallback(w http.ResponseWriter, r *http.Reques
 			UpdatedAt: time.Now(),
 		}
 		if err := userRegistry.Register(newUser); err != nil {
-			log.Printf("Warning: failed to auto-register user %s: %v", installation.GitHubUsername, err)
+			slog.WarnContext(logCtx, "Failed to auto-register user", "error", err)
 		} else {
-			log.Printf("Auto-registered user: %s", installation.GitHubUsername)
+			slog.InfoContext(logCtx, "Auto-registered user")
 		}
 	}
 
@@ -817,6 +957,7 @@ func (s *Server) handleGetGitHubToken(w http.ResponseWriter, r *http.Request) {
 	// Extract user ID from request context or header
 	// In a real implementation, this would come from authenticated user context
 	userID := r.Header.Get("X-User-ID")
+	audit(r, "github.token", userID)
 	if userID == "" {
 		http.Error(w, "User ID required", http.StatusUnauthorized)
 		return
@@ -836,9 +977,20 @@ func (s *Server) handleGetGitHubToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	token, expiresAt := s.installationToken(installation)
+	if installation.InstallationID != 0 && s.tokenBroker != nil {
+		var err error
+		token, expiresAt, err = s.tokenBroker.Token(r.Context(), installation.InstallationID)
+		if err != nil {
+			sendM4JSONError(w, http.StatusBadGateway, "token_refresh_failed", fmt.Sprintf("Failed to refresh GitHub token: %v", err), nil)
+			return
+		}
+		s.syncInstallationToken(r.Context(), installation)
+	}
+
 	resp := map[string]interface{}{
-		"token":       installation.Token,
-		"expires_at":  installation.TokenExpiresAt,
+		"token":       token,
+		"expires_at":  expiresAt,
 		"user_id":     installation.UserID,
 		"github_user": installation.GitHubUsername,
 	}
@@ -869,11 +1021,13 @@ func (s *Server) handleGetGitHubOAuthURL(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	audit(r, "github.oauth_url", "")
 	var req GitHubOAuthURLRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
+	audit(r, "", req.RepoFullName)
 
 	appConfig := s.appConfig
 	if appConfig == nil {
@@ -0,0 +1,262 @@
+package coordination
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexus/nexus/pkg/logging"
+)
+
+const (
+	// defaultAuditLimit and maxAuditLimit bound how many events one audit
+	// query returns
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// Credentials a request can authenticate with, recorded as the actor
+const (
+	credentialService   = "service"
+	credentialAdmin     = "admin"
+	credentialWebhook   = "github_webhook"
+	credentialAnonymous = "anonymous"
+	// credentialMember prefixes the member a usage upload token belongs to
+	credentialMember = "member:"
+)
+
+type auditContextKey struct{}
+
+// auditEntry collects what the audit log needs to know about a request
+// while it's handled. Requests that no handler marks with an action aren't
+// recorded.
+type auditEntry struct {
+	action      string
+	target      string
+	claimedUser string
+	credential  string
+}
+
+func auditEntryFrom(r *http.Request) *auditEntry {
+	entry, _ := r.Context().Value(auditContextKey{}).(*auditEntry)
+	return entry
+}
+
+// audit marks the request as a privileged action on target, to be recorded
+// once it's handled. Handlers may call it again as they learn more; empty
+// arguments keep what was set before.
+func audit(r *http.Request, action, target string) {
+	entry := auditEntryFrom(r)
+	if entry == nil {
+		return
+	}
+	if action != "" {
+		entry.action = action
+	}
+	if target != "" {
+		entry.target = target
+	}
+}
+
+// auditClaimedUser records the user the request says it acts for, such as
+// the GitHub user it registers a key or creates a workspace for. Nothing
+// verifies the claim, so it is kept apart from the actor.
+func auditClaimedUser(r *http.Request, user string) {
+	if entry := auditEntryFrom(r); entry != nil && user != "" {
+		entry.claimedUser = user
+	}
+}
+
+// auditCredential records the credential the request authenticated with
+func auditCredential(r *http.Request, credential string) {
+	if entry := auditEntryFrom(r); entry != nil {
+		entry.credential = credential
+	}
+}
+
+// auditMiddleware records the requests handlers mark with audit, with a
+// result taken from the status they respond with. Mutating requests that
+// are refused with 401 before any handler runs are recorded as auth.denied.
+// It runs inside loggingMiddleware so events carry the request ID.
+func (s *Server) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := &auditEntry{}
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(wrapped, r.WithContext(context.WithValue(r.Context(), auditContextKey{}, entry)))
+
+		if entry.action == "" && wrapped.statusCode == http.StatusUnauthorized && isMutating(r.Method) {
+			entry.action = "auth.denied"
+			entry.target = r.Method + " " + r.URL.Path
+		}
+		if entry.action == "" {
+			return
+		}
+		event := &AuditEvent{
+			Timestamp:   time.Now().UTC(),
+			Actor:       entry.actor(),
+			ClaimedUser: entry.claimedUserFor(r),
+			Action:      entry.action,
+			Target:      entry.target,
+			SourceIP:    sourceIP(r),
+			Result:      auditResult(wrapped.statusCode),
+			Status:      wrapped.statusCode,
+			RequestID:   logging.RequestID(r.Context()),
+		}
+		if err := s.auditLog.RecordAudit(event); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to record audit event", "action", event.Action, "error", err)
+		}
+	})
+}
+
+// actor is the credential the request authenticated with. It is the only
+// identity the server has verified, so it is always what's recorded as
+// the actor.
+func (e *auditEntry) actor() string {
+	if e.credential == "" {
+		return credentialAnonymous
+	}
+	return e.credential
+}
+
+// claimedUserFor is the user the handler named, else the X-User-ID the
+// request claims
+func (e *auditEntry) claimedUserFor(r *http.Request) string {
+	if e.claimedUser != "" {
+		return e.claimedUser
+	}
+	return r.Header.Get("X-User-ID")
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+func auditResult(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return AuditDenied
+	case status >= 400:
+		return AuditFailure
+	default:
+		return AuditSuccess
+	}
+}
+
+// sourceIP is the address the request came from. X-Forwarded-For is not
+// trusted, since any client can set it.
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isAdmin reports whether the request carries the admin token. Admin
+// endpoints are closed while no admin token is configured.
+func (s *Server) isAdmin(r *http.Request) bool {
+	if s.config.Auth.AdminToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Auth.AdminToken)) == 1
+}
+
+// AuditResponse lists audit events, newest first
+type AuditResponse struct {
+	Events []AuditEvent `json:"events"`
+	Count  int          `json:"count"`
+}
+
+// handleAudit returns the audit log to admins
+// GET /api/v1/audit
+// Query params: actor, user, action, target, result, since, until (RFC 3339 or
+// YYYY-MM-DD), limit (1 to 1000, default 100)
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// Reading the audit log is itself audited, so attempts without the
+	// admin token show up as denied
+	audit(r, "audit.read", "")
+	if !s.isAdmin(r) {
+		sendM4JSONError(w, http.StatusForbidden, "admin_required", "The audit log requires the admin token", nil)
+		return
+	}
+	auditCredential(r, credentialAdmin)
+
+	query := r.URL.Query()
+	filter := AuditFilter{
+		Actor:       query.Get("actor"),
+		ClaimedUser: query.Get("user"),
+		Action:      query.Get("action"),
+		Target:      query.Get("target"),
+		Result:      query.Get("result"),
+		Limit:       defaultAuditLimit,
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			sendM4JSONError(w, http.StatusBadRequest, "invalid_filter", "limit must be a number from 1 to 1000", nil)
+			return
+		}
+		filter.Limit = limit
+	}
+	for _, bound := range []struct {
+		param string
+		value *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		value := query.Get(bound.param)
+		if value == "" {
+			continue
+		}
+		t, err := parseAuditTime(value, bound.param == "until")
+		if err != nil {
+			sendM4JSONError(w, http.StatusBadRequest, "invalid_filter", bound.param+" must be an RFC 3339 time or a YYYY-MM-DD date", nil)
+			return
+		}
+		*bound.value = t
+	}
+
+	events, err := s.auditLog.QueryAudit(filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to query audit log", "error", err)
+		sendM4JSONError(w, http.StatusInternalServerError, "storage_error", "Failed to query the audit log", nil)
+		return
+	}
+	if events == nil {
+		events = []AuditEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AuditResponse{Events: events, Count: len(events)})
+}
+
+// parseAuditTime reads an RFC 3339 time or a UTC date; a date ending a
+// range covers the whole day
+func parseAuditTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
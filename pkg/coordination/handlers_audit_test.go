@@ -0,0 +1,236 @@
+package coordination
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAdminToken = "admin-secret"
+
+func newAuditTestServer() (*Server, http.Handler) {
+	cfg := &Config{}
+	cfg.Registry.Storage.Type = "memory"
+	cfg.Auth.AdminToken = testAdminToken
+	srv := NewServer(cfg)
+	return srv, srv.loggingMiddleware(srv.auditMiddleware(srv.authMiddleware(srv.router)))
+}
+
+func serve(handler http.Handler, method, target, token string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	req.RemoteAddr = "203.0.113.7:51234"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func queryAudit(t *testing.T, handler http.Handler, query string) []AuditEvent {
+	t.Helper()
+	w := serve(handler, http.MethodGet, "/api/v1/audit"+query, testAdminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp AuditResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, len(resp.Events), resp.Count)
+	return resp.Events
+}
+
+func TestAuditMutatingHandlers(t *testing.T) {
+	srv, handler := newAuditTestServer()
+	require.NoError(t, srv.workspaceRegistry.Create(&DBWorkspace{WorkspaceID: "ws-1", UserID: "alice", WorkspaceName: "shop"}))
+
+	w := serve(handler, http.MethodPost, "/api/v1/users/register-github", "", strings.NewReader(
+		`{"github_username":"alice","github_id":1,"ssh_pubkey":"ssh-ed25519 AAAA","ssh_pubkey_fingerprint":"SHA256:abc"}`))
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = serve(handler, http.MethodPost, "/api/v1/workspaces/ws-1/stop", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = serve(handler, http.MethodDelete, "/api/v1/workspaces/missing", "", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	req := httptest.NewRequest(http.MethodGet, "/api/github/token", nil)
+	req.Header.Set("X-User-ID", "bob")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	serve(handler, http.MethodGet, "/api/v1/workspaces/ws-1/status", "", nil)
+
+	events := queryAudit(t, handler, "")
+	require.Len(t, events, 4, "reads aren't audited")
+
+	assert.Equal(t, "github.token", events[0].Action)
+	assert.Equal(t, credentialAnonymous, events[0].Actor, "a claimed X-User-ID is not the actor")
+	assert.Equal(t, "bob", events[0].ClaimedUser)
+	assert.Equal(t, "bob", events[0].Target)
+	assert.Equal(t, AuditFailure, events[0].Result)
+
+	assert.Equal(t, "workspace.delete", events[1].Action)
+	assert.Equal(t, "missing", events[1].Target)
+	assert.Equal(t, AuditFailure, events[1].Result)
+	assert.Equal(t, http.StatusNotFound, events[1].Status)
+
+	assert.Equal(t, "workspace.stop", events[2].Action)
+	assert.Equal(t, "ws-1", events[2].Target)
+	assert.Equal(t, credentialAnonymous, events[2].Actor)
+
+	register := events[3]
+	assert.Equal(t, "ssh_key.register", register.Action)
+	assert.Equal(t, credentialAnonymous, register.Actor)
+	assert.Equal(t, "alice", register.ClaimedUser)
+	assert.Equal(t, "SHA256:abc", register.Target)
+	assert.Equal(t, "203.0.113.7", register.SourceIP)
+	assert.Equal(t, AuditSuccess, register.Result)
+	assert.NotEmpty(t, register.RequestID)
+	assert.WithinDuration(t, time.Now(), register.Timestamp, time.Minute)
+
+	events = queryAudit(t, handler, "")
+	assert.Equal(t, "audit.read", events[0].Action, "reading the audit log is audited too")
+	assert.Equal(t, credentialAdmin, events[0].Actor)
+}
+
+func TestAuditCredentialActor(t *testing.T) {
+	srv, _ := newAuditTestServer()
+	srv.config.Auth.Enabled = true
+	srv.config.Server.AuthToken = "service-token"
+	handler := srv.loggingMiddleware(srv.auditMiddleware(srv.authMiddleware(srv.router)))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/carol", nil)
+	req.Header.Set("Authorization", "Bearer service-token")
+	req.Header.Set("X-User-ID", "mallory")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	events := queryAudit(t, handler, "?action=user")
+	require.Len(t, events, 1)
+	assert.Equal(t, "user.delete", events[0].Action)
+	assert.Equal(t, credentialService, events[0].Actor, "the actor is the credential, whatever user the request claims")
+	assert.Equal(t, "mallory", events[0].ClaimedUser)
+	assert.Equal(t, "carol", events[0].Target)
+
+	events = queryAudit(t, handler, "?user=mallory")
+	require.Len(t, events, 1)
+	assert.Equal(t, "user.delete", events[0].Action)
+}
+
+func TestAuditUnauthorizedMutations(t *testing.T) {
+	srv, _ := newAuditTestServer()
+	srv.config.Auth.Enabled = true
+	srv.config.Server.AuthToken = "service-token"
+	handler := srv.loggingMiddleware(srv.auditMiddleware(srv.authMiddleware(srv.router)))
+
+	w := serve(handler, http.MethodDelete, "/api/v1/users/carol", "", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w = serve(handler, http.MethodPost, "/api/v1/workspaces/ws-1/stop", "wrong", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w = serve(handler, http.MethodGet, "/api/v1/workspaces", "", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	events := queryAudit(t, handler, "?action=auth.denied")
+	require.Len(t, events, 2, "rejected reads aren't audited")
+	assert.Equal(t, "POST /api/v1/workspaces/ws-1/stop", events[0].Target)
+	assert.Equal(t, "DELETE /api/v1/users/carol", events[1].Target)
+	assert.Equal(t, AuditDenied, events[1].Result)
+	assert.Equal(t, credentialAnonymous, events[1].Actor)
+}
+
+func TestHandleAuditRequiresAdmin(t *testing.T) {
+	srv, handler := newAuditTestServer()
+
+	w := serve(handler, http.MethodGet, "/api/v1/audit", "", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(handler, http.MethodGet, "/api/v1/audit", "wrong", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	events, err := srv.auditLog.QueryAudit(AuditFilter{Action: "audit.read"})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, AuditDenied, events[0].Result)
+
+	srv.config.Auth.AdminToken = ""
+	w = serve(handler, http.MethodGet, "/api/v1/audit", "", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code, "the audit log is closed without an admin token")
+}
+
+func TestHandleAuditFilters(t *testing.T) {
+	srv, handler := newAuditTestServer()
+	day := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	for _, event := range []AuditEvent{
+		{Timestamp: day.AddDate(0, 0, -1), Actor: "alice", Action: "workspace.create", Target: "ws-1", Result: AuditSuccess},
+		{Timestamp: day, Actor: "alice", Action: "workspace.delete", Target: "ws-1", Result: AuditSuccess},
+		{Timestamp: day, Actor: "bob", Action: "workspace.delete", Target: "ws-2", Result: AuditDenied},
+		{Timestamp: day.AddDate(0, 0, 1), Actor: "bob", Action: "workspaces.list", Result: AuditSuccess},
+	} {
+		require.NoError(t, srv.auditLog.RecordAudit(&event))
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		targets []string
+	}{
+		{"actor", "?actor=alice", []string{"ws-1", "ws-1"}},
+		{"action_prefix", "?action=workspace", []string{"ws-2", "ws-1", "ws-1"}},
+		{"result", "?result=denied", []string{"ws-2"}},
+		{"target", "?target=ws-1&action=workspace.delete", []string{"ws-1"}},
+		{"dates", "?since=2026-05-10&until=2026-05-10&actor=bob", []string{"ws-2"}},
+		{"limit", "?limit=1&action=workspace", []string{"ws-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var targets []string
+			for _, event := range queryAudit(t, handler, tt.query) {
+				if event.Action != "audit.read" {
+					targets = append(targets, event.Target)
+				}
+			}
+			assert.Equal(t, tt.targets, targets)
+		})
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=5000", "?since=yesterday"} {
+		w := serve(handler, http.MethodGet, "/api/v1/audit"+query, testAdminToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestSQLiteAuditStore(t *testing.T) {
+	registry, err := NewSQLiteRegistry(t.TempDir() + "/test.db")
+	require.NoError(t, err)
+	store := NewSQLiteAuditStore(registry.db)
+
+	now := time.Now().UTC()
+	first := &AuditEvent{Timestamp: now.Add(-time.Hour), Actor: "service", ClaimedUser: "alice", Action: "ssh_key.register", Target: "SHA256:abc", SourceIP: "10.0.0.1", Result: AuditSuccess, Status: 201, RequestID: "req-1"}
+	require.NoError(t, store.RecordAudit(first))
+	require.NoError(t, store.RecordAudit(&AuditEvent{Timestamp: now, Actor: "alice", Action: "workspace.create", Target: "ws-1", Result: AuditFailure, Status: 500}))
+	assert.NotZero(t, first.ID)
+
+	events, err := store.QueryAudit(AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "workspace.create", events[0].Action, "newest first")
+	assert.Equal(t, first.ID, events[1].ID)
+	assert.Equal(t, "10.0.0.1", events[1].SourceIP)
+	assert.Equal(t, "req-1", events[1].RequestID)
+	assert.Equal(t, "alice", events[1].ClaimedUser)
+	assert.WithinDuration(t, first.Timestamp, events[1].Timestamp, time.Millisecond)
+
+	events, err = store.QueryAudit(AuditFilter{ClaimedUser: "alice", Action: "ssh_key", Since: now.Add(-2 * time.Hour), Until: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "SHA256:abc", events[0].Target)
+
+	events, err = store.QueryAudit(AuditFilter{Action: "ssh"})
+	require.NoError(t, err)
+	assert.Empty(t, events, "action prefixes match whole segments")
+
+	_, err = registry.db.Exec("UPDATE audit_log SET actor = 'mallory'")
+	assert.Error(t, err, "the audit log is append-only")
+	_, err = registry.db.Exec("DELETE FROM audit_log")
+	assert.Error(t, err, "the audit log is append-only")
+}
@@ -5,10 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -16,9 +16,15 @@ import (
 
 	"github.com/nexus/nexus/pkg/config"
 	"github.com/nexus/nexus/pkg/github"
+	"github.com/nexus/nexus/pkg/logging"
 	"github.com/nexus/nexus/pkg/provider"
+	"github.com/nexus/nexus/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// tracedClient makes outgoing requests that carry the caller's trace context
+var tracedClient = &http.Client{Transport: tracing.Transport(nil)}
+
 type M4RegisterGitHubUserRequest struct {
 	GitHubUsername          string `json:"github_username"`
 	GitHubID                int64  `json:"github_id"`
@@ -35,11 +41,13 @@ type M4RegisterGitHubUserResponse struct {
 }
 
 type M4Repository struct {
-	Owner  string `json:"owner"`
-	Name   string `json:"name"`
-	URL    string `json:"url"`
-	Branch string `json:"branch"`
-	IsFork bool   `json:"is_fork"`
+	Owner  string             `json:"owner"`
+	Name   string             `json:"name"`
+	URL    string             `json:"url"`
+	Branch string             `json:"branch"`
+	Commit string             `json:"commit,omitempty"`
+	IsFork bool               `json:"is_fork"`
+	Clone  config.CloneConfig `json:"clone,omitempty"`
 }
 
 type M4HealthCheckConfig struct {
@@ -147,18 +155,27 @@ type M4ErrorResponse struct {
 	RequestID string                 `json:"request_id"`
 }
 
+// requestIDHeader carries the request ID in requests and responses
+const requestIDHeader = "X-Request-ID"
+
 func generateRequestID() string {
 	return fmt.Sprintf("req-%d", time.Now().UnixNano())
 }
 
 func sendM4JSONError(w http.ResponseWriter, statusCode int, errorCode string, message string, details map[string]interface{}) {
+	// The logging middleware has already assigned the request its ID
+	requestID := w.Header().Get(requestIDHeader)
+	if requestID == "" {
+		requestID = generateRequestID()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	resp := M4ErrorResponse{
 		Error:     errorCode,
 		Message:   message,
 		Details:   details,
-		RequestID: generateRequestID(),
+		RequestID: requestID,
 	}
 	json.NewEncoder(w).Encode(resp)
 }
@@ -169,11 +186,14 @@ func (s *Server) handleM4RegisterGitHub(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	audit(r, "ssh_key.register", "")
 	var req M4RegisterGitHubUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		sendM4JSONError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", map[string]interface{}{"error": err.Error()})
 		return
 	}
+	auditClaimedUser(r, req.GitHubUsername)
+	audit(r, "", req.SSHPublicKeyFingerprint)
 
 	if req.GitHubUsername == "" || req.GitHubID == 0 || req.SSHPublicKey == "" || req.SSHPublicKeyFingerprint == "" {
 		sendM4JSONError(w, http.StatusBadRequest, "missing_fields", "Missing required fields", map[string]interface{}{
@@ -225,11 +245,15 @@ func (s *Server) handleM4CreateWorkspace(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	audit(r, "workspace.create", "")
 	var req M4CreateWorkspaceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		sendM4JSONError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", map[string]interface{}{"error": err.Error()})
 		return
 	}
+	// The target is the workspace's name until it has an ID
+	auditClaimedUser(r, req.GitHubUsername)
+	audit(r, "", req.WorkspaceName)
 
 	if req.GitHubUsername == "" || req.WorkspaceName == "" || req.Provider == "" {
 		sendM4JSONError(w, http.StatusBadRequest, "missing_fields", "Missing required fields", map[string]interface{}{
@@ -287,12 +311,27 @@ func (s *Server) handleM4CreateWorkspace(w http.ResponseWriter, r *http.Request)
 	forkCreated := false
 	forkURL := ""
 
-	repoCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	repoCtx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	repoInfo, err := github.GetRepositoryInfo(repoCtx, installation.Token, repoOwner, repoName)
+	var repoInfo *github.Repository
+	err = s.withGitHubToken(repoCtx, installation, func(token string) error {
+		var infoErr error
+		repoInfo, infoErr = github.GetRepositoryInfo(repoCtx, token, repoOwner, repoName)
+		return infoErr
+	})
 	if err == nil && repoInfo != nil && repoInfo.Private && repoInfo.Owner.Login != req.GitHubUsername {
-		fork, forkErr := github.ForkRepository(repoCtx, installation.Token, repoOwner, repoName)
+		// A fork lands in the account of whoever the token acts for, so it
+		// is made with the user's own token rather than the App's
+		var fork *github.Repository
+		forkErr := fmt.Errorf("no GitHub user token to fork with")
+		if userToken := s.userToken(installation); userToken != "" {
+			fork, forkErr = github.ForkRepository(repoCtx, userToken, repoOwner, repoName)
+		}
+		if forkErr != nil {
+			slog.WarnContext(r.Context(), "Failed to fork repository, cloning the original",
+				"repository", repoOwner+"/"+repoName, "error", forkErr)
+		}
 		if forkErr == nil && fork != nil {
 			forkCreated = true
 			forkURL = fork.CloneURL
@@ -314,13 +353,21 @@ func (s *Server) handleM4CreateWorkspace(w http.ResponseWriter, r *http.Request)
 		RepoURL:       repoURL,
 		RepoBranch:    req.Repository.Branch,
 	}
+	if req.Repository.Commit != "" {
+		commit := req.Repository.Commit
+		ws.RepoCommit = &commit
+	}
 
 	if err := s.workspaceRegistry.Create(ws); err != nil {
 		sendM4JSONError(w, http.StatusInternalServerError, "workspace_creation_failed", fmt.Sprintf("Failed to create workspace: %v", err), nil)
 		return
 	}
+	audit(r, "", workspaceID)
 
-	go s.provisionWorkspace(context.Background(), workspaceID, user.ID, req, int(sshPort), installation.Token)
+	// Provisioning outlives the request but stays in its trace
+	s.startProvisioning(context.WithoutCancel(r.Context()), workspaceID, func(ctx context.Context) {
+		s.provisionWorkspace(ctx, workspaceID, user.ID, req, int(sshPort), installation)
+	})
 
 	resp := M4CreateWorkspaceResponse{
 		WorkspaceID:       workspaceID,
@@ -338,94 +385,188 @@ func (s *Server) handleM4CreateWorkspace(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(resp)
 }
 
-func (s *Server) provisionWorkspace(ctx context.Context, workspaceID, userID string, req M4CreateWorkspaceRequest, sshPort int, githubToken string) {
-	fmt.Printf("[PROVISION START] Workspace: %s, User: %s, Token: %v\n", workspaceID, userID, githubToken != "")
+// provisioning is a workspace whose provisioning is still running
+type provisioning struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startProvisioning runs provision in the background with a context that
+// cancelProvisioning cancels, so a workspace torn down mid-provisioning
+// doesn't keep cloning and starting containers
+func (s *Server) startProvisioning(ctx context.Context, workspaceID string, provision func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &provisioning{cancel: cancel, done: make(chan struct{})}
+
+	s.provisioningMu.Lock()
+	s.provisioning[workspaceID] = p
+	s.provisioningMu.Unlock()
+
+	go func() {
+		defer func() {
+			cancel()
+			s.provisioningMu.Lock()
+			delete(s.provisioning, workspaceID)
+			s.provisioningMu.Unlock()
+			close(p.done)
+		}()
+		provision(ctx)
+	}()
+}
+
+// cancelProvisioning cancels the workspace's provisioning, if it is still
+// running, and waits for it to return
+func (s *Server) cancelProvisioning(workspaceID string) {
+	s.provisioningMu.Lock()
+	p := s.provisioning[workspaceID]
+	s.provisioningMu.Unlock()
+
+	if p != nil {
+		p.cancel()
+		<-p.done
+	}
+}
+
+// startStep starts a provisioning step: a span in the workspace's trace and
+// a timer for the step duration metric. Call the returned func with the
+// step's error to end both.
+func (s *Server) startStep(ctx context.Context, step string, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "provision."+step, attrs...)
+	ctx = logging.With(ctx, slog.String("step", step))
+	return ctx, func(err error) {
+		s.metrics.observeProvision(step, start, err)
+		tracing.End(span, err)
+	}
+}
+
+func (s *Server) provisionWorkspace(ctx context.Context, workspaceID, userID string, req M4CreateWorkspaceRequest, sshPort int, installation *GitHubInstallation) {
+	// Every provisioning log carries the workspace and user, alongside the
+	// ID of the request that asked for it
+	ctx = logging.With(ctx, slog.String("workspace_id", workspaceID), slog.String("user_id", userID))
+	slog.InfoContext(ctx, "Provisioning workspace",
+		"repository", req.Repository.Owner+"/"+req.Repository.Name,
+		"installation_id", installation.InstallationID,
+		"has_token", s.hasInstallationToken(installation))
+
+	ctx, endProvision := s.startStep(ctx, "total",
+		attribute.String("workspace.id", workspaceID),
+		attribute.String("workspace.provider", req.Provider),
+		attribute.String("repository", req.Repository.Owner+"/"+req.Repository.Name),
+	)
+	provisionErr := fmt.Errorf("provisioning did not finish")
+	defer func() { endProvision(provisionErr) }()
 
 	if err := s.workspaceRegistry.UpdateStatus(workspaceID, "creating"); err != nil {
-		fmt.Printf("[PROVISION ERROR] Failed to update workspace status to creating: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to mark workspace creating", "error", err)
 		return
 	}
 
 	if err := s.workspaceRegistry.UpdateSSHPort(workspaceID, sshPort, "localhost"); err != nil {
-		fmt.Printf("[PROVISION WARN] Failed to update SSH port: %v\n", err)
+		slog.WarnContext(ctx, "Failed to update SSH port", "error", err)
 	}
 
-	fmt.Printf("[PROVISION INFO] Provisioning workspace %s with GitHub token for user %s\n", workspaceID, userID)
-	fmt.Printf("[PROVISION INFO] Repository: %s/%s\n", req.Repository.Owner, req.Repository.Name)
-	fmt.Printf("[PROVISION INFO] GitHub token available: %v\n", githubToken != "")
-
 	workspaceDir := fmt.Sprintf("/tmp/nexus-workspaces/%s", workspaceID)
-	fmt.Printf("[PROVISION CLONE] Cloning to: %s\n", workspaceDir)
-	if err := s.cloneRepository(ctx, req.Repository, githubToken, workspaceDir); err != nil {
-		fmt.Printf("[PROVISION ERROR] Failed to clone repository: %v\n", err)
+	slog.InfoContext(ctx, "Cloning repository", "dir", workspaceDir)
+	stepCtx, endStep := s.startStep(ctx, "clone")
+	err := s.withGitHubToken(stepCtx, installation, func(token string) error {
+		return s.cloneRepository(stepCtx, req.Repository, mirrorScope(installation), token, workspaceDir)
+	})
+	endStep(err)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to clone repository", "error", err)
 		s.workspaceRegistry.UpdateStatus(workspaceID, "error")
 		return
 	}
-	fmt.Printf("[PROVISION CLONE] Clone successful\n")
+	slog.InfoContext(ctx, "Cloned repository")
 
 	configPath := filepath.Join(workspaceDir, ".nexus", "config.yaml")
 	var cfg *config.Config
 	if _, err := os.Stat(configPath); err == nil {
 		cfg, err = config.LoadConfig(configPath)
 		if err != nil {
-			fmt.Printf("[PROVISION WARN] Failed to load .nexus/config.yaml: %v, using defaults\n", err)
+			slog.WarnContext(ctx, "Failed to load .nexus/config.yaml, using defaults", "error", err)
 			cfg = &config.Config{Services: make(map[string]config.Service)}
 		}
 	} else {
-		fmt.Printf("[PROVISION INFO] No .nexus/config.yaml found, skipping service provisioning\n")
+		slog.InfoContext(ctx, "No .nexus/config.yaml found, skipping service provisioning")
 		cfg = &config.Config{Services: make(map[string]config.Service)}
 	}
 
+	cloneOpts := mergeCloneConfig(req.Repository, cfg.Clone)
+	stepCtx, endStep = s.startStep(ctx, "checkout")
+	err = s.withGitHubToken(stepCtx, installation, func(token string) error {
+		return s.applyCloneConfig(stepCtx, req.Repository, cloneOpts, token, workspaceDir)
+	})
+	endStep(err)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to apply clone options", "error", err)
+		s.workspaceRegistry.UpdateStatus(workspaceID, "error")
+		return
+	}
+
+	if commit, err := resolveHeadCommit(ctx, workspaceDir); err != nil {
+		slog.WarnContext(ctx, "Failed to resolve cloned commit", "error", err)
+	} else if err := s.workspaceRegistry.Update(workspaceID, map[string]interface{}{"repo_commit": commit}); err != nil {
+		slog.WarnContext(ctx, "Failed to record repo commit", "error", err)
+	}
+
 	providerName := req.Provider
 	if providerName == "" {
 		providerName = "docker"
 	}
 
 	if s.provider == nil || s.provider.Name() != providerName {
-		fmt.Printf("[PROVISION ERROR] Provider not initialized or mismatch: %s\n", providerName)
+		slog.ErrorContext(ctx, "Provider not initialized or mismatch", "provider", providerName)
 		s.workspaceRegistry.UpdateStatus(workspaceID, "error")
 		return
 	}
 
-	session, err := s.provider.Create(ctx, workspaceID, workspaceDir, cfg)
+	stepCtx, endStep = s.startStep(ctx, "create")
+	session, err := s.provider.Create(stepCtx, workspaceID, workspaceDir, cfg)
+	endStep(err)
 	if err != nil {
-		fmt.Printf("[PROVISION ERROR] Failed to create provider container: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to create workspace container", "error", err)
 		s.workspaceRegistry.UpdateStatus(workspaceID, "error")
 		return
 	}
-	fmt.Printf("[PROVISION INFO] Container created: %s\n", session.ID)
+	slog.InfoContext(ctx, "Created workspace container", "session_id", session.ID)
 
-	if err := s.provider.Start(ctx, session.ID); err != nil {
-		fmt.Printf("[PROVISION ERROR] Failed to start container: %v\n", err)
+	stepCtx, endStep = s.startStep(ctx, "start")
+	err = s.provider.Start(stepCtx, session.ID)
+	endStep(err)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to start workspace container", "error", err)
 		s.workspaceRegistry.UpdateStatus(workspaceID, "error")
 		return
 	}
-	fmt.Printf("[PROVISION INFO] Container started\n")
+	slog.InfoContext(ctx, "Started workspace container")
 
 	portsToForward := map[string]int{"22": 22}
-	for name, svc := range cfg.Services {
+	for _, svc := range cfg.Services {
 		if svc.Port > 0 {
 			containerPortStr := fmt.Sprintf("%d", svc.Port)
 			portsToForward[containerPortStr] = svc.Port
-			fmt.Printf("[PROVISION PORT] Service %s: port %d\n", name, svc.Port)
 		}
 	}
 
 	if lxcProvider, ok := s.provider.(interface {
 		SetupPortForwarding(context.Context, string, map[string]int) error
 	}); ok {
-		fmt.Printf("[PROVISION PORT] Setting up port forwarding for %d ports\n", len(portsToForward))
 		if err := lxcProvider.SetupPortForwarding(ctx, session.ID, portsToForward); err != nil {
-			fmt.Printf("[PROVISION ERROR] Failed to setup port forwarding: %v\n", err)
+			slog.ErrorContext(ctx, "Failed to set up port forwarding", "error", err)
 		} else {
-			fmt.Printf("[PROVISION PORT] Port forwarding configured successfully\n")
+			slog.InfoContext(ctx, "Set up port forwarding", "ports", portsToForward)
 		}
 	}
 
-	if err := s.setupSSHAccess(ctx, session.ID, req.GitHubUsername); err != nil {
-		fmt.Printf("[PROVISION WARN] Failed to setup SSH access: %v\n", err)
+	stepCtx, endStep = s.startStep(ctx, "ssh")
+	err = s.setupSSHAccess(stepCtx, session.ID, req.GitHubUsername)
+	endStep(err)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to set up SSH access", "error", err)
 	} else {
-		fmt.Printf("[PROVISION INFO] SSH access configured for user: %s\n", req.GitHubUsername)
+		slog.InfoContext(ctx, "Set up SSH access", "github_username", req.GitHubUsername)
 	}
 
 	portMappings := make(map[string]int)
@@ -434,16 +575,14 @@ func (s *Server) provisionWorkspace(ctx context.Context, workspaceID, userID str
 	}); ok {
 		mappings, err := dockerProvider.GetPortMappings(ctx, session.ID)
 		if err != nil {
-			fmt.Printf("[PROVISION WARN] Failed to get port mappings: %v\n", err)
+			slog.WarnContext(ctx, "Failed to get port mappings", "error", err)
 		} else {
 			portMappings = mappings
-			fmt.Printf("[PROVISION INFO] Port mappings: %v\n", portMappings)
+			slog.InfoContext(ctx, "Resolved port mappings", "ports", portMappings)
 
 			if sshPort, exists := portMappings["22"]; exists {
 				if err := s.workspaceRegistry.UpdateSSHPort(workspaceID, sshPort, "localhost"); err != nil {
-					fmt.Printf("[PROVISION WARN] Failed to update SSH port: %v\n", err)
-				} else {
-					fmt.Printf("[PROVISION INFO] Updated SSH port to %d\n", sshPort)
+					slog.WarnContext(ctx, "Failed to update SSH port", "error", err)
 				}
 			}
 
@@ -458,9 +597,7 @@ func (s *Server) provisionWorkspace(ctx context.Context, workspaceID, userID str
 							Cmd: []string{"sh", "-c", fmt.Sprintf("echo 'export %s=%s' >> /etc/environment", envKey, envValue)},
 						}
 						if err := s.provider.Exec(ctx, session.ID, execOpts); err != nil {
-							fmt.Printf("[PROVISION WARN] Failed to inject env var %s: %v\n", envKey, err)
-						} else {
-							fmt.Printf("[PROVISION INFO] Injected %s=%s\n", envKey, envValue)
+							slog.WarnContext(ctx, "Failed to inject service port", "env", envKey, "error", err)
 						}
 					}
 				}
@@ -469,24 +606,29 @@ func (s *Server) provisionWorkspace(ctx context.Context, workspaceID, userID str
 	}
 
 	if len(cfg.Services) > 0 {
-		fmt.Printf("[PROVISION SERVICES] Setting up %d services\n", len(cfg.Services))
-		if err := s.setupWorkspaceServices(ctx, workspaceID, session.ID, cfg, portMappings); err != nil {
-			fmt.Printf("[PROVISION ERROR] Failed to setup services: %v\n", err)
+		stepCtx, endStep = s.startStep(ctx, "services")
+		err = s.setupWorkspaceServices(stepCtx, workspaceID, session.ID, cfg, portMappings)
+		endStep(err)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to set up services", "error", err)
 			s.workspaceRegistry.UpdateStatus(workspaceID, "error")
 			return
 		}
 
-		fmt.Printf("[PROVISION HEALTH] Waiting for services to be healthy\n")
-		if err := s.waitForServicesHealthy(ctx, session.ID, cfg.Services); err != nil {
-			fmt.Printf("[PROVISION WARN] Some services may not be healthy: %v\n", err)
+		stepCtx, endStep = s.startStep(ctx, "health")
+		err = s.waitForServicesHealthy(stepCtx, session.ID, cfg.Services)
+		endStep(err)
+		if err != nil {
+			slog.WarnContext(ctx, "Some services may not be healthy", "error", err)
 		}
 	}
 
 	if err := s.workspaceRegistry.UpdateStatus(workspaceID, "running"); err != nil {
-		fmt.Printf("[PROVISION ERROR] Failed to update workspace status to running: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to mark workspace running", "error", err)
 	}
 
-	fmt.Printf("[PROVISION SUCCESS] Workspace %s provisioned successfully\n", workspaceID)
+	provisionErr = nil
+	slog.InfoContext(ctx, "Provisioned workspace")
 }
 
 func (s *Server) handleM4GetWorkspaceStatus(w http.ResponseWriter, r *http.Request) {
@@ -738,12 +880,14 @@ func (s *Server) handleM4WorkspacesRouter(w http.ResponseWriter, r *http.Request
 
 	if len(parts) >= 2 && parts[1] == "stop" {
 		if r.Method == http.MethodPost {
+			audit(r, "workspace.stop", parts[0])
 			s.handleM4StopWorkspace(w, r)
 			return
 		}
 	}
 
 	if r.Method == http.MethodDelete {
+		audit(r, "workspace.delete", parts[0])
 		s.handleM4DeleteWorkspace(w, r)
 		return
 	}
@@ -751,36 +895,75 @@ func (s *Server) handleM4WorkspacesRouter(w http.ResponseWriter, r *http.Request
 	http.Error(w, "Invalid endpoint", http.StatusNotFound)
 }
 
-func (s *Server) cloneRepository(ctx context.Context, repo M4Repository, githubToken, workspaceDir string) error {
-	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
-		return fmt.Errorf("failed to create workspace directory: %w", err)
+// withGitHubToken calls fn with the freshest token available for an installation.
+// GitHub App installations go through the token broker, so tokens are refreshed
+// before expiry and a 401 is retried once with a newly minted token. User OAuth
+// tokens cannot be refreshed with the App JWT and are passed through unchanged.
+func (s *Server) withGitHubToken(ctx context.Context, installation *GitHubInstallation, fn func(token string) error) error {
+	if installation.InstallationID == 0 || s.tokenBroker == nil {
+		token, _ := s.installationToken(installation)
+		return fn(token)
 	}
 
-	cloneURL := repo.URL
-	if githubToken != "" && strings.Contains(cloneURL, "github.com") {
-		cloneURL = strings.Replace(cloneURL, "https://", fmt.Sprintf("https://%s@", githubToken), 1)
+	return s.tokenBroker.Do(ctx, installation.InstallationID, func(token string) error {
+		s.syncInstallationToken(ctx, installation)
+		return fn(token)
+	})
+}
+
+// installationToken returns the token stored on installation, which
+// syncInstallationToken may replace at any time
+func (s *Server) installationToken(installation *GitHubInstallation) (string, time.Time) {
+	s.gitHubInstallationsMu.RLock()
+	defer s.gitHubInstallationsMu.RUnlock()
+	return installation.Token, installation.TokenExpiresAt
+}
+
+func (s *Server) hasInstallationToken(installation *GitHubInstallation) bool {
+	token, _ := s.installationToken(installation)
+	return token != ""
+}
+
+// userToken returns the token that acts as the user: their OAuth token, or
+// the stored token for users without an App installation
+func (s *Server) userToken(installation *GitHubInstallation) string {
+	if installation.UserToken != "" || installation.InstallationID != 0 {
+		return installation.UserToken
 	}
+	token, _ := s.installationToken(installation)
+	return token
+}
 
-	branch := repo.Branch
-	if branch == "" {
-		branch = "main"
+// syncInstallationToken copies the broker's current token onto the stored
+// installation so that persisted records and token lookups stay fresh
+func (s *Server) syncInstallationToken(ctx context.Context, installation *GitHubInstallation) {
+	token, expiresAt, err := s.tokenBroker.Token(ctx, installation.InstallationID)
+	if err != nil {
+		return
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "clone", "--branch", branch, "--depth", "1", cloneURL, workspaceDir)
-	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	s.gitHubInstallationsMu.Lock()
+	changed := installation.Token != token
+	if changed {
+		installation.Token = token
+		installation.TokenExpiresAt = expiresAt
+		installation.UpdatedAt = time.Now()
+	}
+	snapshot := *installation
+	s.gitHubInstallationsMu.Unlock()
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("git clone failed: %w\nOutput: %s", err, string(output))
+	if !changed {
+		return
 	}
 
-	fmt.Printf("Successfully cloned repository to %s\n", workspaceDir)
-	return nil
+	if sqliteReg, ok := s.registry.(*SQLiteRegistry); ok {
+		if err := sqliteReg.StoreGitHubInstallation(&snapshot); err != nil {
+			slog.WarnContext(ctx, "Failed to persist refreshed GitHub token", "github_username", installation.GitHubUsername, "error", err)
+		}
+	}
 }
 
 func (s *Server) setupWorkspaceServices(ctx context.Context, workspaceID, containerID string, cfg *config.Config, portMappings map[string]int) error {
-	fmt.Printf("[PROVISION SERVICES] Registering %d services for workspace\n", len(cfg.Services))
-
 	services := make(map[string]DBService)
 	now := time.Now()
 	for name, svc := range cfg.Services {
@@ -800,7 +983,6 @@ func (s *Server) setupWorkspaceServices(ctx context.Context, workspaceID, contai
 			containerPortStr := fmt.Sprintf("%d", svc.Port)
 			if hostPort, exists := portMappings[containerPortStr]; exists {
 				dbService.LocalPort = &hostPort
-				fmt.Printf("[PROVISION SERVICES] Service %s: container port %d -> host port %d\n", name, svc.Port, hostPort)
 			}
 		}
 
@@ -808,11 +990,10 @@ func (s *Server) setupWorkspaceServices(ctx context.Context, workspaceID, contai
 	}
 
 	if err := s.workspaceRegistry.UpdateServices(workspaceID, services); err != nil {
-		fmt.Printf("[PROVISION WARN] Failed to update service registry: %v\n", err)
-		return err
+		return fmt.Errorf("failed to update service registry: %w", err)
 	}
 
-	fmt.Printf("[PROVISION SERVICES] Services registered successfully\n")
+	slog.InfoContext(ctx, "Registered services", "count", len(services))
 	return nil
 }
 
@@ -823,8 +1004,6 @@ func (s *Server) waitForServicesHealthy(ctx context.Context, containerID string,
 
 	// Simplified health check: just verify the container is accessible
 	// Individual service health is managed inside the workspace container
-	fmt.Printf("[PROVISION HEALTH] Verifying workspace container is accessible\n")
-
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
@@ -836,28 +1015,30 @@ func (s *Server) waitForServicesHealthy(ctx context.Context, containerID string,
 			return fmt.Errorf("context canceled while waiting for services")
 		case <-timeout:
 			// After timeout, consider services healthy - they're running in the container
-			fmt.Printf("[PROVISION HEALTH] Workspace container is accessible\n")
+			slog.WarnContext(ctx, "Workspace container not confirmed accessible, assuming services are running")
 			return nil
 		case <-ticker.C:
 			// Simple check: can we access the container?
 			checkCmd := []string{"sh", "-c", "echo 'container accessible'"}
 			if err := s.provider.Exec(ctx, containerID, provider.ExecOptions{Cmd: checkCmd}); err != nil {
-				fmt.Printf("[PROVISION HEALTH] Container check failed: %v\n", err)
+				slog.DebugContext(ctx, "Container check failed", "error", err)
 				continue
 			}
 
 			// Container is accessible
-			fmt.Printf("[PROVISION HEALTH] Workspace container is accessible and healthy\n")
+			slog.InfoContext(ctx, "Workspace container is accessible")
 			return nil
 		}
 	}
 }
 
 func (s *Server) setupSSHAccess(ctx context.Context, containerID, githubUsername string) error {
-	fmt.Printf("[PROVISION SSH] Setting up SSH access for GitHub user: %s\n", githubUsername)
-
 	keysURL := fmt.Sprintf("https://github.com/%s.keys", githubUsername)
-	resp, err := http.Get(keysURL)
+	keysReq, err := http.NewRequestWithContext(ctx, http.MethodGet, keysURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch GitHub keys: %w", err)
+	}
+	resp, err := tracedClient.Do(keysReq)
 	if err != nil {
 		return fmt.Errorf("failed to fetch GitHub keys: %w", err)
 	}
@@ -876,8 +1057,6 @@ func (s *Server) setupSSHAccess(ctx context.Context, containerID, githubUsername
 		return fmt.Errorf("no SSH keys found for GitHub user: %s", githubUsername)
 	}
 
-	fmt.Printf("[PROVISION SSH] Fetched %d bytes of SSH keys from GitHub\n", len(keys))
-
 	setupCommands := []string{
 		"mkdir -p /root/.ssh",
 		"chmod 700 /root/.ssh",
@@ -896,10 +1075,9 @@ func (s *Server) setupSSHAccess(ctx context.Context, containerID, githubUsername
 			Cmd: []string{"sh", "-c", cmd},
 		}
 		if err := s.provider.Exec(ctx, containerID, execOpts); err != nil {
-			fmt.Printf("[PROVISION SSH WARN] Command failed: %s - %v\n", cmd, err)
+			slog.WarnContext(ctx, "SSH setup command failed", "command", cmd, "error", err)
 		}
 	}
 
-	fmt.Printf("[PROVISION SSH] SSH server configured and started\n")
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/nexus/nexus/pkg/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -306,3 +307,34 @@ func TestLoggingMiddleware(t *testing.T) {
 	handler.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestLoggingMiddleware_RequestID(t *testing.T) {
+	srv := NewServer(&Config{})
+
+	var ctxID string
+	handler := srv.loggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxID = logging.RequestID(r.Context())
+		sendM4JSONError(w, http.StatusNotFound, "not_found", "Workspace not found", nil)
+	}))
+
+	// Error responses carry the ID the middleware assigned
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/workspaces/ws-1", nil))
+	var resp M4ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.NotEmpty(t, resp.RequestID)
+	assert.Equal(t, resp.RequestID, w.Header().Get("X-Request-ID"))
+	assert.Equal(t, resp.RequestID, ctxID)
+
+	// Caller-supplied IDs are kept, unless they are unsafe to log
+	req := httptest.NewRequest("GET", "/api/v1/workspaces/ws-1", nil)
+	req.Header.Set("X-Request-ID", "client-abc")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "client-abc", w.Header().Get("X-Request-ID"))
+
+	req.Header.Set("X-Request-ID", "bad id\n")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.NotEqual(t, "bad id\n", w.Header().Get("X-Request-ID"))
+}
@@ -0,0 +1,254 @@
+package coordination
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexus/nexus/pkg/metrics"
+)
+
+const (
+	// maxUsageBatchBytes and maxUsageBatchEntries bound one upload; the CLI
+	// splits larger uploads into several batches
+	maxUsageBatchBytes   = 10 << 20
+	maxUsageBatchEntries = 5000
+	// maxUsageDays bounds how far back a team report reaches
+	maxUsageDays = 365
+)
+
+// UsageBatchRequest uploads a member's usage entries for a project. User is
+// whatever identifies the member to the team, which may be a pseudonym. When
+// the upload is authenticated with a member token, User must be that member
+// or their pseudonym for the project, and defaults to the member. The first
+// pseudonym a member uploads under is bound to them for the project.
+type UsageBatchRequest struct {
+	User    string             `json:"user"`
+	Project string             `json:"project"`
+	Entries []metrics.UsageLog `json:"entries"`
+}
+
+type UsageBatchResponse struct {
+	Accepted   int `json:"accepted"`
+	Duplicates int `json:"duplicates"`
+}
+
+func (s *Server) handleUsageBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	audit(r, "usage.upload", "")
+	var req UsageBatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUsageBatchBytes)).Decode(&req); err != nil {
+		sendM4JSONError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	auditClaimedUser(r, req.User)
+	audit(r, "", req.Project)
+	member, isMember := s.usageMember(r)
+	if isMember {
+		if req.User == "" {
+			req.User = member
+		}
+		if req.User != member && !metrics.IsPseudonym(req.User) {
+			sendM4JSONError(w, http.StatusForbidden, "user_mismatch", "Usage can only be uploaded for the member the token belongs to", map[string]interface{}{
+				"member": member,
+			})
+			return
+		}
+	} else if s.config.Auth.Enabled {
+		// A shared service token doesn't say which member is uploading, so
+		// it can't vouch for the user in the body
+		sendM4JSONError(w, http.StatusForbidden, "member_token_required", "Usage uploads need the member's token from usage.member_tokens", nil)
+		return
+	}
+	if req.User == "" || req.Project == "" {
+		sendM4JSONError(w, http.StatusBadRequest, "missing_fields", "Missing required fields", map[string]interface{}{
+			"required": []string{"user", "project"},
+		})
+		return
+	}
+	if len(req.Entries) > maxUsageBatchEntries {
+		sendM4JSONError(w, http.StatusRequestEntityTooLarge, "batch_too_large", "Too many entries in one batch", map[string]interface{}{
+			"max_entries": maxUsageBatchEntries,
+		})
+		return
+	}
+	for i := range req.Entries {
+		if err := metrics.Validate(&req.Entries[i]); err != nil {
+			sendM4JSONError(w, http.StatusBadRequest, "invalid_entry", err.Error(), map[string]interface{}{"index": i})
+			return
+		}
+		if req.Entries[i].ID == "" || req.Entries[i].Timestamp == "" {
+			sendM4JSONError(w, http.StatusBadRequest, "invalid_entry", "Uploaded entries need an id and timestamp", map[string]interface{}{"index": i})
+			return
+		}
+	}
+
+	if isMember && req.User != member {
+		err := s.usage.BindPseudonym(member, req.Project, req.User)
+		if errors.Is(err, ErrPseudonymBound) {
+			sendM4JSONError(w, http.StatusForbidden, "pseudonym_mismatch", "Usage can only be uploaded under the pseudonym this member first used in the project", map[string]interface{}{
+				"member": member,
+			})
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to bind usage pseudonym", "project", req.Project, "error", err)
+			sendM4JSONError(w, http.StatusInternalServerError, "storage_error", "Failed to bind pseudonym", nil)
+			return
+		}
+	}
+
+	accepted, err := s.usage.AddUsage(req.User, req.Project, req.Entries)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to store usage batch", "project", req.Project, "error", err)
+		sendM4JSONError(w, http.StatusInternalServerError, "storage_error", "Failed to store usage entries", nil)
+		return
+	}
+	slog.InfoContext(r.Context(), "Stored usage batch", "project", req.Project, "accepted", accepted, "entries", len(req.Entries))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(UsageBatchResponse{Accepted: accepted, Duplicates: len(req.Entries) - accepted})
+}
+
+// usageMember returns the member whose usage upload token the request carries
+func (s *Server) usageMember(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	for member, memberToken := range s.config.Usage.MemberTokens {
+		if memberToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(memberToken)) == 1 {
+			return member, true
+		}
+	}
+	return "", false
+}
+
+// teamUsageSource returns the team's entries for the project, and only the
+// given user's when a user is named. It writes an error response and returns
+// false when the request can't be served.
+func (s *Server) teamUsageSource(w http.ResponseWriter, r *http.Request) (metrics.Source, string, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, "", false
+	}
+	project := r.URL.Query().Get("project")
+	if project == "" {
+		sendM4JSONError(w, http.StatusBadRequest, "missing_fields", "Missing required fields", map[string]interface{}{
+			"required": []string{"project"},
+		})
+		return nil, "", false
+	}
+	user := r.URL.Query().Get("user")
+	if user != "" && s.config.Usage.HideUsers {
+		sendM4JSONError(w, http.StatusForbidden, "users_hidden", "This server only reports team totals", nil)
+		return nil, "", false
+	}
+
+	source := metrics.SourceFunc(func(filters metrics.Filter) ([]metrics.UsageLog, error) {
+		filters.Project = project
+		filters.User = user
+		return s.usage.QueryUsage(filters)
+	})
+	return source, project, true
+}
+
+// usageDays reads a positive day count from the query, or def when absent
+func usageDays(w http.ResponseWriter, r *http.Request, param string, def int) (int, bool) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return def, true
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 || days > maxUsageDays {
+		sendM4JSONError(w, http.StatusBadRequest, "invalid_days", param+" must be a number of days from 1 to 365", nil)
+		return 0, false
+	}
+	return days, true
+}
+
+func writeUsageJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// handleTeamUsage serves the team report for a project over the last days
+func (s *Server) handleTeamUsage(w http.ResponseWriter, r *http.Request) {
+	source, project, ok := s.teamUsageSource(w, r)
+	if !ok {
+		return
+	}
+	days, ok := usageDays(w, r, "days", 7)
+	if !ok {
+		return
+	}
+
+	report, err := metrics.NewReporter().GenerateTeamReport(source, project, days, s.config.Usage.HideUsers)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to generate team usage report", "project", project, "error", err)
+		sendM4JSONError(w, http.StatusInternalServerError, "report_error", "Failed to generate team usage report", nil)
+		return
+	}
+	writeUsageJSON(w, report)
+}
+
+// handleTeamUsageSummary serves the team's summary for one day, today unless
+// date=YYYY-MM-DD is given
+func (s *Server) handleTeamUsageSummary(w http.ResponseWriter, r *http.Request) {
+	source, project, ok := s.teamUsageSource(w, r)
+	if !ok {
+		return
+	}
+	date := time.Now()
+	if value := r.URL.Query().Get("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			sendM4JSONError(w, http.StatusBadRequest, "invalid_date", "date must be YYYY-MM-DD", nil)
+			return
+		}
+		date = parsed
+	}
+
+	summary, err := metrics.NewReporter().GenerateDailySummary(source, date)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to generate team usage summary", "project", project, "error", err)
+		sendM4JSONError(w, http.StatusInternalServerError, "report_error", "Failed to generate team usage summary", nil)
+		return
+	}
+	writeUsageJSON(w, summary)
+}
+
+// handleTeamUsageBenchmark compares the team's last current days with the
+// baseline days before them
+func (s *Server) handleTeamUsageBenchmark(w http.ResponseWriter, r *http.Request) {
+	source, project, ok := s.teamUsageSource(w, r)
+	if !ok {
+		return
+	}
+	baseline, ok := usageDays(w, r, "baseline", 14)
+	if !ok {
+		return
+	}
+	current, ok := usageDays(w, r, "current", 7)
+	if !ok {
+		return
+	}
+
+	comparison, err := metrics.NewReporter().GenerateBenchmark(source, baseline, current)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to generate team usage benchmark", "project", project, "error", err)
+		sendM4JSONError(w, http.StatusInternalServerError, "report_error", "Failed to generate team usage benchmark", nil)
+		return
+	}
+	writeUsageJSON(w, comparison)
+}
@@ -0,0 +1,225 @@
+package coordination
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nexus/nexus/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUsageTestServer(hideUsers bool) *Server {
+	cfg := &Config{}
+	cfg.Registry.Storage.Type = "memory"
+	cfg.Usage.HideUsers = hideUsers
+	return NewServer(cfg)
+}
+
+func usageEntry(id, name string, success bool) metrics.UsageLog {
+	return metrics.UsageLog{
+		ID:         id,
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Invocation: metrics.Invocation{Type: "skill", Name: name},
+		Outcome:    metrics.Outcome{Success: success, Duration: 1000},
+	}
+}
+
+func uploadUsage(t *testing.T, srv *Server, req UsageBatchRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	srv.handleUsageBatch(w, httptest.NewRequest(http.MethodPost, "/api/v1/usage/batches", bytes.NewReader(body)))
+	return w
+}
+
+func TestHandleUsageBatch(t *testing.T) {
+	srv := newUsageTestServer(false)
+	batch := UsageBatchRequest{
+		User:    "alice",
+		Project: "shop",
+		Entries: []metrics.UsageLog{usageEntry("e1", "go-testing", true), usageEntry("e2", "go-testing", false)},
+	}
+
+	w := uploadUsage(t, srv, batch)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var resp UsageBatchResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, UsageBatchResponse{Accepted: 2}, resp)
+
+	w = uploadUsage(t, srv, batch)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, UsageBatchResponse{Duplicates: 2}, resp, "uploading again stores nothing new")
+
+	batch.User = "bob"
+	w = uploadUsage(t, srv, batch)
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, 2, resp.Accepted, "entry IDs are only unique per user")
+
+	stored, err := srv.usage.QueryUsage(metrics.Filter{Project: "shop", User: "alice"})
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, "alice", stored[0].User)
+	assert.Equal(t, "shop", stored[0].Context.Project)
+}
+
+func TestHandleUsageBatchMemberTokens(t *testing.T) {
+	srv := newUsageTestServer(false)
+	srv.config.Auth.Enabled = true
+	srv.config.Server.AuthToken = "service-token"
+	srv.config.Usage.MemberTokens = map[string]string{"alice": "alice-token", "bob": "bob-token"}
+	handler := srv.authMiddleware(srv.router)
+
+	upload := func(token string, req UsageBatchRequest) *httptest.ResponseRecorder {
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/usage/batches", bytes.NewReader(body))
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	entries := []metrics.UsageLog{usageEntry("e1", "go-testing", true)}
+
+	w := upload("alice-token", UsageBatchRequest{User: "bob", Project: "shop", Entries: entries})
+	assert.Equal(t, http.StatusForbidden, w.Code, "a member can't upload as someone else")
+	w = upload("service-token", UsageBatchRequest{User: "alice", Project: "shop", Entries: entries})
+	assert.Equal(t, http.StatusForbidden, w.Code, "the shared token can't vouch for a user")
+
+	w = upload("alice-token", UsageBatchRequest{Project: "shop", Entries: entries})
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	bobKey, err := metrics.NewPseudonymKey()
+	require.NoError(t, err)
+	bob := metrics.Pseudonym(bobKey, "shop")
+	w = upload("bob-token", UsageBatchRequest{User: bob, Project: "shop", Entries: entries})
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	w = upload("alice-token", UsageBatchRequest{User: bob, Project: "shop", Entries: entries})
+	assert.Equal(t, http.StatusForbidden, w.Code, "a pseudonym stays with the member who first used it")
+	w = upload("bob-token", UsageBatchRequest{User: metrics.Pseudonym(bobKey, "blog"), Project: "shop", Entries: entries})
+	assert.Equal(t, http.StatusForbidden, w.Code, "a member keeps one pseudonym per project")
+
+	stored, err := srv.usage.QueryUsage(metrics.Filter{Project: "shop"})
+	require.NoError(t, err)
+	var users []string
+	for _, entry := range stored {
+		users = append(users, entry.User)
+	}
+	assert.ElementsMatch(t, []string{"alice", bob}, users)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/workspaces", nil)
+	r.Header.Set("Authorization", "Bearer alice-token")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "member tokens only reach the usage endpoints")
+}
+
+func TestHandleUsageBatchValidation(t *testing.T) {
+	srv := newUsageTestServer(false)
+
+	tests := []struct {
+		name    string
+		request UsageBatchRequest
+		code    string
+	}{
+		{"missing_user", UsageBatchRequest{Project: "shop"}, "missing_fields"},
+		{"missing_id", UsageBatchRequest{User: "alice", Project: "shop", Entries: []metrics.UsageLog{usageEntry("", "go-testing", true)}}, "invalid_entry"},
+		{"missing_name", UsageBatchRequest{User: "alice", Project: "shop", Entries: []metrics.UsageLog{usageEntry("e1", "", true)}}, "invalid_entry"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := uploadUsage(t, srv, tt.request)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp M4ErrorResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, tt.code, resp.Error)
+		})
+	}
+}
+
+func TestHandleTeamUsage(t *testing.T) {
+	srv := newUsageTestServer(false)
+	uploadUsage(t, srv, UsageBatchRequest{User: "alice", Project: "shop", Entries: []metrics.UsageLog{usageEntry("e1", "go-testing", true)}})
+	uploadUsage(t, srv, UsageBatchRequest{User: "bob", Project: "shop", Entries: []metrics.UsageLog{usageEntry("e1", "go-testing", false)}})
+	uploadUsage(t, srv, UsageBatchRequest{User: "carol", Project: "blog", Entries: []metrics.UsageLog{usageEntry("e1", "go-testing", true)}})
+
+	w := httptest.NewRecorder()
+	srv.handleTeamUsage(w, httptest.NewRequest(http.MethodGet, "/api/v1/usage/team?project=shop", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var report metrics.TeamReport
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	assert.Equal(t, 7, report.Days)
+	assert.Equal(t, 2, report.Contributors)
+	require.Len(t, report.Adoption, 1)
+	assert.Equal(t, 2, report.Adoption[0].Users)
+	assert.Len(t, report.ByUser, 2)
+
+	w = httptest.NewRecorder()
+	srv.handleTeamUsage(w, httptest.NewRequest(http.MethodGet, "/api/v1/usage/team", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	srv.handleTeamUsage(w, httptest.NewRequest(http.MethodGet, "/api/v1/usage/team?project=shop&days=0", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleTeamUsageHideUsers(t *testing.T) {
+	srv := newUsageTestServer(true)
+	uploadUsage(t, srv, UsageBatchRequest{User: "alice", Project: "shop", Entries: []metrics.UsageLog{usageEntry("e1", "go-testing", true)}})
+
+	w := httptest.NewRecorder()
+	srv.handleTeamUsage(w, httptest.NewRequest(http.MethodGet, "/api/v1/usage/team?project=shop", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var report metrics.TeamReport
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	assert.Equal(t, 1, report.Contributors)
+	assert.Empty(t, report.ByUser)
+
+	w = httptest.NewRecorder()
+	srv.handleTeamUsageSummary(w, httptest.NewRequest(http.MethodGet, "/api/v1/usage/team/summary?project=shop&user=alice", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestSQLiteUsageStore(t *testing.T) {
+	registry, err := NewSQLiteRegistry(t.TempDir() + "/test.db")
+	require.NoError(t, err)
+	store := NewSQLiteUsageStore(registry.db)
+
+	older := usageEntry("e1", "go-testing", true)
+	older.Timestamp = time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+	added, err := store.AddUsage("alice", "shop", []metrics.UsageLog{older, usageEntry("e2", "review", true)})
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	added, err = store.AddUsage("alice", "shop", []metrics.UsageLog{usageEntry("e2", "review", true)})
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+
+	entries, err := store.QueryUsage(metrics.Filter{Project: "shop"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "e1", entries[0].ID, "entries come back oldest first")
+	assert.Equal(t, "alice", entries[0].User)
+
+	entries, err = store.QueryUsage(metrics.Filter{Project: "shop", StartTime: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "e2", entries[0].ID)
+
+	entries, err = store.QueryUsage(metrics.Filter{Project: "blog"})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.NoError(t, store.BindPseudonym("bob", "shop", "anon-000000000001"))
+	require.NoError(t, store.BindPseudonym("bob", "shop", "anon-000000000001"), "binding again is a no-op")
+	assert.ErrorIs(t, store.BindPseudonym("bob", "shop", "anon-000000000002"), ErrPseudonymBound)
+	assert.ErrorIs(t, store.BindPseudonym("carol", "shop", "anon-000000000001"), ErrPseudonymBound)
+	assert.NoError(t, store.BindPseudonym("carol", "blog", "anon-000000000001"), "bindings are per project")
+}
@@ -0,0 +1,335 @@
+package coordination
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nexus/nexus/pkg/github"
+)
+
+// maxWebhookPayloadBytes bounds the size of a webhook delivery we are willing to buffer
+const maxWebhookPayloadBytes = 25 << 20
+
+// GitHubWebhookResponse is returned for every accepted webhook delivery
+type GitHubWebhookResponse struct {
+	Event       string `json:"event"`
+	Action      string `json:"action,omitempty"`
+	Result      string `json:"result"`
+	WorkspaceID string `json:"workspace_id,omitempty"`
+}
+
+// handleGitHubWebhook receives GitHub webhook deliveries
+// POST /webhooks/github
+// Verifies X-Hub-Signature-256 and maps pull_request events onto PR workspaces
+func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.config.GitHub.WebhookSecret == "" {
+		sendM4JSONError(w, http.StatusServiceUnavailable, "webhooks_disabled", "GitHub webhook secret not configured", nil)
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookPayloadBytes))
+	if err != nil {
+		sendM4JSONError(w, http.StatusBadRequest, "invalid_request", "Failed to read webhook payload", map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if err := github.VerifyWebhookSignature(s.config.GitHub.WebhookSecret, payload, r.Header.Get(github.WebhookSignatureHeader)); err != nil {
+		sendM4JSONError(w, http.StatusUnauthorized, "invalid_signature", "Webhook signature verification failed", nil)
+		return
+	}
+	auditCredential(r, credentialWebhook)
+
+	event := r.Header.Get(github.WebhookEventHeader)
+	switch event {
+	case "ping":
+		writeWebhookResponse(w, http.StatusOK, GitHubWebhookResponse{Event: event, Result: "pong"})
+	case "pull_request":
+		var prEvent github.PullRequestEvent
+		if err := json.Unmarshal(payload, &prEvent); err != nil {
+			sendM4JSONError(w, http.StatusBadRequest, "invalid_payload", "Invalid pull_request payload", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		if s.config.GitHub.PullRequests.Enabled {
+			// PR workspaces are created and torn down on the author's behalf
+			audit(r, "webhook.pull_request", pullRequestWorkspaceName(&prEvent))
+			auditClaimedUser(r, prEvent.PullRequest.User.Login)
+		}
+		s.handlePullRequestEvent(w, &prEvent)
+	default:
+		writeWebhookResponse(w, http.StatusAccepted, GitHubWebhookResponse{Event: event, Result: "ignored"})
+	}
+}
+
+// handlePullRequestEvent provisions a workspace when a PR is opened or updated
+// and tears it down when the PR is closed
+func (s *Server) handlePullRequestEvent(w http.ResponseWriter, event *github.PullRequestEvent) {
+	resp := GitHubWebhookResponse{Event: "pull_request", Action: event.Action}
+
+	if !s.config.GitHub.PullRequests.Enabled {
+		resp.Result = "ignored"
+		writeWebhookResponse(w, http.StatusAccepted, resp)
+		return
+	}
+
+	name := pullRequestWorkspaceName(event)
+	existing := s.findWorkspaceByName(name)
+
+	switch event.Action {
+	case "opened", "reopened", "synchronize":
+		if isForkPullRequest(event) && !s.config.GitHub.PullRequests.AllowForks {
+			resp.Result = "ignored_fork"
+			writeWebhookResponse(w, http.StatusAccepted, resp)
+			return
+		}
+		if existing != nil {
+			if existing.RepoCommit != nil && *existing.RepoCommit == event.PullRequest.Head.SHA {
+				resp.Result = "up_to_date"
+				resp.WorkspaceID = existing.WorkspaceID
+				writeWebhookResponse(w, http.StatusOK, resp)
+				return
+			}
+			s.startTeardown(existing)
+		}
+
+		workspaceID, err := s.createPullRequestWorkspace(event, name)
+		if err != nil {
+			sendM4JSONError(w, http.StatusInternalServerError, "workspace_creation_failed", fmt.Sprintf("Failed to create PR workspace: %v", err), nil)
+			return
+		}
+
+		resp.Result = "provisioning"
+		resp.WorkspaceID = workspaceID
+		writeWebhookResponse(w, http.StatusAccepted, resp)
+	case "closed":
+		if existing == nil {
+			resp.Result = "not_found"
+			writeWebhookResponse(w, http.StatusOK, resp)
+			return
+		}
+
+		s.startTeardown(existing)
+		resp.Result = "deleting"
+		resp.WorkspaceID = existing.WorkspaceID
+		writeWebhookResponse(w, http.StatusAccepted, resp)
+	default:
+		resp.Result = "ignored"
+		writeWebhookResponse(w, http.StatusAccepted, resp)
+	}
+}
+
+// createPullRequestWorkspace registers a workspace for the PR head commit and provisions it in the background
+func (s *Server) createPullRequestWorkspace(event *github.PullRequestEvent, name string) (string, error) {
+	author := event.PullRequest.User.Login
+	userID := author
+	if user, err := s.registry.GetUserRegistry().GetByUsername(author); err == nil {
+		userID = user.ID
+	}
+
+	head := event.PullRequest.Head
+	repo := M4Repository{
+		Owner:  head.Repo.Owner.Login,
+		Name:   head.Repo.Name,
+		URL:    head.Repo.CloneURL,
+		Branch: head.Ref,
+		Commit: head.SHA,
+		IsFork: isForkPullRequest(event),
+	}
+
+	providerName := s.config.GitHub.PullRequests.Provider
+	if providerName == "" && s.provider != nil {
+		providerName = s.provider.Name()
+	}
+	image := s.config.GitHub.PullRequests.Image
+	if image == "" {
+		image = "ubuntu:22.04"
+	}
+
+	req := M4CreateWorkspaceRequest{
+		GitHubUsername: author,
+		WorkspaceName:  name,
+		Repository:     repo,
+		Provider:       providerName,
+		Image:          image,
+	}
+
+	workspaceID := fmt.Sprintf("ws-%d", time.Now().UnixNano())
+	sshPort := 2222 + int(time.Now().UnixNano()%100)
+	commit := head.SHA
+
+	ws := &DBWorkspace{
+		WorkspaceID:   workspaceID,
+		UserID:        userID,
+		WorkspaceName: name,
+		Status:        "creating",
+		Provider:      providerName,
+		Image:         image,
+		RepoOwner:     repo.Owner,
+		RepoName:      repo.Name,
+		RepoURL:       repo.URL,
+		RepoBranch:    repo.Branch,
+		RepoCommit:    &commit,
+	}
+
+	if err := s.workspaceRegistry.Create(ws); err != nil {
+		return "", err
+	}
+
+	installation := &GitHubInstallation{
+		InstallationID: event.Installation.ID,
+		UserID:         userID,
+		GitHubUserID:   event.PullRequest.User.ID,
+		GitHubUsername: author,
+	}
+
+	s.startProvisioning(context.Background(), workspaceID, func(ctx context.Context) {
+		s.provisionWorkspace(ctx, workspaceID, userID, req, sshPort, installation)
+
+		if !s.config.GitHub.PullRequests.Comment {
+			return
+		}
+		if ws, err := s.workspaceRegistry.Get(workspaceID); err == nil && ws.Status == "running" {
+			s.commentPullRequestWorkspace(ctx, event, installation, ws)
+		}
+	})
+
+	return workspaceID, nil
+}
+
+// isForkPullRequest reports whether the PR's head branch lives outside the repository
+func isForkPullRequest(event *github.PullRequestEvent) bool {
+	return event.PullRequest.Head.Repo.FullName != event.Repository.FullName
+}
+
+// startTeardown marks the workspace deleting, so later events for the PR no
+// longer find it, and tears it down in the background. Teardown waits for
+// provisioning to stop, which would otherwise hold up GitHub's delivery.
+func (s *Server) startTeardown(ws *DBWorkspace) {
+	if err := s.workspaceRegistry.UpdateStatus(ws.WorkspaceID, "deleting"); err != nil {
+		slog.Warn("Failed to mark workspace deleting", "workspace_id", ws.WorkspaceID, "error", err)
+	}
+	go s.teardownWorkspace(context.Background(), ws)
+}
+
+// teardownWorkspace stops any provisioning still running for the workspace,
+// then destroys its container, checkout and registry entry
+func (s *Server) teardownWorkspace(ctx context.Context, ws *DBWorkspace) {
+	s.cancelProvisioning(ws.WorkspaceID)
+
+	if s.provider != nil {
+		if err := s.provider.Destroy(ctx, ws.WorkspaceID); err != nil {
+			slog.WarnContext(ctx, "Failed to destroy workspace", "workspace_id", ws.WorkspaceID, "error", err)
+		}
+	}
+
+	if err := os.RemoveAll(fmt.Sprintf("/tmp/nexus-workspaces/%s", ws.WorkspaceID)); err != nil {
+		slog.WarnContext(ctx, "Failed to remove workspace checkout", "workspace_id", ws.WorkspaceID, "error", err)
+	}
+
+	if err := s.workspaceRegistry.Delete(ws.WorkspaceID); err != nil {
+		slog.WarnContext(ctx, "Failed to delete workspace", "workspace_id", ws.WorkspaceID, "error", err)
+	}
+}
+
+// commentPullRequestWorkspace posts the workspace SSH and service URLs on the pull request
+func (s *Server) commentPullRequestWorkspace(ctx context.Context, event *github.PullRequestEvent, installation *GitHubInstallation, ws *DBWorkspace) {
+	body := s.pullRequestCommentBody(ws)
+	owner := event.Repository.Owner.Login
+	repo := event.Repository.Name
+
+	err := s.withGitHubToken(ctx, installation, func(token string) error {
+		if token == "" {
+			return fmt.Errorf("no GitHub token available")
+		}
+		_, err := github.CreateIssueComment(ctx, token, owner, repo, event.Number, body)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to comment on pull request",
+			"repository", event.Repository.FullName, "pull_request", event.Number, "error", err)
+	}
+}
+
+// pullRequestCommentBody renders the markdown comment describing how to reach a PR workspace
+func (s *Server) pullRequestCommentBody(ws *DBWorkspace) string {
+	sshHost := "localhost"
+	if ws.SSHHost != nil {
+		sshHost = *ws.SSHHost
+	}
+	sshPort := 2222
+	if ws.SSHPort != nil {
+		sshPort = *ws.SSHPort
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Nexus workspace `%s` is ready\n\n", ws.WorkspaceID)
+	if ws.RepoCommit != nil {
+		fmt.Fprintf(&b, "Commit: `%s`\n\n", *ws.RepoCommit)
+	}
+	fmt.Fprintf(&b, "**SSH:** `ssh -p %d root@%s`\n", sshPort, sshHost)
+
+	services, err := s.workspaceRegistry.GetServices(ws.WorkspaceID)
+	if err == nil && len(services) > 0 {
+		names := make([]string, 0, len(services))
+		for name := range services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		b.WriteString("\n**Services:**\n")
+		for _, name := range names {
+			svc := services[name]
+			port := svc.Port
+			if svc.LocalPort != nil {
+				port = *svc.LocalPort
+			}
+			fmt.Fprintf(&b, "- %s: http://%s:%d\n", name, sshHost, port)
+		}
+	}
+
+	return b.String()
+}
+
+// findWorkspaceByName returns the newest workspace with the given name that
+// isn't being deleted, or nil if none exists. A replaced workspace's
+// provisioning may still set it running before teardown cancels it, so the
+// newest one wins over any older one left with the same name.
+func (s *Server) findWorkspaceByName(name string) *DBWorkspace {
+	workspaces, err := s.workspaceRegistry.List()
+	if err != nil {
+		return nil
+	}
+	var found *DBWorkspace
+	for _, ws := range workspaces {
+		if ws.WorkspaceName != name || ws.Status == "deleting" {
+			continue
+		}
+		if found == nil || ws.CreatedAt.After(found.CreatedAt) {
+			found = ws
+		}
+	}
+	return found
+}
+
+// pullRequestWorkspaceName derives a stable workspace name for a pull request
+func pullRequestWorkspaceName(event *github.PullRequestEvent) string {
+	return fmt.Sprintf("pr-%s-%s-%d", event.Repository.Owner.Login, event.Repository.Name, event.Number)
+}
+
+func writeWebhookResponse(w http.ResponseWriter, statusCode int, resp GitHubWebhookResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
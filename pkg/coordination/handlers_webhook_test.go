@@ -0,0 +1,198 @@
+package coordination
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nexus/nexus/pkg/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookSecret = "test-webhook-secret"
+
+func newWebhookTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	server := NewServer(&Config{})
+	server.config.GitHub.WebhookSecret = testWebhookSecret
+	server.config.GitHub.PullRequests.Enabled = true
+	return server
+}
+
+func pullRequestPayload(action, sha string) []byte {
+	return pullRequestPayloadFrom("org/project", action, sha)
+}
+
+// pullRequestPayloadFrom builds a PR event whose head branch lives in headRepo
+func pullRequestPayloadFrom(headRepo, action, sha string) []byte {
+	event := map[string]interface{}{
+		"action": action,
+		"number": 42,
+		"pull_request": map[string]interface{}{
+			"user": map[string]interface{}{"login": "alice", "id": 1},
+			"head": map[string]interface{}{
+				"ref": "feature",
+				"sha": sha,
+				"repo": map[string]interface{}{
+					"name":      "project",
+					"full_name": headRepo,
+					"owner":     map[string]interface{}{"login": "org"},
+					"clone_url": "/nonexistent/org/project.git",
+				},
+			},
+		},
+		"repository": map[string]interface{}{
+			"name":      "project",
+			"full_name": "org/project",
+			"owner":     map[string]interface{}{"login": "org"},
+		},
+	}
+	data, _ := json.Marshal(event)
+	return data
+}
+
+func sendWebhook(server *Server, event string, payload []byte, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(payload))
+	req.Header.Set(github.WebhookEventHeader, event)
+	req.Header.Set(github.WebhookSignatureHeader, signature)
+	w := httptest.NewRecorder()
+	server.handleGitHubWebhook(w, req)
+	return w
+}
+
+func TestGitHubWebhook_RejectsBadSignature(t *testing.T) {
+	server := newWebhookTestServer(t)
+	payload := pullRequestPayload("opened", "abc123")
+
+	w := sendWebhook(server, "pull_request", payload, github.SignWebhookPayload("wrong", payload))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	workspaces, _ := server.workspaceRegistry.List()
+	assert.Empty(t, workspaces)
+}
+
+func TestGitHubWebhook_DisabledWithoutSecret(t *testing.T) {
+	server := NewServer(&Config{})
+	payload := []byte(`{}`)
+
+	w := sendWebhook(server, "ping", payload, github.SignWebhookPayload("", payload))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestGitHubWebhook_Ping(t *testing.T) {
+	server := newWebhookTestServer(t)
+	payload := []byte(`{"zen":"Keep it logically awesome."}`)
+
+	w := sendWebhook(server, "ping", payload, github.SignWebhookPayload(testWebhookSecret, payload))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGitHubWebhook_PullRequestLifecycle(t *testing.T) {
+	server := newWebhookTestServer(t)
+
+	opened := pullRequestPayload("opened", "abc123")
+	w := sendWebhook(server, "pull_request", opened, github.SignWebhookPayload(testWebhookSecret, opened))
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var resp GitHubWebhookResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "provisioning", resp.Result)
+	require.NotEmpty(t, resp.WorkspaceID)
+
+	ws, err := server.workspaceRegistry.Get(resp.WorkspaceID)
+	require.NoError(t, err)
+	assert.Equal(t, "pr-org-project-42", ws.WorkspaceName)
+	assert.Equal(t, "feature", ws.RepoBranch)
+	require.NotNil(t, ws.RepoCommit)
+	assert.Equal(t, "abc123", *ws.RepoCommit)
+
+	closed := pullRequestPayload("closed", "abc123")
+	w = sendWebhook(server, "pull_request", closed, github.SignWebhookPayload(testWebhookSecret, closed))
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	resp = GitHubWebhookResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "deleting", resp.Result)
+
+	// Teardown waits for provisioning to return before deleting the
+	// workspace, so no provisioning goroutine outlives the test
+	assert.Eventually(t, func() bool {
+		_, getErr := server.workspaceRegistry.Get(ws.WorkspaceID)
+		return getErr != nil
+	}, 10*time.Second, 10*time.Millisecond)
+	server.provisioningMu.Lock()
+	assert.Empty(t, server.provisioning)
+	server.provisioningMu.Unlock()
+}
+
+func TestGitHubWebhook_ClosedAfterSynchronizeTearsDownReplacement(t *testing.T) {
+	server := newWebhookTestServer(t)
+	send := func(action, sha string) GitHubWebhookResponse {
+		payload := pullRequestPayload(action, sha)
+		w := sendWebhook(server, "pull_request", payload, github.SignWebhookPayload(testWebhookSecret, payload))
+		require.Equal(t, http.StatusAccepted, w.Code)
+		var resp GitHubWebhookResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp
+	}
+
+	opened := send("opened", "abc123")
+	synchronized := send("synchronize", "def456")
+	require.NotEqual(t, opened.WorkspaceID, synchronized.WorkspaceID)
+
+	// The replaced workspace is still being torn down, so the closed event
+	// must resolve to its replacement
+	closed := send("closed", "def456")
+	assert.Equal(t, "deleting", closed.Result)
+	assert.Equal(t, synchronized.WorkspaceID, closed.WorkspaceID)
+
+	assert.Eventually(t, func() bool {
+		workspaces, _ := server.workspaceRegistry.List()
+		return len(workspaces) == 0
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestGitHubWebhook_SkipsForksUnlessAllowed(t *testing.T) {
+	server := newWebhookTestServer(t)
+	payload := pullRequestPayloadFrom("mallory/project", "opened", "abc123")
+
+	w := sendWebhook(server, "pull_request", payload, github.SignWebhookPayload(testWebhookSecret, payload))
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var resp GitHubWebhookResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "ignored_fork", resp.Result)
+	workspaces, _ := server.workspaceRegistry.List()
+	assert.Empty(t, workspaces)
+
+	server.config.GitHub.PullRequests.AllowForks = true
+	w = sendWebhook(server, "pull_request", payload, github.SignWebhookPayload(testWebhookSecret, payload))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	resp = GitHubWebhookResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "provisioning", resp.Result)
+
+	ws, err := server.workspaceRegistry.Get(resp.WorkspaceID)
+	require.NoError(t, err)
+	server.teardownWorkspace(context.Background(), ws)
+}
+
+func TestGitHubWebhook_IgnoredWhenPullRequestWorkspacesDisabled(t *testing.T) {
+	server := newWebhookTestServer(t)
+	server.config.GitHub.PullRequests.Enabled = false
+	payload := pullRequestPayload("opened", "abc123")
+
+	w := sendWebhook(server, "pull_request", payload, github.SignWebhookPayload(testWebhookSecret, payload))
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	workspaces, _ := server.workspaceRegistry.List()
+	assert.Empty(t, workspaces)
+}
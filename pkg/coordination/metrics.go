@@ -0,0 +1,94 @@
+package coordination
+
+import (
+	"time"
+
+	"github.com/nexus/nexus/pkg/prom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// provisionBuckets suit provisioning steps, which take seconds to minutes
+var provisionBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// serverMetrics are the Prometheus metrics the coordination server exposes on
+// /metrics
+type serverMetrics struct {
+	registry      *prometheus.Registry
+	http          *prom.HTTPMetrics
+	provision     *prometheus.HistogramVec
+	heartbeats    *prometheus.CounterVec
+	commands      *prometheus.HistogramVec
+	streamClients *prometheus.GaugeVec
+}
+
+func newServerMetrics(s *Server) *serverMetrics {
+	registry := prometheus.NewRegistry()
+	factory := promauto.With(registry)
+	m := &serverMetrics{
+		registry: registry,
+		http:     prom.NewHTTPMetrics(registry, "nexus"),
+		provision: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "nexus_workspace_provision_duration_seconds",
+			Help:    "Time spent provisioning workspaces, by step and outcome; step total covers the whole run",
+			Buckets: provisionBuckets,
+		}, []string{"step", "outcome"}),
+		heartbeats: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "nexus_node_heartbeats_total",
+			Help: "Heartbeats received from registered nodes",
+		}, []string{"node"}),
+		commands: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "nexus_command_duration_seconds",
+			Help:    "Time nodes took to run commands, by command type and status",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"type", "status"}),
+		streamClients: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nexus_event_stream_clients",
+			Help: "Clients connected to the event stream, by transport",
+		}, []string{"transport"}),
+	}
+
+	registry.MustRegister(prom.NewGaugeFunc("nexus_nodes", "Registered nodes, by status and provider",
+		[]string{"status", "provider"}, func(set func(float64, ...string)) {
+			nodes, err := s.registry.List()
+			if err != nil {
+				return
+			}
+			counts := make(map[[2]string]int)
+			for _, node := range nodes {
+				counts[[2]string{node.Status, node.Provider}]++
+			}
+			for key, count := range counts {
+				set(float64(count), key[0], key[1])
+			}
+		}))
+
+	registry.MustRegister(prom.NewGaugeFunc("nexus_workspaces", "Workspaces, by status and provider",
+		[]string{"status", "provider"}, func(set func(float64, ...string)) {
+			workspaces, err := s.workspaceRegistry.List()
+			if err != nil {
+				return
+			}
+			counts := make(map[[2]string]int)
+			for _, ws := range workspaces {
+				counts[[2]string{ws.Status, ws.Provider}]++
+			}
+			for key, count := range counts {
+				set(float64(count), key[0], key[1])
+			}
+		}))
+
+	return m
+}
+
+// observeProvision records how long a provisioning step took since start
+func (m *serverMetrics) observeProvision(step string, start time.Time, err error) {
+	m.provision.WithLabelValues(step, outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
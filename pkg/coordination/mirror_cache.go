@@ -0,0 +1,84 @@
+package coordination
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// MirrorCache keeps a bare mirror of every repository cloned on this node so
+// later workspaces of the same repository only fetch what the mirror lacks.
+// Mirrors are kept apart per GitHub installation or user, so one tenant's
+// credentials never fill a mirror another tenant clones from.
+type MirrorCache struct {
+	dir   string
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewMirrorCache creates a mirror cache rooted at dir. A relative dir is
+// taken to be under dataDir, the directory holding the server's database.
+func NewMirrorCache(dir, dataDir string) *MirrorCache {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(dataDir, dir)
+	}
+	return &MirrorCache{
+		dir:   dir,
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+// Path returns where scope's mirror of cloneURL lives
+func (m *MirrorCache) Path(scope, cloneURL string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + cloneURL))
+	return filepath.Join(m.dir, hex.EncodeToString(sum[:8])+".git")
+}
+
+// Update creates or refreshes scope's mirror of cloneURL and returns its
+// path. env carries the git credentials, which are never stored in the mirror.
+func (m *MirrorCache) Update(ctx context.Context, scope, cloneURL string, env []string) (string, error) {
+	path := m.Path(scope, cloneURL)
+
+	lock := m.lockFor(path)
+	lock.Lock()
+	defer lock.Unlock()
+
+	var cmd *exec.Cmd
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err == nil {
+		cmd = exec.CommandContext(ctx, "git", "-C", path, "remote", "update", "--prune")
+	} else {
+		// Mirrors hold private repositories, so only the server may read them
+		if err := os.MkdirAll(m.dir, 0700); err != nil {
+			return "", fmt.Errorf("failed to create mirror directory: %w", err)
+		}
+		if err := os.Chmod(m.dir, 0700); err != nil {
+			return "", fmt.Errorf("failed to restrict mirror directory: %w", err)
+		}
+		os.RemoveAll(path)
+		cmd = exec.CommandContext(ctx, "git", "clone", "--mirror", cloneURL, path)
+	}
+	cmd.Env = env
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("mirror update failed: %w\nOutput: %s", err, string(output))
+	}
+
+	return path, nil
+}
+
+func (m *MirrorCache) lockFor(path string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lock, ok := m.locks[path]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.locks[path] = lock
+	}
+	return lock
+}
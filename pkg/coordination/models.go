@@ -192,8 +192,12 @@ type GitHubInstallation struct {
 	RepoFullName   string    `json:"repo_full_name"`
 	Token          string    `json:"token"`
 	TokenExpiresAt time.Time `json:"token_expires_at"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	// UserToken is the user's own OAuth token. Token becomes an App
+	// installation token once one is minted, and those act as the App, so
+	// anything done in the user's name, like forking, needs this one.
+	UserToken string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // Validate validates GitHub installation data
@@ -3,6 +3,7 @@ package coordination
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -11,9 +12,11 @@ import (
 	"time"
 
 	"github.com/nexus/nexus/pkg/github"
+	"github.com/nexus/nexus/pkg/prom"
 	"github.com/nexus/nexus/pkg/provider"
 	"github.com/nexus/nexus/pkg/provider/docker"
 	"github.com/nexus/nexus/pkg/provider/lxc"
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 // Node represents a remote node in the coordination system
@@ -72,6 +75,9 @@ type Command struct {
 	Timeout time.Duration          `json:"timeout,omitempty"`
 	User    string                 `json:"user,omitempty"`
 	Created time.Time              `json:"created"`
+	// TraceContext carries the W3C trace context of the request that sent
+	// the command, so the node's work joins the same trace
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 // CommandResult represents the result of a command execution
@@ -330,6 +336,8 @@ type Server struct {
 	config                *Config
 	registry              Registry
 	workspaceRegistry     WorkspaceRegistry
+	usage                 UsageStore
+	auditLog              AuditStore
 	httpSrv               *http.Server
 	router                *http.ServeMux
 	clients               map[chan Event]bool
@@ -337,9 +345,14 @@ type Server struct {
 	commandCh             chan CommandResult
 	provider              provider.Provider
 	appConfig             *github.AppConfig
+	tokenBroker           *github.TokenBroker
+	mirrorCache           *MirrorCache
 	oauthStateStore       *OAuthStateStore
 	gitHubInstallations   map[string]*GitHubInstallation
 	gitHubInstallationsMu sync.RWMutex
+	provisioning          map[string]*provisioning
+	provisioningMu        sync.Mutex
+	metrics               *serverMetrics
 }
 
 // OAuthStateStore stores OAuth state tokens with expiration for CSRF protection
@@ -412,37 +425,41 @@ func initializeRegistry(cfg *Config) Registry {
 		storageType = "sqlite"
 	}
 
-	storagePath := cfg.Registry.Storage.Path
-	if storagePath == "" {
-		if home, err := os.UserHomeDir(); err == nil {
-			storagePath = filepath.Join(home, ".nexus-runtime", "data", "nexus.db")
-		} else {
-			storagePath = ".nexus-runtime/data/nexus.db"
-		}
-	}
-
-	if envPath := os.Getenv("DB_PATH"); envPath != "" {
-		storagePath = envPath
-	}
+	storagePath := registryStoragePath(cfg)
 
 	switch storageType {
 	case "sqlite":
 		sqliteRegistry, err := NewSQLiteRegistry(storagePath)
 		if err != nil {
-			fmt.Printf("Warning: failed to initialize SQLite registry at %s, falling back to in-memory: %v\n", storagePath, err)
+			slog.Warn("Failed to initialize SQLite registry, falling back to in-memory", "path", storagePath, "error", err)
 			return NewInMemoryRegistry()
 		}
-		fmt.Printf("Using SQLite registry at: %s\n", storagePath)
+		slog.Info("Using SQLite registry", "path", storagePath)
 		return sqliteRegistry
 	case "memory":
-		fmt.Printf("Using in-memory registry\n")
+		slog.Info("Using in-memory registry")
 		return NewInMemoryRegistry()
 	default:
-		fmt.Printf("Warning: unknown storage type %s, falling back to in-memory\n", storageType)
+		slog.Warn("Unknown storage type, falling back to in-memory", "type", storageType)
 		return NewInMemoryRegistry()
 	}
 }
 
+// registryStoragePath returns where the SQLite database lives; its directory
+// is the server's data directory
+func registryStoragePath(cfg *Config) string {
+	if envPath := os.Getenv("DB_PATH"); envPath != "" {
+		return envPath
+	}
+	if cfg.Registry.Storage.Path != "" {
+		return cfg.Registry.Storage.Path
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".nexus-runtime", "data", "nexus.db")
+	}
+	return ".nexus-runtime/data/nexus.db"
+}
+
 // NewServer creates a new coordination server
 func NewServer(cfg *Config) *Server {
 	registry := initializeRegistry(cfg)
@@ -451,26 +468,41 @@ func NewServer(cfg *Config) *Server {
 		config:              cfg,
 		registry:            registry,
 		workspaceRegistry:   NewInMemoryWorkspaceRegistry(),
+		usage:               NewInMemoryUsageStore(),
+		auditLog:            NewInMemoryAuditStore(),
 		router:              http.NewServeMux(),
 		clients:             make(map[chan Event]bool),
 		commandCh:           make(chan CommandResult, 100),
 		oauthStateStore:     NewOAuthStateStore(5 * time.Minute),
 		gitHubInstallations: make(map[string]*GitHubInstallation),
+		provisioning:        make(map[string]*provisioning),
+	}
+
+	if sqliteRegistry, ok := registry.(*SQLiteRegistry); ok {
+		srv.usage = NewSQLiteUsageStore(sqliteRegistry.db)
+		srv.auditLog = NewSQLiteAuditStore(sqliteRegistry.db)
+	}
+
+	srv.metrics = newServerMetrics(srv)
+
+	if cfg.Git.MirrorDir != "" {
+		srv.mirrorCache = NewMirrorCache(cfg.Git.MirrorDir, filepath.Dir(registryStoragePath(cfg)))
 	}
 
 	if err := srv.initializeProvider(); err != nil {
-		fmt.Printf("Warning: failed to initialize provider: %v\n", err)
+		slog.Warn("Failed to initialize provider", "error", err)
 	}
 
 	appConfig, err := github.NewAppConfig()
 	if err != nil {
-		fmt.Printf("Warning: GitHub App not configured: %v\n", err)
+		slog.Warn("GitHub App not configured", "error", err)
 	} else {
 		srv.appConfig = appConfig
+		srv.tokenBroker = github.NewTokenBroker(appConfig.AppID, appConfig.PrivateKey)
 	}
 
 	if testToken := os.Getenv("GITHUB_TOKEN"); testToken != "" {
-		fmt.Printf("TEST MODE: Injecting GitHub token for development\n")
+		slog.Warn("Test mode: injecting GITHUB_TOKEN for development")
 		srv.gitHubInstallations["IniZio"] = &GitHubInstallation{
 			Token:          testToken,
 			GitHubUsername: "IniZio",
@@ -526,10 +558,20 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/api/v1/workspaces/", s.handleM4WorkspacesRouter)
 
 	s.router.HandleFunc("/health", s.handleHealth)
-	s.router.HandleFunc("/metrics", s.handleMetrics)
+	s.router.Handle("/metrics", prom.Handler(s.metrics.registry))
+	s.router.HandleFunc("/api/v1/metrics", s.handleMetrics)
 
 	s.router.HandleFunc("/ws", s.handleWebSocket)
 
+	// Team usage
+	s.router.HandleFunc("/api/v1/usage/batches", s.handleUsageBatch)
+	s.router.HandleFunc("/api/v1/usage/team", s.handleTeamUsage)
+	s.router.HandleFunc("/api/v1/usage/team/summary", s.handleTeamUsageSummary)
+	s.router.HandleFunc("/api/v1/usage/team/benchmark", s.handleTeamUsageBenchmark)
+
+	// Audit log (admin token only)
+	s.router.HandleFunc("/api/v1/audit", s.handleAudit)
+
 	s.router.HandleFunc("/api/v1/users/register-github", s.handleM4RegisterGitHub)
 	s.router.HandleFunc("/api/v1/workspaces/create-from-repo", s.handleM4CreateWorkspace)
 	s.router.HandleFunc("/api/v1/workspaces", s.handleM4ListWorkspacesRouter)
@@ -540,12 +582,16 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/api/github/oauth-url", s.handleGetGitHubOAuthURL)
 	s.router.HandleFunc("/workspace/auth-success", s.handleAuthSuccess)
 	s.router.HandleFunc("/workspace/auth-error", s.handleAuthError)
+
+	// GitHub webhooks (authenticated by payload signature)
+	s.router.HandleFunc("/webhooks/github", s.handleGitHubWebhook)
 }
 
 // Request routing helpers
 func (s *Server) handleNodesRequest(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
+		audit(r, "node.register", "")
 		s.handleRegisterNode(w, r)
 	case http.MethodGet:
 		s.handleListNodes(w, r)
@@ -564,10 +610,20 @@ func (s *Server) handleNodeRequest(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(path, "/")
 	nodeID := parts[0]
 
+	if len(parts) == 2 && parts[1] == "heartbeat" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleNodeHeartbeat(w, r, nodeID)
+		return
+	}
+
 	// Check if this is a command request
 	if len(parts) >= 3 && parts[1] == "commands" {
 		switch r.Method {
 		case http.MethodPost:
+			audit(r, "command.send", nodeID)
 			s.handleSendCommand(w, r, nodeID)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -583,8 +639,10 @@ func (s *Server) handleNodeRequest(w http.ResponseWriter, r *http.Request) {
 			s.handleGetNode(w, r, nodeID)
 		}
 	case http.MethodPut:
+		audit(r, "node.update", nodeID)
 		s.handleUpdateNode(w, r, nodeID)
 	case http.MethodDelete:
+		audit(r, "node.unregister", nodeID)
 		s.handleUnregisterNode(w, r, nodeID)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -608,6 +666,7 @@ func (s *Server) handleCommandResultRequest(w http.ResponseWriter, r *http.Reque
 	switch r.Method {
 	case http.MethodPost:
 		if strings.HasSuffix(r.URL.Path, "/result") {
+			audit(r, "command.result", commandID)
 			s.handleCommandResult(w, r, commandID)
 		} else {
 			http.Error(w, "Invalid endpoint", http.StatusBadRequest)
@@ -623,7 +682,7 @@ func (s *Server) Start() error {
 
 	s.httpSrv = &http.Server{
 		Addr:         addr,
-		Handler:      s.corsMiddleware(s.authMiddleware(s.loggingMiddleware(s.router))),
+		Handler:      tracing.Middleware(s.router, s.metrics.http.Middleware(s.router, s.corsMiddleware(s.loggingMiddleware(s.auditMiddleware(s.authMiddleware(s.router)))))),
 		ReadTimeout:  s.parseTimeout(s.config.Server.ReadTimeout),
 		WriteTimeout: s.parseTimeout(s.config.Server.WriteTimeout),
 		IdleTimeout:  s.parseTimeout(s.config.Server.IdleTimeout),
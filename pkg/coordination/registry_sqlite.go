@@ -157,16 +157,18 @@ func (r *SQLiteRegistry) StoreGitHubInstallation(installation *GitHubInstallatio
 	_, err := r.db.Exec(`
 		INSERT INTO github_installations (
 			installation_id, user_id, github_user_id, github_username, 
-			repo_full_name, token, token_expires_at, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			repo_full_name, token, token_expires_at, user_token, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(user_id) DO UPDATE SET
+			installation_id = excluded.installation_id,
 			token = excluded.token,
+			user_token = CASE WHEN excluded.user_token = '' THEN user_token ELSE excluded.user_token END,
 			token_expires_at = excluded.token_expires_at,
 			github_username = excluded.github_username,
 			updated_at = CURRENT_TIMESTAMP
 	`, installation.InstallationID, installation.UserID, installation.GitHubUserID,
 		installation.GitHubUsername, installation.RepoFullName, installation.Token,
-		installation.TokenExpiresAt, time.Now(), time.Now())
+		installation.TokenExpiresAt, installation.UserToken, time.Now(), time.Now())
 
 	if err != nil {
 		return fmt.Errorf("failed to store GitHub installation: %w", err)
@@ -180,7 +182,7 @@ func (r *SQLiteRegistry) GetGitHubInstallation(userID string) (*GitHubInstallati
 
 	err := r.db.QueryRow(`
 		SELECT installation_id, user_id, github_user_id, github_username, 
-		       repo_full_name, token, token_expires_at, created_at, updated_at
+		       repo_full_name, token, token_expires_at, user_token, created_at, updated_at
 		FROM github_installations
 		WHERE user_id = ?
 	`, userID).Scan(
@@ -191,6 +193,7 @@ func (r *SQLiteRegistry) GetGitHubInstallation(userID string) (*GitHubInstallati
 		&installation.RepoFullName,
 		&installation.Token,
 		&installation.TokenExpiresAt,
+		&installation.UserToken,
 		&installation.CreatedAt,
 		&installation.UpdatedAt,
 	)
@@ -35,6 +35,38 @@ func TestSQLiteRegistry_CreateAndRetrieve(t *testing.T) {
 	assert.Equal(t, installation.GitHubUsername, retrieved.GitHubUsername)
 }
 
+func TestSQLiteRegistry_KeepsUserToken(t *testing.T) {
+	registry, err := NewSQLiteRegistry(t.TempDir() + "/test.db")
+	require.NoError(t, err)
+
+	installation := &GitHubInstallation{
+		InstallationID: 7,
+		UserID:         "user123",
+		GitHubUserID:   456,
+		GitHubUsername: "testuser",
+		Token:          "gho_user_token",
+		UserToken:      "gho_user_token",
+		TokenExpiresAt: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, registry.StoreGitHubInstallation(installation))
+
+	// Refreshing the installation token must not drop the user's token
+	refreshed := *installation
+	refreshed.Token = "ghs_installation_token"
+	refreshed.UserToken = ""
+	require.NoError(t, registry.StoreGitHubInstallation(&refreshed))
+
+	retrieved, err := registry.GetGitHubInstallation("user123")
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_installation_token", retrieved.Token)
+	assert.Equal(t, "gho_user_token", retrieved.UserToken)
+
+	server := NewServer(&Config{})
+	assert.Equal(t, "gho_user_token", server.userToken(retrieved), "forks use the user's token, not the App's")
+	assert.Equal(t, "gho_plain", server.userToken(&GitHubInstallation{Token: "gho_plain"}))
+	assert.Empty(t, server.userToken(&GitHubInstallation{InstallationID: 7, Token: "ghs_installation_token"}))
+}
+
 func TestSQLiteRegistry_StoreFork(t *testing.T) {
 	dbFile := t.TempDir() + "/test.db"
 	registry, err := NewSQLiteRegistry(dbFile)
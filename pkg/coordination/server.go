@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -12,7 +12,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/nexus/nexus/pkg/logging"
 	"github.com/nexus/nexus/pkg/paths"
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 // ServerInfo contains server runtime information
@@ -46,13 +48,29 @@ func StartServer(configPath string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	logFile, err := logging.Setup(cfg.Logging)
+	if err != nil {
+		return fmt.Errorf("failed to set up logging: %w", err)
+	}
+	defer logFile.Close()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, "nexus-coordination")
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
 	// Create server instance
 	srv := NewServer(cfg)
 
 	// Start server in goroutine
 	serverErr := make(chan error, 1)
 	go func() {
-		log.Printf("Starting coordination server on %s:%d", cfg.Server.Host, cfg.Server.Port)
+		slog.Info("Starting coordination server", "host", cfg.Server.Host, "port", cfg.Server.Port)
 		if err := srv.Start(); err != nil && err != http.ErrServerClosed {
 			serverErr <- fmt.Errorf("server failed to start: %w", err)
 		}
@@ -67,7 +85,7 @@ func StartServer(configPath string) error {
 	case err := <-serverErr:
 		return err
 	case sig := <-sigCh:
-		log.Printf("Received signal %v, shutting down gracefully", sig)
+		slog.Info("Shutting down gracefully", "signal", sig.String())
 
 		// Create shutdown context with timeout
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -75,11 +93,11 @@ func StartServer(configPath string) error {
 
 		// Shutdown server
 		if err := srv.Stop(shutdownCtx); err != nil {
-			log.Printf("Error during server shutdown: %v", err)
+			slog.Error("Server shutdown failed", "error", err)
 			return err
 		}
 
-		log.Println("Server shutdown complete")
+		slog.Info("Server shutdown complete")
 		return nil
 	}
 }
@@ -222,17 +240,17 @@ func (s *Server) RestoreRegistry(backupData []byte) error {
 		nodeJSON, _ := json.Marshal(nodeData)
 		var node Node
 		if err := json.Unmarshal(nodeJSON, &node); err != nil {
-			log.Printf("Failed to restore node from backup: %v", err)
+			slog.Warn("Failed to restore node from backup", "error", err)
 			continue
 		}
 
 		if err := s.registry.Register(&node); err != nil {
-			log.Printf("Failed to register restored node %s: %v", node.ID, err)
+			slog.Warn("Failed to register restored node", "node_id", node.ID, "error", err)
 			continue
 		}
 	}
 
-	log.Printf("Registry restored from backup with %d nodes", len(nodesData))
+	slog.Info("Registry restored from backup", "nodes", len(nodesData))
 	return nil
 }
 
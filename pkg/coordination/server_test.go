@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -35,6 +36,7 @@ func TestValidateConfig(t *testing.T) {
 					JWTSecret   string   `yaml:"jwt_secret,omitempty"`
 					TokenExpiry string   `yaml:"token_expiry,omitempty"`
 					AllowedIPs  []string `yaml:"allowed_ips,omitempty"`
+					AdminToken  string   `yaml:"admin_token,omitempty"`
 				}{Enabled: false},
 			},
 			wantErr: false,
@@ -112,6 +114,7 @@ func TestValidateConfig(t *testing.T) {
 					JWTSecret   string   `yaml:"jwt_secret,omitempty"`
 					TokenExpiry string   `yaml:"token_expiry,omitempty"`
 					AllowedIPs  []string `yaml:"allowed_ips,omitempty"`
+					AdminToken  string   `yaml:"admin_token,omitempty"`
 				}{Enabled: true, JWTSecret: ""},
 			},
 			wantErr: true,
@@ -134,6 +137,7 @@ func TestValidateConfig(t *testing.T) {
 					JWTSecret   string   `yaml:"jwt_secret,omitempty"`
 					TokenExpiry string   `yaml:"token_expiry,omitempty"`
 					AllowedIPs  []string `yaml:"allowed_ips,omitempty"`
+					AdminToken  string   `yaml:"admin_token,omitempty"`
 				}{Enabled: true, JWTSecret: "short"},
 			},
 			wantErr: true,
@@ -405,6 +409,68 @@ func TestHandleMetrics(t *testing.T) {
 	assert.Contains(t, resp, "services")
 }
 
+func TestPrometheusMetrics(t *testing.T) {
+	srv := NewServer(&Config{
+		Server: struct {
+			Host         string `yaml:"host,omitempty"`
+			Port         int    `yaml:"port,omitempty"`
+			AuthToken    string `yaml:"auth_token,omitempty"`
+			JWTSecret    string `yaml:"jwt_secret,omitempty"`
+			ReadTimeout  string `yaml:"read_timeout,omitempty"`
+			WriteTimeout string `yaml:"write_timeout,omitempty"`
+			IdleTimeout  string `yaml:"idle_timeout,omitempty"`
+		}{Host: "localhost", Port: 3001},
+	})
+	require.NoError(t, srv.registry.Register(&Node{ID: "node1", Status: "active", Provider: "docker"}))
+	require.NoError(t, srv.workspaceRegistry.Create(&DBWorkspace{WorkspaceID: "ws1", UserID: "u1", WorkspaceName: "ws", Status: "running", Provider: "docker"}))
+	handler := srv.metrics.http.Middleware(srv.router, srv.router)
+
+	heartbeat := httptest.NewRequest(http.MethodPost, "/api/v1/nodes/node1/heartbeat", bytes.NewReader([]byte(`{"status":"active"}`)))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, heartbeat)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	unknown := httptest.NewRequest(http.MethodPost, "/api/v1/nodes/ghost/heartbeat", bytes.NewReader([]byte(`{}`)))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, unknown)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	srv.metrics.observeProvision("clone", time.Now(), nil)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `nexus_node_heartbeats_total{node="node1"} 1`)
+	assert.NotContains(t, body, `node="ghost"`)
+	assert.Contains(t, body, `nexus_nodes{provider="docker",status="active"} 1`)
+	assert.Contains(t, body, `nexus_workspaces{provider="docker",status="running"} 1`)
+	assert.Contains(t, body, `nexus_http_requests_total{method="POST",route="/api/v1/nodes/",status="204"} 1`)
+	assert.Contains(t, body, `nexus_http_requests_total{method="POST",route="/api/v1/nodes/",status="404"} 1`)
+	assert.Contains(t, body, `nexus_workspace_provision_duration_seconds_count{outcome="success",step="clone"} 1`)
+}
+
+func TestNodeHeartbeatTelemetry(t *testing.T) {
+	srv := NewServer(&Config{})
+	require.NoError(t, srv.registry.Register(&Node{
+		ID:       "node1",
+		Status:   "active",
+		Metadata: map[string]interface{}{"os": "linux"},
+	}))
+
+	body := `{"status":"active","capabilities":{"cpu_cores":4,"providers":["docker"]},` +
+		`"metadata":{"telemetry":{"load":{"load1":0.5}}}}`
+	w := httptest.NewRecorder()
+	srv.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/nodes/node1/heartbeat", bytes.NewReader([]byte(body))))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	node, err := srv.registry.Get("node1")
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), node.Capabilities["cpu_cores"])
+	assert.Equal(t, "linux", node.Metadata["os"])
+	assert.Equal(t, map[string]interface{}{"load": map[string]interface{}{"load1": 0.5}}, node.Metadata["telemetry"])
+}
+
 func TestHandleListServices(t *testing.T) {
 	srv := NewServer(&Config{
 		Server: struct {
@@ -497,6 +563,7 @@ func TestAuthMiddleware(t *testing.T) {
 					JWTSecret   string   `yaml:"jwt_secret,omitempty"`
 					TokenExpiry string   `yaml:"token_expiry,omitempty"`
 					AllowedIPs  []string `yaml:"allowed_ips,omitempty"`
+					AdminToken  string   `yaml:"admin_token,omitempty"`
 				}{Enabled: tt.authEnabled},
 			}
 			srv := NewServer(cfg)
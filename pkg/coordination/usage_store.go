@@ -0,0 +1,239 @@
+package coordination
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nexus/nexus/pkg/metrics"
+)
+
+// UsageStore keeps the usage entries team members upload, per user and
+// project. An entry uploaded twice by the same user is stored once.
+type UsageStore interface {
+	// AddUsage stores entries for user in project and returns how many
+	// weren't already stored
+	AddUsage(user, project string, entries []metrics.UsageLog) (int, error)
+	// QueryUsage returns the stored entries that match filters, oldest first
+	QueryUsage(filters metrics.Filter) ([]metrics.UsageLog, error)
+	// BindPseudonym records that member uploads to project under pseudonym.
+	// The first pseudonym a member uploads under stays theirs, and no other
+	// member can use it; anything else returns ErrPseudonymBound.
+	BindPseudonym(member, project, pseudonym string) error
+}
+
+// ErrPseudonymBound is returned for a pseudonym that is bound to another
+// member, or a member already bound to another pseudonym
+var ErrPseudonymBound = errors.New("pseudonym is bound to another member")
+
+type pseudonymKey struct {
+	member, project string
+}
+
+// normalizeUsage stamps an uploaded entry with its user and project and
+// rewrites its timestamp in UTC, so stored timestamps sort as strings
+func normalizeUsage(user, project string, entry metrics.UsageLog) (metrics.UsageLog, error) {
+	t, err := time.Parse(time.RFC3339, entry.Timestamp)
+	if err != nil {
+		return entry, fmt.Errorf("entry %s has an invalid timestamp %q", entry.ID, entry.Timestamp)
+	}
+	entry.Timestamp = t.UTC().Format(time.RFC3339)
+	entry.User = user
+	entry.Context.Project = project
+	return entry, nil
+}
+
+type usageKey struct {
+	user, id string
+}
+
+type InMemoryUsageStore struct {
+	entries    map[usageKey]metrics.UsageLog
+	pseudonyms map[pseudonymKey]string
+	mu         sync.RWMutex
+}
+
+func NewInMemoryUsageStore() UsageStore {
+	return &InMemoryUsageStore{
+		entries:    make(map[usageKey]metrics.UsageLog),
+		pseudonyms: make(map[pseudonymKey]string),
+	}
+}
+
+func (s *InMemoryUsageStore) AddUsage(user, project string, entries []metrics.UsageLog) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, uploaded := range entries {
+		entry, err := normalizeUsage(user, project, uploaded)
+		if err != nil {
+			return added, err
+		}
+		key := usageKey{user, entry.ID}
+		if _, exists := s.entries[key]; exists {
+			continue
+		}
+		s.entries[key] = entry
+		added++
+	}
+	return added, nil
+}
+
+func (s *InMemoryUsageStore) QueryUsage(filters metrics.Filter) ([]metrics.UsageLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []metrics.UsageLog
+	for _, entry := range s.entries {
+		if filters.Matches(entry) {
+			results = append(results, entry)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Timestamp < results[j].Timestamp })
+	return results, nil
+}
+
+func (s *InMemoryUsageStore) BindPseudonym(member, project, pseudonym string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bound, ok := s.pseudonyms[pseudonymKey{member, project}]; ok {
+		if bound != pseudonym {
+			return ErrPseudonymBound
+		}
+		return nil
+	}
+	for key, bound := range s.pseudonyms {
+		if key.project == project && bound == pseudonym {
+			return ErrPseudonymBound
+		}
+	}
+	s.pseudonyms[pseudonymKey{member, project}] = pseudonym
+	return nil
+}
+
+// SQLiteUsageStore keeps usage entries in the coordination database's
+// usage_logs table. Project and time are indexed; the rest of each entry is
+// stored as JSON and filtered after it's read.
+type SQLiteUsageStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteUsageStore(db *sql.DB) UsageStore {
+	return &SQLiteUsageStore{db: db}
+}
+
+func (s *SQLiteUsageStore) AddUsage(user, project string, entries []metrics.UsageLog) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO usage_logs (user_id, entry_id, project, timestamp, entry)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare usage insert: %w", err)
+	}
+	defer stmt.Close()
+
+	added := 0
+	for _, uploaded := range entries {
+		entry, normErr := normalizeUsage(user, project, uploaded)
+		if normErr != nil {
+			return 0, normErr
+		}
+		data, marshalErr := json.Marshal(entry)
+		if marshalErr != nil {
+			return 0, fmt.Errorf("failed to marshal usage entry: %w", marshalErr)
+		}
+		result, execErr := stmt.Exec(user, entry.ID, project, entry.Timestamp, string(data))
+		if execErr != nil {
+			return 0, fmt.Errorf("failed to store usage entry: %w", execErr)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit usage entries: %w", err)
+	}
+	return added, nil
+}
+
+func (s *SQLiteUsageStore) QueryUsage(filters metrics.Filter) ([]metrics.UsageLog, error) {
+	query := "SELECT entry FROM usage_logs WHERE 1 = 1"
+	var args []interface{}
+	if filters.Project != "" {
+		query += " AND project = ?"
+		args = append(args, filters.Project)
+	}
+	if filters.User != "" {
+		query += " AND user_id = ?"
+		args = append(args, filters.User)
+	}
+	if !filters.StartTime.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, filters.StartTime.UTC().Format(time.RFC3339))
+	}
+	if !filters.EndTime.IsZero() {
+		query += " AND timestamp <= ?"
+		args = append(args, filters.EndTime.UTC().Format(time.RFC3339))
+	}
+	query += " ORDER BY timestamp"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage: %w", err)
+	}
+	defer rows.Close()
+
+	var results []metrics.UsageLog
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan usage entry: %w", err)
+		}
+		var entry metrics.UsageLog
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse usage entry: %w", err)
+		}
+		if filters.Matches(entry) {
+			results = append(results, entry)
+		}
+	}
+	return results, rows.Err()
+}
+
+func (s *SQLiteUsageStore) BindPseudonym(member, project, pseudonym string) error {
+	// The insert is ignored when either the member or the pseudonym is
+	// already bound in the project, so the binding exists afterwards only if
+	// it was new or the same one
+	if _, err := s.db.Exec(`
+		INSERT OR IGNORE INTO usage_pseudonyms (member, project, pseudonym)
+		VALUES (?, ?, ?)
+	`, member, project, pseudonym); err != nil {
+		return fmt.Errorf("failed to bind pseudonym: %w", err)
+	}
+
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM usage_pseudonyms
+		WHERE member = ? AND project = ? AND pseudonym = ?
+	`, member, project, pseudonym).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to read pseudonym binding: %w", err)
+	}
+	if count == 0 {
+		return ErrPseudonymBound
+	}
+	return nil
+}
@@ -123,6 +123,9 @@ func (r *InMemoryWorkspaceRegistry) Update(id string, updates map[string]interfa
 		case "node_id":
 			nodeID := value.(string)
 			ws.NodeID = &nodeID
+		case "repo_commit":
+			commit := value.(string)
+			ws.RepoCommit = &commit
 		}
 	}
 
@@ -2,9 +2,7 @@ package ctrl
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -13,9 +11,12 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/nexus/nexus/pkg/adapters"
 	"github.com/nexus/nexus/pkg/config"
+	"github.com/nexus/nexus/pkg/generated"
 	"github.com/nexus/nexus/pkg/lock"
 	"github.com/nexus/nexus/pkg/paths"
+	"github.com/nexus/nexus/pkg/plugins"
 	"github.com/nexus/nexus/pkg/provider"
 	"github.com/nexus/nexus/pkg/templates"
 	"github.com/nexus/nexus/pkg/worktree"
@@ -34,15 +35,22 @@ type Controller interface {
 	Init(ctx context.Context) error
 	Dev(ctx context.Context, branch string) error
 	WorkspaceCreate(ctx context.Context, name string) error
+	WorkspaceCreateFrom(ctx context.Context, name, baseRef string) error
 	WorkspaceUp(ctx context.Context, name string) error
 	WorkspaceDown(ctx context.Context, name string) error
 	WorkspaceShell(ctx context.Context, name string) error
 	WorkspaceList(ctx context.Context) error
 	WorkspaceRm(ctx context.Context, name string) error
+	WorkspacePrune(ctx context.Context) error
 	WorkspaceServices(ctx context.Context, name string) ([]PortMapping, error)
 	WorkspaceConnect(ctx context.Context, name string) error
-	Apply(ctx context.Context) error
+	Apply(ctx context.Context, opts ApplyOptions) error
+	Clean(ctx context.Context) error
 	PluginUpdate(ctx context.Context) error
+	PluginInstall(ctx context.Context, frozen bool) error
+	PluginSearch(ctx context.Context, index, term string) error
+	PluginAdd(ctx context.Context, index, ref string) error
+	PluginRemove(ctx context.Context, name string) error
 	PluginList(ctx context.Context) error
 	Kill(ctx context.Context, sessionID string) error
 	List(ctx context.Context) ([]provider.Session, error)
@@ -75,8 +83,14 @@ func (c *BaseController) Dev(ctx context.Context, branch string) error {
 	return c.WorkspaceCreate(ctx, branch)
 }
 
-func (c *BaseController) WorkspaceCreate(_ context.Context, name string) error {
-	if name == "" || strings.Contains(name, "/") || strings.Contains(name, "..") {
+func (c *BaseController) WorkspaceCreate(ctx context.Context, name string) error {
+	return c.WorkspaceCreateFrom(ctx, name, "")
+}
+
+// WorkspaceCreateFrom creates a workspace whose branch starts at baseRef
+// (the current HEAD when empty). Names may contain "/" like any git branch.
+func (c *BaseController) WorkspaceCreateFrom(_ context.Context, name, baseRef string) error {
+	if err := worktree.ValidateBranchName(name); err != nil {
 		return fmt.Errorf("invalid workspace name: %s", name)
 	}
 	fmt.Printf("🚀 Creating workspace '%s'...\n", name)
@@ -88,11 +102,16 @@ func (c *BaseController) WorkspaceCreate(_ context.Context, name string) error {
 
 	projectRoot := paths.GetProjectRoot()
 	worktreesDir := paths.GetWorktreesDir(projectRoot)
-	if _, err := os.Stat(filepath.Join(worktreesDir, name)); err == nil {
+	if _, err := os.Stat(filepath.Join(worktreesDir, worktree.DirName(name))); err == nil {
 		return fmt.Errorf("workspace '%s' already exists", name)
 	}
 
-	wtPath, err := c.WorktreeManager.Add(name)
+	var wtPath string
+	if baseRef != "" {
+		wtPath, err = c.WorktreeManager.AddFrom(name, baseRef)
+	} else {
+		wtPath, err = c.WorktreeManager.Add(name)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create worktree: %w", err)
 	}
@@ -102,16 +121,29 @@ func (c *BaseController) WorkspaceCreate(_ context.Context, name string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	merged, err := cfg.GetMergedTemplates(root)
+	env, err := cfg.ConditionEnvironment(root)
+	if err != nil {
+		return fmt.Errorf("failed to detect agents: %w", err)
+	}
+	merged, err := cfg.GetMergedTemplates(root, env)
 	if err != nil {
 		return fmt.Errorf("failed to merge templates: %w", err)
 	}
 
-	if err := cfg.GenerateAgentConfigs(wtPath, merged); err != nil {
+	plan := generated.NewPlan(projectRoot)
+	if err := cfg.GenerateAgentConfigs(plan, wtPath, merged, env); err != nil {
 		return fmt.Errorf("failed to generate agent configs: %w", err)
 	}
+	manifest, err := generated.LoadManifest(generated.ManifestPath(projectRoot))
+	if err != nil {
+		return err
+	}
+	// The worktree is a fresh checkout, so nothing in it was edited by hand
+	if _, err := plan.Apply(manifest, true); err != nil {
+		return fmt.Errorf("failed to write agent configs: %w", err)
+	}
 
-	fmt.Printf("✅ Workspace created at %s/%s/\n", paths.GetWorktreesDir(projectRoot), name)
+	fmt.Printf("✅ Workspace created at %s/%s/\n", paths.GetWorktreesDir(projectRoot), worktree.DirName(name))
 	return nil
 }
 
@@ -124,7 +156,7 @@ func (c *BaseController) WorkspaceUp(ctx context.Context, name string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	workspacePath, err := filepath.Abs(filepath.Join(paths.GetWorktreesDir(projectRoot), name))
+	workspacePath, err := filepath.Abs(filepath.Join(paths.GetWorktreesDir(projectRoot), worktree.DirName(name)))
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path for workspace: %w", err)
 	}
@@ -138,7 +170,7 @@ func (c *BaseController) WorkspaceUp(ctx context.Context, name string) error {
 		return fmt.Errorf("provider '%s' not found", pName)
 	}
 
-	sessionID := fmt.Sprintf("%s-%s", cfg.Name, name)
+	sessionID := fmt.Sprintf("%s-%s", cfg.Name, worktree.DirName(name))
 	fmt.Printf("🐳 Creating %s session %s...\n", pName, sessionID)
 
 	session, err := p.Create(ctx, sessionID, workspacePath, cfg)
@@ -187,7 +219,7 @@ func (c *BaseController) WorkspaceDown(ctx context.Context, name string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	sessionID := fmt.Sprintf("%s-%s", cfg.Name, name)
+	sessionID := fmt.Sprintf("%s-%s", cfg.Name, worktree.DirName(name))
 	found := false
 	for _, p := range c.Providers {
 		sessions, _ := p.List(ctx)
@@ -218,7 +250,7 @@ func (c *BaseController) WorkspaceShell(ctx context.Context, name string) error
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	sessionID := fmt.Sprintf("%s-%s", cfg.Name, name)
+	sessionID := fmt.Sprintf("%s-%s", cfg.Name, worktree.DirName(name))
 	for _, p := range c.Providers {
 		sessions, _ := p.List(ctx)
 		for _, s := range sessions {
@@ -236,50 +268,58 @@ func (c *BaseController) WorkspaceShell(ctx context.Context, name string) error
 func (c *BaseController) WorkspaceList(ctx context.Context) error {
 	fmt.Println("📋 Active workspaces:")
 
-	projectRoot := paths.GetProjectRoot()
-	worktreesDir := paths.GetWorktreesDir(projectRoot)
-	entries, err := os.ReadDir(worktreesDir)
-	if err != nil {
+	worktrees, err := c.WorktreeManager.List()
+	if err != nil || len(worktrees) == 0 {
 		fmt.Println("  No active workspaces")
 		return nil
 	}
 
+	projectRoot := paths.GetProjectRoot()
 	cfg, _ := config.LoadConfig(filepath.Join(paths.GetConfigDir(projectRoot), "config.yaml"))
 
-	found := false
-	for _, entry := range entries {
-		if entry.IsDir() {
-			name := entry.Name()
-			status := "stopped"
-			ports := ""
-
-			if cfg != nil {
-				sessionID := fmt.Sprintf("%s-%s", cfg.Name, name)
-				for _, p := range c.Providers {
-					sessions, _ := p.List(ctx)
-					for _, s := range sessions {
-						if s.Labels["nexus.session.id"] == sessionID {
-							status = "running"
-							var portList []string
-							for pPort, hPort := range s.Services {
-								portList = append(portList, fmt.Sprintf("%s->%d", pPort, hPort))
-							}
-							if len(portList) > 0 {
-								ports = " (ports: " + strings.Join(portList, ", ") + ")"
-							}
-						}
+	var sessions []provider.Session
+	if cfg != nil {
+		for _, p := range c.Providers {
+			providerSessions, _ := p.List(ctx)
+			sessions = append(sessions, providerSessions...)
+		}
+	}
+
+	for _, wt := range worktrees {
+		name := wt.Name()
+		status := "stopped"
+		ports := ""
+
+		if wt.Prunable {
+			status = "missing"
+		} else if cfg != nil {
+			sessionID := fmt.Sprintf("%s-%s", cfg.Name, worktree.DirName(name))
+			for _, s := range sessions {
+				if s.Labels["nexus.session.id"] == sessionID {
+					status = "running"
+					var portList []string
+					for pPort, hPort := range s.Services {
+						portList = append(portList, fmt.Sprintf("%s->%d", pPort, hPort))
+					}
+					if len(portList) > 0 {
+						ports = " (ports: " + strings.Join(portList, ", ") + ")"
 					}
 				}
 			}
+		}
 
-			fmt.Printf("  - %s [%s]%s\n", name, status, ports)
-			found = true
+		head := wt.Head
+		if len(head) > 7 {
+			head = head[:7]
+		}
+		dirty := ""
+		if wt.Dirty {
+			dirty = ", dirty"
 		}
-	}
 
-	if !found {
-		fmt.Println("  No active workspaces")
+		fmt.Printf("  - %s [%s] %s%s%s\n", name, status, head, dirty, ports)
 	}
+
 	return nil
 }
 
@@ -294,6 +334,15 @@ func (c *BaseController) WorkspaceRm(_ context.Context, name string) error {
 	return nil
 }
 
+func (c *BaseController) WorkspacePrune(_ context.Context) error {
+	if err := c.WorktreeManager.Prune(); err != nil {
+		return err
+	}
+
+	fmt.Println("✅ Stale worktrees pruned")
+	return nil
+}
+
 func (c *BaseController) WorkspaceServices(ctx context.Context, name string) ([]PortMapping, error) {
 	projectRoot := paths.GetProjectRoot()
 	cfg, err := config.LoadConfig(filepath.Join(paths.GetConfigDir(projectRoot), "config.yaml"))
@@ -301,7 +350,7 @@ func (c *BaseController) WorkspaceServices(ctx context.Context, name string) ([]
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	sessionID := fmt.Sprintf("%s-%s", cfg.Name, name)
+	sessionID := fmt.Sprintf("%s-%s", cfg.Name, worktree.DirName(name))
 	var services []PortMapping
 
 	for _, p := range c.Providers {
@@ -332,8 +381,8 @@ func (c *BaseController) WorkspaceConnect(ctx context.Context, name string) erro
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	sessionID := fmt.Sprintf("%s-%s", cfg.Name, name)
-	workspacePath, _ := filepath.Abs(filepath.Join(paths.GetWorktreesDir(projectRoot), name))
+	sessionID := fmt.Sprintf("%s-%s", cfg.Name, worktree.DirName(name))
+	workspacePath, _ := filepath.Abs(filepath.Join(paths.GetWorktreesDir(projectRoot), worktree.DirName(name)))
 
 	fmt.Println("🔗 Workspace Connection Info")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -633,8 +682,22 @@ wait
 	return nil
 }
 
-func (c *BaseController) Apply(ctx context.Context) error {
-	fmt.Println("🔄 Applying latest configuration to agent configs...")
+// ApplyOptions control how nexus apply writes generated files
+type ApplyOptions struct {
+	// DryRun prints a unified diff of every file that would change and writes nothing
+	DryRun bool
+	// Check fails when any generated file is out of date and writes nothing
+	Check bool
+	// Force overwrites files nexus did not generate or that were edited by hand
+	Force bool
+}
+
+func (c *BaseController) Apply(ctx context.Context, opts ApplyOptions) error {
+	if opts.DryRun || opts.Check {
+		fmt.Println("🔍 Comparing agent configs with the latest configuration...")
+	} else {
+		fmt.Println("🔄 Applying latest configuration to agent configs...")
+	}
 
 	projectRoot := paths.GetProjectRoot()
 	cfg, err := config.LoadConfig(filepath.Join(paths.GetConfigDir(projectRoot), "config.yaml"))
@@ -642,206 +705,175 @@ func (c *BaseController) Apply(ctx context.Context) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	agents := config.DetectInstalledAgents()
-	if len(agents) == 0 {
+	// Agents are detected once; every workspace below is generated for them
+	env, err := cfg.ConditionEnvironment(".")
+	if err != nil {
+		return fmt.Errorf("failed to detect agents: %w", err)
+	}
+	registry, err := cfg.AgentRegistry(".", env)
+	if err != nil {
+		return fmt.Errorf("failed to load agent adapters: %w", err)
+	}
+	detected := registry.Named(env.Agents)
+	if len(detected) == 0 {
+		// Without agents there is nothing to compare against, and a check
+		// that passes regardless would hide stale files
+		if opts.Check {
+			return fmt.Errorf("no AI agents detected, so generated files cannot be checked; install the agents the project uses")
+		}
 		fmt.Println("⚠️  No AI agents detected. Install Cursor, OpenCode, or Claude to use nexus.")
 		return nil
 	}
 
-	fmt.Printf("🤖 Detected agents: %v\n", agents)
+	fmt.Printf("🤖 Detected agents: %v\n", adapters.Names(detected))
 
-	merged, err := cfg.GetMergedTemplates(".")
+	merged, err := cfg.GetMergedTemplates(".", env)
 	if err != nil {
 		return fmt.Errorf("failed to merge templates: %w", err)
 	}
 
-	if err := cfg.GenerateAgentConfigs(".", merged); err != nil {
-		return fmt.Errorf("failed to generate agent configs: %w", err)
-	}
-
-	// Generate additional agent-specific configurations
-	for _, agent := range agents {
-		switch agent {
-		case "opencode":
-			_ = c.copyPluginCapabilitiesToOpenCode(cfg)
-		}
+	mcpServers, err := cfg.MCPServers(".", env)
+	if err != nil {
+		return fmt.Errorf("failed to merge plugin MCP servers: %w", err)
 	}
-	for _, agent := range agents {
-		switch agent {
-		case "cursor":
-			if err := c.generateCursorConfig(cfg); err != nil {
-				fmt.Printf("❌ Failed to update Cursor config: %v\n", err)
-			} else {
-				fmt.Println("✅ Updated Cursor configuration")
-			}
-		case "opencode":
-			if err := c.generateOpenCodeConfig(cfg); err != nil {
-				fmt.Printf("❌ Failed to update OpenCode config: %v\n", err)
-			} else {
-				fmt.Println("✅ Updated OpenCode agent config")
-			}
-		case "claude-desktop":
-			if err := c.generateClaudeDesktopConfig(cfg); err != nil {
-				fmt.Printf("❌ Failed to update Claude Desktop config: %v\n", err)
-			} else {
-				fmt.Println("✅ Refreshed Claude Desktop settings")
-			}
-		case "claude-code":
-			if err := c.generateClaudeCodeConfig(cfg); err != nil {
-				fmt.Printf("❌ Failed to update Claude Code config: %v\n", err)
-			} else {
-				fmt.Println("✅ Refreshed Claude Code settings")
-			}
+	if len(mcpServers) > 0 {
+		names := make([]string, 0, len(mcpServers))
+		for name := range mcpServers {
+			names = append(names, name)
 		}
+		sort.Strings(names)
+		fmt.Printf("🔌 MCP servers: %s\n", strings.Join(names, ", "))
 	}
 
-	fmt.Println("✅ All agent configurations synchronized")
-	return nil
-}
-
-func (c *BaseController) generateCursorConfig(cfg *config.Config) error {
-	projectRoot := paths.GetProjectRoot()
-	cursorDir := ".cursor"
-	if err := os.MkdirAll(cursorDir, 0755); err == nil {
-		_ = c.createCursorRules(cursorDir)
+	plan := generated.NewPlan(projectRoot)
+	// The plan covers every workspace, so outputs it no longer produces are stale
+	plan.RemoveStale()
+	if err := cfg.GenerateAgentConfigs(plan, ".", merged, env); err != nil {
+		return fmt.Errorf("failed to generate agent configs: %w", err)
 	}
-
 	worktreesDir := paths.GetWorktreesDir(projectRoot)
-	entries, err := os.ReadDir(worktreesDir)
-	if err != nil {
-		return nil
+	if entries, readErr := os.ReadDir(worktreesDir); readErr == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			worktreePath := filepath.Join(worktreesDir, entry.Name())
+			if err := cfg.GenerateAgentConfigs(plan, worktreePath, merged, env); err != nil {
+				// Its files weren't all planned, so none of them count as stale
+				plan.Keep(worktreePath)
+				fmt.Printf("⚠️  Warning: failed to update agent configs in %s: %v\n", worktreePath, err)
+			}
+		}
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
+	for _, adapter := range detected {
+		files, err := adapter.MCPFiles(mcpServers)
+		if err != nil {
+			fmt.Printf("❌ Failed to update %s MCP config: %v\n", adapter.Name(), err)
 			continue
 		}
-
-		worktreePath := filepath.Join(worktreesDir, entry.Name())
-		cursorDir := filepath.Join(worktreePath, ".cursor")
-		if err := os.MkdirAll(cursorDir, 0755); err != nil {
-			continue
+		for _, file := range files {
+			writeToProjectAndWorktrees(plan, file.Path, file.Data)
 		}
-
-		_ = c.createCursorRules(cursorDir)
 	}
 
-	return nil
-}
-
-func (c *BaseController) createCursorRules(cursorDir string) error {
-	rulesDir := filepath.Join(cursorDir, "rules", "nexus")
-	if err := os.MkdirAll(rulesDir, 0755); err != nil {
+	manifest, err := generated.LoadManifest(generated.ManifestPath(projectRoot))
+	if err != nil {
 		return err
 	}
 
-	rules := map[string]string{
-		"code-quality.md": "# Code Quality Standards\n\nThis rule defines coding standards from the nexus/standard plugin.",
-		"security.md":     "# Security Guidelines\n\nThis rule defines security guidelines from the nexus/standard plugin.",
+	if opts.DryRun || opts.Check {
+		return reportChanges(plan, manifest, opts)
 	}
 
-	for filename, content := range rules {
-		rulePath := filepath.Join(rulesDir, filename)
-		if err := os.WriteFile(rulePath, []byte(content), 0644); err != nil {
-			fmt.Printf("⚠️  Warning: failed to write rule file %s: %v\n", rulePath, err)
-		}
+	changes, err := plan.Apply(manifest, opts.Force)
+	if err != nil {
+		return err
 	}
+	printChanges(changes)
 
+	fmt.Println("✅ All agent configurations synchronized")
 	return nil
 }
 
-func (c *BaseController) generateOpenCodeConfig(cfg *config.Config) error {
-	opencodeConfig := map[string]interface{}{
-		"$schema": "https://opencode.ai/config.json",
-		"instructions": []string{
-			"AGENTS.md",
-			".opencode/rules/**/*.md",
-			".opencode/skills/**/*.md",
-			".opencode/commands/**/*.md",
-		},
-	}
+// Clean removes every file nexus generated for agents and the .gitignore
+// lines it added. Files edited by hand are kept.
+func (c *BaseController) Clean(ctx context.Context) error {
+	fmt.Println("🧹 Removing generated agent files...")
 
-	data, err := json.MarshalIndent(opencodeConfig, "", "  ")
+	projectRoot := paths.GetProjectRoot()
+	manifest, err := generated.LoadManifest(generated.ManifestPath(projectRoot))
 	if err != nil {
 		return err
 	}
-	_ = c.copyPluginCapabilitiesToOpenCode(cfg)
-	if err := os.WriteFile("opencode.json", data, 0644); err != nil {
-		fmt.Printf("⚠️  Warning: failed to write opencode.json: %v\n", err)
-	}
 
-	projectRoot := paths.GetProjectRoot()
-	worktreesDir := paths.GetWorktreesDir(projectRoot)
-	entries, err := os.ReadDir(worktreesDir)
+	plan := generated.NewPlan(projectRoot)
+	plan.RemoveStale()
+	changes, err := plan.Apply(manifest, false)
 	if err != nil {
-		return nil
+		return err
 	}
-
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-
-		worktreePath := filepath.Join(worktreesDir, entry.Name())
-		_ = c.copyPluginCapabilitiesToOpenCodeWorktree(cfg, worktreePath)
-		configPath := filepath.Join(worktreePath, "opencode.json")
-		if err := os.WriteFile(configPath, data, 0644); err != nil {
-			fmt.Printf("⚠️  Warning: failed to write worktree opencode.json: %v\n", err)
-		}
+	if len(changes) == 0 {
+		fmt.Println("✅ No generated files to remove")
+		return nil
 	}
 
+	printChanges(changes)
+	fmt.Println("✅ Generated agent files removed")
 	return nil
 }
 
-func (c *BaseController) copyPluginCapabilitiesToOpenCode(cfg *config.Config) error {
-	// Copy capabilities from project templates to .opencode directory
-	projectRoot := paths.GetProjectRoot()
-	dirMappings := map[string]string{
-		filepath.Join(".opencode", "rules", "nexus"):    filepath.Join(paths.GetConfigDir(projectRoot), "templates/rules"),
-		filepath.Join(".opencode", "skills", "nexus"):   filepath.Join(paths.GetConfigDir(projectRoot), "templates/skills"),
-		filepath.Join(".opencode", "commands", "nexus"): filepath.Join(paths.GetConfigDir(projectRoot), "templates/commands"),
+// reportChanges prints the diff of a plan for --dry-run and fails for
+// --check when anything is out of date
+func reportChanges(plan *generated.Plan, manifest *generated.Manifest, opts ApplyOptions) error {
+	changes, err := plan.Changes(manifest)
+	if err != nil {
+		return err
 	}
-
-	// Create render data for template variables
-	renderData := config.RenderData{
-		ProjectName: cfg.Name,
+	if len(changes) == 0 {
+		fmt.Println("✅ Generated files are up to date")
+		return nil
 	}
 
-	for localDir, templateDir := range dirMappings {
-		if err := os.MkdirAll(localDir, 0755); err != nil {
-			continue
-		}
-
-		if _, err := os.Stat(templateDir); os.IsNotExist(err) {
-			continue
+	for _, change := range changes {
+		if opts.DryRun {
+			fmt.Print(change.Diff())
 		}
-
-		entries, err := os.ReadDir(templateDir)
-		if err != nil {
-			continue
+		switch {
+		case change.Removed && change.Conflict:
+			fmt.Printf("⚠️  %s is stale but was edited by hand; apply will keep it\n", change.Path)
+		case change.Conflict && !opts.Force:
+			fmt.Printf("⚠️  %s was not generated by nexus or was edited by hand; apply needs --force to overwrite it\n", change.Path)
 		}
+	}
 
-		for _, entry := range entries {
-			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
-				continue
-			}
-			src := filepath.Join(templateDir, entry.Name())
-			dst := filepath.Join(localDir, entry.Name())
-			if data, err := os.ReadFile(src); err == nil {
-				manager := templates.NewManager(".")
-				rendered, err := manager.RenderTemplate(string(data), renderData)
-				if err == nil {
-					if err := os.WriteFile(dst, []byte(rendered), 0644); err != nil {
-						fmt.Printf("⚠️  Warning: failed to write rendered template %s: %v\n", dst, err)
-					}
-				}
-			}
+	if opts.Check {
+		stale := make([]string, 0, len(changes))
+		for _, change := range changes {
+			stale = append(stale, change.Path)
 		}
+		return fmt.Errorf("%d generated files are out of date; run nexus apply:\n  - %s", len(changes), strings.Join(stale, "\n  - "))
 	}
 
+	fmt.Printf("📝 %d files would change\n", len(changes))
 	return nil
 }
 
+func printChanges(changes []generated.Change) {
+	for _, change := range changes {
+		switch {
+		case change.Removed && change.Conflict:
+			fmt.Printf("⚠️  Kept %s: edited by hand, no longer managed by nexus\n", change.Path)
+		case change.Removed:
+			fmt.Printf("  removed %s\n", change.Path)
+		case !change.Exists:
+			fmt.Printf("  created %s\n", change.Path)
+		default:
+			fmt.Printf("  updated %s\n", change.Path)
+		}
+	}
+}
+
 func (c *BaseController) downloadPluginCapabilities(plugin config.TemplateRepo, baseDir string) error {
 	projectRoot := paths.GetProjectRoot()
 	pluginName := "nexus"
@@ -986,277 +1018,410 @@ type GitHubFile struct {
 	Content string
 }
 
-func (c *BaseController) copyPluginCapabilitiesToOpenCodeWorktree(cfg *config.Config, worktreePath string) error {
-	baseDirs := []string{"rules", "skills", "commands"}
+// getGitSHA gets the current commit SHA of a git repository
+func (c *BaseController) getGitSHA(repoDir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = repoDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
 
-	// Create render data for template variables
-	renderData := config.RenderData{
-		ProjectName: cfg.Name,
+func (c *BaseController) PluginUpdate(ctx context.Context) error {
+	fmt.Println("🔄 Updating plugins to latest versions...")
+
+	projectRoot := paths.GetProjectRoot()
+	configDir := paths.GetConfigDir(projectRoot)
+
+	registry := plugins.NewRegistry()
+	if err := registry.DiscoverPlugins(configDir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("⚠️  Warning: failed to discover local plugins: %v\n", err)
 	}
 
-	for _, baseDir := range baseDirs {
-		worktreePluginDir := filepath.Join(worktreePath, ".opencode", baseDir, "nexus")
-		if err := os.MkdirAll(worktreePluginDir, 0755); err != nil {
-			continue
-		}
+	remoteDirs, err := discoverRemotes(filepath.Join(configDir, "remotes"), registry)
+	if err != nil {
+		return err
+	}
 
-		projectPluginDir := filepath.Join(".opencode", baseDir, "nexus")
-		entries, err := os.ReadDir(projectPluginDir)
-		if err != nil {
-			continue
-		}
+	requirements := make(map[string]string)
+	for name := range remoteDirs {
+		requirements[name] = "*"
+	}
 
-		for _, entry := range entries {
-			if entry.IsDir() {
+	// Constraints from config.yaml, e.g. plugins: [{name: golang-base, version: "^1.2"}].
+	// Entries with a repository, such as those added from a plugin index,
+	// are resolved against that repository.
+	if cfg, err := config.LoadConfig(filepath.Join(configDir, "config.yaml")); err == nil {
+		refs := cfg.PluginRefs()
+		for _, ref := range refs {
+			if _, ok := registry.GetPlugin(ref.Name); ok || ref.Repository == "" {
 				continue
 			}
-			src := filepath.Join(projectPluginDir, entry.Name())
-			dst := filepath.Join(worktreePluginDir, entry.Name())
-			if data, err := os.ReadFile(src); err == nil {
-				manager := templates.NewManager(".")
-				rendered, err := manager.RenderTemplate(string(data), renderData)
-				if err == nil {
-					if err := os.WriteFile(dst, []byte(rendered), 0644); err != nil {
-						fmt.Printf("⚠️  Warning: failed to write worktree capability %s: %v\n", dst, err)
-					}
-				}
+			registry.AddPlugin(ref.Name, &plugins.Plugin{Name: ref.Name, Repository: ref.Repository, Path: ref.Path})
+		}
+		for name, constraint := range pluginRequirements(refs) {
+			if _, ok := registry.GetPlugin(name); ok {
+				requirements[name] = constraint
 			}
 		}
 	}
 
-	return nil
-}
-
-func (c *BaseController) generateClaudeDesktopConfig(cfg *config.Config) error {
-	claudeConfig := map[string]interface{}{
-		// MCP removed - Claude Desktop config without MCP server
+	if len(requirements) == 0 {
+		// A lockfile left behind by removed plugins is emptied rather than kept stale
+		if _, err := c.LockManager.LoadLockfile(); err != nil {
+			fmt.Println("✅ No plugins to update")
+			return nil
+		}
 	}
 
-	data, err := json.MarshalIndent(claudeConfig, "", "  ")
+	lockfile, err := c.LockManager.ResolveLockfile(registry, requirements, plugins.GitTagLister{})
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile("claude_desktop_config.json", data, 0644); err != nil {
-		fmt.Printf("⚠️  Warning: failed to write claude_desktop_config.json: %v\n", err)
+	// Repositories without version tags are pinned to the commit checked out
+	// in remotes/, or to the remote HEAD when there is no local checkout
+	for name, entry := range lockfile.Plugins {
+		if entry.Repository == "" || entry.Tag != "" {
+			continue
+		}
+		var sha string
+		if repoDir, ok := remoteDirs[name]; ok {
+			sha, err = c.getGitSHA(repoDir)
+		} else {
+			sha, err = plugins.GitTagLister{}.Head(entry.Repository)
+		}
+		if err != nil {
+			fmt.Printf("⚠️  Warning: failed to pin plugin %s: %v\n", name, err)
+			continue
+		}
+		entry.Version = "latest"
+		entry.SHA = sha
 	}
 
-	projectRoot := paths.GetProjectRoot()
-	worktreesDir := paths.GetWorktreesDir(projectRoot)
-	entries, err := os.ReadDir(worktreesDir)
+	contentHash, err := c.LockManager.ContentHash(lockfile)
 	if err != nil {
-		return nil
+		return fmt.Errorf("failed to generate content hash: %w", err)
 	}
+	lockfile.Metadata.ContentHash = contentHash
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
+	if err := c.LockManager.SaveLockfile(lockfile); err != nil {
+		return fmt.Errorf("failed to save lockfile: %w", err)
+	}
 
-		worktreePath := filepath.Join(worktreesDir, entry.Name())
-		configPath := filepath.Join(worktreePath, "claude_desktop_config.json")
-		if err := os.WriteFile(configPath, data, 0644); err != nil {
-			fmt.Printf("⚠️  Warning: failed to write worktree claude_desktop_config.json: %v\n", err)
+	names := make([]string, 0, len(lockfile.Plugins))
+	for name := range lockfile.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entry := lockfile.Plugins[name]
+		ref := entry.Tag
+		if ref == "" {
+			ref = entry.Version
 		}
+		fmt.Printf("  %s %s (%s)\n", name, ref, shortSHA(entry.SHA))
 	}
 
+	fmt.Println("✅ Updated nexus.lock")
+	fmt.Println("✅ All plugins updated successfully")
 	return nil
 }
 
-func (c *BaseController) generateClaudeCodeConfig(cfg *config.Config) error {
-	claudeConfig := map[string]interface{}{
-		// MCP removed - Claude Code config without MCP server
+// PluginInstall fetches the plugins pinned in nexus.lock into the plugins
+// cache. In frozen mode a missing or outdated lockfile is an error instead of
+// being refreshed, so every checkout installs exactly the same plugins.
+func (c *BaseController) PluginInstall(ctx context.Context, frozen bool) error {
+	fmt.Println("📦 Installing plugins from nexus.lock...")
+
+	projectRoot := paths.GetProjectRoot()
+	configDir := paths.GetConfigDir(projectRoot)
+
+	lockfile, err := c.LockManager.LoadLockfile()
+	if err != nil {
+		if frozen {
+			return fmt.Errorf("--frozen requires a lockfile: %w", err)
+		}
+		if err := c.PluginUpdate(ctx); err != nil {
+			return err
+		}
+		if lockfile, err = c.LockManager.LoadLockfile(); err != nil {
+			return err
+		}
+	}
+
+	registry := plugins.NewRegistry()
+	if err := registry.DiscoverPlugins(configDir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to discover local plugins: %w", err)
 	}
 
-	data, err := json.MarshalIndent(claudeConfig, "", "  ")
+	// Remotes fetched by extends are locked by PluginUpdate alongside the
+	// configured plugins, so they are required here too
+	remoteDirs, err := discoverRemotes(filepath.Join(configDir, "remotes"), plugins.NewRegistry())
 	if err != nil {
 		return err
 	}
+	requirements := make(map[string]string)
+	for name := range remoteDirs {
+		requirements[name] = "*"
+	}
 
-	if err := os.WriteFile("claude_code_config.json", data, 0644); err != nil {
-		fmt.Printf("⚠️  Warning: failed to write claude_code_config.json: %v\n", err)
+	// Plain names in config.yaml may refer to templates rather than plugins;
+	// only entries that are locked, local or versioned are checked
+	if cfg, err := config.LoadConfig(filepath.Join(configDir, "config.yaml")); err == nil {
+		for name, constraint := range pluginRequirements(cfg.PluginRefs()) {
+			_, local := registry.GetPlugin(name)
+			_, locked := lockfile.Plugins[name]
+			if local || locked || constraint != "*" {
+				requirements[name] = constraint
+			}
+		}
 	}
 
-	projectRoot := paths.GetProjectRoot()
-	worktreesDir := paths.GetWorktreesDir(projectRoot)
-	entries, err := os.ReadDir(worktreesDir)
+	if err := c.LockManager.CheckFrozen(lockfile, registry, requirements); err != nil {
+		if frozen {
+			return err
+		}
+		fmt.Printf("⚠️  %v\n", err)
+		if err := c.PluginUpdate(ctx); err != nil {
+			return err
+		}
+		if lockfile, err = c.LockManager.LoadLockfile(); err != nil {
+			return err
+		}
+	}
+
+	installed, err := c.LockManager.Install(lockfile, paths.GetPluginsCacheDir(projectRoot), lock.GitFetcher{})
 	if err != nil {
-		return nil
+		return err
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+	for _, p := range installed {
+		ref := p.Entry.Tag
+		if ref == "" {
+			ref = p.Entry.Version
 		}
-
-		worktreePath := filepath.Join(worktreesDir, entry.Name())
-		configPath := filepath.Join(worktreePath, "claude_code_config.json")
-		if err := os.WriteFile(configPath, data, 0644); err != nil {
-			fmt.Printf("⚠️  Warning: failed to write worktree claude_code_config.json: %v\n", err)
+		source := "local"
+		switch {
+		case p.Dir != "" && p.Cached:
+			source = "cached"
+		case p.Dir != "":
+			source = "fetched"
 		}
+		fmt.Printf("  %s %s (%s, %s)\n", p.Name, ref, shortSHA(p.Entry.SHA), source)
 	}
 
+	fmt.Printf("✅ Installed %d plugins\n", len(installed))
 	return nil
 }
 
-// isPluginEnabled checks if a plugin should be enabled based on project files
-func (c *BaseController) isPluginEnabled(baseDir, name string) bool {
-	switch name {
-	case "golang":
-		return fileExists(filepath.Join(baseDir, "go.mod")) || fileExists(filepath.Join(baseDir, "go.sum"))
-	case "node":
-		return fileExists(filepath.Join(baseDir, "package.json"))
-	default:
-		return true
+// discoverRemotes adds the git checkouts in remotesDir to registry as
+// repository plugins and returns their directories by name
+func discoverRemotes(remotesDir string, registry *plugins.Registry) (map[string]string, error) {
+	entries, err := os.ReadDir(remotesDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read remotes dir: %w", err)
 	}
-}
 
-// fileExists checks if a file exists
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
-}
+	remoteDirs := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		repoName := entry.Name()
+		repoDir := filepath.Join(remotesDir, repoName)
 
-// getGitSHA gets the current commit SHA of a git repository
-func (c *BaseController) getGitSHA(repoDir string) (string, error) {
-	cmd := exec.Command("git", "rev-parse", "HEAD")
-	cmd.Dir = repoDir
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
+		cmd := exec.Command("git", "config", "--get", "remote.origin.url")
+		cmd.Dir = repoDir
+		urlBytes, err := cmd.Output()
+		if err != nil {
+			continue
+		}
+
+		plugin := &plugins.Plugin{Name: repoName, Metadata: make(map[string]string)}
+		if manifest, err := plugins.LoadManifest(filepath.Join(repoDir, "plugin.yaml")); err == nil {
+			plugin = &manifest.Plugin
+		}
+		plugin.Repository = strings.TrimSpace(string(urlBytes))
+
+		registry.AddPlugin(repoName, plugin)
+		remoteDirs[repoName] = repoDir
 	}
-	return strings.TrimSpace(string(output)), nil
+	return remoteDirs, nil
 }
 
-// generateContentHash generates a hash of the lockfile content for integrity verification
-func (c *BaseController) generateContentHash(lockfile *lock.Lockfile) (string, error) {
-	// Create canonical representation of plugins
-	var pluginKeys []string
-	for k := range lockfile.Plugins {
-		pluginKeys = append(pluginKeys, k)
+// pluginRequirements maps the plugins listed in config.yaml to their version
+// constraints; entries without a version accept any version
+func pluginRequirements(refs []config.PluginRef) map[string]string {
+	requirements := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		version := ref.Version
+		if version == "" {
+			version = "*"
+		}
+		requirements[ref.Name] = version
 	}
-	sort.Strings(pluginKeys)
-
-	var canonical strings.Builder
-	canonical.WriteString(fmt.Sprintf("version:%s\n", lockfile.Version))
+	return requirements
+}
 
-	for _, key := range pluginKeys {
-		entry := lockfile.Plugins[key]
-		canonical.WriteString(fmt.Sprintf("plugin:%s|%s|%s\n",
-			key, entry.Version, entry.SHA))
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
 	}
-
-	hash := sha256.Sum256([]byte(canonical.String()))
-	return hex.EncodeToString(hash[:]), nil
+	return sha
 }
 
-func (c *BaseController) PluginUpdate(ctx context.Context) error {
-	fmt.Println("🔄 Updating plugins to latest versions...")
+func (c *BaseController) PluginList(ctx context.Context) error {
+	fmt.Println("📦 Loaded remote templates:")
 
 	projectRoot := paths.GetProjectRoot()
-	lockfile := &lock.Lockfile{
-		Version: "1.0",
-		Plugins: make(map[string]*lock.LockEntry),
-		Metadata: lock.LockMetadata{
-			Generator: "nexus",
-			Extra:     make(map[string]string),
-		},
+	cfg, err := config.LoadConfig(filepath.Join(paths.GetConfigDir(projectRoot), "config.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	remotesDir := filepath.Join(paths.GetConfigDir(projectRoot), "remotes")
-	entries, err := os.ReadDir(remotesDir)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to read remotes dir: %w", err)
+	refs := cfg.PluginRefs()
+	if len(refs) == 0 {
+		fmt.Println("  No plugins loaded")
+		return nil
 	}
 
-	if entries != nil {
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				continue
-			}
-			repoName := entry.Name()
-			repoDir := filepath.Join(remotesDir, repoName)
-
-			cmd := exec.Command("git", "config", "--get", "remote.origin.url")
-			cmd.Dir = repoDir
-			urlBytes, err := cmd.Output()
-			if err != nil {
-				continue
-			}
-			url := strings.TrimSpace(string(urlBytes))
-
-			sha, err := c.getGitSHA(repoDir)
-			if err != nil {
-				continue
-			}
-
-			lockfile.Plugins[repoName] = &lock.LockEntry{
-				Name:       repoName,
-				Version:    "latest",
-				SHA:        sha,
-				Repository: url,
-				Path:       "",
-				Metadata:   make(map[string]string),
+	lockfile, _ := c.LockManager.LoadLockfile()
+	for _, ref := range refs {
+		switch {
+		case lockfile != nil && lockfile.Plugins[ref.Name] != nil:
+			entry := lockfile.Plugins[ref.Name]
+			version := entry.Tag
+			if version == "" {
+				version = entry.Version
 			}
+			fmt.Printf("  %s %s (%s)\n", ref.Name, version, shortSHA(entry.SHA))
+		case ref.Repository != "":
+			fmt.Printf("  %s (%s, not locked)\n", ref.Name, ref.Repository)
+		default:
+			fmt.Printf("  %s (named plugin)\n", ref.Name)
 		}
 	}
 
-	if len(lockfile.Plugins) > 0 {
-		contentHash, err := c.generateContentHash(lockfile)
-		if err != nil {
-			return fmt.Errorf("failed to generate content hash: %w", err)
-		}
-		lockfile.Metadata.ContentHash = contentHash
+	return nil
+}
 
-		if err := c.LockManager.SaveLockfile(lockfile); err != nil {
-			return fmt.Errorf("failed to save lockfile: %w", err)
+// loadPluginIndex loads the plugin index at source, falling back to the
+// plugin_index setting in config.yaml
+func (c *BaseController) loadPluginIndex(source string) (*plugins.Index, error) {
+	if source == "" {
+		projectRoot := paths.GetProjectRoot()
+		if cfg, err := config.LoadConfig(filepath.Join(paths.GetConfigDir(projectRoot), "config.yaml")); err == nil {
+			source = cfg.PluginIndex
 		}
-
-		fmt.Println("✅ Updated nexus.lock")
 	}
+	if source == "" {
+		return nil, fmt.Errorf("no plugin index configured; set plugin_index in .nexus/config.yaml or pass --index")
+	}
+	return plugins.LoadIndex(source)
+}
 
-	fmt.Println("✅ All plugins updated successfully")
-
-	// TODO: Implement lockfile generation
+// PluginSearch lists the plugins in the index matching term
+func (c *BaseController) PluginSearch(_ context.Context, index, term string) error {
+	idx, err := c.loadPluginIndex(index)
+	if err != nil {
+		return err
+	}
 
-	fmt.Println("✅ Updated nexus.lock")
-	fmt.Println("✅ All plugins updated successfully")
+	matches := idx.Search(term)
+	if len(matches) == 0 {
+		fmt.Printf("No plugins match %q\n", term)
+		return nil
+	}
 
+	fmt.Printf("%-30s %-10s %s\n", "NAME", "LATEST", "DESCRIPTION")
+	for _, entry := range matches {
+		latest := entry.Latest()
+		if latest == "" {
+			latest = "-"
+		}
+		fmt.Printf("%-30s %-10s %s\n", entry.Name, latest, entry.Description)
+	}
 	return nil
 }
 
-func (c *BaseController) PluginList(ctx context.Context) error {
-	fmt.Println("📦 Loaded remote templates:")
-
-	projectRoot := paths.GetProjectRoot()
-	cfg, err := config.LoadConfig(filepath.Join(paths.GetConfigDir(projectRoot), "config.yaml"))
+// PluginAdd adds a plugin from the index to config.yaml and updates nexus.lock.
+// ref is "name" or "name@constraint". config.yaml is restored when the lock
+// cannot be updated.
+func (c *BaseController) PluginAdd(ctx context.Context, index, ref string) error {
+	name, constraint, err := plugins.ParsePluginRef(ref)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
 
-	if len(cfg.Plugins) == 0 {
-		fmt.Println("  No plugins loaded")
-		return nil
+	idx, err := c.loadPluginIndex(index)
+	if err != nil {
+		return err
+	}
+	entry, ok := idx.Lookup(name)
+	if !ok {
+		return fmt.Errorf("plugin %s not found in the plugin index", name)
 	}
 
-	for _, plugin := range cfg.Plugins {
-		repo, ok := plugin.(config.TemplateRepo)
-		if !ok {
-			if name, ok := plugin.(string); ok {
-				fmt.Printf("  %s (named plugin)\n", name)
+	if constraint != "" && len(entry.Versions) > 0 {
+		parsed, err := plugins.ParseConstraint(constraint)
+		if err != nil {
+			return err
+		}
+		satisfied := false
+		for _, v := range entry.Versions {
+			if version, err := plugins.ParseVersion(v); err == nil && parsed.Check(version) {
+				satisfied = true
+				break
 			}
-			continue
 		}
-		fmt.Printf("  %s", repo.URL)
-		if repo.Branch != "" {
-			fmt.Printf(" (%s)", repo.Branch)
+		if !satisfied {
+			return fmt.Errorf("no published version of %s satisfies %s (available: %s)", name, constraint, strings.Join(entry.Versions, ", "))
 		}
-		fmt.Println()
 	}
 
+	configPath := filepath.Join(paths.GetConfigDir(paths.GetProjectRoot()), "config.yaml")
+	original, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := config.AddPlugin(configPath, config.PluginRef{
+		Name:       entry.Name,
+		Version:    constraint,
+		Repository: entry.Repository,
+		Path:       entry.Path,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("➕ Added %s to config.yaml\n", ref)
+
+	if err := c.PluginUpdate(ctx); err != nil {
+		if restoreErr := os.WriteFile(configPath, original, 0644); restoreErr != nil {
+			return fmt.Errorf("%w (restoring config.yaml also failed: %v)", err, restoreErr)
+		}
+		return fmt.Errorf("failed to add %s, config.yaml restored: %w", name, err)
+	}
 	return nil
 }
 
+// PluginRemove removes a plugin from config.yaml and updates nexus.lock
+func (c *BaseController) PluginRemove(ctx context.Context, name string) error {
+	configPath := filepath.Join(paths.GetConfigDir(paths.GetProjectRoot()), "config.yaml")
+	removed, err := config.RemovePlugin(configPath, name)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("plugin %s is not in config.yaml", name)
+	}
+	fmt.Printf("➖ Removed %s from config.yaml\n", name)
+
+	return c.PluginUpdate(ctx)
+}
+
 func (c *BaseController) Kill(ctx context.Context, sessionID string) error {
 	for _, p := range c.Providers {
 		sessions, _ := p.List(ctx)
@@ -8,9 +8,12 @@ import (
 	"testing"
 
 	"github.com/nexus/nexus/pkg/config"
+	"github.com/nexus/nexus/pkg/lock"
 	"github.com/nexus/nexus/pkg/provider"
+	"github.com/nexus/nexus/pkg/worktree"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockProvider struct {
@@ -69,6 +72,21 @@ func (m *MockWorktreeManager) Remove(branch string) error {
 	return args.Error(0)
 }
 
+func (m *MockWorktreeManager) AddFrom(branch, baseRef string) (string, error) {
+	args := m.Called(branch, baseRef)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockWorktreeManager) List() ([]worktree.Worktree, error) {
+	args := m.Called()
+	return args.Get(0).([]worktree.Worktree), args.Error(1)
+}
+
+func (m *MockWorktreeManager) Prune() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
 func TestBaseController_Init(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "ctrl-test-")
 	if err != nil {
@@ -109,6 +127,7 @@ func TestBaseController_WorkspaceRm(t *testing.T) {
 
 	os.MkdirAll(".nexus/worktrees/test-workspace", 0755)
 	os.WriteFile(".nexus/config.yaml", []byte("name: test-project"), 0644)
+	mockWT.On("List").Return([]worktree.Worktree{{Path: ".nexus/worktrees/test-workspace", Branch: "test-workspace"}}, nil)
 
 	err = ctrl.WorkspaceList(context.Background())
 
@@ -184,6 +203,67 @@ func TestBaseController_WorkspaceCreate(t *testing.T) {
 	mockWT.AssertExpectations(t)
 }
 
+func TestBaseController_WorkspaceCreateFrom(t *testing.T) {
+	mockWT := new(MockWorktreeManager)
+
+	tempDir, err := os.MkdirTemp("", "ctrl-create-from-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	oldCwd, _ := os.Getwd()
+	os.Chdir(tempDir)
+	defer os.Chdir(oldCwd)
+
+	ctrl := NewBaseController(nil, mockWT)
+	assert.NoError(t, ctrl.Init(context.Background()))
+
+	wtPath := filepath.Join(tempDir, "feature--login")
+	os.MkdirAll(wtPath, 0755)
+	mockWT.On("AddFrom", "feature/login", "v1.0.0").Return(wtPath, nil)
+
+	err = ctrl.WorkspaceCreateFrom(context.Background(), "feature/login", "v1.0.0")
+	assert.NoError(t, err)
+	mockWT.AssertExpectations(t)
+
+	err = ctrl.WorkspaceCreateFrom(context.Background(), "bad..name", "")
+	assert.Error(t, err)
+}
+
+func TestBaseController_WorkspaceListUsesWorktreeMetadata(t *testing.T) {
+	mockWT := new(MockWorktreeManager)
+	mockWT.On("List").Return([]worktree.Worktree{
+		{Path: "/tmp/worktrees/feature--login", Branch: "feature/login", Head: "0123456789abcdef", Dirty: true},
+		{Path: "/tmp/worktrees/gone", Branch: "gone", Prunable: true},
+	}, nil)
+
+	mockP := new(MockProvider)
+	mockP.On("Name").Return("docker")
+	mockP.On("List", mock.Anything).Return([]provider.Session{
+		{ID: "test-project-feature--login", Labels: map[string]string{"nexus.session.id": "test-project-feature--login"}},
+	}, nil)
+
+	tempDir, err := os.MkdirTemp("", "ctrl-list-wt-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	oldCwd, _ := os.Getwd()
+	os.Chdir(tempDir)
+	defer os.Chdir(oldCwd)
+
+	os.MkdirAll(".nexus", 0755)
+	os.WriteFile(".nexus/config.yaml", []byte("name: test-project"), 0644)
+
+	ctrl := NewBaseController([]provider.Provider{mockP}, mockWT)
+	assert.NoError(t, ctrl.WorkspaceList(context.Background()))
+
+	mockWT.AssertExpectations(t)
+	mockP.AssertCalled(t, "List", mock.Anything)
+}
+
 func TestDetectPortFromCommand(t *testing.T) {
 	tests := []struct {
 		command  string
@@ -594,3 +674,118 @@ func TestBaseController_SimpleMethods(t *testing.T) {
 
 	mockP.AssertExpectations(t)
 }
+
+func TestBaseController_PluginAddAndRemove(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tempDir := t.TempDir()
+	pluginRepo := filepath.Join(tempDir, "golang-base")
+	os.MkdirAll(pluginRepo, 0755)
+
+	runGit := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = pluginRepo
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	runGit("init", "-b", "main")
+	runGit("config", "user.email", "test@example.com")
+	runGit("config", "user.name", "Test User")
+	os.WriteFile(filepath.Join(pluginRepo, "plugin.yaml"), []byte("plugin:\n  name: golang-base\n"), 0644)
+	runGit("add", "plugin.yaml")
+	runGit("commit", "-m", "Initial")
+	runGit("tag", "v1.2.0")
+
+	indexPath := filepath.Join(tempDir, "index.yaml")
+	os.WriteFile(indexPath, []byte("plugins:\n  - name: golang-base\n    repository: "+pluginRepo+"\n    versions: [\"1.2.0\"]\n"), 0644)
+
+	projectDir := filepath.Join(tempDir, "project")
+	os.MkdirAll(filepath.Join(projectDir, ".nexus"), 0755)
+	configPath := filepath.Join(projectDir, ".nexus", "config.yaml")
+	os.WriteFile(configPath, []byte("name: demo\n"), 0644)
+
+	oldCwd, _ := os.Getwd()
+	os.Chdir(projectDir)
+	defer os.Chdir(oldCwd)
+	t.Setenv("NEXUS_PROJECT_ROOT", projectDir)
+
+	controller := &BaseController{LockManager: lock.NewManager(projectDir)}
+	ctx := context.Background()
+
+	err := controller.PluginAdd(ctx, indexPath, "golang-base@^2")
+	assert.ErrorContains(t, err, "no published version")
+
+	require.NoError(t, controller.PluginAdd(ctx, indexPath, "golang-base@^1.2"))
+
+	cfg, err := config.LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []config.PluginRef{{Name: "golang-base", Version: "^1.2", Repository: pluginRepo}}, cfg.PluginRefs())
+
+	lockfile, err := controller.LockManager.LoadLockfile()
+	require.NoError(t, err)
+	require.Contains(t, lockfile.Plugins, "golang-base")
+	assert.Equal(t, "v1.2.0", lockfile.Plugins["golang-base"].Tag)
+
+	require.NoError(t, controller.PluginRemove(ctx, "golang-base"))
+	lockfile, err = controller.LockManager.LoadLockfile()
+	require.NoError(t, err)
+	assert.Empty(t, lockfile.Plugins)
+
+	assert.Error(t, controller.PluginRemove(ctx, "golang-base"))
+}
+
+func TestBaseController_PluginInstallFrozenWithRemotes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tempDir := t.TempDir()
+	runGit := func(dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	createRepo := func(name, manifest string) string {
+		dir := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		runGit(dir, "init", "-b", "main")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(manifest), 0644))
+		runGit(dir, "add", "plugin.yaml")
+		runGit(dir, "commit", "-m", "Initial")
+		return dir
+	}
+
+	baseRepo := createRepo("golang-base", "plugin:\n  name: golang-base\n")
+	runGit(baseRepo, "tag", "v1.2.0")
+	corpRepo := createRepo("corp", "plugin:\n  name: corp\n  dependencies: [golang-base]\n")
+
+	// corp is fetched by extends into remotes/ and depends on golang-base
+	projectDir := filepath.Join(tempDir, "project")
+	remotesDir := filepath.Join(projectDir, ".nexus", "remotes")
+	require.NoError(t, os.MkdirAll(remotesDir, 0755))
+	runGit(remotesDir, "clone", "--quiet", corpRepo, "corp")
+	configPath := filepath.Join(projectDir, ".nexus", "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("name: demo\nplugins:\n  - name: golang-base\n    repository: "+baseRepo+"\n"), 0644))
+
+	oldCwd, _ := os.Getwd()
+	os.Chdir(projectDir)
+	defer os.Chdir(oldCwd)
+	t.Setenv("NEXUS_PROJECT_ROOT", projectDir)
+
+	controller := &BaseController{LockManager: lock.NewManager(projectDir)}
+	ctx := context.Background()
+
+	require.NoError(t, controller.PluginUpdate(ctx))
+	lockfile, err := controller.LockManager.LoadLockfile()
+	require.NoError(t, err)
+	require.Contains(t, lockfile.Plugins, "corp")
+	assert.Equal(t, []string{"golang-base"}, lockfile.Plugins["corp"].Dependencies)
+
+	assert.NoError(t, controller.PluginInstall(ctx, true))
+}
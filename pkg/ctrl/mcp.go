@@ -0,0 +1,24 @@
+package ctrl
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/nexus/nexus/pkg/generated"
+	"github.com/nexus/nexus/pkg/paths"
+)
+
+// writeToProjectAndWorktrees plans writing data to relPath in the project
+// and in every worktree
+func writeToProjectAndWorktrees(plan *generated.Plan, relPath string, data []byte) {
+	plan.Write(relPath, data)
+
+	worktreesDir := paths.GetWorktreesDir(paths.GetProjectRoot())
+	if entries, err := os.ReadDir(worktreesDir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				plan.Write(filepath.Join(worktreesDir, entry.Name(), relPath), data)
+			}
+		}
+	}
+}
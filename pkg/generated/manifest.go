@@ -0,0 +1,89 @@
+// Package generated plans, diffs and writes the files nexus generates for
+// agents, and tracks which of them nexus owns.
+package generated
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nexus/nexus/pkg/paths"
+)
+
+// ManifestFile is the name of the ownership manifest in the state directory
+const ManifestFile = "generated.json"
+
+// Manifest records the files nexus generated and the hash of the content it
+// last wrote, so hand edits can be told apart from nexus's own output
+type Manifest struct {
+	// Files maps paths relative to the project root to sha256 hashes
+	Files map[string]string `json:"files"`
+	// Gitignore lists the .gitignore lines nexus added
+	Gitignore []string `json:"gitignore,omitempty"`
+
+	path string
+}
+
+// ManifestPath returns the location of the project's ownership manifest
+func ManifestPath(projectRoot string) string {
+	return filepath.Join(paths.GetStateDir(projectRoot), ManifestFile)
+}
+
+// LoadManifest reads the manifest at path. A missing manifest is empty.
+func LoadManifest(path string) (*Manifest, error) {
+	manifest := &Manifest{Files: make(map[string]string), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]string)
+	}
+	return manifest, nil
+}
+
+// Save writes the manifest back to where it was loaded from
+func (m *Manifest) Save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(m.path), err)
+	}
+	return os.WriteFile(m.path, append(data, '\n'), 0644)
+}
+
+// Owns reports whether nexus generated the file at rel and it still holds
+// exactly what nexus wrote
+func (m *Manifest) Owns(rel string, current []byte) bool {
+	hash, ok := m.Files[rel]
+	return ok && hash == Hash(current)
+}
+
+// Record marks rel as generated with the given content
+func (m *Manifest) Record(rel string, data []byte) {
+	m.Files[rel] = Hash(data)
+}
+
+// Forget removes rel from the manifest
+func (m *Manifest) Forget(rel string) {
+	delete(m.Files, rel)
+}
+
+// Hash returns the hex sha256 of data
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
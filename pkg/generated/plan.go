@@ -0,0 +1,391 @@
+package generated
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// GitignoreFile is the .gitignore nexus adds the patterns of generated files to
+const GitignoreFile = ".gitignore"
+
+// Plan collects the files a run of nexus would generate, so they can be
+// diffed against disk, checked or written in one go
+type Plan struct {
+	root      string
+	files     map[string][]byte
+	gitignore []string
+	// removeStale is set when the plan covers every generated file
+	removeStale bool
+	// kept are directories whose generated files are never stale
+	kept []string
+}
+
+// Change is a planned file whose content differs from disk
+type Change struct {
+	// Path is relative to the project root
+	Path string
+	Old  []byte
+	New  []byte
+	// Exists is false for files the plan creates
+	Exists bool
+	// Removed is set for generated files the plan no longer produces
+	Removed bool
+	// Shared files such as .gitignore are edited in place and never owned
+	Shared bool
+	// Conflict is set when the file exists but nexus did not generate it,
+	// or it was edited since nexus last wrote it. Conflicting removals are
+	// never applied; the file is kept and forgotten.
+	Conflict bool
+
+	abs string
+}
+
+// ConflictError lists files nexus refuses to overwrite
+type ConflictError struct {
+	Paths []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("refusing to overwrite files nexus did not generate or that were edited by hand:\n  - %s\nrerun with --force to overwrite them",
+		strings.Join(e.Paths, "\n  - "))
+}
+
+// NewPlan creates an empty plan for the project at root
+func NewPlan(root string) *Plan {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &Plan{root: root, files: make(map[string][]byte)}
+}
+
+// Write plans a file that nexus generates and owns. Later writes to the same
+// path replace earlier ones.
+func (p *Plan) Write(path string, data []byte) {
+	p.files[p.abs(path)] = data
+}
+
+// Gitignore plans adding patterns to the project's .gitignore
+func (p *Plan) Gitignore(patterns ...string) {
+	for _, pattern := range patterns {
+		if pattern != "" && !containsString(p.gitignore, pattern) {
+			p.gitignore = append(p.gitignore, pattern)
+		}
+	}
+}
+
+// RemoveStale marks the plan as covering every generated file. Files in the
+// manifest that the plan no longer produces are then removed, along with the
+// .gitignore lines nexus added that are no longer planned.
+func (p *Plan) RemoveStale() {
+	p.removeStale = true
+}
+
+// Keep leaves the generated files under dir out of stale removal, for parts
+// of the project whose files could not be planned this time
+func (p *Plan) Keep(dir string) {
+	p.kept = append(p.kept, p.abs(dir))
+}
+
+// ReadFile returns the planned content of path, or its content on disk when
+// nothing is planned for it
+func (p *Plan) ReadFile(path string) ([]byte, error) {
+	if data, ok := p.files[p.abs(path)]; ok {
+		return data, nil
+	}
+	return os.ReadFile(path)
+}
+
+// Paths returns the planned paths relative to the project root, sorted
+func (p *Plan) Paths() []string {
+	result := make([]string, 0, len(p.files))
+	for abs := range p.files {
+		result = append(result, p.rel(abs))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Changes compares the plan with disk and returns the files that would
+// change, sorted by path
+func (p *Plan) Changes(manifest *Manifest) ([]Change, error) {
+	var changes []Change
+
+	for abs, data := range p.files {
+		change := Change{Path: p.rel(abs), New: data, abs: abs}
+
+		current, err := os.ReadFile(abs)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("failed to read %s: %w", abs, err)
+		default:
+			if bytes.Equal(current, data) {
+				continue
+			}
+			change.Old = current
+			change.Exists = true
+			change.Conflict = !manifest.Owns(change.Path, current)
+		}
+		changes = append(changes, change)
+	}
+
+	if p.removeStale {
+		for _, rel := range p.stale(manifest) {
+			abs := filepath.Join(p.root, filepath.FromSlash(rel))
+			current, err := os.ReadFile(abs)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", abs, err)
+			}
+			changes = append(changes, Change{
+				Path:     rel,
+				Old:      current,
+				Exists:   true,
+				Removed:  true,
+				Conflict: !manifest.Owns(rel, current),
+				abs:      abs,
+			})
+		}
+	}
+
+	gitignore, _, err := p.resolveGitignore(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if gitignore != nil {
+		changes = append(changes, *gitignore)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// Apply writes the changed files, removes stale ones and records every
+// planned file in manifest. Unless force is set, nothing is written when a
+// change would overwrite a file nexus does not own.
+func (p *Plan) Apply(manifest *Manifest, force bool) ([]Change, error) {
+	changes, err := p.Changes(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		var conflicts []string
+		for _, change := range changes {
+			if change.Conflict && !change.Removed {
+				conflicts = append(conflicts, change.Path)
+			}
+		}
+		if len(conflicts) > 0 {
+			return nil, &ConflictError{Paths: conflicts}
+		}
+	}
+
+	// Resolved before .gitignore is rewritten, while the manifest still
+	// tells nexus's lines apart from the user's
+	_, owned, err := p.resolveGitignore(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		if change.Removed {
+			if change.Conflict {
+				continue
+			}
+			if err := os.Remove(change.abs); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("failed to remove %s: %w", change.Path, err)
+			}
+			p.removeEmptyDirs(filepath.Dir(change.abs))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(change.abs), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", change.Path, err)
+		}
+		if err := os.WriteFile(change.abs, change.New, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", change.Path, err)
+		}
+	}
+
+	if p.removeStale {
+		for _, rel := range p.stale(manifest) {
+			manifest.Forget(rel)
+		}
+	}
+	for abs, data := range p.files {
+		manifest.Record(p.rel(abs), data)
+	}
+	manifest.Gitignore = owned
+	if err := manifest.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save generated file manifest: %w", err)
+	}
+	return changes, nil
+}
+
+// stale returns the manifest paths the plan no longer produces, sorted
+func (p *Plan) stale(manifest *Manifest) []string {
+	var result []string
+	for rel := range manifest.Files {
+		abs := filepath.Join(p.root, filepath.FromSlash(rel))
+		if _, ok := p.files[abs]; !ok && !p.isKept(abs) {
+			result = append(result, rel)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (p *Plan) isKept(abs string) bool {
+	for _, dir := range p.kept {
+		if abs == dir || strings.HasPrefix(abs, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveGitignore returns the change to .gitignore, or nil when it stays
+// the same, and the lines nexus owns once it is applied. Lines nexus added
+// are dropped when no longer planned if the plan removes stale files; lines
+// the user wrote are never touched.
+func (p *Plan) resolveGitignore(manifest *Manifest) (*Change, []string, error) {
+	abs := filepath.Join(p.root, GitignoreFile)
+	current, err := os.ReadFile(abs)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", abs, err)
+	}
+
+	var lines []string
+	if len(current) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(current), "\n"), "\n")
+	}
+
+	var kept, owned []string
+	present := make(map[string]bool)
+	dropped := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if containsString(manifest.Gitignore, trimmed) {
+			if p.removeStale && !containsString(p.gitignore, trimmed) {
+				dropped = true
+				continue
+			}
+			if !present[trimmed] {
+				owned = append(owned, trimmed)
+			}
+		}
+		kept = append(kept, line)
+		present[trimmed] = true
+	}
+
+	appended := false
+	for _, pattern := range p.gitignore {
+		if !present[pattern] {
+			kept = append(kept, pattern)
+			owned = append(owned, pattern)
+			present[pattern] = true
+			appended = true
+		}
+	}
+	sort.Strings(owned)
+
+	if !dropped && !appended {
+		return nil, owned, nil
+	}
+
+	var updated []byte
+	if len(kept) > 0 {
+		updated = []byte(strings.Join(kept, "\n") + "\n")
+	}
+	return &Change{
+		Path:   GitignoreFile,
+		Old:    current,
+		New:    updated,
+		Exists: exists,
+		Shared: true,
+		abs:    abs,
+	}, owned, nil
+}
+
+// removeEmptyDirs removes dir and its parents while they are empty, stopping
+// at the project root
+func (p *Plan) removeEmptyDirs(dir string) {
+	for dir != p.root && strings.HasPrefix(dir, p.root+string(filepath.Separator)) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// Diff returns the change as a unified diff
+func (c Change) Diff() string {
+	from, to := "a/"+c.Path, "b/"+c.Path
+	if !c.Exists {
+		from = "/dev/null"
+	}
+	if c.Removed {
+		to = "/dev/null"
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(c.Old),
+		B:        splitLines(c.New),
+		FromFile: from,
+		ToFile:   to,
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Sprintf("--- %s\n+++ %s\n(diff unavailable: %v)\n", from, to, err)
+	}
+	return diff
+}
+
+// splitLines splits data into newline-terminated lines for difflib, which
+// expects every line to end in a newline
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) abs(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Join(p.root, path)
+}
+
+func (p *Plan) rel(abs string) string {
+	if rel, err := filepath.Rel(p.root, abs); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return abs
+}
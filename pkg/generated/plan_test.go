@@ -0,0 +1,201 @@
+package generated
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan_Changes(t *testing.T) {
+	root := t.TempDir()
+	manifest, err := LoadManifest(filepath.Join(root, "state", ManifestFile))
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "same.md"), []byte("same\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "owned.md"), []byte("old\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "hand.md"), []byte("mine\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("node_modules/\n"), 0644))
+	manifest.Record("owned.md", []byte("old\n"))
+
+	plan := NewPlan(root)
+	plan.Write(filepath.Join(root, "same.md"), []byte("same\n"))
+	plan.Write(filepath.Join(root, "owned.md"), []byte("new\n"))
+	plan.Write(filepath.Join(root, "hand.md"), []byte("generated\n"))
+	plan.Write(filepath.Join(root, "dir", "created.md"), []byte("created\n"))
+	plan.Gitignore(".cursor/")
+
+	changes, err := plan.Changes(manifest)
+	require.NoError(t, err)
+
+	byPath := make(map[string]Change)
+	for _, change := range changes {
+		byPath[change.Path] = change
+	}
+	assert.Len(t, changes, 4)
+	assert.NotContains(t, byPath, "same.md")
+	assert.False(t, byPath["owned.md"].Conflict)
+	assert.True(t, byPath["hand.md"].Conflict)
+	assert.False(t, byPath["dir/created.md"].Exists)
+	assert.True(t, byPath[".gitignore"].Shared)
+	assert.False(t, byPath[".gitignore"].Conflict)
+
+	assert.Equal(t, "--- a/owned.md\n+++ b/owned.md\n@@ -1 +1 @@\n-old\n+new\n", byPath["owned.md"].Diff())
+	assert.Contains(t, byPath["dir/created.md"].Diff(), "--- /dev/null\n+++ b/dir/created.md\n")
+}
+
+func TestPlan_Apply(t *testing.T) {
+	root := t.TempDir()
+	manifestPath := filepath.Join(root, "state", ManifestFile)
+	manifest, err := LoadManifest(manifestPath)
+	require.NoError(t, err)
+
+	handPath := filepath.Join(root, "AGENTS.md")
+	require.NoError(t, os.WriteFile(handPath, []byte("hand written\n"), 0644))
+
+	plan := NewPlan(root)
+	plan.Write(handPath, []byte("generated\n"))
+	plan.Write(filepath.Join(root, ".cursor", "mcp.json"), []byte("{}\n"))
+
+	_, err = plan.Apply(manifest, false)
+	var conflict *ConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, []string{"AGENTS.md"}, conflict.Paths)
+	assert.NoFileExists(t, filepath.Join(root, ".cursor", "mcp.json"), "nothing is written on conflict")
+
+	changes, err := plan.Apply(manifest, true)
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	content, err := os.ReadFile(handPath)
+	require.NoError(t, err)
+	assert.Equal(t, "generated\n", string(content))
+
+	reloaded, err := LoadManifest(manifestPath)
+	require.NoError(t, err)
+	assert.True(t, reloaded.Owns("AGENTS.md", []byte("generated\n")))
+	assert.True(t, reloaded.Owns(".cursor/mcp.json", []byte("{}\n")))
+
+	// A regenerated file nexus owns is overwritten without --force
+	plan = NewPlan(root)
+	plan.Write(handPath, []byte("regenerated\n"))
+	_, err = plan.Apply(reloaded, false)
+	require.NoError(t, err)
+
+	// Once edited by hand it is protected again
+	require.NoError(t, os.WriteFile(handPath, []byte("edited\n"), 0644))
+	plan = NewPlan(root)
+	plan.Write(handPath, []byte("generated again\n"))
+	_, err = plan.Apply(reloaded, false)
+	assert.True(t, errors.As(err, &conflict))
+}
+
+func TestPlan_ReadFile(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "AGENTS.md")
+	require.NoError(t, os.WriteFile(path, []byte("on disk\n"), 0644))
+
+	plan := NewPlan(root)
+	content, err := plan.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "on disk\n", string(content))
+
+	plan.Write(path, []byte("planned\n"))
+	content, err = plan.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "planned\n", string(content))
+	assert.Equal(t, []string{"AGENTS.md"}, plan.Paths())
+}
+
+func TestPlan_Gitignore(t *testing.T) {
+	root := t.TempDir()
+	gitignore := filepath.Join(root, GitignoreFile)
+	manifest, err := LoadManifest(filepath.Join(root, "state", ManifestFile))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(gitignore, []byte("node_modules/\n\n# local\n.vscode/"), 0644))
+
+	plan := NewPlan(root)
+	plan.Gitignore(".cursor/", ".vscode/", "AGENTS.md")
+	_, err = plan.Apply(manifest, false)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(gitignore)
+	require.NoError(t, err)
+	assert.Equal(t, "node_modules/\n\n# local\n.vscode/\n.cursor/\nAGENTS.md\n", string(content))
+	assert.Equal(t, []string{".cursor/", "AGENTS.md"}, manifest.Gitignore, ".vscode/ was already there, so the user owns it")
+
+	// Unchanged patterns leave the file alone
+	plan = NewPlan(root)
+	plan.Gitignore(".cursor/", "AGENTS.md")
+	changes, err := plan.Changes(manifest)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// A plan that removes stale files drops the lines nexus no longer needs
+	plan = NewPlan(root)
+	plan.Gitignore("AGENTS.md")
+	plan.RemoveStale()
+	_, err = plan.Apply(manifest, false)
+	require.NoError(t, err)
+
+	content, err = os.ReadFile(gitignore)
+	require.NoError(t, err)
+	assert.Equal(t, "node_modules/\n\n# local\n.vscode/\nAGENTS.md\n", string(content))
+	assert.Equal(t, []string{"AGENTS.md"}, manifest.Gitignore)
+}
+
+func TestPlan_RemoveStale(t *testing.T) {
+	root := t.TempDir()
+	manifest, err := LoadManifest(filepath.Join(root, "state", ManifestFile))
+	require.NoError(t, err)
+
+	plan := NewPlan(root)
+	plan.Write(filepath.Join(root, ".cursor", "rules", "golang", "style.mdc"), []byte("style\n"))
+	plan.Write(filepath.Join(root, ".opencode", "rules", "edited.md"), []byte("edited\n"))
+	plan.Write(filepath.Join(root, "AGENTS.md"), []byte("agents\n"))
+	_, err = plan.Apply(manifest, false)
+	require.NoError(t, err)
+
+	editedPath := filepath.Join(root, ".opencode", "rules", "edited.md")
+	require.NoError(t, os.WriteFile(editedPath, []byte("edited by hand\n"), 0644))
+
+	// Without RemoveStale, files missing from the plan are left alone
+	plan = NewPlan(root)
+	plan.Write(filepath.Join(root, "AGENTS.md"), []byte("agents\n"))
+	changes, err := plan.Changes(manifest)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	plan.RemoveStale()
+	changes, err = plan.Changes(manifest)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, ".opencode/rules/edited.md", changes[1].Path)
+	assert.True(t, changes[1].Removed)
+	assert.True(t, changes[1].Conflict)
+	assert.Contains(t, changes[0].Diff(), "+++ /dev/null\n")
+
+	_, err = plan.Apply(manifest, false)
+	require.NoError(t, err)
+
+	assert.NoDirExists(t, filepath.Join(root, ".cursor"), "empty directories are removed")
+	assert.FileExists(t, editedPath, "files edited by hand are kept")
+	assert.Equal(t, map[string]string{"AGENTS.md": Hash([]byte("agents\n"))}, manifest.Files)
+
+	plan = NewPlan(root)
+	plan.RemoveStale()
+	plan.Keep(root)
+	changes, err = plan.Changes(manifest)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "kept directories have no stale files")
+
+	plan = NewPlan(root)
+	plan.RemoveStale()
+	_, err = plan.Apply(manifest, false)
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(root, "AGENTS.md"))
+	assert.DirExists(t, root)
+}
@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 // AppConfig holds GitHub App configuration
@@ -190,7 +192,7 @@ func ExchangeCodeForToken(ctx context.Context, clientID, clientSecret, code stri
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
@@ -248,8 +250,8 @@ type GitHubInstallationsResponse struct {
 	} `json:"installations"`
 }
 
-// getInstallationIDForUser fetches the GitHub App installation ID for the authenticated user
-func getInstallationIDForUser(ctx context.Context, userAccessToken string) (int64, error) {
+// GetInstallationIDForUser fetches the GitHub App installation ID for the authenticated user
+func GetInstallationIDForUser(ctx context.Context, userAccessToken string) (int64, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com/user/installations", nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create request: %w", err)
@@ -258,7 +260,7 @@ func getInstallationIDForUser(ctx context.Context, userAccessToken string) (int6
 	req.Header.Set("Authorization", fmt.Sprintf("token %s", userAccessToken))
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
 	resp, err := client.Do(req)
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch installations: %w", err)
@@ -307,7 +309,7 @@ func GenerateInstallationAccessToken(ctx context.Context, appID int64, privateKe
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", jwtToken))
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to get installation token: %w", err)
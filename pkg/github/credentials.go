@@ -0,0 +1,90 @@
+package github
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CloneAuth describes how git authenticates against a remote. Credentials are
+// handed to git through the process environment only, so nothing is written
+// into the clone's .git/config or shows up in the command line.
+type CloneAuth struct {
+	// Token is a GitHub token sent as an Authorization header for HTTPS remotes
+	Token string
+	// SSHKeyPath is a private key (e.g. a deploy key) used for SSH remotes
+	SSHKeyPath string
+}
+
+// IsSSHURL reports whether a clone URL uses the SSH transport
+func IsSSHURL(cloneURL string) bool {
+	if strings.HasPrefix(cloneURL, "ssh://") {
+		return true
+	}
+	// scp-like syntax: git@github.com:owner/repo.git
+	return !strings.Contains(cloneURL, "://") && strings.Contains(cloneURL, "@") && strings.Contains(cloneURL, ":")
+}
+
+// GitEnv returns the environment for running git against cloneURL with these
+// credentials
+func (a CloneAuth) GitEnv(cloneURL string) []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if IsSSHURL(cloneURL) {
+		if a.SSHKeyPath != "" {
+			// git runs GIT_SSH_COMMAND through the shell
+			env = append(env, "GIT_SSH_COMMAND=ssh -i "+shellQuote(a.SSHKeyPath)+" -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new")
+		}
+		return env
+	}
+
+	if a.Token != "" && strings.Contains(cloneURL, "github.com") {
+		// Scope the header to github.com so it is never sent to other hosts,
+		// e.g. submodules pointing elsewhere
+		env = appendGitConfig(env, "http.https://github.com/.extraheader", authorizationHeader(a.Token))
+	}
+
+	return env
+}
+
+// appendGitConfig adds a config entry through GIT_CONFIG_COUNT, after any
+// entries the environment already passes that way
+func appendGitConfig(env []string, key, value string) []string {
+	count := 0
+	result := make([]string, 0, len(env)+3)
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "GIT_CONFIG_COUNT="); ok {
+			count, _ = strconv.Atoi(v)
+			continue
+		}
+		result = append(result, kv)
+	}
+	return append(result,
+		fmt.Sprintf("GIT_CONFIG_COUNT=%d", count+1),
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", count, key),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, value),
+	)
+}
+
+// shellQuote quotes s as a single shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Redact replaces the token in s, so git output can be surfaced in errors and logs
+func (a CloneAuth) Redact(s string) string {
+	if a.Token == "" {
+		return s
+	}
+	s = strings.ReplaceAll(s, a.Token, "***")
+	return strings.ReplaceAll(s, base64.StdEncoding.EncodeToString([]byte("x-access-token:"+a.Token)), "***")
+}
+
+// authorizationHeader builds the basic auth header GitHub accepts for both
+// OAuth and installation tokens over git's smart HTTP protocol
+func authorizationHeader(token string) string {
+	creds := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+	return "Authorization: Basic " + creds
+}
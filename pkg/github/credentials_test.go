@@ -0,0 +1,98 @@
+package github
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSSHURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"git@github.com:org/repo.git", true},
+		{"ssh://git@github.com/org/repo.git", true},
+		{"https://github.com/org/repo.git", false},
+		{"/srv/git/repo.git", false},
+		{"org/repo", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsSSHURL(tt.url))
+		})
+	}
+}
+
+func TestCloneAuthGitEnv_TokenUsesExtraHeader(t *testing.T) {
+	auth := CloneAuth{Token: "ghs_secret"}
+
+	env := auth.GitEnv("https://github.com/org/repo.git")
+
+	cmd := exec.Command("git", "config", "--get", "http.https://github.com/.extraheader")
+	cmd.Env = env
+	output, err := cmd.Output()
+	require.NoError(t, err)
+
+	header := strings.TrimSpace(string(output))
+	assert.True(t, strings.HasPrefix(header, "Authorization: Basic "))
+	assert.NotContains(t, header, "ghs_secret", "token should only appear base64 encoded")
+	assert.Equal(t, "***", auth.Redact(header[len("Authorization: Basic "):]))
+}
+
+func TestCloneAuthGitEnv_TokenNotSentToOtherHosts(t *testing.T) {
+	auth := CloneAuth{Token: "ghs_secret"}
+
+	env := auth.GitEnv("https://gitlab.example.com/org/repo.git")
+
+	for _, kv := range env {
+		assert.NotContains(t, kv, "extraheader")
+	}
+}
+
+func TestCloneAuthGitEnv_KeepsCallerGitConfig(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "user.name")
+	t.Setenv("GIT_CONFIG_VALUE_0", "caller")
+	auth := CloneAuth{Token: "ghs_secret"}
+
+	env := auth.GitEnv("https://github.com/org/repo.git")
+
+	for key, want := range map[string]string{"user.name": "caller", "http.https://github.com/.extraheader": authorizationHeader("ghs_secret")} {
+		cmd := exec.Command("git", "config", "--get", key)
+		cmd.Env = env
+		output, err := cmd.Output()
+		require.NoError(t, err, key)
+		assert.Equal(t, want, strings.TrimSpace(string(output)))
+	}
+}
+
+func TestCloneAuthGitEnv_SSHKeyPath(t *testing.T) {
+	auth := CloneAuth{Token: "ghs_secret", SSHKeyPath: "/keys/it's deploy key"}
+
+	env := auth.GitEnv("git@github.com:org/repo.git")
+
+	var sshCommand string
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "GIT_SSH_COMMAND="); ok {
+			sshCommand = v
+		}
+		assert.NotContains(t, kv, "extraheader", "token must not be used for SSH remotes")
+	}
+	assert.Equal(t, `ssh -i '/keys/it'\''s deploy key' -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new`, sshCommand)
+
+	// The shell reads the quoted path back as one word
+	output, err := exec.Command("sh", "-c", "printf %s "+shellQuote(auth.SSHKeyPath)).Output()
+	require.NoError(t, err)
+	assert.Equal(t, auth.SSHKeyPath, string(output))
+}
+
+func TestCloneAuthRedact(t *testing.T) {
+	auth := CloneAuth{Token: "ghs_secret"}
+	assert.Equal(t, "fatal: could not read from https://***@github.com", auth.Redact("fatal: could not read from https://ghs_secret@github.com"))
+	assert.Equal(t, "unchanged", CloneAuth{}.Redact("unchanged"))
+}
@@ -0,0 +1,34 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrUnauthorized is matched by errors returned when GitHub rejects the
+// credentials of a request, typically because a token has expired
+var ErrUnauthorized = errors.New("github: unauthorized")
+
+// APIError represents a non-success response from the GitHub REST API
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("GitHub API error (%d)", e.StatusCode)
+	}
+	return fmt.Sprintf("GitHub API error (%d): %s", e.StatusCode, e.Body)
+}
+
+// Is reports whether a 401 response should be treated as ErrUnauthorized
+func (e *APIError) Is(target error) bool {
+	return target == ErrUnauthorized && e.StatusCode == http.StatusUnauthorized
+}
+
+// IsUnauthorized reports whether err was caused by GitHub rejecting a token
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
@@ -6,8 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
+
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 // ForkRequest represents a GitHub fork creation request
@@ -45,7 +46,7 @@ type Repository struct {
 
 // GetUserRepos lists all repositories owned by the authenticated user
 func GetUserRepos(ctx context.Context, token string) ([]Repository, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com/user/repos", nil)
 	if err != nil {
@@ -63,7 +64,7 @@ func GetUserRepos(ctx context.Context, token string) ([]Repository, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error (%d): %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var repos []Repository
@@ -93,7 +94,7 @@ func ForkRepository(ctx context.Context, token, owner, repo string) (*Repository
 	}
 
 	// Create fork if it doesn't exist
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
 
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/forks", owner, repo)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
@@ -127,7 +128,7 @@ func ForkRepository(ctx context.Context, token, owner, repo string) (*Repository
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error (%d): %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var forkResp Repository
@@ -140,7 +141,7 @@ func ForkRepository(ctx context.Context, token, owner, repo string) (*Repository
 
 // IsForkOf verifies if a repository is a fork of another
 func IsForkOf(ctx context.Context, token, forkOwner, forkRepo, origOwner, origRepo string) (bool, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
 
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", forkOwner, forkRepo)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -158,7 +159,7 @@ func IsForkOf(ctx context.Context, token, forkOwner, forkRepo, origOwner, origRe
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("GitHub API error (%d)", resp.StatusCode)
+		return false, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var repo struct {
@@ -184,7 +185,7 @@ func IsForkOf(ctx context.Context, token, forkOwner, forkRepo, origOwner, origRe
 
 // GetForkURL returns the HTTPS clone URL for a repository
 func GetForkURL(ctx context.Context, token, owner, repo string) (string, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
 
 	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
@@ -203,7 +204,7 @@ func GetForkURL(ctx context.Context, token, owner, repo string) (string, error)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("GitHub API error (%d): %s", resp.StatusCode, string(body))
+		return "", &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var repository Repository
@@ -211,21 +212,10 @@ func GetForkURL(ctx context.Context, token, owner, repo string) (string, error)
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Return HTTPS URL with token embedded
-	if repository.CloneURL != "" {
-		// Replace git:// with https:// if needed, and format with token
-		cloneURL := repository.CloneURL
-		if strings.HasPrefix(cloneURL, "git@") {
-			// Convert git SSH URL to HTTPS
-			parts := strings.Split(cloneURL, ":")
-			if len(parts) == 2 {
-				parts = strings.Split(parts[1], "/")
-				if len(parts) == 2 {
-					return fmt.Sprintf("https://%s@github.com/%s/%s.git", token, parts[0], parts[1]), nil
-				}
-			}
-		}
-		return cloneURL, nil
+	// Always hand back a plain HTTPS URL; credentials are supplied to git
+	// separately via CloneAuth so they never end up in .git/config
+	if repository.CloneURL != "" && !IsSSHURL(repository.CloneURL) {
+		return repository.CloneURL, nil
 	}
 
 	return fmt.Sprintf("https://github.com/%s/%s.git", repository.Owner.Login, repository.Name), nil
@@ -233,7 +223,7 @@ func GetForkURL(ctx context.Context, token, owner, repo string) (string, error)
 
 // GetRepositoryInfo retrieves information about a repository
 func GetRepositoryInfo(ctx context.Context, token, owner, repo string) (*Repository, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
 
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -252,7 +242,7 @@ func GetRepositoryInfo(ctx context.Context, token, owner, repo string) (*Reposit
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error (%d): %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var repository Repository
@@ -265,7 +255,7 @@ func GetRepositoryInfo(ctx context.Context, token, owner, repo string) (*Reposit
 
 // getRepositoryParent retrieves parent repository info for a fork
 func getRepositoryParent(ctx context.Context, token, owner, repo string) (*Repository, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
 
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -283,7 +273,7 @@ func getRepositoryParent(ctx context.Context, token, owner, repo string) (*Repos
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error (%d)", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var data struct {
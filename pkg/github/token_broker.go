@@ -0,0 +1,123 @@
+package github
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTokenRefreshWindow is how long before expiry a cached installation
+// token is considered stale and re-minted
+const DefaultTokenRefreshWindow = 5 * time.Minute
+
+// mintFunc requests a new installation access token from GitHub
+type mintFunc func(ctx context.Context, installationID int64) (string, time.Time, error)
+
+// cachedToken holds the current token for a single installation. The
+// per-entry mutex serialises refreshes so concurrent callers share one mint.
+type cachedToken struct {
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// TokenBroker caches GitHub App installation tokens per installation and
+// refreshes them with the App JWT shortly before they expire
+type TokenBroker struct {
+	refreshWindow time.Duration
+	mint          mintFunc
+	now           func() time.Time
+
+	mu     sync.Mutex
+	tokens map[int64]*cachedToken
+}
+
+// NewTokenBroker creates a token broker that mints installation tokens for the given GitHub App
+func NewTokenBroker(appID int64, privateKey *rsa.PrivateKey) *TokenBroker {
+	return &TokenBroker{
+		refreshWindow: DefaultTokenRefreshWindow,
+		mint: func(ctx context.Context, installationID int64) (string, time.Time, error) {
+			return GenerateInstallationAccessToken(ctx, appID, privateKey, installationID)
+		},
+		now:    time.Now,
+		tokens: make(map[int64]*cachedToken),
+	}
+}
+
+// Token returns a valid access token for the installation, minting a new one
+// if none is cached or the cached token expires within the refresh window
+func (b *TokenBroker) Token(ctx context.Context, installationID int64) (string, time.Time, error) {
+	if installationID == 0 {
+		return "", time.Time{}, fmt.Errorf("installation ID is required")
+	}
+
+	entry := b.entry(installationID)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.token != "" && b.now().Add(b.refreshWindow).Before(entry.expiresAt) {
+		return entry.token, entry.expiresAt, nil
+	}
+
+	token, expiresAt, err := b.mint(ctx, installationID)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to refresh installation token %d: %w", installationID, err)
+	}
+
+	entry.token = token
+	entry.expiresAt = expiresAt
+	return token, expiresAt, nil
+}
+
+// Invalidate drops the cached token for an installation so the next call to Token mints a fresh one
+func (b *TokenBroker) Invalidate(installationID int64) {
+	b.mu.Lock()
+	entry, exists := b.tokens[installationID]
+	b.mu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	entry.mu.Lock()
+	entry.token = ""
+	entry.expiresAt = time.Time{}
+	entry.mu.Unlock()
+}
+
+// Do calls fn with a valid installation token. If fn fails because GitHub
+// rejected the token, the token is invalidated and fn is retried once with a
+// freshly minted one.
+func (b *TokenBroker) Do(ctx context.Context, installationID int64, fn func(token string) error) error {
+	token, _, err := b.Token(ctx, installationID)
+	if err != nil {
+		return err
+	}
+
+	err = fn(token)
+	if err == nil || !IsUnauthorized(err) {
+		return err
+	}
+
+	b.Invalidate(installationID)
+	token, _, err = b.Token(ctx, installationID)
+	if err != nil {
+		return err
+	}
+
+	return fn(token)
+}
+
+func (b *TokenBroker) entry(installationID int64) *cachedToken {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, exists := b.tokens[installationID]
+	if !exists {
+		entry = &cachedToken{}
+		b.tokens[installationID] = entry
+	}
+	return entry
+}
@@ -0,0 +1,110 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBroker creates a broker whose minted tokens are numbered sequentially
+func newTestBroker(now time.Time, ttl time.Duration) (*TokenBroker, *int) {
+	mints := 0
+	broker := NewTokenBroker(12345, nil)
+	broker.now = func() time.Time { return now }
+	broker.mint = func(ctx context.Context, installationID int64) (string, time.Time, error) {
+		mints++
+		return fmt.Sprintf("ghs_%d_%d", installationID, mints), now.Add(ttl), nil
+	}
+	return broker, &mints
+}
+
+func TestTokenBroker_CachesPerInstallation(t *testing.T) {
+	broker, mints := newTestBroker(time.Now(), time.Hour)
+	ctx := context.Background()
+
+	first, _, err := broker.Token(ctx, 1)
+	require.NoError(t, err)
+	second, _, err := broker.Token(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, *mints)
+
+	other, _, err := broker.Token(ctx, 2)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+	assert.Equal(t, 2, *mints)
+}
+
+func TestTokenBroker_RefreshesBeforeExpiry(t *testing.T) {
+	now := time.Now()
+	broker, mints := newTestBroker(now, time.Hour)
+	ctx := context.Background()
+
+	first, _, err := broker.Token(ctx, 1)
+	require.NoError(t, err)
+
+	// Inside the refresh window the cached token is no longer handed out
+	broker.now = func() time.Time { return now.Add(time.Hour - DefaultTokenRefreshWindow/2) }
+	second, _, err := broker.Token(ctx, 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, 2, *mints)
+}
+
+func TestTokenBroker_RequiresInstallationID(t *testing.T) {
+	broker, _ := newTestBroker(time.Now(), time.Hour)
+
+	_, _, err := broker.Token(context.Background(), 0)
+	assert.Error(t, err)
+}
+
+func TestTokenBroker_DoRetriesUnauthorizedOnce(t *testing.T) {
+	broker, mints := newTestBroker(time.Now(), time.Hour)
+
+	var seen []string
+	err := broker.Do(context.Background(), 1, func(token string) error {
+		seen = append(seen, token)
+		if len(seen) == 1 {
+			return &APIError{StatusCode: http.StatusUnauthorized}
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, seen, 2)
+	assert.NotEqual(t, seen[0], seen[1])
+	assert.Equal(t, 2, *mints)
+}
+
+func TestTokenBroker_DoDoesNotRetryOtherErrors(t *testing.T) {
+	broker, mints := newTestBroker(time.Now(), time.Hour)
+
+	calls := 0
+	err := broker.Do(context.Background(), 1, func(token string) error {
+		calls++
+		return &APIError{StatusCode: http.StatusNotFound}
+	})
+
+	assert.Error(t, err)
+	assert.False(t, IsUnauthorized(err))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, *mints)
+}
+
+func TestTokenBroker_DoGivesUpAfterSecondUnauthorized(t *testing.T) {
+	broker, _ := newTestBroker(time.Now(), time.Hour)
+
+	calls := 0
+	err := broker.Do(context.Background(), 1, func(token string) error {
+		calls++
+		return fmt.Errorf("clone failed: %w", ErrUnauthorized)
+	})
+
+	assert.True(t, IsUnauthorized(err))
+	assert.Equal(t, 2, calls)
+}
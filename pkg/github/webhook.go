@@ -0,0 +1,89 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Webhook headers sent by GitHub with every delivery
+const (
+	WebhookEventHeader     = "X-GitHub-Event"
+	WebhookDeliveryHeader  = "X-GitHub-Delivery"
+	WebhookSignatureHeader = "X-Hub-Signature-256"
+)
+
+// VerifyWebhookSignature checks the X-Hub-Signature-256 header of a webhook
+// delivery against an HMAC-SHA256 of the raw payload keyed with the secret
+func VerifyWebhookSignature(secret string, payload []byte, signature string) error {
+	if secret == "" {
+		return fmt.Errorf("webhook secret not configured")
+	}
+
+	hexDigest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return fmt.Errorf("missing or malformed %s header", WebhookSignatureHeader)
+	}
+
+	got, err := hex.DecodeString(hexDigest)
+	if err != nil {
+		return fmt.Errorf("malformed webhook signature: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return fmt.Errorf("webhook signature mismatch")
+	}
+
+	return nil
+}
+
+// SignWebhookPayload returns the X-Hub-Signature-256 value GitHub would send for a payload
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookRepository is the repository object embedded in webhook payloads
+type WebhookRepository struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	Private  bool   `json:"private"`
+	CloneURL string `json:"clone_url"`
+}
+
+// PullRequestRef is the head or base ref of a pull request
+type PullRequestRef struct {
+	Ref  string            `json:"ref"`
+	SHA  string            `json:"sha"`
+	Repo WebhookRepository `json:"repo"`
+}
+
+// PullRequestEvent is the payload of a pull_request webhook delivery
+type PullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		HTMLURL string `json:"html_url"`
+		State   string `json:"state"`
+		Merged  bool   `json:"merged"`
+		User    struct {
+			Login string `json:"login"`
+			ID    int64  `json:"id"`
+		} `json:"user"`
+		Head PullRequestRef `json:"head"`
+		Base PullRequestRef `json:"base"`
+	} `json:"pull_request"`
+	Repository   WebhookRepository `json:"repository"`
+	Installation struct {
+		ID int64 `json:"id"`
+	} `json:"installation"`
+}
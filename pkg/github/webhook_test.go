@@ -0,0 +1,61 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	payload := []byte(`{"action":"opened"}`)
+	secret := "webhook-secret"
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "valid signature",
+			secret:    secret,
+			signature: SignWebhookPayload(secret, payload),
+			wantErr:   false,
+		},
+		{
+			name:      "wrong secret",
+			secret:    secret,
+			signature: SignWebhookPayload("other-secret", payload),
+			wantErr:   true,
+		},
+		{
+			name:      "missing prefix",
+			secret:    secret,
+			signature: "deadbeef",
+			wantErr:   true,
+		},
+		{
+			name:      "not hex",
+			secret:    secret,
+			signature: "sha256=zz",
+			wantErr:   true,
+		},
+		{
+			name:      "no secret configured",
+			secret:    "",
+			signature: SignWebhookPayload("", payload),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(tt.secret, payload, tt.signature)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package github
 import (
 	"fmt"
 	"net/url"
-	"os"
 	"os/exec"
 	"strings"
 )
@@ -11,6 +10,12 @@ import (
 func ParseRepoURL(repoString string) (owner string, repo string, err error) {
 	repoString = strings.TrimSpace(repoString)
 
+	if IsSSHURL(repoString) && !strings.HasPrefix(repoString, "ssh://") {
+		// scp-like syntax: git@github.com:owner/repo.git
+		repoString = repoString[strings.Index(repoString, ":")+1:]
+		repoString = strings.TrimSuffix(repoString, ".git")
+	}
+
 	if strings.Contains(repoString, "://") {
 		u, err := url.Parse(repoString)
 		if err != nil {
@@ -44,12 +49,10 @@ func BuildCloneURL(owner, repo string) string {
 	return fmt.Sprintf("https://github.com/%s/%s.git", owner, repo)
 }
 
-func CloneRepository(url, destDir, token string) error {
-	// If token provided and URL is GitHub, inject token for authentication
-	if token != "" && strings.Contains(url, "github.com") {
-		url = strings.Replace(url, "https://github.com",
-			fmt.Sprintf("https://%s@github.com", token), 1)
-	}
+// CloneRepository clones url into destDir. Credentials in auth are passed to
+// git through the environment and never persisted in the clone.
+func CloneRepository(url, destDir string, auth CloneAuth) error {
+	env := auth.GitEnv(url)
 
 	args := []string{"clone", url}
 	if destDir != "" {
@@ -57,16 +60,13 @@ func CloneRepository(url, destDir, token string) error {
 	}
 
 	cmd := exec.Command("git", args...)
-
-	// Disable terminal prompt to prevent hanging on auth failures
-	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Env = env
 
 	var stderr strings.Builder
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("failed to clone repository: %w (stderr: %s)", err, stderr.String())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to clone repository: %w (stderr: %s)", err, auth.Redact(stderr.String()))
 	}
 
 	return nil
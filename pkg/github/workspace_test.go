@@ -35,6 +35,20 @@ func TestParseRepoURL(t *testing.T) {
 			wantRepo:   "linux",
 			wantErr:    false,
 		},
+		{
+			name:       "ssh scp-like url",
+			repoString: "git@github.com:torvalds/linux.git",
+			wantOwner:  "torvalds",
+			wantRepo:   "linux",
+			wantErr:    false,
+		},
+		{
+			name:       "ssh url",
+			repoString: "ssh://git@github.com/torvalds/linux.git",
+			wantOwner:  "torvalds",
+			wantRepo:   "linux",
+			wantErr:    false,
+		},
 		{
 			name:       "invalid format",
 			repoString: "invalid",
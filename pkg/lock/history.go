@@ -0,0 +1,105 @@
+package lock
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of Change
+const (
+	ChangeAdded   = "added"
+	ChangeUpdated = "updated"
+	ChangeRemoved = "removed"
+)
+
+// Change is a plugin added, removed or moved to another version by one
+// commit of nexus.lock
+type Change struct {
+	Time   time.Time `json:"time"`
+	Commit string    `json:"commit"`
+	Plugin string    `json:"plugin"`
+	Kind   string    `json:"kind"`
+	// From and To are the versions before and after; From is empty for an
+	// added plugin and To for a removed one
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// History returns every plugin change in the git history of nexus.lock,
+// oldest first, dated by commit time. Uncommitted edits aren't included.
+func (m *Manager) History() ([]Change, error) {
+	log, err := m.git("log", "--reverse", "--format=%H %cI", "--", "nexus.lock")
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	previous := &Lockfile{}
+	for _, line := range strings.Split(strings.TrimSpace(log), "\n") {
+		commit, date, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		committed, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse commit time %q: %w", date, err)
+		}
+
+		// The lockfile is gone in commits that deleted it
+		current := &Lockfile{}
+		if data, showErr := m.git("show", commit+":./nexus.lock"); showErr == nil {
+			if err := yaml.Unmarshal([]byte(data), current); err != nil {
+				return nil, fmt.Errorf("failed to parse nexus.lock at %s: %w", commit[:7], err)
+			}
+		}
+
+		for _, change := range DiffLockfiles(previous, current) {
+			change.Time = committed
+			change.Commit = commit
+			changes = append(changes, change)
+		}
+		previous = current
+	}
+	return changes, nil
+}
+
+// DiffLockfiles lists the plugins added, removed or updated between two
+// lockfiles, by plugin name. A plugin is updated when its version or pinned
+// commit changed.
+func DiffLockfiles(before, after *Lockfile) []Change {
+	var changes []Change
+	for name, entry := range after.Plugins {
+		old, existed := before.Plugins[name]
+		switch {
+		case !existed:
+			changes = append(changes, Change{Plugin: name, Kind: ChangeAdded, To: entry.Version})
+		case old.Version != entry.Version || old.SHA != entry.SHA:
+			changes = append(changes, Change{Plugin: name, Kind: ChangeUpdated, From: old.Version, To: entry.Version})
+		}
+	}
+	for name, entry := range before.Plugins {
+		if _, exists := after.Plugins[name]; !exists {
+			changes = append(changes, Change{Plugin: name, Kind: ChangeRemoved, From: entry.Version})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Plugin < changes[j].Plugin })
+	return changes
+}
+
+func (m *Manager) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = m.baseDir
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s failed: %w, output: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,79 @@
+package lock
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_History(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	project := filepath.Join(repo, "app")
+	require.NoError(t, os.MkdirAll(project, 0755))
+	commit := func(date string) {
+		for _, args := range [][]string{{"add", "-A"}, {"commit", "--quiet", "-m", date}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repo
+			cmd.Env = append(os.Environ(),
+				"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+				"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+				"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+			output, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(output))
+		}
+	}
+	cmd := exec.Command("git", "init", "--quiet")
+	cmd.Dir = repo
+	require.NoError(t, cmd.Run())
+
+	manager := NewManager(project)
+	require.NoError(t, manager.SaveLockfile(lockfileFor(t, manager,
+		&LockEntry{Name: "golang-base", Version: "1.0.0", SHA: "a"},
+		&LockEntry{Name: "review", Version: "2.0.0", SHA: "b"},
+	)))
+	commit("2026-05-01T10:00:00Z")
+
+	require.NoError(t, os.WriteFile(filepath.Join(project, "README.md"), []byte("unrelated"), 0644))
+	commit("2026-05-02T10:00:00Z")
+
+	require.NoError(t, manager.SaveLockfile(lockfileFor(t, manager,
+		&LockEntry{Name: "golang-base", Version: "1.1.0", SHA: "c"},
+		&LockEntry{Name: "tdd", Version: "0.1.0", SHA: "d"},
+	)))
+	commit("2026-05-10T10:00:00Z")
+
+	changes, err := manager.History()
+	require.NoError(t, err)
+	require.Len(t, changes, 5)
+
+	first := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, "golang-base", changes[0].Plugin)
+	assert.Equal(t, ChangeAdded, changes[0].Kind)
+	assert.True(t, first.Equal(changes[0].Time))
+	assert.Equal(t, "review", changes[1].Plugin)
+
+	later := changes[2:]
+	assert.Equal(t, Change{Time: later[0].Time, Commit: later[0].Commit, Plugin: "golang-base", Kind: ChangeUpdated, From: "1.0.0", To: "1.1.0"}, later[0])
+	assert.Equal(t, ChangeRemoved, later[1].Kind)
+	assert.Equal(t, "review", later[1].Plugin)
+	assert.Equal(t, ChangeAdded, later[2].Kind)
+	assert.Equal(t, "tdd", later[2].Plugin)
+	assert.True(t, time.Date(2026, 5, 10, 10, 0, 0, 0, time.UTC).Equal(later[0].Time))
+}
+
+func TestManager_HistoryOutsideGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	_, err := NewManager(t.TempDir()).History()
+	assert.Error(t, err)
+}
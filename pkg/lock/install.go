@@ -0,0 +1,256 @@
+package lock
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nexus/nexus/pkg/plugins"
+)
+
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Fetcher checks out a repository at an exact commit
+type Fetcher interface {
+	Fetch(repository, sha, dest string) error
+}
+
+// GitFetcher fetches plugin repositories with git
+type GitFetcher struct{}
+
+// Fetch checks out repository at sha in dest, which must not exist yet
+func (GitFetcher) Fetch(repository, sha, dest string) error {
+	tmp := dest + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmp, err)
+	}
+
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", repository},
+	}
+	for _, args := range steps {
+		if err := runGit(tmp, args...); err != nil {
+			os.RemoveAll(tmp)
+			return err
+		}
+	}
+
+	// Servers that refuse to serve unadvertised commits need a full fetch
+	if err := runGit(tmp, "fetch", "--quiet", "--depth", "1", "origin", sha); err != nil {
+		if fullErr := runGit(tmp, "fetch", "--quiet", "--tags", "origin"); fullErr != nil {
+			os.RemoveAll(tmp)
+			return fullErr
+		}
+	}
+	if err := runGit(tmp, "checkout", "--quiet", "--detach", sha); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, dest)
+}
+
+// InstalledPlugin is a plugin materialized from the lockfile
+type InstalledPlugin struct {
+	Name  string
+	Entry *LockEntry
+	// Dir holds the plugin's files; empty for local plugins
+	Dir string
+	// Cached is true when the pinned commit was already in the cache
+	Cached bool
+}
+
+// Install fetches every locked repository plugin at its pinned SHA into
+// cacheDir, reusing checkouts that are already there. The lockfile must pass
+// VerifyIntegrity, and every checkout must match its SHA exactly.
+func (m *Manager) Install(lockfile *Lockfile, cacheDir string, fetcher Fetcher) ([]InstalledPlugin, error) {
+	if err := m.VerifyIntegrity(lockfile); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(lockfile.Plugins))
+	for name := range lockfile.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	installed := make([]InstalledPlugin, 0, len(names))
+	for _, name := range names {
+		entry := lockfile.Plugins[name]
+		if entry.Repository == "" {
+			installed = append(installed, InstalledPlugin{Name: name, Entry: entry})
+			continue
+		}
+
+		if !commitSHAPattern.MatchString(entry.SHA) {
+			return nil, fmt.Errorf("plugin %s is not pinned to a commit (sha %q); run nexus plugin update", name, entry.SHA)
+		}
+
+		checkout := checkoutPath(cacheDir, name, entry)
+		cached := false
+		if _, err := os.Stat(checkout); err == nil {
+			if verifyErr := verifyCheckout(checkout, entry.SHA); verifyErr == nil {
+				cached = true
+			} else if err := os.RemoveAll(checkout); err != nil {
+				return nil, fmt.Errorf("failed to remove corrupt cache entry %s: %w", checkout, err)
+			}
+		}
+
+		if !cached {
+			if err := os.MkdirAll(filepath.Dir(checkout), 0755); err != nil {
+				return nil, fmt.Errorf("failed to create plugin cache: %w", err)
+			}
+			if err := fetcher.Fetch(entry.Repository, entry.SHA, checkout); err != nil {
+				return nil, fmt.Errorf("failed to fetch plugin %s at %s: %w", name, entry.SHA, err)
+			}
+			if err := verifyCheckout(checkout, entry.SHA); err != nil {
+				os.RemoveAll(checkout)
+				return nil, fmt.Errorf("plugin %s: %w", name, err)
+			}
+		}
+
+		installed = append(installed, InstalledPlugin{Name: name, Entry: entry, Dir: CachePath(cacheDir, name, entry), Cached: cached})
+	}
+
+	return installed, nil
+}
+
+// CachePath returns the directory that Install checks the locked repository
+// plugin out to, including the plugin's path within the repository
+func CachePath(cacheDir, name string, entry *LockEntry) string {
+	return filepath.Join(checkoutPath(cacheDir, name, entry), entry.Path)
+}
+
+func checkoutPath(cacheDir, name string, entry *LockEntry) string {
+	return filepath.Join(cacheDir, strings.ReplaceAll(name, "/", "--"), entry.SHA)
+}
+
+// CheckFrozen reports where the lockfile disagrees with the project: a failed
+// integrity check, required plugins missing from the lock, locked repository
+// plugins that neither a requirement nor a locked dependency reaches, locked
+// versions outside the configured constraints, and local plugins in registry
+// that changed since the lock was written.
+func (m *Manager) CheckFrozen(lockfile *Lockfile, registry *plugins.Registry, requirements map[string]string) error {
+	var problems []string
+
+	if err := m.VerifyIntegrity(lockfile); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	names := make([]string, 0, len(requirements))
+	for name := range requirements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entry, ok := lockfile.Plugins[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("plugin %s is in config but not in nexus.lock", name))
+			continue
+		}
+
+		constraint, err := plugins.ParseConstraint(requirements[name])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("plugin %s: %v", name, err))
+			continue
+		}
+		if constraint.String() == "*" {
+			continue
+		}
+		version, err := plugins.ParseVersion(entry.Version)
+		if err != nil || !constraint.Check(version) {
+			problems = append(problems, fmt.Sprintf("plugin %s is locked at %s, which does not satisfy %s", name, entry.Version, constraint))
+		}
+	}
+
+	// Transitive dependencies are locked without being required directly
+	reachable := make(map[string]bool, len(lockfile.Plugins))
+	queue := make([]string, 0, len(names))
+	queue = append(queue, names...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if reachable[name] {
+			continue
+		}
+		reachable[name] = true
+		if entry, ok := lockfile.Plugins[name]; ok {
+			queue = append(queue, entry.Dependencies...)
+		}
+	}
+
+	locked := make([]string, 0, len(lockfile.Plugins))
+	for name := range lockfile.Plugins {
+		locked = append(locked, name)
+	}
+	sort.Strings(locked)
+
+	for _, name := range locked {
+		entry := lockfile.Plugins[name]
+		if entry.Repository != "" {
+			if !reachable[name] {
+				problems = append(problems, fmt.Sprintf("plugin %s is in nexus.lock but not in config", name))
+			}
+			continue
+		}
+		plugin, ok := registry.GetPlugin(name)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("local plugin %s is in nexus.lock but no longer exists", name))
+			continue
+		}
+		sha, err := m.generatePluginSHA(plugin)
+		if err != nil {
+			return err
+		}
+		if sha != entry.SHA {
+			problems = append(problems, fmt.Sprintf("local plugin %s changed since nexus.lock was written", name))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("nexus.lock is out of date:\n  - %s\nrun nexus plugin update to refresh it", strings.Join(problems, "\n  - "))
+}
+
+// verifyCheckout checks that dir is a clean checkout of sha
+func verifyCheckout(dir, sha string) error {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to read checkout at %s: %w", dir, err)
+	}
+	if head := strings.TrimSpace(string(output)); head != sha {
+		return fmt.Errorf("integrity check failed: %s is at %s, expected %s", dir, head, sha)
+	}
+
+	cmd = exec.Command("git", "status", "--porcelain")
+	cmd.Dir = dir
+	output, err = cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to read checkout status at %s: %w", dir, err)
+	}
+	if len(strings.TrimSpace(string(output))) > 0 {
+		return fmt.Errorf("integrity check failed: %s has modified files", dir)
+	}
+	return nil
+}
+
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w, output: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package lock
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nexus/nexus/pkg/plugins"
+)
+
+// createPluginRepo creates a git repository with two commits and returns its
+// path and the SHAs of both commits
+func createPluginRepo(t *testing.T) (string, string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+		return strings.TrimSpace(string(output))
+	}
+
+	git("init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "plugin.yaml"), []byte("plugin:\n  name: golang-base\n  version: 1.0.0\n"), 0644))
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "v1")
+	first := git("rev-parse", "HEAD")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "plugin.yaml"), []byte("plugin:\n  name: golang-base\n  version: 1.1.0\n"), 0644))
+	git("commit", "--quiet", "-am", "v1.1")
+	second := git("rev-parse", "HEAD")
+
+	return repo, first, second
+}
+
+func lockfileFor(t *testing.T, manager *Manager, entries ...*LockEntry) *Lockfile {
+	t.Helper()
+	lockfile := &Lockfile{Version: "1.0", Plugins: make(map[string]*LockEntry)}
+	for _, entry := range entries {
+		lockfile.Plugins[entry.Name] = entry
+	}
+	hash, err := manager.ContentHash(lockfile)
+	require.NoError(t, err)
+	lockfile.Metadata.ContentHash = hash
+	return lockfile
+}
+
+func TestManager_InstallFetchesPinnedCommit(t *testing.T) {
+	repo, first, _ := createPluginRepo(t)
+	cacheDir := t.TempDir()
+	manager := NewManager(t.TempDir())
+
+	lockfile := lockfileFor(t, manager, &LockEntry{Name: "golang-base", Version: "1.0.0", SHA: first, Repository: repo})
+
+	installed, err := manager.Install(lockfile, cacheDir, GitFetcher{})
+	require.NoError(t, err)
+	require.Len(t, installed, 1)
+	assert.False(t, installed[0].Cached)
+	assert.Equal(t, filepath.Join(cacheDir, "golang-base", first), installed[0].Dir)
+
+	manifest, err := os.ReadFile(filepath.Join(installed[0].Dir, "plugin.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "version: 1.0.0", "the pinned commit is checked out, not the branch head")
+
+	// A second install reuses the cached checkout
+	installed, err = manager.Install(lockfile, cacheDir, GitFetcher{})
+	require.NoError(t, err)
+	assert.True(t, installed[0].Cached)
+}
+
+func TestManager_InstallRejectsModifiedCache(t *testing.T) {
+	repo, first, _ := createPluginRepo(t)
+	cacheDir := t.TempDir()
+	manager := NewManager(t.TempDir())
+	lockfile := lockfileFor(t, manager, &LockEntry{Name: "golang-base", Version: "1.0.0", SHA: first, Repository: repo})
+
+	installed, err := manager.Install(lockfile, cacheDir, GitFetcher{})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(installed[0].Dir, "plugin.yaml"), []byte("tampered"), 0644))
+
+	// The modified checkout is discarded and fetched again
+	installed, err = manager.Install(lockfile, cacheDir, GitFetcher{})
+	require.NoError(t, err)
+	assert.False(t, installed[0].Cached)
+	manifest, err := os.ReadFile(filepath.Join(installed[0].Dir, "plugin.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "version: 1.0.0")
+}
+
+type wrongCommitFetcher struct {
+	sha string
+}
+
+func (f wrongCommitFetcher) Fetch(repository, _, dest string) error {
+	return GitFetcher{}.Fetch(repository, f.sha, dest)
+}
+
+func TestManager_InstallFailsOnSHAMismatch(t *testing.T) {
+	repo, first, second := createPluginRepo(t)
+	cacheDir := t.TempDir()
+	manager := NewManager(t.TempDir())
+	lockfile := lockfileFor(t, manager, &LockEntry{Name: "golang-base", Version: "1.0.0", SHA: first, Repository: repo})
+
+	_, err := manager.Install(lockfile, cacheDir, wrongCommitFetcher{sha: second})
+	assert.ErrorContains(t, err, "integrity check failed")
+	assert.NoDirExists(t, filepath.Join(cacheDir, "golang-base", first))
+}
+
+func TestManager_InstallVerifiesLockfile(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	lockfile := lockfileFor(t, manager, &LockEntry{Name: "golang-base", Version: "1.0.0", SHA: strings.Repeat("a", 40), Repository: "repo"})
+	lockfile.Plugins["golang-base"].SHA = strings.Repeat("b", 40)
+
+	_, err := manager.Install(lockfile, t.TempDir(), GitFetcher{})
+	assert.ErrorContains(t, err, "lockfile integrity check failed")
+
+	unpinned := lockfileFor(t, manager, &LockEntry{Name: "golang-base", Version: "1.0.0", SHA: "deadbeef", Repository: "repo"})
+	_, err = manager.Install(unpinned, t.TempDir(), GitFetcher{})
+	assert.ErrorContains(t, err, "not pinned to a commit")
+}
+
+func TestManager_CheckFrozen(t *testing.T) {
+	manager := NewManager(t.TempDir())
+
+	registry := plugins.NewRegistry()
+	local := &plugins.Plugin{Name: "base", Version: "1.0.0"}
+	registry.AddPlugin("core/base", local)
+	localSHA, err := manager.generatePluginSHA(local)
+	require.NoError(t, err)
+
+	lockfile := lockfileFor(t, manager,
+		&LockEntry{Name: "core/base", Version: "1.0.0", SHA: localSHA},
+		&LockEntry{Name: "golang-base", Version: "1.2.0", Tag: "v1.2.0", SHA: strings.Repeat("a", 40), Repository: "repo"},
+	)
+
+	assert.NoError(t, manager.CheckFrozen(lockfile, registry, map[string]string{"golang-base": "^1.2", "core/base": "*"}))
+
+	err = manager.CheckFrozen(lockfile, registry, map[string]string{"golang-base": "^2", "go-tools": "*"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "golang-base is locked at 1.2.0, which does not satisfy ^2")
+	assert.Contains(t, err.Error(), "go-tools is in config but not in nexus.lock")
+
+	err = manager.CheckFrozen(lockfile, registry, map[string]string{"core/base": "*"})
+	assert.ErrorContains(t, err, "plugin golang-base is in nexus.lock but not in config")
+
+	transitive := lockfileFor(t, manager,
+		&LockEntry{Name: "corp", Version: "latest", SHA: strings.Repeat("c", 40), Repository: "corp-repo", Dependencies: []string{"golang-base"}},
+		&LockEntry{Name: "golang-base", Version: "1.2.0", Tag: "v1.2.0", SHA: strings.Repeat("a", 40), Repository: "repo"},
+	)
+	assert.NoError(t, manager.CheckFrozen(transitive, registry, map[string]string{"corp": "*"}))
+
+	local.Version = "1.1.0"
+	err = manager.CheckFrozen(lockfile, registry, nil)
+	assert.ErrorContains(t, err, "local plugin core/base changed")
+}
@@ -19,6 +19,7 @@ type LockEntry struct {
 	Name         string            `yaml:"name"`
 	Version      string            `yaml:"version"`
 	SHA          string            `yaml:"sha"`
+	Tag          string            `yaml:"tag,omitempty"`
 	Repository   string            `yaml:"repository,omitempty"`
 	Path         string            `yaml:"path,omitempty"`
 	Dependencies []string          `yaml:"dependencies,omitempty"`
@@ -52,6 +53,17 @@ func NewManager(baseDir string) *Manager {
 
 // GenerateLockfile creates a lockfile from active plugins
 func (m *Manager) GenerateLockfile(registry *plugins.Registry, activePlugins []string) (*Lockfile, error) {
+	requirements := make(map[string]string, len(activePlugins))
+	for _, name := range activePlugins {
+		requirements[name] = "*"
+	}
+	return m.ResolveLockfile(registry, requirements, nil)
+}
+
+// ResolveLockfile creates a lockfile for plugins matching the version
+// constraints in requirements. Plugins with a repository are pinned to the
+// tag and commit selected from the repository's tags when tags is non-nil.
+func (m *Manager) ResolveLockfile(registry *plugins.Registry, requirements map[string]string, tags plugins.TagLister) (*Lockfile, error) {
 	lockfile := &Lockfile{
 		Version: "1.0",
 		Plugins: make(map[string]*LockEntry),
@@ -61,32 +73,31 @@ func (m *Manager) GenerateLockfile(registry *plugins.Registry, activePlugins []s
 		},
 	}
 
-	// Resolve dependencies to get all plugins that need to be locked
-	allPlugins, err := registry.ResolveDependencies(activePlugins)
+	resolutions, err := plugins.NewResolver(registry, tags).Resolve(requirements)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve dependencies: %w", err)
 	}
 
-	// Generate lock entries for all plugins
-	for _, pluginName := range allPlugins {
-		plugin, exists := registry.GetPlugin(pluginName)
-		if !exists {
-			return nil, fmt.Errorf("plugin %s not found in registry", pluginName)
-		}
+	for pluginName, resolution := range resolutions {
+		plugin := resolution.Plugin
 
-		// Generate deterministic SHA for the plugin
-		sha, err := m.generatePluginSHA(plugin)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate SHA for plugin %s: %w", pluginName, err)
+		sha := resolution.SHA
+		if sha == "" {
+			// Generate deterministic SHA for plugins not pinned to a commit
+			sha, err = m.generatePluginSHA(plugin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate SHA for plugin %s: %w", pluginName, err)
+			}
 		}
 
 		lockfile.Plugins[pluginName] = &LockEntry{
 			Name:         plugin.Name,
-			Version:      plugin.Version,
+			Version:      resolution.Version,
 			SHA:          sha,
+			Tag:          resolution.Tag,
 			Repository:   plugin.Repository,
 			Path:         plugin.Path,
-			Dependencies: plugin.Dependencies,
+			Dependencies: plugin.Dependencies.Names(),
 			Metadata:     plugin.Metadata,
 		}
 	}
@@ -150,6 +161,11 @@ func (m *Manager) VerifyIntegrity(lockfile *Lockfile) error {
 	return nil
 }
 
+// ContentHash returns the integrity hash of a lockfile's plugin entries
+func (m *Manager) ContentHash(lockfile *Lockfile) (string, error) {
+	return m.generateContentHash(lockfile)
+}
+
 // IsUpToDate checks if the lockfile is up to date with the current plugin registry
 func (m *Manager) IsUpToDate(registry *plugins.Registry, activePlugins []string) (bool, error) {
 	currentLockfile, err := m.GenerateLockfile(registry, activePlugins)
@@ -175,7 +191,7 @@ func (m *Manager) generatePluginSHA(plugin *plugins.Plugin) (string, error) {
 		plugin.Version,
 		plugin.Repository,
 		plugin.Path,
-		strings.Join(plugin.Dependencies, ","),
+		canonicalizeDependencies(plugin.Dependencies),
 		m.canonicalizeMetadata(plugin.Metadata),
 	)
 
@@ -197,8 +213,12 @@ func (m *Manager) generateContentHash(lockfile *Lockfile) (string, error) {
 
 	for _, key := range pluginKeys {
 		entry := lockfile.Plugins[key]
-		canonical.WriteString(fmt.Sprintf("plugin:%s|%s|%s\n",
+		canonical.WriteString(fmt.Sprintf("plugin:%s|%s|%s",
 			key, entry.Version, entry.SHA))
+		if entry.Tag != "" {
+			canonical.WriteString("|" + entry.Tag)
+		}
+		canonical.WriteString("\n")
 	}
 
 	hash := sha256.Sum256([]byte(canonical.String()))
@@ -227,3 +247,17 @@ func (m *Manager) canonicalizeMetadata(metadata map[string]string) string {
 
 	return result.String()
 }
+
+// canonicalizeDependencies renders dependencies as "name" or "name@constraint",
+// so manifests using the plain list form hash the same as before constraints existed
+func canonicalizeDependencies(deps plugins.Dependencies) string {
+	parts := make([]string, 0, len(deps))
+	for _, name := range deps.Names() {
+		if constraint := deps[name]; constraint != "" && constraint != "*" {
+			parts = append(parts, name+"@"+constraint)
+		} else {
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, ",")
+}
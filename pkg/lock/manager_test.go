@@ -25,7 +25,7 @@ func TestManager_GenerateLockfile(t *testing.T) {
 	gitPlugin := &plugins.Plugin{
 		Name:         "git",
 		Version:      "2.0.0",
-		Dependencies: []string{"core/base"},
+		Dependencies: plugins.Dependencies{"core/base": "*"},
 	}
 	registry.AddPlugin("core/base", basePlugin)
 	registry.AddPlugin("myorg/git", gitPlugin)
@@ -165,3 +165,39 @@ func TestManager_LoadLockfile_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "lockfile not found")
 }
+
+type staticTagLister []plugins.Tag
+
+func (s staticTagLister) ListTags(string) ([]plugins.Tag, error) {
+	return s, nil
+}
+
+func TestManager_ResolveLockfilePinsTagAndSHA(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "lock-resolve-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	registry := plugins.NewRegistry()
+	registry.AddPlugin("golang-base", &plugins.Plugin{Name: "golang-base", Repository: "https://example.com/golang-base.git"})
+
+	v120, _ := plugins.ParseVersion("1.2.0")
+	v131, _ := plugins.ParseVersion("1.3.1")
+	lister := staticTagLister{
+		{Name: "v1.2.0", Version: v120, SHA: "1111111111111111111111111111111111111111"},
+		{Name: "v1.3.1", Version: v131, SHA: "2222222222222222222222222222222222222222"},
+	}
+
+	manager := NewManager(tempDir)
+	lockfile, err := manager.ResolveLockfile(registry, map[string]string{"golang-base": "~1.2"}, lister)
+	require.NoError(t, err)
+
+	entry := lockfile.Plugins["golang-base"]
+	require.NotNil(t, entry)
+	assert.Equal(t, "1.2.0", entry.Version)
+	assert.Equal(t, "v1.2.0", entry.Tag)
+	assert.Equal(t, "1111111111111111111111111111111111111111", entry.SHA)
+	assert.NoError(t, manager.VerifyIntegrity(lockfile))
+
+	_, err = manager.ResolveLockfile(registry, map[string]string{"golang-base": "^2"}, lister)
+	assert.ErrorContains(t, err, "project requires ^2")
+}
@@ -0,0 +1,148 @@
+// Package logging builds the log/slog loggers the coordination server and
+// node agent write through. Attributes added to a context with With, such as
+// request, workspace and user IDs, are attached to every record logged with
+// that context, so one request's lines can be found together.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config configures a logger
+type Config struct {
+	// Level is debug, info, warn or error; empty means info
+	Level string `yaml:"level,omitempty" json:"level,omitempty"`
+	// Format is json or text; empty means text
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// Output is stdout, stderr or a file path; empty means stderr
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
+	// MaxSize is the size in megabytes at which a log file is rotated; 0
+	// never rotates
+	MaxSize int `yaml:"max_size,omitempty" json:"max_size,omitempty"`
+	// MaxBackups is how many rotated files are kept; 0 keeps all
+	MaxBackups int `yaml:"max_backups,omitempty" json:"max_backups,omitempty"`
+	// MaxAge is how many days rotated files are kept; 0 keeps them forever
+	MaxAge int `yaml:"max_age,omitempty" json:"max_age,omitempty"`
+}
+
+// New builds a logger from cfg. The returned closer closes the log file when
+// Output names one.
+func New(cfg Config) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out io.WriteCloser
+	switch cfg.Output {
+	case "", "stderr":
+		out = nopCloser{os.Stderr}
+	case "stdout":
+		out = nopCloser{os.Stdout}
+	default:
+		file, err := openRotatingFile(cfg.Output, cfg.MaxSize, cfg.MaxBackups, cfg.MaxAge)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = file
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		out.Close()
+		return nil, nil, fmt.Errorf("unknown log format %q, expected json or text", cfg.Format)
+	}
+
+	return slog.New(contextHandler{handler}), out, nil
+}
+
+// Setup builds a logger from cfg and makes it the default, so the slog
+// package functions and the standard log package both write through it
+func Setup(cfg Config) (io.Closer, error) {
+	logger, closer, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return closer, nil
+}
+
+// ParseLevel parses a level name; empty means info
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+type attrsKey struct{}
+
+// With returns ctx carrying attrs, which are added to every record logged
+// with the returned context or one derived from it
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying id, which is logged as request_id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return With(context.WithValue(ctx, requestIDKey{}, id), slog.String("request_id", id))
+}
+
+// RequestID returns the request ID ctx carries, or "" when it has none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the attributes a context carries, and the ID of the
+// trace it's part of, to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_JSONWithContextAttrs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nexus.log")
+	logger, closer, err := New(Config{Level: "warn", Format: "json", Output: path})
+	require.NoError(t, err)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = With(ctx, slog.String("workspace_id", "ws-1"))
+	logger.InfoContext(ctx, "dropped below level")
+	logger.WarnContext(ctx, "clone failed", "error", "boom")
+	require.NoError(t, closer.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "clone failed", record["msg"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "ws-1", record["workspace_id"])
+	assert.Equal(t, "boom", record["error"])
+	assert.Equal(t, "req-1", RequestID(ctx))
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, _, err := New(Config{Level: "loud"})
+	assert.Error(t, err)
+
+	_, _, err = New(Config{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nexus.log")
+	f, err := openRotatingFile(path, 1, 2, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	chunk := []byte(strings.Repeat("x", 600*1024))
+	for i := 0; i < 5; i++ {
+		_, err = f.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	backups, err := filepath.Glob(filepath.Join(dir, "nexus-*.log"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 2, "only max_backups rotated files are kept")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(chunk)), info.Size())
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nexus.log")
+	stale := filepath.Join(dir, "nexus-2020-01-01T00-00-00.000.log")
+	require.NoError(t, os.WriteFile(stale, []byte("old"), 0644))
+
+	f, err := openRotatingFile(path, 1, 0, 7)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte(strings.Repeat("x", 1024*1024)))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("rotate"))
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, stale)
+	backups, err := filepath.Glob(filepath.Join(dir, "nexus-*.log"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+}
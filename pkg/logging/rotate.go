@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated files so they sort by age
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile is a log file that is moved aside once it grows past maxSize.
+// Rotated files are named <name>-<time><ext> beside it and pruned by count
+// and age.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	now  func() time.Time
+}
+
+func openRotatingFile(path string, maxSizeMB, maxBackups, maxAgeDays int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+		now:        time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// rotate moves the current file aside, starts a new one and prunes old ones
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	ext := filepath.Ext(f.path)
+	backup := strings.TrimSuffix(f.path, ext) + "-" + f.now().UTC().Format(backupTimeFormat) + ext
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.prune()
+	return nil
+}
+
+// prune removes rotated files beyond maxBackups or older than maxAge
+func (f *rotatingFile) prune() {
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		path    string
+		rotated time.Time
+	}
+	var backups []backup
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(match, prefix), ext)
+		if rotated, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, backup{match, rotated})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotated.After(backups[j].rotated) })
+
+	cutoff := f.now().Add(-f.maxAge)
+	for i, b := range backups {
+		if (f.maxBackups > 0 && i >= f.maxBackups) || (f.maxAge > 0 && b.rotated.Before(cutoff)) {
+			os.Remove(b.path)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxCaptureBody bounds a capture request, which holds one entry or a batch
+const maxCaptureBody = 1 << 20
+
+// Validate fills in the defaults for an entry reported by an agent hook and
+// rejects one that can't be reported on
+func Validate(entry *UsageLog) error {
+	if entry.Invocation.Name == "" {
+		return errors.New("invocation name is required")
+	}
+	switch InvocationType(entry.Invocation.Type) {
+	case InvocationSkill, InvocationCommand, InvocationRule:
+	case "":
+		entry.Invocation.Type = string(InvocationSkill)
+	default:
+		return fmt.Errorf("unknown invocation type %q, expected skill, command or rule", entry.Invocation.Type)
+	}
+	if entry.Timestamp != "" {
+		if _, err := time.Parse(time.RFC3339, entry.Timestamp); err != nil {
+			return fmt.Errorf("timestamp %q is not RFC 3339", entry.Timestamp)
+		}
+	}
+	if entry.Outcome.Duration < 0 || entry.Outcome.TokensUsed < 0 || entry.Outcome.Cost < 0 {
+		return errors.New("duration, tokens and cost can't be negative")
+	}
+	return nil
+}
+
+// NewCaptureHandler serves POST /api/v1/usage, which records the usage entry
+// or JSON array of entries in the request body. Every entry is validated
+// before any is recorded. Requests must be sent as application/json, which a
+// web page can't do cross-origin without a preflight this handler never
+// answers, so pages open in a browser can't report usage to a TCP listener.
+func NewCaptureHandler(logger *Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("POST /api/v1/usage", func(w http.ResponseWriter, r *http.Request) {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeCaptureError(w, http.StatusUnsupportedMediaType, errors.New("usage must be sent with Content-Type: application/json"))
+			return
+		}
+		entries, err := decodeEntries(http.MaxBytesReader(w, r.Body, maxCaptureBody))
+		if err != nil {
+			writeCaptureError(w, http.StatusBadRequest, err)
+			return
+		}
+		for i := range entries {
+			if err := Validate(&entries[i]); err != nil {
+				writeCaptureError(w, http.StatusBadRequest, fmt.Errorf("entry %d: %w", i, err))
+				return
+			}
+		}
+		for _, entry := range entries {
+			if err := logger.Log(entry); err != nil {
+				writeCaptureError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]int{"recorded": len(entries)})
+	})
+	return mux
+}
+
+// decodeEntries reads one entry or an array of them
+func decodeEntries(r io.Reader) ([]UsageLog, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" {
+		return nil, errors.New("request body is empty")
+	}
+
+	if strings.HasPrefix(trimmed, "[") {
+		var entries []UsageLog
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("invalid usage entries: %w", err)
+		}
+		return entries, nil
+	}
+	var entry UsageLog
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("invalid usage entry: %w", err)
+	}
+	return []UsageLog{entry}, nil
+}
+
+func writeCaptureError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// ListenCapture listens on addr, a host:port or "unix:" followed by a socket
+// path. A stale socket left by a server that didn't shut down cleanly is
+// replaced.
+func ListenCapture(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a usage capture server is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return net.Listen("unix", path)
+}
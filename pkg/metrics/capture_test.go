@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureHandler(t *testing.T) {
+	logger := NewLogger(t.TempDir())
+	handler := NewCaptureHandler(logger)
+
+	postAs := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/usage", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	post := func(body string) *httptest.ResponseRecorder {
+		return postAs("application/json; charset=utf-8", body)
+	}
+
+	// A cross-origin form post from a browser can't set a JSON content type
+	rec := postAs("text/plain", `{"invocation":{"name":"injected"}}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	rec = post(`{"agent":"sisyphus","invocation":{"name":"analyze-codebase"},"outcome":{"success":true,"duration":1200}}`)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec = post(`[{"invocation":{"type":"command","name":"/review"}},{"invocation":{"type":"rule","name":"go-style"}}]`)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.JSONEq(t, `{"recorded":2}`, rec.Body.String())
+
+	// One bad entry rejects the whole batch
+	rec = post(`[{"invocation":{"name":"ok"}},{"invocation":{"type":"macro","name":"bad"}}]`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "entry 1")
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"invocation":{}}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`not json`).Code)
+
+	entries, err := logger.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "skill", entries[0].Invocation.Type, "type defaults to skill")
+}
+
+func TestListenCapture_UnixSocket(t *testing.T) {
+	// t.TempDir can exceed the roughly 100 byte limit on socket paths
+	dir, err := os.MkdirTemp("", "nexus")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	addr := "unix:" + filepath.Join(dir, "usage.sock")
+
+	listener, err := ListenCapture(addr)
+	require.NoError(t, err)
+	_, err = ListenCapture(addr)
+	assert.Error(t, err, "a live socket is not replaced")
+	require.NoError(t, listener.Close())
+}
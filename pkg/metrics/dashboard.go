@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// Dashboard is a period report with the series the HTML report charts:
+// invocations, success, tokens and cost by day, and invocations by hour
+type Dashboard struct {
+	From          string              `json:"from"`
+	To            string              `json:"to"`
+	Days          int                 `json:"days"`
+	Metrics       ProductivityMetrics `json:"metrics"`
+	Patterns      []UsagePattern      `json:"patterns"`
+	Daily         []DailyTrend        `json:"daily"`
+	Hours         [24]int             `json:"hours"`
+	PeakTimeOfDay string              `json:"peakTimeOfDay"`
+}
+
+// DailyTrend is one day of a Dashboard, including days without usage
+type DailyTrend struct {
+	Date        string  `json:"date"`
+	Invocations int     `json:"invocations"`
+	SuccessRate float64 `json:"successRate"`
+	TokensUsed  int64   `json:"tokensUsed"`
+	Cost        float64 `json:"cost"`
+}
+
+// GenerateDashboard reports on the last days of usage, day by day in the
+// local time zone
+func (r *Reporter) GenerateDashboard(source Source, days int) (*Dashboard, error) {
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -days)
+
+	logs, err := source.Query(Filter{
+		StartTime: startDate,
+		EndTime:   endDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs: %w", err)
+	}
+
+	metrics, err := r.calculator.Calculate(logs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate metrics: %w", err)
+	}
+	patterns, err := r.analyzer.AnalyzePatterns(logs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze patterns: %w", err)
+	}
+
+	dashboard := &Dashboard{
+		From:          startDate.Format("2006-01-02"),
+		To:            endDate.Format("2006-01-02"),
+		Days:          days,
+		Metrics:       *metrics,
+		Patterns:      patterns,
+		Daily:         dailyTrends(logs, startDate, endDate),
+		PeakTimeOfDay: r.analyzer.getPeakTimeOfDay(logs),
+	}
+	for _, log := range logs {
+		if t, err := time.Parse(time.RFC3339, log.Timestamp); err == nil {
+			dashboard.Hours[t.In(endDate.Location()).Hour()]++
+		}
+	}
+	return dashboard, nil
+}
+
+// dailyTrends totals logs by day from start to end, with a zero entry for
+// each day nothing was logged
+func dailyTrends(logs []UsageLog, start, end time.Time) []DailyTrend {
+	var trends []DailyTrend
+	index := make(map[string]int)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		index[date] = len(trends)
+		trends = append(trends, DailyTrend{Date: date})
+	}
+	if date := end.Format("2006-01-02"); trends[len(trends)-1].Date != date {
+		index[date] = len(trends)
+		trends = append(trends, DailyTrend{Date: date})
+	}
+
+	successes := make([]int, len(trends))
+	for _, log := range logs {
+		t, err := time.Parse(time.RFC3339, log.Timestamp)
+		if err != nil {
+			continue
+		}
+		i, ok := index[t.In(end.Location()).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		trends[i].Invocations++
+		trends[i].TokensUsed += log.Outcome.TokensUsed
+		trends[i].Cost += log.Outcome.Cost
+		if log.Outcome.Success {
+			successes[i]++
+		}
+	}
+	for i := range trends {
+		if trends[i].Invocations > 0 {
+			trends[i].SuccessRate = float64(successes[i]) / float64(trends[i].Invocations) * 100
+		}
+	}
+	return trends
+}
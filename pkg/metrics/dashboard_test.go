@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDashboard(t *testing.T) {
+	logger := NewLogger(t.TempDir())
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	entries := []UsageLog{
+		{Timestamp: yesterday.Format(time.RFC3339), Invocation: Invocation{Type: "skill", Name: "go-testing"}, Outcome: Outcome{Success: true, TokensUsed: 100, Cost: 0.25}},
+		{Timestamp: now.Format(time.RFC3339), Invocation: Invocation{Type: "skill", Name: "go-testing"}, Outcome: Outcome{Success: true, TokensUsed: 200}},
+		{Timestamp: now.Format(time.RFC3339), Invocation: Invocation{Type: "command", Name: "/review"}, Outcome: Outcome{Success: false}},
+	}
+	for _, entry := range entries {
+		require.NoError(t, logger.Log(entry))
+	}
+
+	dashboard, err := NewReporter().GenerateDashboard(logger, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), dashboard.Metrics.TotalInvocations)
+	require.Len(t, dashboard.Daily, 4, "every day in the period, empty or not")
+	assert.Equal(t, now.Format("2006-01-02"), dashboard.To)
+
+	assert.Equal(t, DailyTrend{Date: yesterday.Format("2006-01-02"), Invocations: 1, SuccessRate: 100, TokensUsed: 100, Cost: 0.25}, dashboard.Daily[2])
+	today := dashboard.Daily[3]
+	assert.Equal(t, 2, today.Invocations)
+	assert.Equal(t, float64(50), today.SuccessRate)
+	assert.Equal(t, DailyTrend{Date: dashboard.Daily[0].Date}, dashboard.Daily[0])
+
+	assert.Equal(t, 3, dashboard.Hours[now.Hour()], "yesterday's entry was logged at the same hour")
+	assert.Equal(t, NewAnalyzer().getTimeOfDay(now.Hour()), dashboard.PeakTimeOfDay)
+}
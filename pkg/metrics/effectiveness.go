@@ -0,0 +1,380 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MinSamples is how many invocations each side of a comparison needs before
+// its confidence intervals can be trusted; effects with fewer carry a warning
+const MinSamples = 30
+
+// CutPoint is a moment that may have changed how agents work, such as a
+// plugin added to or updated in nexus.lock
+type CutPoint struct {
+	Time   time.Time `json:"time"`
+	Plugin string    `json:"plugin"`
+	// Change says what happened, e.g. "updated 1.0.0 → 1.1.0"; it is empty
+	// for dates given by hand
+	Change string `json:"change,omitempty"`
+}
+
+// Estimate is how one measure differs between usage before and after a cut
+// point, with the 95% confidence interval of the difference
+type Estimate struct {
+	Before     float64 `json:"before"`
+	After      float64 `json:"after"`
+	Difference float64 `json:"difference"`
+	Low        float64 `json:"low"`
+	High       float64 `json:"high"`
+	// Significant is set when the interval excludes no change
+	Significant bool `json:"significant"`
+}
+
+// Effect compares usage in the window before a cut point with the window
+// after it: all usage for the plugin's effect, or one of its skills
+type Effect struct {
+	Cut CutPoint `json:"cut"`
+	// Skill is empty for the plugin's effect on all usage
+	Skill         string `json:"skill,omitempty"`
+	BeforeSamples int    `json:"beforeSamples"`
+	AfterSamples  int    `json:"afterSamples"`
+	// SuccessRate differs in percentage points
+	SuccessRate *Estimate `json:"successRate,omitempty"`
+	// Duration differs in milliseconds per invocation
+	Duration *Estimate `json:"duration,omitempty"`
+	// Tokens differ per invocation, over the invocations that report them
+	Tokens   *Estimate `json:"tokens,omitempty"`
+	Warnings []string  `json:"warnings,omitempty"`
+}
+
+// Capability is a rule, skill or command installed in the project by plugin
+type Capability struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Plugin string `json:"plugin,omitempty"`
+}
+
+// EffectivenessReport is the A/B analysis of a project's cut points
+type EffectivenessReport struct {
+	WindowDays      int          `json:"windowDays"`
+	Effects         []Effect     `json:"effects"`
+	Unused          []Capability `json:"unused,omitempty"`
+	Recommendations []string     `json:"recommendations"`
+}
+
+// CompareEffects measures each cut point's effect over window on either side
+// of it: on all usage, and on each skill of the cut's plugin used on both
+// sides. A skill belongs to a plugin when its category is the plugin's name
+// or it has the "<plugin>-<item>" name nexus renders plugin skills under.
+func (a *Analyzer) CompareEffects(logs []UsageLog, cuts []CutPoint, window time.Duration) []Effect {
+	var effects []Effect
+	for _, cut := range cuts {
+		var before, after []UsageLog
+		for _, log := range logs {
+			t, err := time.Parse(time.RFC3339, log.Timestamp)
+			if err != nil {
+				continue
+			}
+			switch {
+			case !t.Before(cut.Time.Add(-window)) && t.Before(cut.Time):
+				before = append(before, log)
+			case !t.Before(cut.Time) && t.Before(cut.Time.Add(window)):
+				after = append(after, log)
+			}
+		}
+		effects = append(effects, compareLogs(cut, "", before, after))
+
+		beforeBySkill, afterBySkill := a.groupBySkill(before), a.groupBySkill(after)
+		var skills []string
+		for skill, skillLogs := range afterBySkill {
+			if len(beforeBySkill[skill]) > 0 && fromPlugin(skillLogs[0].Invocation, cut.Plugin) {
+				skills = append(skills, skill)
+			}
+		}
+		sort.Strings(skills)
+		for _, skill := range skills {
+			effects = append(effects, compareLogs(cut, skill, beforeBySkill[skill], afterBySkill[skill]))
+		}
+	}
+	return effects
+}
+
+// fromPlugin reports whether invocation is of a skill that plugin provides
+func fromPlugin(invocation Invocation, plugin string) bool {
+	if invocation.Category == plugin {
+		return true
+	}
+	return plugin != "" && strings.HasPrefix(capabilityName(invocation.Name), renderedPrefix(plugin))
+}
+
+// renderedPrefix starts the names nexus renders plugin's skills under
+func renderedPrefix(plugin string) string {
+	return strings.ReplaceAll(plugin, "/", "-") + "-"
+}
+
+func compareLogs(cut CutPoint, skill string, before, after []UsageLog) Effect {
+	effect := Effect{Cut: cut, Skill: skill, BeforeSamples: len(before), AfterSamples: len(after)}
+
+	successes := func(logs []UsageLog) int {
+		n := 0
+		for _, log := range logs {
+			if log.Outcome.Success {
+				n++
+			}
+		}
+		return n
+	}
+	effect.SuccessRate = proportionDifference(successes(before), len(before), successes(after), len(after))
+
+	durations := func(logs []UsageLog) []float64 {
+		values := make([]float64, 0, len(logs))
+		for _, log := range logs {
+			values = append(values, float64(log.Outcome.Duration))
+		}
+		return values
+	}
+	effect.Duration = meanDifference(durations(before), durations(after))
+
+	tokens := func(logs []UsageLog) []float64 {
+		var values []float64
+		for _, log := range logs {
+			if log.Outcome.TokensUsed > 0 {
+				values = append(values, float64(log.Outcome.TokensUsed))
+			}
+		}
+		return values
+	}
+	effect.Tokens = meanDifference(tokens(before), tokens(after))
+
+	if len(before) < MinSamples {
+		effect.Warnings = append(effect.Warnings, fmt.Sprintf("only %d invocations before, fewer than %d", len(before), MinSamples))
+	}
+	if len(after) < MinSamples {
+		effect.Warnings = append(effect.Warnings, fmt.Sprintf("only %d invocations after, fewer than %d", len(after), MinSamples))
+	}
+	return effect
+}
+
+// GenerateEffectiveness compares usage in the windowDays before and after
+// each cut point, and recommends changes from the whole period
+func (r *Reporter) GenerateEffectiveness(source Source, cuts []CutPoint, windowDays int) (*EffectivenessReport, error) {
+	window := time.Duration(windowDays) * 24 * time.Hour
+	endDate := time.Now()
+	startDate := endDate.Add(-window)
+	for _, cut := range cuts {
+		if cut.Time.Add(-window).Before(startDate) {
+			startDate = cut.Time.Add(-window)
+		}
+	}
+
+	logs, err := source.Query(Filter{
+		StartTime: startDate,
+		EndTime:   endDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs: %w", err)
+	}
+
+	effects := r.analyzer.CompareEffects(logs, cuts, window)
+	report := &EffectivenessReport{
+		WindowDays:      windowDays,
+		Effects:         effects,
+		Unused:          r.unused(logs),
+		Recommendations: effectInsights(effects),
+	}
+	report.Recommendations = append(report.Recommendations, r.recommendations(logs)...)
+	return report, nil
+}
+
+// effectInsights describes the effects whose intervals exclude no change
+// and which have enough invocations on both sides to be trusted
+func effectInsights(effects []Effect) []string {
+	var insights []string
+	for _, effect := range effects {
+		if len(effect.Warnings) > 0 {
+			continue
+		}
+		subject := "all usage"
+		if effect.Skill != "" {
+			subject = effect.Skill
+		}
+		when := strings.TrimSpace(effect.Cut.Plugin+" "+effect.Cut.Change) + " on " + effect.Cut.Time.Format("2006-01-02")
+
+		if e := effect.SuccessRate; e != nil && e.Significant {
+			icon := "✅"
+			if e.Difference < 0 {
+				icon = "⚠️ "
+			}
+			insights = append(insights, fmt.Sprintf("%s After %s, %s success rate changed %+.1f pts (95%% CI %+.1f to %+.1f)",
+				icon, when, subject, e.Difference, e.Low, e.High))
+		}
+		if e := effect.Duration; e != nil && e.Significant {
+			icon := "⚡"
+			if e.Difference > 0 {
+				icon = "⚠️ "
+			}
+			insights = append(insights, fmt.Sprintf("%s After %s, %s took %+.1fs per invocation (95%% CI %+.1fs to %+.1fs)",
+				icon, when, subject, e.Difference/1000, e.Low/1000, e.High/1000))
+		}
+		if e := effect.Tokens; e != nil && e.Significant {
+			icon := "📉"
+			if e.Difference > 0 {
+				icon = "📈"
+			}
+			insights = append(insights, fmt.Sprintf("%s After %s, %s used %+.0f tokens per invocation (95%% CI %+.0f to %+.0f)",
+				icon, when, subject, e.Difference, e.Low, e.High))
+		}
+	}
+	return insights
+}
+
+// minFailureSamples is how many invocations a skill needs before its failure
+// rate is worth a recommendation
+const minFailureSamples = 10
+
+// recommendations are the changes logs give evidence for: skills that fail
+// often and installed capabilities that were never used
+func (r *Reporter) recommendations(logs []UsageLog) []string {
+	recommendations := failingSkills(r.analyzer.groupBySkill(logs))
+
+	byType := make(map[string][]string)
+	for _, c := range r.unused(logs) {
+		byType[c.Type] = append(byType[c.Type], c.Name)
+	}
+	for _, kind := range []string{string(InvocationRule), string(InvocationSkill), string(InvocationCommand)} {
+		if names := byType[kind]; len(names) > 0 {
+			recommendations = append(recommendations, fmt.Sprintf("🧹 %s never triggered in this period: %s; consider disabling them",
+				capitalize(kind)+"s", strings.Join(names, ", ")))
+		}
+	}
+	return recommendations
+}
+
+// failingSkills describes the skills that fail at least a fifth of the time
+// with 95% confidence
+func failingSkills(bySkill map[string][]UsageLog) []string {
+	skills := make([]string, 0, len(bySkill))
+	for skill := range bySkill {
+		skills = append(skills, skill)
+	}
+	sort.Strings(skills)
+
+	var failing []string
+	for _, skill := range skills {
+		logs := bySkill[skill]
+		if len(logs) < minFailureSamples {
+			continue
+		}
+		failures := 0
+		for _, log := range logs {
+			if !log.Outcome.Success {
+				failures++
+			}
+		}
+		low, high := wilsonInterval(failures, len(logs))
+		if low < 0.2 {
+			continue
+		}
+		failing = append(failing, fmt.Sprintf("🔧 Skill %s fails often: %d of %d invocations (95%% CI %.0f%%–%.0f%%); review its instructions",
+			skill, failures, len(logs), low*100, high*100))
+	}
+	return failing
+}
+
+// unused lists the installed capabilities no entry in logs invoked, under
+// either their own name or the one nexus rendered them under. Transcripts
+// only record skills and commands, and rules only arrive from agent hooks, so
+// capabilities of a type no entry records are left out rather than reported.
+func (r *Reporter) unused(logs []UsageLog) []Capability {
+	recorded := make(map[string]bool)
+	used := make(map[Capability]bool)
+	for _, log := range logs {
+		recorded[log.Invocation.Type] = true
+		used[Capability{Type: log.Invocation.Type, Name: capabilityName(log.Invocation.Name)}] = true
+	}
+	var unused []Capability
+	for _, c := range r.installed {
+		if !recorded[c.Type] {
+			continue
+		}
+		name := capabilityName(c.Name)
+		if used[Capability{Type: c.Type, Name: name}] {
+			continue
+		}
+		if c.Plugin != "" && used[Capability{Type: c.Type, Name: renderedPrefix(c.Plugin) + name}] {
+			continue
+		}
+		unused = append(unused, c)
+	}
+	return unused
+}
+
+// capabilityName drops the slash of a command and the plugin prefix of a
+// skill, so "/review" and "superpowers:review" both match an installed
+// review
+func capabilityName(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// InstalledCapabilities lists the rules, skills and commands plugin installs
+// from the rules/, skills/ and commands/ directories under each of dirs,
+// named after their files without extensions. Missing directories are
+// skipped.
+func InstalledCapabilities(plugin string, dirs ...string) ([]Capability, error) {
+	seen := make(map[Capability]bool)
+	var capabilities []Capability
+	kinds := map[string]string{
+		"rules":    string(InvocationRule),
+		"skills":   string(InvocationSkill),
+		"commands": string(InvocationCommand),
+	}
+	for _, dir := range dirs {
+		for subdir, kind := range kinds {
+			root := filepath.Join(dir, subdir)
+			if _, err := os.Stat(root); os.IsNotExist(err) {
+				continue
+			}
+			err := filepath.WalkDir(root, func(_ string, d os.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+					return nil
+				}
+				name, _, _ := strings.Cut(d.Name(), ".")
+				c := Capability{Type: kind, Name: name, Plugin: plugin}
+				if !seen[c] {
+					seen[c] = true
+					capabilities = append(capabilities, c)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", root, err)
+			}
+		}
+	}
+	sort.Slice(capabilities, func(i, j int) bool {
+		if capabilities[i].Type != capabilities[j].Type {
+			return capabilities[i].Type < capabilities[j].Type
+		}
+		return capabilities[i].Name < capabilities[j].Name
+	})
+	return capabilities, nil
+}
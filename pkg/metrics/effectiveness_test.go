@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeanDifference(t *testing.T) {
+	e := meanDifference([]float64{10, 12, 14}, []float64{20, 22, 24})
+	require.NotNil(t, e)
+	assert.Equal(t, float64(10), e.Difference)
+	// Welch df is 4, so t = 2.776 and se = sqrt(4/3 + 4/3)
+	assert.InDelta(t, 10-2.776*1.63299, e.Low, 1e-3)
+	assert.InDelta(t, 10+2.776*1.63299, e.High, 1e-3)
+	assert.True(t, e.Significant)
+
+	assert.Nil(t, meanDifference([]float64{1}, []float64{1, 2}))
+}
+
+func TestProportionDifference(t *testing.T) {
+	e := proportionDifference(50, 100, 60, 100)
+	require.NotNil(t, e)
+	assert.InDelta(t, 10, e.Difference, 1e-9)
+	assert.InDelta(t, 10-13.7, e.Low, 0.1)
+	assert.False(t, e.Significant)
+
+	assert.Nil(t, proportionDifference(0, 0, 1, 1))
+}
+
+func TestWilsonInterval(t *testing.T) {
+	low, high := wilsonInterval(12, 20)
+	assert.InDelta(t, 0.387, low, 1e-3)
+	assert.InDelta(t, 0.781, high, 1e-3)
+
+	low, high = wilsonInterval(0, 10)
+	assert.Equal(t, float64(0), low)
+	assert.Greater(t, high, 0.0)
+}
+
+func TestCompareEffects(t *testing.T) {
+	cut := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+	var logs []UsageLog
+	add := func(day int, name, category string, success bool, duration int64) {
+		logs = append(logs, UsageLog{
+			ID:         fmt.Sprintf("%d-%d", day, len(logs)),
+			Timestamp:  cut.AddDate(0, 0, day).Add(time.Duration(len(logs)) * time.Minute).Format(time.RFC3339),
+			Invocation: Invocation{Type: "skill", Name: name, Category: category},
+			Outcome:    Outcome{Success: success, Duration: duration},
+		})
+	}
+	// Before the cut, golang:test fails half the time; after, it always succeeds
+	for i := 0; i < 40; i++ {
+		add(-1-i%7, "golang:test", "golang", i%2 == 0, 5000+int64(i%3)*100)
+		add(i%7, "golang:test", "golang", true, 3000+int64(i%3)*100)
+	}
+	add(-1, "review", "", true, 1000)
+	add(1, "review", "", true, 1000)
+	add(-2, "golang-lint", "", true, 1000)
+	add(2, "golang-lint", "", true, 1000)
+	add(-30, "golang:test", "golang", false, 9000)
+
+	effects := NewAnalyzer().CompareEffects(logs, []CutPoint{{Time: cut, Plugin: "golang", Change: "updated 1.0.0 → 1.1.0"}}, 14*24*time.Hour)
+	require.Len(t, effects, 3, "all usage, then the plugin's own skills")
+
+	all := effects[0]
+	assert.Empty(t, all.Skill)
+	assert.Equal(t, 42, all.BeforeSamples, "the entry a month before is outside the window")
+	assert.Equal(t, 42, all.AfterSamples)
+
+	assert.Equal(t, "golang-lint", effects[1].Skill, "skills nexus rendered for the plugin belong to it")
+
+	skill := effects[2]
+	assert.Equal(t, "golang:test", skill.Skill)
+	assert.Equal(t, 40, skill.BeforeSamples)
+	require.NotNil(t, skill.SuccessRate)
+	assert.InDelta(t, 50, skill.SuccessRate.Difference, 1e-9)
+	assert.True(t, skill.SuccessRate.Significant)
+	require.NotNil(t, skill.Duration)
+	assert.InDelta(t, -2000, skill.Duration.Difference, 1e-9)
+	assert.True(t, skill.Duration.Significant)
+	assert.Nil(t, skill.Tokens, "no entry reported tokens")
+	assert.Empty(t, skill.Warnings)
+
+	insights := effectInsights(effects)
+	assert.Contains(t, insights, "✅ After golang updated 1.0.0 → 1.1.0 on 2026-05-10, golang:test success rate changed +50.0 pts (95% CI +34.5 to +65.5)")
+
+	few := NewAnalyzer().CompareEffects(logs[:10], []CutPoint{{Time: cut, Plugin: "golang"}}, 14*24*time.Hour)
+	assert.Contains(t, few[0].Warnings, "only 5 invocations before, fewer than 30")
+	assert.Empty(t, effectInsights(few), "effects with too few invocations aren't reported as findings")
+}
+
+func TestRecommendations(t *testing.T) {
+	var logs []UsageLog
+	for i := 0; i < 20; i++ {
+		logs = append(logs, UsageLog{Invocation: Invocation{Type: "skill", Name: "flaky"}, Outcome: Outcome{Success: i%5 == 0}})
+		logs = append(logs, UsageLog{Invocation: Invocation{Type: "skill", Name: "solid"}, Outcome: Outcome{Success: i != 0}})
+	}
+	logs = append(logs, UsageLog{Invocation: Invocation{Type: "command", Name: "/review"}, Outcome: Outcome{Success: true}})
+	logs = append(logs, UsageLog{Invocation: Invocation{Type: "rule", Name: "nexus:tdd"}, Outcome: Outcome{Success: true}})
+
+	reporter := NewReporter().WithInstalled([]Capability{
+		{Type: "command", Name: "review"},
+		{Type: "rule", Name: "tdd"},
+		{Type: "rule", Name: "security"},
+		{Type: "rule", Name: "style"},
+		{Type: "skill", Name: "flaky"},
+	})
+	recommendations := reporter.recommendations(logs)
+	require.Len(t, recommendations, 2)
+	assert.True(t, strings.HasPrefix(recommendations[0], "🔧 Skill flaky fails often: 16 of 20 invocations"), recommendations[0])
+	assert.Equal(t, "🧹 Rules never triggered in this period: security, style; consider disabling them", recommendations[1])
+
+	assert.Empty(t, NewReporter().recommendations(logs[:5]), "too few invocations and nothing installed to check")
+
+	rendered := []UsageLog{{Invocation: Invocation{Type: "skill", Name: "base-review"}, Outcome: Outcome{Success: true}}}
+	reporter = NewReporter().WithInstalled([]Capability{
+		{Type: "skill", Name: "review", Plugin: "base"},
+		{Type: "skill", Name: "lint", Plugin: "base"},
+		{Type: "rule", Name: "tdd", Plugin: "base"},
+	})
+	assert.Equal(t, []Capability{{Type: "skill", Name: "lint", Plugin: "base"}}, reporter.unused(rendered),
+		"skills match their rendered names, and rules aren't reported when nothing records rules")
+}
+
+func TestInstalledCapabilities(t *testing.T) {
+	templates := t.TempDir()
+	agents := t.TempDir()
+	write := func(dir, path string) {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, nil, 0644))
+	}
+	write(templates, "rules/tdd.md")
+	write(templates, "rules/nexus/security.md.tpl")
+	write(templates, "skills/web-search.yaml")
+	write(templates, "commands/.gitkeep")
+	write(agents, "rules/tdd.md")
+
+	capabilities, err := InstalledCapabilities("base", templates, agents, filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Equal(t, []Capability{
+		{Type: "rule", Name: "security", Plugin: "base"},
+		{Type: "rule", Name: "tdd", Plugin: "base"},
+		{Type: "skill", Name: "web-search", Plugin: "base"},
+	}, capabilities)
+}
@@ -0,0 +1,332 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format is how a report is written out
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatTable    Format = "table"
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// Formats lists every Format, in the order help text shows them
+var Formats = []Format{FormatJSON, FormatTable, FormatCSV, FormatMarkdown, FormatHTML}
+
+func ParseFormat(s string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(s, string(format)) {
+			return format, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("unknown format %q (use %s)", s, strings.Join(names, ", "))
+}
+
+// Report is the period report behind `nexus usage metrics`
+type Report struct {
+	Summary  *DailySummary        `json:"summary"`
+	Metrics  *ProductivityMetrics `json:"metrics"`
+	Patterns []UsagePattern       `json:"patterns"`
+}
+
+// Table is one section of a report in rows and columns, which every format
+// but JSON is written from
+type Table struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// Render writes report in format. Report is one of the reports the Reporter
+// generates: a *DailySummary, *Report, []UsagePattern, *BenchmarkComparison,
+// *TeamReport, *EffectivenessReport or *Dashboard. HTML renders a *Dashboard
+// with charts and any other report as tables.
+func Render(w io.Writer, format Format, title string, report interface{}) error {
+	if format == FormatJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	tables, err := Tables(report)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatTable:
+		return writeText(w, tables)
+	case FormatCSV:
+		return writeCSV(w, tables)
+	case FormatMarkdown:
+		return writeMarkdown(w, title, tables)
+	case FormatHTML:
+		dashboard, _ := report.(*Dashboard)
+		return writeHTML(w, title, tables, dashboard)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// Tables splits a report into the sections the text formats print
+func Tables(report interface{}) ([]Table, error) {
+	switch r := report.(type) {
+	case *DailySummary:
+		return summaryTables(r), nil
+	case *Report:
+		tables := []Table{metricsTable("Metrics", r.Metrics)}
+		if r.Summary != nil {
+			tables = append(tables, summaryTables(r.Summary)[1:]...)
+		}
+		return append(tables, patternsTable(r.Patterns)), nil
+	case []UsagePattern:
+		return []Table{patternsTable(r)}, nil
+	case *BenchmarkComparison:
+		return []Table{benchmarkTable(r)}, nil
+	case *TeamReport:
+		tables := []Table{
+			metricsTable("Metrics", &r.Metrics),
+			patternsTable(r.Patterns),
+			adoptionTable(r.Adoption),
+		}
+		if len(r.ByUser) > 0 {
+			tables = append(tables, userUsageTable(r.ByUser))
+		}
+		return tables, nil
+	case *EffectivenessReport:
+		recommendations := Table{Title: "Recommendations", Columns: []string{"Recommendation"}}
+		for _, recommendation := range r.Recommendations {
+			recommendations.Rows = append(recommendations.Rows, []string{recommendation})
+		}
+		return []Table{effectsTable(r.Effects), recommendations}, nil
+	case *Dashboard:
+		return []Table{
+			metricsTable("Metrics", &r.Metrics),
+			trendTable(r.Daily),
+			patternsTable(r.Patterns),
+		}, nil
+	default:
+		return nil, fmt.Errorf("can't render a %T report", report)
+	}
+}
+
+func summaryTables(s *DailySummary) []Table {
+	tables := []Table{metricsTable("Summary for "+s.Date, &s.Metrics)}
+	top := Table{Title: "Top skills", Columns: []string{"Skill", "Invocations"}}
+	for _, skill := range s.TopSkills {
+		top.Rows = append(top.Rows, []string{skill.Skill, strconv.Itoa(skill.Count)})
+	}
+	insights := Table{Title: "Insights", Columns: []string{"Insight"}}
+	for _, insight := range s.Insights {
+		insights.Rows = append(insights.Rows, []string{insight})
+	}
+	return append(tables, top, insights)
+}
+
+func metricsTable(title string, m *ProductivityMetrics) Table {
+	table := Table{Title: title, Columns: []string{"Metric", "Value"}}
+	if m == nil {
+		return table
+	}
+	table.Rows = [][]string{
+		{"Total invocations", strconv.FormatInt(m.TotalInvocations, 10)},
+		{"Skill invocations", strconv.FormatInt(m.SkillInvocations, 10)},
+		{"Command invocations", strconv.FormatInt(m.CommandInvocations, 10)},
+		{"Rule applications", strconv.FormatInt(m.RuleApplications, 10)},
+		{"Success rate", percent(m.SuccessRate)},
+		{"Average duration", seconds(m.AverageDuration)},
+		{"Total duration", seconds(float64(m.TotalDuration))},
+		{"Invocations per hour", fmt.Sprintf("%.1f", m.InvocationsPerHour)},
+		{"Tokens used", strconv.FormatInt(m.TotalTokensUsed, 10)},
+		{"Tokens per task", fmt.Sprintf("%.0f", m.TokensPerTask)},
+		{"Cost", dollars(m.TotalCost)},
+	}
+	return table
+}
+
+func patternsTable(patterns []UsagePattern) Table {
+	table := Table{Title: "Skill patterns", Columns: []string{"Skill", "Invocations", "Success rate", "Average duration", "Peak time"}}
+	for _, p := range patterns {
+		table.Rows = append(table.Rows, []string{
+			p.Skill, strconv.Itoa(p.Frequency), percent(p.SuccessRate), seconds(p.AverageDuration), p.TimeOfDay,
+		})
+	}
+	return table
+}
+
+func benchmarkTable(b *BenchmarkComparison) Table {
+	return Table{
+		Title:   "Benchmark",
+		Columns: []string{"Metric", "Baseline", "Current", "Improvement"},
+		Rows: [][]string{
+			{"Invocations", strconv.FormatInt(b.Baseline.TotalInvocations, 10), strconv.FormatInt(b.Current.TotalInvocations, 10), ""},
+			{"Average duration", seconds(b.Baseline.AverageDuration), seconds(b.Current.AverageDuration), signedPercent(b.PercentImprovement.Duration)},
+			{"Success rate", percent(b.Baseline.SuccessRate), percent(b.Current.SuccessRate), fmt.Sprintf("%+.1f pts", b.PercentImprovement.SuccessRate)},
+			{"Tokens per task", fmt.Sprintf("%.0f", b.Baseline.TokensPerTask), fmt.Sprintf("%.0f", b.Current.TokensPerTask), signedPercent(b.PercentImprovement.TokensPerTask)},
+			{"Cost", dollars(b.Baseline.TotalCost), dollars(b.Current.TotalCost), ""},
+		},
+	}
+}
+
+func adoptionTable(adoption []Adoption) Table {
+	table := Table{Title: "Adoption", Columns: []string{"Type", "Name", "Users", "Invocations", "Success rate"}}
+	for _, a := range adoption {
+		table.Rows = append(table.Rows, []string{
+			a.Type, a.Name, strconv.Itoa(a.Users), strconv.Itoa(a.Invocations), percent(a.SuccessRate),
+		})
+	}
+	return table
+}
+
+func userUsageTable(users []UserUsage) Table {
+	table := Table{Title: "By member", Columns: []string{"User", "Invocations", "Success rate", "Tokens used", "Cost"}}
+	for _, u := range users {
+		table.Rows = append(table.Rows, []string{
+			u.User, strconv.Itoa(u.Invocations), percent(u.SuccessRate), strconv.FormatInt(u.TokensUsed, 10), dollars(u.Cost),
+		})
+	}
+	return table
+}
+
+func trendTable(days []DailyTrend) Table {
+	table := Table{Title: "By day", Columns: []string{"Date", "Invocations", "Success rate", "Tokens used", "Cost"}}
+	for _, d := range days {
+		table.Rows = append(table.Rows, []string{
+			d.Date, strconv.Itoa(d.Invocations), percent(d.SuccessRate), strconv.FormatInt(d.TokensUsed, 10), dollars(d.Cost),
+		})
+	}
+	return table
+}
+
+func effectsTable(effects []Effect) Table {
+	table := Table{
+		Title:   "Effects (95% confidence intervals, * when they exclude no change)",
+		Columns: []string{"Date", "Plugin", "Change", "Skill", "Before", "After", "Success rate", "Duration", "Tokens", "Warnings"},
+	}
+	for _, e := range effects {
+		skill := e.Skill
+		if skill == "" {
+			skill = "(all)"
+		}
+		table.Rows = append(table.Rows, []string{
+			e.Cut.Time.Format("2006-01-02"), e.Cut.Plugin, e.Cut.Change, skill,
+			strconv.Itoa(e.BeforeSamples), strconv.Itoa(e.AfterSamples),
+			estimate(e.SuccessRate, 1, "%+.1f pts"),
+			estimate(e.Duration, 1000, "%+.1fs"),
+			estimate(e.Tokens, 1, "%+.0f"),
+			strings.Join(e.Warnings, "; "),
+		})
+	}
+	return table
+}
+
+// estimate shows a difference and its interval, each divided by scale and
+// written with format
+func estimate(e *Estimate, scale float64, format string) string {
+	if e == nil {
+		return "n/a"
+	}
+	s := fmt.Sprintf(format+" ["+format+", "+format+"]", e.Difference/scale, e.Low/scale, e.High/scale)
+	if e.Significant {
+		s += "*"
+	}
+	return s
+}
+
+func percent(v float64) string {
+	return fmt.Sprintf("%.1f%%", v)
+}
+
+func signedPercent(v float64) string {
+	return fmt.Sprintf("%+.1f%%", v)
+}
+
+func seconds(ms float64) string {
+	return fmt.Sprintf("%.1fs", ms/1000)
+}
+
+func dollars(v float64) string {
+	return fmt.Sprintf("$%.2f", v)
+}
+
+func writeText(w io.Writer, tables []Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, table := range tables {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, strings.ToUpper(table.Title))
+		fmt.Fprintln(tw, strings.Join(table.Columns, "\t"))
+		if len(table.Rows) == 0 {
+			fmt.Fprintln(tw, "(none)")
+		}
+		for _, row := range table.Rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// writeCSV writes each table as its own block: a header row, then its rows,
+// with a blank line between tables
+func writeCSV(w io.Writer, tables []Table) error {
+	for i, table := range tables {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		cw := csv.NewWriter(w)
+		if err := cw.Write(table.Columns); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(table.Rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMarkdown(w io.Writer, title string, tables []Table) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", title)
+	for _, table := range tables {
+		fmt.Fprintf(&b, "\n## %s\n\n", table.Title)
+		if len(table.Rows) == 0 {
+			b.WriteString("_None_\n")
+			continue
+		}
+		b.WriteString("| " + strings.Join(escapeMarkdown(table.Columns), " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat(" --- |", len(table.Columns)) + "\n")
+		for _, row := range table.Rows {
+			b.WriteString("| " + strings.Join(escapeMarkdown(row), " | ") + " |\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func escapeMarkdown(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+	}
+	return escaped
+}
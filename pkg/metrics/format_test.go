@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("Markdown")
+	require.NoError(t, err)
+	assert.Equal(t, FormatMarkdown, format)
+
+	_, err = ParseFormat("xml")
+	assert.ErrorContains(t, err, "json, table, csv, markdown, html")
+}
+
+func TestRender(t *testing.T) {
+	patterns := []UsagePattern{
+		{Skill: "go-testing", Frequency: 3, AverageDuration: 1200, SuccessRate: 100, TimeOfDay: "morning"},
+		{Skill: "a|b, c", Frequency: 1, AverageDuration: 300, TimeOfDay: "night"},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, Render(&out, FormatCSV, "Patterns", patterns))
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"Skill", "Invocations", "Success rate", "Average duration", "Peak time"}, records[0])
+	assert.Equal(t, []string{"a|b, c", "1", "0.0%", "0.3s", "night"}, records[2])
+
+	out.Reset()
+	require.NoError(t, Render(&out, FormatMarkdown, "Patterns", patterns))
+	assert.Contains(t, out.String(), "# Patterns\n")
+	assert.Contains(t, out.String(), "| go-testing | 3 | 100.0% | 1.2s | morning |\n")
+	assert.Contains(t, out.String(), `| a\|b, c |`)
+
+	out.Reset()
+	require.NoError(t, Render(&out, FormatTable, "Patterns", patterns))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "SKILL PATTERNS", lines[0])
+	assert.Equal(t, strings.Index(lines[1], "Invocations"), strings.Index(lines[2], "3"), "columns line up")
+
+	out.Reset()
+	require.NoError(t, Render(&out, FormatJSON, "Patterns", patterns))
+	assert.Contains(t, out.String(), `"skill": "go-testing"`)
+
+	assert.Error(t, Render(&out, FormatTable, "Patterns", "not a report"))
+}
+
+func TestRenderHTML(t *testing.T) {
+	dashboard := &Dashboard{
+		From:          "2026-05-01",
+		To:            "2026-05-02",
+		Metrics:       ProductivityMetrics{TotalInvocations: 3},
+		Patterns:      []UsagePattern{{Skill: "<script>", Frequency: 3, SuccessRate: 50}},
+		Daily:         []DailyTrend{{Date: "2026-05-01", Invocations: 1}, {Date: "2026-05-02", Invocations: 2, Cost: 1.5}},
+		PeakTimeOfDay: "morning",
+	}
+	dashboard.Hours[9] = 3
+
+	var out bytes.Buffer
+	require.NoError(t, Render(&out, FormatHTML, "Sprint retro", dashboard))
+	html := out.String()
+	assert.Contains(t, html, "<title>Sprint retro</title>")
+	assert.Contains(t, html, "2026-05-01 to 2026-05-02")
+	assert.Contains(t, html, "Invocations per day")
+	assert.Contains(t, html, `style="height: 50.0%"`)
+	assert.Contains(t, html, `style="width: 50.0%"`)
+	assert.Contains(t, html, "Busiest: morning")
+	assert.Contains(t, html, "&lt;script&gt;")
+	assert.NotContains(t, html, "<script>")
+	assert.NotContains(t, html, "http", "the report loads nothing from elsewhere")
+
+	out.Reset()
+	require.NoError(t, Render(&out, FormatHTML, "Benchmark", &BenchmarkComparison{}))
+	assert.Contains(t, out.String(), "<h2>Benchmark</h2>")
+	assert.NotContains(t, out.String(), `class="charts"`)
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+)
+
+// chart is a bar chart drawn with plain HTML and CSS, so the report needs no
+// scripts or network access to display
+type chart struct {
+	Title      string
+	Note       string
+	Horizontal bool
+	Bars       []chartBar
+}
+
+type chartBar struct {
+	Label string
+	// ShowLabel is false when there are too many bars to label them all
+	ShowLabel bool
+	Value     string
+	// Size is the bar's length as a percentage of the longest bar
+	Size string
+}
+
+// newChart scales each bar to the largest value and labels about ten of them
+func newChart(title string, labels []string, values []float64, format func(float64) string) chart {
+	c := chart{Title: title}
+	largest := 0.0
+	for _, v := range values {
+		largest = max(largest, v)
+	}
+	step := max(len(values)/10, 1)
+	for i, v := range values {
+		size := 0.0
+		if largest > 0 {
+			size = v / largest * 100
+		}
+		c.Bars = append(c.Bars, chartBar{
+			Label:     labels[i],
+			ShowLabel: i%step == 0,
+			Value:     format(v),
+			Size:      strconv.FormatFloat(size, 'f', 1, 64),
+		})
+	}
+	return c
+}
+
+func dashboardCharts(d *Dashboard) []chart {
+	var dates []string
+	var invocations, tokens, cost []float64
+	for _, day := range d.Daily {
+		dates = append(dates, day.Date[5:])
+		invocations = append(invocations, float64(day.Invocations))
+		tokens = append(tokens, float64(day.TokensUsed))
+		cost = append(cost, day.Cost)
+	}
+	count := func(v float64) string { return strconv.FormatFloat(v, 'f', 0, 64) }
+
+	var skills []string
+	var rates []float64
+	for _, p := range d.Patterns {
+		skills = append(skills, p.Skill)
+		rates = append(rates, p.SuccessRate)
+	}
+	success := newChart("Success rate by skill", skills, rates, percent)
+	success.Horizontal = true
+	for i := range success.Bars {
+		// Success rates are scaled to 100%, not to the best skill
+		success.Bars[i].Size = strconv.FormatFloat(rates[i], 'f', 1, 64)
+		success.Bars[i].ShowLabel = true
+	}
+
+	var hours []string
+	var byHour []float64
+	for hour, n := range d.Hours {
+		hours = append(hours, fmt.Sprintf("%02d", hour))
+		byHour = append(byHour, float64(n))
+	}
+	peak := newChart("Invocations by hour of day", hours, byHour, count)
+	peak.Note = "Busiest: " + d.PeakTimeOfDay
+
+	return []chart{
+		newChart("Invocations per day", dates, invocations, count),
+		success,
+		newChart("Tokens per day", dates, tokens, count),
+		newChart("Cost per day", dates, cost, dollars),
+		peak,
+	}
+}
+
+func writeHTML(w io.Writer, title string, tables []Table, dashboard *Dashboard) error {
+	page := struct {
+		Title     string
+		Period    string
+		Generated string
+		Charts    []chart
+		Tables    []Table
+	}{
+		Title:     title,
+		Generated: time.Now().Format("2006-01-02 15:04"),
+		Tables:    tables,
+	}
+	if dashboard != nil {
+		page.Period = dashboard.From + " to " + dashboard.To
+		page.Charts = dashboardCharts(dashboard)
+	}
+	return htmlReport.Execute(w, page)
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; margin: 2rem auto; max-width: 1100px; padding: 0 1rem; }
+h1 { margin-bottom: 0.25rem; }
+.meta { color: #656d76; margin-top: 0; }
+.charts { display: grid; grid-template-columns: repeat(auto-fit, minmax(480px, 1fr)); gap: 1.5rem; margin: 1.5rem 0; }
+.chart { border: 1px solid #d0d7de; border-radius: 6px; padding: 1rem; break-inside: avoid; }
+.chart h2, section h2 { font-size: 1.05rem; margin: 0 0 0.75rem; }
+.note { color: #656d76; font-size: 0.85rem; margin: -0.5rem 0 0.75rem; }
+.columns { display: flex; align-items: flex-end; gap: 2px; height: 160px; }
+.column { flex: 1; display: flex; flex-direction: column; justify-content: flex-end; height: 100%; min-width: 0; }
+.column .bar { background: #0969da; min-height: 1px; }
+.labels { display: flex; gap: 2px; font-size: 0.7rem; color: #656d76; }
+.labels span { flex: 1; min-width: 0; overflow: visible; white-space: nowrap; }
+.rows .row { display: grid; grid-template-columns: 35% 1fr 4.5rem; align-items: center; gap: 0.5rem; margin: 0.3rem 0; font-size: 0.85rem; }
+.rows .track { background: #eaeef2; height: 0.9rem; border-radius: 3px; }
+.rows .bar { background: #1a7f37; height: 100%; border-radius: 3px; }
+.rows .name { overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+.rows .value { text-align: right; }
+.empty { color: #656d76; font-style: italic; }
+section { margin: 1.5rem 0; break-inside: avoid; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9rem; }
+th, td { border-bottom: 1px solid #d0d7de; padding: 0.35rem 0.6rem; text-align: left; }
+th { background: #f6f8fa; }
+@media print { body { margin: 0; } .charts { grid-template-columns: 1fr 1fr; } }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{if .Period}}{{.Period}} · {{end}}Generated {{.Generated}}</p>
+{{if .Charts}}<div class="charts">
+{{range .Charts}}<div class="chart">
+<h2>{{.Title}}</h2>
+{{if .Note}}<p class="note">{{.Note}}</p>{{end}}
+{{if not .Bars}}<p class="empty">No usage</p>
+{{else if .Horizontal}}<div class="rows">
+{{range .Bars}}<div class="row" title="{{.Label}}: {{.Value}}"><span class="name">{{.Label}}</span><div class="track"><div class="bar" style="width: {{.Size}}%"></div></div><span class="value">{{.Value}}</span></div>
+{{end}}</div>
+{{else}}<div class="columns">
+{{range .Bars}}<div class="column" title="{{.Label}}: {{.Value}}"><div class="bar" style="height: {{.Size}}%"></div></div>
+{{end}}</div>
+<div class="labels">{{range .Bars}}<span>{{if .ShowLabel}}{{.Label}}{{end}}</span>{{end}}</div>
+{{end}}</div>
+{{end}}</div>
+{{end}}{{range .Tables}}<section>
+<h2>{{.Title}}</h2>
+{{if .Rows}}<table>
+<thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{else}}<p class="empty">None</p>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
@@ -10,46 +10,44 @@ import (
 	"time"
 )
 
+// Logger records usage entries in an append-only store under
+// .nexus/logs/usage. Each entry is one JSON line in the file for its UTC day,
+// so logging never rewrites earlier entries and time-range queries only read
+// the days they cover. Every access holds a lock on the store, so concurrent
+// agents can log to the same project.
 type Logger struct {
-	filePath string
-	store    *LogStore
+	dir        string
+	legacyPath string
+	options    StoreOptions
 }
 
-func NewLogger(basePath string) *Logger {
-	return &Logger{
-		filePath: filepath.Join(basePath, ".nexus", "logs", "usage.json"),
-		store:    &LogStore{},
-	}
+// StoreOptions control how long usage entries are kept and when they are
+// compacted
+type StoreOptions struct {
+	// RetentionDays drops entries older than this many days; 0 keeps them all
+	RetentionDays int
+	// CompactAfterDays gzips days older than this, sorted and without
+	// duplicates; 0 never compacts
+	CompactAfterDays int
 }
 
-func (l *Logger) Load() error {
-	data, err := os.ReadFile(l.filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			l.store = &LogStore{
-				Entries: []UsageLog{},
-				Metadata: StoreMeta{
-					Version:     "1.0",
-					LastUpdated: time.Now().Format(time.RFC3339),
-				},
-			}
-			return nil
-		}
-		return fmt.Errorf("failed to read log file: %w", err)
-	}
-
-	if err := json.Unmarshal(data, l.store); err != nil {
-		return fmt.Errorf("failed to unmarshal log store: %w", err)
-	}
+// DefaultStoreOptions keep every entry and compact days a week old
+var DefaultStoreOptions = StoreOptions{CompactAfterDays: 7}
 
-	return nil
+func NewLogger(basePath string) *Logger {
+	return NewLoggerWithOptions(basePath, DefaultStoreOptions)
 }
 
-func (l *Logger) Log(entry UsageLog) error {
-	if err := l.Load(); err != nil {
-		return err
+func NewLoggerWithOptions(basePath string, options StoreOptions) *Logger {
+	logsDir := filepath.Join(basePath, ".nexus", "logs")
+	return &Logger{
+		dir:        filepath.Join(logsDir, "usage"),
+		legacyPath: filepath.Join(logsDir, "usage.json"),
+		options:    options,
 	}
+}
 
+func (l *Logger) Log(entry UsageLog) error {
 	if entry.ID == "" {
 		entry.ID = generateID()
 	}
@@ -58,46 +56,118 @@ func (l *Logger) Log(entry UsageLog) error {
 		entry.Timestamp = time.Now().Format(time.RFC3339)
 	}
 
-	l.store.Entries = append(l.store.Entries, entry)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage entry: %w", err)
+	}
 
-	return l.Save()
+	return l.withLock(true, func() error {
+		if err := l.migrate(); err != nil {
+			return err
+		}
+		if err := appendLine(l.partitionPath(entryDay(entry), false), line); err != nil {
+			return err
+		}
+		if l.maintenanceDue() {
+			return l.maintain()
+		}
+		return nil
+	})
 }
 
 func (l *Logger) Query(filters Filter) ([]UsageLog, error) {
-	if err := l.Load(); err != nil {
-		return nil, err
+	if _, err := os.Stat(l.legacyPath); err == nil {
+		if err := l.withLock(true, l.migrate); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := os.Stat(l.dir); os.IsNotExist(err) {
+		return nil, nil
 	}
 
 	var results []UsageLog
-	for _, entry := range l.store.Entries {
-		if l.matches(entry, filters) {
-			results = append(results, entry)
+	err := l.withLock(false, func() error {
+		parts, err := l.partitions()
+		if err != nil {
+			return err
 		}
-	}
-
-	return results, nil
+		for _, part := range parts {
+			if !part.overlaps(filters) {
+				continue
+			}
+			entries, err := l.readPartition(part)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if filters.Matches(entry) {
+					results = append(results, entry)
+				}
+			}
+		}
+		return nil
+	})
+	return results, err
 }
 
-func (l *Logger) Save() error {
-	if err := os.MkdirAll(filepath.Dir(l.filePath), 0755); err != nil {
-		return fmt.Errorf("failed to create log directory: %w", err)
+// Import records the entries whose IDs aren't already in the store and
+// returns how many it recorded, so importing the same transcript twice
+// records it once
+func (l *Logger) Import(entries []UsageLog) (int, error) {
+	byDay := make(map[time.Time][]UsageLog)
+	for _, entry := range entries {
+		if entry.ID == "" {
+			entry.ID = generateID()
+		}
+		byDay[entryDay(entry)] = append(byDay[entryDay(entry)], entry)
 	}
 
-	l.store.Metadata.LastUpdated = time.Now().Format(time.RFC3339)
-
-	data, err := json.MarshalIndent(l.store, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal log store: %w", err)
-	}
+	imported := 0
+	err := l.withLock(true, func() error {
+		if err := l.migrate(); err != nil {
+			return err
+		}
+		for day, dayEntries := range byDay {
+			stored, err := l.readPartition(partition{day: day, plain: true, compacted: true})
+			if err != nil {
+				return err
+			}
+			seen := make(map[string]bool, len(stored))
+			for _, entry := range stored {
+				seen[entry.ID] = true
+			}
 
-	if err := os.WriteFile(l.filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write log file: %w", err)
-	}
+			var fresh []UsageLog
+			for _, entry := range dayEntries {
+				if !seen[entry.ID] {
+					seen[entry.ID] = true
+					fresh = append(fresh, entry)
+				}
+			}
+			if len(fresh) == 0 {
+				continue
+			}
+			if err := appendEntries(l.partitionPath(day, false), fresh); err != nil {
+				return err
+			}
+			imported += len(fresh)
+		}
+		if l.maintenanceDue() {
+			return l.maintain()
+		}
+		return nil
+	})
+	return imported, err
+}
 
-	return nil
+// Maintain applies the retention and compaction options now. Log runs it at
+// most once a day on its own.
+func (l *Logger) Maintain() error {
+	return l.withLock(true, l.maintain)
 }
 
-func (l *Logger) matches(entry UsageLog, filters Filter) bool {
+// Matches reports whether entry passes every filter that is set
+func (filters Filter) Matches(entry UsageLog) bool {
 	if filters.Agent != "" && entry.Agent != filters.Agent {
 		return false
 	}
@@ -122,6 +192,14 @@ func (l *Logger) matches(entry UsageLog, filters Filter) bool {
 		}
 	}
 
+	if filters.Project != "" && entry.Context.Project != filters.Project {
+		return false
+	}
+
+	if filters.User != "" && entry.User != filters.User {
+		return false
+	}
+
 	if filters.Category != "" && entry.Invocation.Category != filters.Category {
 		return false
 	}
@@ -147,4 +225,6 @@ type Filter struct {
 	EndTime   time.Time
 	Category  string
 	Type      string
+	Project   string
+	User      string
 }
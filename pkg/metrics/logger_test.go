@@ -1,7 +1,10 @@
 package metrics
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -101,19 +104,15 @@ func TestLogger_QueryByTimeRange(t *testing.T) {
 	assert.Len(t, todayLogs, 1)
 }
 
-func TestLogger_LoadCreatesNewStore(t *testing.T) {
-	tmpDir := t.TempDir()
-	logPath := loggerFilePath(tmpDir)
-	logger := NewLogger(tmpDir)
+func TestLogger_QueryEmptyStore(t *testing.T) {
+	logger := NewLogger(t.TempDir())
 
-	_ = os.Remove(logPath)
-
-	err := logger.Load()
+	entries, err := logger.Query(Filter{})
 	require.NoError(t, err)
-	assert.Len(t, logger.store.Entries, 0)
+	assert.Empty(t, entries)
 }
 
-func TestLogger_SaveAndLoad(t *testing.T) {
+func TestLogger_PersistsAcrossLoggers(t *testing.T) {
 	tmpDir := t.TempDir()
 	logger := NewLogger(tmpDir)
 
@@ -127,12 +126,162 @@ func TestLogger_SaveAndLoad(t *testing.T) {
 	err := logger.Log(entry)
 	require.NoError(t, err)
 
-	newLogger := NewLogger(tmpDir)
-	err = newLogger.Load()
+	entries, err := NewLogger(tmpDir).Query(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestLogger_MigratesLegacyStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	legacyPath := loggerFilePath(tmpDir)
+	require.NoError(t, os.MkdirAll(filepath.Dir(legacyPath), 0755))
+
+	legacy := LogStore{Entries: []UsageLog{
+		{ID: "old-1", Timestamp: "2026-01-01T10:00:00Z", Agent: "sisyphus"},
+		{ID: "old-2", Timestamp: "2026-01-02T10:00:00Z", Agent: "oracle"},
+	}}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(legacyPath, data, 0644))
+
+	logger := NewLogger(tmpDir)
+	entries, err := logger.Query(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.NoFileExists(t, legacyPath)
+	assert.FileExists(t, legacyPath+".migrated")
+
+	require.NoError(t, logger.Log(UsageLog{Agent: "sisyphus"}))
+	entries, err = NewLogger(tmpDir).Query(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 3, "legacy entries are migrated only once")
+}
+
+func TestLogger_MigrationResumesWithoutDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	legacyPath := loggerFilePath(tmpDir)
+	require.NoError(t, os.MkdirAll(filepath.Dir(legacyPath), 0755))
+
+	// Entries from early versions may have no ID
+	legacy := LogStore{Entries: []UsageLog{
+		{Timestamp: "2026-01-01T10:00:00Z", Agent: "sisyphus"},
+		{Timestamp: "2026-01-01T10:00:00Z", Agent: "sisyphus"},
+		{Timestamp: "2026-01-02T10:00:00Z", Agent: "oracle"},
+	}}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(legacyPath, data, 0644))
+
+	entries, err := NewLogger(tmpDir).Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	// A run that wrote the partitions but crashed before retiring the old
+	// file migrates it again
+	require.NoError(t, os.Rename(legacyPath+".migrated", legacyPath))
+	entries, err = NewLogger(tmpDir).Query(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 3, "entries already migrated are not added twice")
+	assert.NoFileExists(t, legacyPath)
+
+	leftovers, err := filepath.Glob(filepath.Join(filepath.Dir(legacyPath), "usage", "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestLogger_ConcurrentWriters(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger := NewLogger(tmpDir)
+			for j := 0; j < 25; j++ {
+				assert.NoError(t, logger.Log(UsageLog{Agent: "sisyphus"}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := NewLogger(tmpDir).Query(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 200)
+}
+
+func TestLogger_RetentionAndCompaction(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := NewLoggerWithOptions(tmpDir, StoreOptions{RetentionDays: 30, CompactAfterDays: 7})
+
+	now := time.Now().UTC()
+	expired := now.AddDate(0, 0, -40).Format(time.RFC3339)
+	old := now.AddDate(0, 0, -10).Format(time.RFC3339)
+	recent := now.Format(time.RFC3339)
+
+	require.NoError(t, logger.Log(UsageLog{ID: "expired", Timestamp: expired}))
+	require.NoError(t, logger.Log(UsageLog{ID: "old", Timestamp: old}))
+	require.NoError(t, logger.Log(UsageLog{ID: "old", Timestamp: old}))
+	require.NoError(t, logger.Log(UsageLog{ID: "recent", Timestamp: recent}))
+	require.NoError(t, logger.Maintain())
+
+	oldDay := truncateDay(now.AddDate(0, 0, -10))
+	assert.NoFileExists(t, logger.partitionPath(truncateDay(now.AddDate(0, 0, -40)), false))
+	assert.NoFileExists(t, logger.partitionPath(oldDay, false))
+	assert.FileExists(t, logger.partitionPath(oldDay, true))
+	assert.FileExists(t, logger.partitionPath(truncateDay(now), false))
+
+	entries, err := logger.Query(Filter{})
+	require.NoError(t, err)
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	assert.Equal(t, []string{"old", "recent"}, ids)
+}
+
+func TestLogger_QuerySkipsPartitionsOutsideRange(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := NewLogger(tmpDir)
+
+	require.NoError(t, logger.Log(UsageLog{Timestamp: "2026-03-01T12:00:00Z"}))
+	require.NoError(t, logger.Log(UsageLog{Timestamp: "2026-03-03T12:00:00Z"}))
+
+	// A partition outside the range is never opened, so a corrupt one is
+	// harmless
+	corrupt := logger.partitionPath(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), true)
+	require.NoError(t, os.WriteFile(corrupt, []byte("not gzip"), 0644))
+
+	entries, err := logger.Query(Filter{
+		StartTime: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	_, err = logger.Query(Filter{})
+	assert.Error(t, err)
+}
+
+func TestLogger_SkipsMalformedLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := NewLogger(tmpDir)
+	now := time.Now()
+	require.NoError(t, logger.Log(UsageLog{ID: "good", Timestamp: now.Format(time.RFC3339)}))
+
+	path := logger.partitionPath(truncateDay(now), false)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"id":"trunc`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	entries, err := logger.Query(Filter{})
 	require.NoError(t, err)
-	assert.Len(t, newLogger.store.Entries, 1)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "good", entries[0].ID)
 }
 
 func loggerFilePath(basePath string) string {
-	return basePath + "/.nexus/logs/usage.json"
+	return filepath.Join(basePath, ".nexus", "logs", "usage.json")
 }
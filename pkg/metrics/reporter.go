@@ -6,10 +6,24 @@ import (
 	"time"
 )
 
+// Source is a set of usage entries a report is drawn from: a project's local
+// Logger, or the team's entries on the coordination server
+type Source interface {
+	Query(filters Filter) ([]UsageLog, error)
+}
+
+// SourceFunc adapts a function to a Source
+type SourceFunc func(filters Filter) ([]UsageLog, error)
+
+func (f SourceFunc) Query(filters Filter) ([]UsageLog, error) {
+	return f(filters)
+}
+
 type Reporter struct {
 	calculator *Calculator
 	analyzer   *Analyzer
 	benchmark  *BenchmarkCalculator
+	installed  []Capability
 }
 
 func NewReporter() *Reporter {
@@ -20,11 +34,18 @@ func NewReporter() *Reporter {
 	}
 }
 
-func (r *Reporter) GenerateDailySummary(logger *Logger, date time.Time) (*DailySummary, error) {
+// WithInstalled tells the reporter which capabilities the project has, so
+// its insights can point out the ones never used
+func (r *Reporter) WithInstalled(installed []Capability) *Reporter {
+	r.installed = installed
+	return r
+}
+
+func (r *Reporter) GenerateDailySummary(source Source, date time.Time) (*DailySummary, error) {
 	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour).Add(-time.Nanosecond)
 
-	logs, err := logger.Query(Filter{
+	logs, err := source.Query(Filter{
 		StartTime: startOfDay,
 		EndTime:   endOfDay,
 	})
@@ -40,7 +61,7 @@ func (r *Reporter) GenerateDailySummary(logger *Logger, date time.Time) (*DailyS
 
 	topSkills := r.getTopSkills(logs, 5)
 
-	insights := r.generateInsights(metrics, topSkills)
+	insights := r.generateInsights(metrics, topSkills, logs)
 
 	return &DailySummary{
 		Date:               startOfDay.Format("2006-01-02"),
@@ -56,11 +77,11 @@ func (r *Reporter) GenerateDailySummary(logger *Logger, date time.Time) (*DailyS
 	}, nil
 }
 
-func (r *Reporter) GenerateReport(logger *Logger, days int) (*ProductivityMetrics, *DailySummary, []UsagePattern, error) {
+func (r *Reporter) GenerateReport(source Source, days int) (*ProductivityMetrics, *DailySummary, []UsagePattern, error) {
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -days)
 
-	logs, err := logger.Query(Filter{
+	logs, err := source.Query(Filter{
 		StartTime: startDate,
 		EndTime:   endDate,
 	})
@@ -74,7 +95,7 @@ func (r *Reporter) GenerateReport(logger *Logger, days int) (*ProductivityMetric
 		return nil, nil, nil, fmt.Errorf("failed to calculate metrics: %w", err)
 	}
 
-	summary, err := r.GenerateDailySummary(logger, endDate)
+	summary, err := r.GenerateDailySummary(source, endDate)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to generate daily summary: %w", err)
 	}
@@ -87,13 +108,13 @@ func (r *Reporter) GenerateReport(logger *Logger, days int) (*ProductivityMetric
 	return metrics, summary, patterns, nil
 }
 
-func (r *Reporter) GenerateBenchmark(logger *Logger, baselineDays, currentDays int) (*BenchmarkComparison, error) {
+func (r *Reporter) GenerateBenchmark(source Source, baselineDays, currentDays int) (*BenchmarkComparison, error) {
 	now := time.Now()
 
 	baselineStart := now.AddDate(0, 0, -baselineDays-currentDays)
 	baselineEnd := now.AddDate(0, 0, -currentDays)
 
-	baselineLogs, err := logger.Query(Filter{
+	baselineLogs, err := source.Query(Filter{
 		StartTime: baselineStart,
 		EndTime:   baselineEnd,
 	})
@@ -102,7 +123,7 @@ func (r *Reporter) GenerateBenchmark(logger *Logger, baselineDays, currentDays i
 		return nil, fmt.Errorf("failed to query baseline logs: %w", err)
 	}
 
-	currentLogs, err := logger.Query(Filter{
+	currentLogs, err := source.Query(Filter{
 		StartTime: baselineEnd,
 		EndTime:   now,
 	})
@@ -140,7 +161,7 @@ func (r *Reporter) getTopSkills(logs []UsageLog, limit int) []SkillCount {
 	return skills
 }
 
-func (r *Reporter) generateInsights(metrics *ProductivityMetrics, topSkills []SkillCount) []string {
+func (r *Reporter) generateInsights(metrics *ProductivityMetrics, topSkills []SkillCount, logs []UsageLog) []string {
 	var insights []string
 
 	if metrics.SuccessRate < 80 {
@@ -175,5 +196,5 @@ func (r *Reporter) generateInsights(metrics *ProductivityMetrics, topSkills []Sk
 		insights = append(insights, fmt.Sprintf("📊 Total tokens used: %dK", tokensInThousands))
 	}
 
-	return insights
+	return append(insights, r.recommendations(logs)...)
 }
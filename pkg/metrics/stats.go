@@ -0,0 +1,113 @@
+package metrics
+
+import "math"
+
+// z95 is the two-sided 95% critical value of the normal distribution
+const z95 = 1.96
+
+// tTable95 holds two-sided 95% critical values of Student's t for 1 to 30
+// degrees of freedom
+var tTable95 = [...]float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// tCritical95 is the 95% critical value of t for df degrees of freedom,
+// rounded down to the nearest tabulated value so intervals err wide
+func tCritical95(df float64) float64 {
+	switch {
+	case df < 1:
+		return tTable95[0]
+	case df <= 30:
+		return tTable95[int(df)-1]
+	case df < 40:
+		return 2.042
+	case df < 60:
+		return 2.021
+	case df < 120:
+		return 2.000
+	case df < 1000:
+		return 1.980
+	default:
+		return z95
+	}
+}
+
+// meanAndVariance returns the mean and unbiased sample variance of values
+func meanAndVariance(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, squares / float64(len(values)-1)
+}
+
+// meanDifference estimates after's mean minus before's with Welch's t
+// interval, which doesn't assume both have the same variance. Both need at
+// least two values.
+func meanDifference(before, after []float64) *Estimate {
+	if len(before) < 2 || len(after) < 2 {
+		return nil
+	}
+	m1, v1 := meanAndVariance(before)
+	m2, v2 := meanAndVariance(after)
+	a, b := v1/float64(len(before)), v2/float64(len(after))
+	se := math.Sqrt(a + b)
+
+	df := math.Inf(1)
+	if a+b > 0 {
+		df = (a + b) * (a + b) / (a*a/float64(len(before)-1) + b*b/float64(len(after)-1))
+	}
+	return newEstimate(m1, m2, tCritical95(df)*se)
+}
+
+// proportionDifference estimates the change in success rate, in percentage
+// points, with the normal approximation to the difference of proportions
+func proportionDifference(beforeSuccesses, beforeTotal, afterSuccesses, afterTotal int) *Estimate {
+	if beforeTotal == 0 || afterTotal == 0 {
+		return nil
+	}
+	p1 := float64(beforeSuccesses) / float64(beforeTotal)
+	p2 := float64(afterSuccesses) / float64(afterTotal)
+	se := math.Sqrt(p1*(1-p1)/float64(beforeTotal) + p2*(1-p2)/float64(afterTotal))
+	return newEstimate(p1*100, p2*100, z95*se*100)
+}
+
+func newEstimate(before, after, margin float64) *Estimate {
+	difference := after - before
+	low, high := difference-margin, difference+margin
+	return &Estimate{
+		Before:      before,
+		After:       after,
+		Difference:  difference,
+		Low:         low,
+		High:        high,
+		Significant: low > 0 || high < 0,
+	}
+}
+
+// wilsonInterval is the 95% Wilson score interval of a proportion, which
+// stays within 0 to 1 and behaves for small samples
+func wilsonInterval(successes, total int) (float64, float64) {
+	if total == 0 {
+		return 0, 1
+	}
+	n := float64(total)
+	p := float64(successes) / n
+	denominator := 1 + z95*z95/n
+	center := (p + z95*z95/(2*n)) / denominator
+	margin := z95 * math.Sqrt(p*(1-p)/n+z95*z95/(4*n*n)) / denominator
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
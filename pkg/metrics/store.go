@@ -0,0 +1,419 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	partitionLayout = "2006-01-02"
+	partitionExt    = ".jsonl"
+	compactedExt    = ".jsonl.gz"
+	lockName        = ".lock"
+	maintainedName  = ".maintained"
+)
+
+// maintenanceInterval is how often Log applies retention and compaction
+const maintenanceInterval = 24 * time.Hour
+
+// withLock runs fn holding the store lock, exclusive for writers and shared
+// for readers
+func (l *Logger) withLock(exclusive bool, fn func() error) error {
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create usage directory: %w", err)
+	}
+	lock, err := os.OpenFile(filepath.Join(l.dir, lockName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open usage lock: %w", err)
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock, exclusive); err != nil {
+		return fmt.Errorf("failed to lock usage store: %w", err)
+	}
+	defer unlockFile(lock)
+
+	return fn()
+}
+
+// migrate moves entries from the single usage.json file earlier versions
+// rewrote on every log into the append-only store. Each day's partition is
+// rewritten aside and renamed into place, and the old file is renamed last,
+// so a migration cut short leaves whole partitions and is finished by the
+// next run without duplicating entries. Callers hold the exclusive lock.
+func (l *Logger) migrate() error {
+	data, err := os.ReadFile(l.legacyPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read legacy usage log: %w", err)
+	}
+
+	var store LogStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		return fmt.Errorf("failed to parse legacy usage log: %w", err)
+	}
+
+	byDay := make(map[time.Time][]UsageLog)
+	for i, entry := range store.Entries {
+		if entry.ID == "" {
+			entry.ID = legacyID(i, entry)
+		}
+		byDay[entryDay(entry)] = append(byDay[entryDay(entry)], entry)
+	}
+	for day, entries := range byDay {
+		if err := l.mergeEntries(day, entries); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(l.legacyPath, l.legacyPath+".migrated"); err != nil {
+		return fmt.Errorf("failed to retire legacy usage log: %w", err)
+	}
+	return nil
+}
+
+// legacyID names the i-th entry of the legacy file when it has no ID. It is
+// derived from the entry rather than random, so migrating again after a
+// crash recognises entries that already made it across.
+func legacyID(i int, entry UsageLog) string {
+	data, _ := json.Marshal(entry)
+	sum := sha256.Sum256(append(strconv.AppendInt(nil, int64(i), 10), data...))
+	return hex.EncodeToString(sum[:8])
+}
+
+// mergeEntries adds entries to day's partition, leaving out any whose ID the
+// day already holds. The partition is written to a temporary file and renamed
+// over the old one, so readers see it either before or after the merge.
+func (l *Logger) mergeEntries(day time.Time, entries []UsageLog) error {
+	existing, err := l.readPartition(partition{day: day, plain: true, compacted: true})
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, entry := range existing {
+		seen[entry.ID] = true
+	}
+
+	path := l.partitionPath(day, false)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read usage partition: %w", err)
+	}
+	buf := bytes.NewBuffer(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	added := 0
+	for _, entry := range entries {
+		if seen[entry.ID] {
+			continue
+		}
+		seen[entry.ID] = true
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal usage entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		added++
+	}
+	if added == 0 {
+		return nil
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write usage partition: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace usage partition: %w", err)
+	}
+	return nil
+}
+
+// entryDay is the UTC day whose partition holds entry. Entries with
+// timestamps that don't parse go in today's.
+func entryDay(entry UsageLog) time.Time {
+	t, err := time.Parse(time.RFC3339, entry.Timestamp)
+	if err != nil {
+		t = time.Now()
+	}
+	return truncateDay(t)
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (l *Logger) partitionPath(day time.Time, compacted bool) string {
+	ext := partitionExt
+	if compacted {
+		ext = compactedExt
+	}
+	return filepath.Join(l.dir, day.Format(partitionLayout)+ext)
+}
+
+// partition is one day of entries: recent appends in plain JSONL and, once
+// compacted, older ones gzipped
+type partition struct {
+	day       time.Time
+	plain     bool
+	compacted bool
+}
+
+// overlaps reports whether any of the day falls within the filter's range
+func (p partition) overlaps(filters Filter) bool {
+	if !filters.EndTime.IsZero() && p.day.After(filters.EndTime) {
+		return false
+	}
+	if !filters.StartTime.IsZero() && !p.day.Add(24*time.Hour).After(filters.StartTime) {
+		return false
+	}
+	return true
+}
+
+// partitions lists the store's days, oldest first
+func (l *Logger) partitions() ([]partition, error) {
+	dirEntries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage store: %w", err)
+	}
+
+	byDay := make(map[time.Time]*partition)
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		var stamp string
+		compacted := false
+		switch {
+		case strings.HasSuffix(name, compactedExt):
+			stamp, compacted = strings.TrimSuffix(name, compactedExt), true
+		case strings.HasSuffix(name, partitionExt):
+			stamp = strings.TrimSuffix(name, partitionExt)
+		default:
+			continue
+		}
+		day, err := time.Parse(partitionLayout, stamp)
+		if err != nil {
+			continue
+		}
+		part, ok := byDay[day]
+		if !ok {
+			part = &partition{day: day}
+			byDay[day] = part
+		}
+		if compacted {
+			part.compacted = true
+		} else {
+			part.plain = true
+		}
+	}
+
+	parts := make([]partition, 0, len(byDay))
+	for _, part := range byDay {
+		parts = append(parts, *part)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].day.Before(parts[j].day) })
+	return parts, nil
+}
+
+// readPartition reads a day's compacted entries, then its recent appends
+func (l *Logger) readPartition(part partition) ([]UsageLog, error) {
+	var entries []UsageLog
+	if part.compacted {
+		compacted, err := readEntries(l.partitionPath(part.day, true), true)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, compacted...)
+	}
+	if part.plain {
+		plain, err := readEntries(l.partitionPath(part.day, false), false)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, plain...)
+	}
+	return entries, nil
+}
+
+// readEntries reads a partition file. Lines that don't parse, such as one
+// cut short by a crash mid-write, are skipped.
+func readEntries(path string, compressed bool) ([]UsageLog, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open usage partition: %w", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if compressed {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read compacted partition %s: %w", filepath.Base(path), err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var entries []UsageLog
+	buffered := bufio.NewReader(reader)
+	for {
+		line, err := buffered.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var entry UsageLog
+			if json.Unmarshal(line, &entry) == nil {
+				entries = append(entries, entry)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read usage partition: %w", err)
+		}
+	}
+}
+
+// appendLine appends one entry line with a single write, so a reader never
+// sees half an entry from a writer that is still running
+func appendLine(path string, line []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open usage partition: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append usage entry: %w", err)
+	}
+	return file.Close()
+}
+
+func appendEntries(path string, entries []UsageLog) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal usage entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open usage partition: %w", err)
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append usage entries: %w", err)
+	}
+	return file.Close()
+}
+
+func (l *Logger) maintenanceDue() bool {
+	info, err := os.Stat(filepath.Join(l.dir, maintainedName))
+	return err != nil || time.Since(info.ModTime()) > maintenanceInterval
+}
+
+// maintain drops days past retention and compacts days past CompactAfterDays.
+// Callers hold the exclusive lock.
+func (l *Logger) maintain() error {
+	parts, err := l.partitions()
+	if err != nil {
+		return err
+	}
+
+	today := truncateDay(time.Now())
+	for _, part := range parts {
+		age := int(today.Sub(part.day).Hours() / 24)
+		switch {
+		case l.options.RetentionDays > 0 && age > l.options.RetentionDays:
+			for _, compacted := range []bool{false, true} {
+				if err := os.Remove(l.partitionPath(part.day, compacted)); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to drop expired usage: %w", err)
+				}
+			}
+		case l.options.CompactAfterDays > 0 && age > l.options.CompactAfterDays && part.plain:
+			if err := l.compact(part); err != nil {
+				return err
+			}
+		}
+	}
+
+	return os.WriteFile(filepath.Join(l.dir, maintainedName), nil, 0644)
+}
+
+// compact merges a day's appends into its gzipped file, sorted by time and
+// without duplicate IDs. The gzip is written aside and renamed into place
+// before the appends are removed, so a crash loses nothing.
+func (l *Logger) compact(part partition) error {
+	entries, err := l.readPartition(part)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(entries))
+	unique := entries[:0]
+	for _, entry := range entries {
+		if entry.ID != "" && seen[entry.ID] {
+			continue
+		}
+		seen[entry.ID] = true
+		unique = append(unique, entry)
+	}
+	sort.SliceStable(unique, func(i, j int) bool { return unique[i].Timestamp < unique[j].Timestamp })
+
+	target := l.partitionPath(part.day, true)
+	tmp := target + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted partition: %w", err)
+	}
+	gz := gzip.NewWriter(file)
+	encoder := json.NewEncoder(gz)
+	for _, entry := range unique {
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("failed to write compacted partition: %w", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write compacted partition: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write compacted partition: %w", err)
+	}
+
+	if err := os.Rename(tmp, target); err != nil {
+		return fmt.Errorf("failed to replace compacted partition: %w", err)
+	}
+	if err := os.Remove(l.partitionPath(part.day, false)); err != nil {
+		return fmt.Errorf("failed to remove compacted entries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// GenerateTeamReport summarizes the last days of a team's usage: overall
+// metrics, skill patterns, how many members use each rule, skill and command
+// and, unless hideUsers is set, each member's share
+func (r *Reporter) GenerateTeamReport(source Source, project string, days int, hideUsers bool) (*TeamReport, error) {
+	endDate := time.Now()
+	logs, err := source.Query(Filter{
+		StartTime: endDate.AddDate(0, 0, -days),
+		EndTime:   endDate,
+		Project:   project,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs: %w", err)
+	}
+
+	metrics, err := r.calculator.Calculate(logs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate metrics: %w", err)
+	}
+	patterns, err := r.analyzer.AnalyzePatterns(logs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze patterns: %w", err)
+	}
+
+	report := &TeamReport{
+		Project:  project,
+		Days:     days,
+		Metrics:  *metrics,
+		Patterns: patterns,
+		Adoption: adoption(logs),
+	}
+	byUser := userUsage(logs)
+	report.Contributors = len(byUser)
+	if !hideUsers {
+		report.ByUser = byUser
+	}
+	return report, nil
+}
+
+// adoption counts the invocations and distinct users of each rule, skill and
+// command, most widely used first
+func adoption(logs []UsageLog) []Adoption {
+	type key struct{ kind, name string }
+	type tally struct {
+		invocations, successes int
+		users                  map[string]bool
+	}
+	tallies := make(map[key]*tally)
+	for _, log := range logs {
+		k := key{log.Invocation.Type, log.Invocation.Name}
+		t, ok := tallies[k]
+		if !ok {
+			t = &tally{users: make(map[string]bool)}
+			tallies[k] = t
+		}
+		t.invocations++
+		if log.Outcome.Success {
+			t.successes++
+		}
+		t.users[log.User] = true
+	}
+
+	result := make([]Adoption, 0, len(tallies))
+	for k, t := range tallies {
+		result = append(result, Adoption{
+			Type:        k.kind,
+			Name:        k.name,
+			Invocations: t.invocations,
+			Users:       len(t.users),
+			SuccessRate: float64(t.successes) / float64(t.invocations) * 100,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Users != result[j].Users {
+			return result[i].Users > result[j].Users
+		}
+		if result[i].Invocations != result[j].Invocations {
+			return result[i].Invocations > result[j].Invocations
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func userUsage(logs []UsageLog) []UserUsage {
+	byUser := make(map[string]*UserUsage)
+	successes := make(map[string]int)
+	for _, log := range logs {
+		u, ok := byUser[log.User]
+		if !ok {
+			u = &UserUsage{User: log.User}
+			byUser[log.User] = u
+		}
+		u.Invocations++
+		u.TokensUsed += log.Outcome.TokensUsed
+		u.Cost += log.Outcome.Cost
+		if log.Outcome.Success {
+			successes[log.User]++
+		}
+	}
+
+	result := make([]UserUsage, 0, len(byUser))
+	for user, u := range byUser {
+		u.SuccessRate = float64(successes[user]) / float64(u.Invocations) * 100
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Invocations != result[j].Invocations {
+			return result[i].Invocations > result[j].Invocations
+		}
+		return result[i].User < result[j].User
+	})
+	return result
+}
+
+// pseudonymPattern matches the pseudonyms Pseudonym derives
+var pseudonymPattern = regexp.MustCompile(`^anon-[0-9a-f]{12}$`)
+
+// NewPseudonymKey returns a random secret for Pseudonym. It stays in the
+// member's user config; without it nobody, the server included, can tell
+// whose usage a pseudonym stands for.
+func NewPseudonymKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate pseudonym key: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// Pseudonym is a member's stable stand-in in project's team usage, keyed by
+// their secret from NewPseudonymKey. The same key always maps to the same
+// pseudonym within a project, so adoption can still be counted, but
+// pseudonyms can't be matched across projects or reversed from a roster.
+func Pseudonym(key, project string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(project))
+	return "anon-" + hex.EncodeToString(mac.Sum(nil)[:6])
+}
+
+// IsPseudonym reports whether user has the form of a Pseudonym
+func IsPseudonym(user string) bool {
+	return pseudonymPattern.MatchString(user)
+}
+
+// StripContext removes what an entry says about the work itself: the task
+// text, touched files, session ID and error messages. What was invoked and
+// how it went are kept.
+func StripContext(entry UsageLog) UsageLog {
+	entry.Context.Task = ""
+	entry.Context.Files = nil
+	if entry.Metadata != nil {
+		metadata := *entry.Metadata
+		metadata.SessionID = ""
+		metadata.Errors = nil
+		entry.Metadata = &metadata
+	}
+	return entry
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTeamReport(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	entry := func(user, kind, name string, success bool) UsageLog {
+		return UsageLog{
+			ID:         user + "-" + name,
+			Timestamp:  now,
+			User:       user,
+			Invocation: Invocation{Type: kind, Name: name},
+			Context:    Context{Project: "shop"},
+			Outcome:    Outcome{Success: success, TokensUsed: 100, Cost: 0.5},
+		}
+	}
+	logs := []UsageLog{
+		entry("alice", "skill", "go-testing", true),
+		entry("bob", "skill", "go-testing", false),
+		entry("alice", "command", "/review", true),
+		{ID: "other", Timestamp: now, User: "carol", Invocation: Invocation{Type: "skill", Name: "go-testing"}, Context: Context{Project: "blog"}},
+	}
+	source := SourceFunc(func(filters Filter) ([]UsageLog, error) {
+		var results []UsageLog
+		for _, log := range logs {
+			if filters.Matches(log) {
+				results = append(results, log)
+			}
+		}
+		return results, nil
+	})
+
+	report, err := NewReporter().GenerateTeamReport(source, "shop", 7, false)
+	require.NoError(t, err)
+	assert.Equal(t, "shop", report.Project)
+	assert.Equal(t, 2, report.Contributors)
+	assert.Equal(t, int64(3), report.Metrics.TotalInvocations)
+
+	require.Len(t, report.Adoption, 2)
+	assert.Equal(t, Adoption{Type: "skill", Name: "go-testing", Invocations: 2, Users: 2, SuccessRate: 50}, report.Adoption[0])
+	assert.Equal(t, "/review", report.Adoption[1].Name)
+
+	require.Len(t, report.ByUser, 2)
+	assert.Equal(t, UserUsage{User: "alice", Invocations: 2, SuccessRate: 100, TokensUsed: 200, Cost: 1}, report.ByUser[0])
+
+	report, err = NewReporter().GenerateTeamReport(source, "shop", 7, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Contributors)
+	assert.Empty(t, report.ByUser)
+}
+
+func TestPseudonym(t *testing.T) {
+	key, err := NewPseudonymKey()
+	require.NoError(t, err)
+	other, err := NewPseudonymKey()
+	require.NoError(t, err)
+	assert.Len(t, key, 64)
+	assert.NotEqual(t, key, other)
+
+	assert.Equal(t, Pseudonym(key, "shop"), Pseudonym(key, "shop"))
+	assert.NotEqual(t, Pseudonym(key, "shop"), Pseudonym(other, "shop"))
+	assert.NotEqual(t, Pseudonym(key, "shop"), Pseudonym(key, "blog"))
+	assert.True(t, IsPseudonym(Pseudonym(key, "shop")))
+	assert.False(t, IsPseudonym("alice"))
+}
+
+func TestStripContext(t *testing.T) {
+	entry := UsageLog{
+		Invocation: Invocation{Type: "skill", Name: "go-testing"},
+		Context:    Context{Project: "shop", Task: "fix the cart", Files: []string{"cart.go"}},
+		Metadata:   &Metadata{Model: "gpt-5", SessionID: "s1", Errors: []string{"boom"}},
+	}
+
+	stripped := StripContext(entry)
+	assert.Equal(t, entry.Invocation, stripped.Invocation)
+	assert.Equal(t, Context{Project: "shop"}, stripped.Context)
+	assert.Equal(t, &Metadata{Model: "gpt-5"}, stripped.Metadata)
+	assert.Equal(t, "s1", entry.Metadata.SessionID, "the original entry is left alone")
+}
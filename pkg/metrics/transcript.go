@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A transcript is read into turns, each running from one user prompt to the
+// next, before usage entries are derived from it. The Claude Code and
+// OpenCode parsers only differ in how they fill these in.
+
+type transcriptTurn struct {
+	start  time.Time
+	end    time.Time
+	prompt string
+	// command is the slash command that started the turn, if any, and
+	// commandID a stable ID for it
+	command   string
+	commandID string
+	steps     []transcriptStep
+}
+
+// transcriptStep is one model response
+type transcriptStep struct {
+	at     time.Time
+	model  string
+	tokens int64
+	cost   float64
+	failed bool
+	tools  []transcriptTool
+}
+
+type transcriptTool struct {
+	id     string
+	name   string
+	input  map[string]interface{}
+	at     time.Time
+	failed bool
+}
+
+// transcriptSession is what a parser knows about the session as a whole
+type transcriptSession struct {
+	agent   string
+	id      string
+	project string
+	// idPrefix makes entry IDs unique across agents, so importing a session
+	// twice records it once
+	idPrefix string
+}
+
+// maxTaskLength bounds the prompt text kept as an entry's task
+const maxTaskLength = 200
+
+// sessionEntries derives usage entries from a session's turns: one for each
+// slash command, covering the turn it started, and one for each skill the
+// model loaded, covering the rest of that turn
+func sessionEntries(session transcriptSession, turns []transcriptTurn) []UsageLog {
+	var entries []UsageLog
+	for _, turn := range turns {
+		if turn.command != "" {
+			entry := session.entry(turn, turn.start, InvocationCommand, turn.command)
+			entry.ID = session.idPrefix + turn.commandID
+			entry.Outcome.Success = !turn.failed(turn.start)
+			entries = append(entries, entry)
+		}
+		for _, step := range turn.steps {
+			for _, tool := range step.tools {
+				name := skillName(tool)
+				if name == "" {
+					continue
+				}
+				entry := session.entry(turn, tool.at, InvocationSkill, name)
+				entry.ID = session.idPrefix + tool.id
+				entry.Outcome.Success = !tool.failed && !turn.failed(tool.at)
+				entries = append(entries, entry)
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp < entries[j].Timestamp })
+	return entries
+}
+
+// entry builds an invocation running from start to the end of turn, with the
+// tokens, cost, tools and files of every step in between
+func (s transcriptSession) entry(turn transcriptTurn, start time.Time, kind InvocationType, name string) UsageLog {
+	entry := UsageLog{
+		Timestamp: start.UTC().Format(time.RFC3339),
+		Agent:     s.agent,
+		Invocation: Invocation{
+			Type:     string(kind),
+			Name:     name,
+			Category: category(name),
+		},
+		Context:  Context{Task: truncate(turn.prompt, maxTaskLength), Project: s.project},
+		Metadata: &Metadata{SessionID: s.id},
+	}
+	if turn.end.After(start) {
+		entry.Outcome.Duration = turn.end.Sub(start).Milliseconds()
+	}
+
+	seenFiles := make(map[string]bool)
+	for _, step := range turn.steps {
+		if step.at.Before(start) {
+			continue
+		}
+		entry.Outcome.TokensUsed += step.tokens
+		entry.Outcome.Cost += step.cost
+		if entry.Metadata.Model == "" {
+			entry.Metadata.Model = step.model
+		}
+		for _, tool := range step.tools {
+			entry.Metadata.ToolCalls = append(entry.Metadata.ToolCalls, tool.name)
+			if file := toolFile(tool); file != "" && !seenFiles[file] {
+				seenFiles[file] = true
+				entry.Context.Files = append(entry.Context.Files, file)
+			}
+		}
+	}
+	return entry
+}
+
+// failed reports whether a response from since onwards failed outright
+func (t transcriptTurn) failed(since time.Time) bool {
+	for _, step := range t.steps {
+		if step.failed && !step.at.Before(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// skillName is the skill a tool call loads, or "" if it isn't a skill call
+func skillName(tool transcriptTool) string {
+	if !strings.EqualFold(tool.name, "skill") {
+		return ""
+	}
+	for _, key := range []string{"skill", "name", "command"} {
+		if name, ok := tool.input[key].(string); ok && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// toolFile is the file a tool call reads or edits, if any
+func toolFile(tool transcriptTool) string {
+	for _, key := range []string{"file_path", "filePath", "notebook_path"} {
+		if path, ok := tool.input[key].(string); ok && path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// category is the plugin a namespaced name such as "superpowers:brainstorm"
+// comes from
+func category(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if plugin, _, ok := strings.Cut(name, ":"); ok {
+		return plugin
+	}
+	return ""
+}
+
+func projectName(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Base(dir)
+}
+
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "") + "…"
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ClaudeCodeAgent is the agent name entries imported from Claude Code
+// transcripts are recorded under
+const ClaudeCodeAgent = "claude-code"
+
+// claudeRecord is one line of a Claude Code session transcript
+type claudeRecord struct {
+	Type              string          `json:"type"`
+	UUID              string          `json:"uuid"`
+	SessionID         string          `json:"sessionId"`
+	Timestamp         time.Time       `json:"timestamp"`
+	Cwd               string          `json:"cwd"`
+	IsMeta            bool            `json:"isMeta"`
+	IsSidechain       bool            `json:"isSidechain"`
+	IsAPIErrorMessage bool            `json:"isApiErrorMessage"`
+	CostUSD           float64         `json:"costUSD"`
+	Message           json.RawMessage `json:"message"`
+}
+
+type claudeMessage struct {
+	ID      string          `json:"id"`
+	Role    string          `json:"role"`
+	Model   string          `json:"model"`
+	Content json.RawMessage `json:"content"`
+	Usage   struct {
+		InputTokens              int64 `json:"input_tokens"`
+		OutputTokens             int64 `json:"output_tokens"`
+		CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	} `json:"usage"`
+}
+
+type claudeBlock struct {
+	Type      string                 `json:"type"`
+	Text      string                 `json:"text"`
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Input     map[string]interface{} `json:"input"`
+	ToolUseID string                 `json:"tool_use_id"`
+	IsError   bool                   `json:"is_error"`
+}
+
+var (
+	claudeCommandName = regexp.MustCompile(`<command-name>\s*(/?[^<\s]+)\s*</command-name>`)
+	claudeCommandArgs = regexp.MustCompile(`(?s)<command-args>(.*?)</command-args>`)
+)
+
+// ParseClaudeTranscript reads a Claude Code session transcript, one JSON
+// record per line, and returns the slash commands and skills used in it.
+// Tokens count input, output and cache writes; cache reads are left out, as
+// they repeat the whole context on every response. Cost is only known for
+// transcripts that record costUSD.
+func ParseClaudeTranscript(r io.Reader) ([]UsageLog, error) {
+	session := transcriptSession{agent: ClaudeCodeAgent, idPrefix: "claude-code-"}
+	var turns []transcriptTurn
+	var turn *transcriptTurn
+	failedTools := make(map[string]bool)
+	seenMessages := make(map[string]bool)
+
+	reader := bufio.NewReader(r)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, fmt.Errorf("failed to read transcript: %w", readErr)
+		}
+
+		var record claudeRecord
+		var message claudeMessage
+		if json.Unmarshal(line, &record) == nil && (record.Type == "user" || record.Type == "assistant") &&
+			json.Unmarshal(record.Message, &message) == nil {
+			if session.id == "" {
+				session.id = record.SessionID
+			}
+			if session.project == "" {
+				session.project = projectName(record.Cwd)
+			}
+
+			blocks, text := claudeContent(message.Content)
+			switch {
+			case record.Type == "user" && !record.IsMeta && !record.IsSidechain && text != "":
+				if turn != nil {
+					turns = append(turns, *turn)
+				}
+				turn = &transcriptTurn{start: record.Timestamp, end: record.Timestamp, prompt: text}
+				if match := claudeCommandName.FindStringSubmatch(text); match != nil {
+					turn.command = "/" + strings.TrimPrefix(match[1], "/")
+					turn.commandID = record.UUID
+					turn.prompt = ""
+					if args := claudeCommandArgs.FindStringSubmatch(text); args != nil {
+						turn.prompt = strings.TrimSpace(args[1])
+					}
+				}
+			case turn == nil:
+				// Records before the first prompt, such as a resumed
+				// session's summary, belong to no turn
+			case record.Type == "user":
+				for _, block := range blocks {
+					if block.Type == "tool_result" && block.IsError {
+						failedTools[block.ToolUseID] = true
+					}
+				}
+			default:
+				step := transcriptStep{at: record.Timestamp, model: message.Model, failed: record.IsAPIErrorMessage, cost: record.CostUSD}
+				// A response is split across records, one per content
+				// block, each repeating its usage
+				if message.ID == "" || !seenMessages[message.ID] {
+					seenMessages[message.ID] = true
+					step.tokens = message.Usage.InputTokens + message.Usage.OutputTokens + message.Usage.CacheCreationInputTokens
+				}
+				for _, block := range blocks {
+					if block.Type == "tool_use" {
+						step.tools = append(step.tools, transcriptTool{id: block.ID, name: block.Name, input: block.Input, at: record.Timestamp})
+					}
+				}
+				turn.steps = append(turn.steps, step)
+			}
+			if turn != nil && record.Timestamp.After(turn.end) {
+				turn.end = record.Timestamp
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+	if turn != nil {
+		turns = append(turns, *turn)
+	}
+	for _, t := range turns {
+		for _, step := range t.steps {
+			for i := range step.tools {
+				step.tools[i].failed = failedTools[step.tools[i].id]
+			}
+		}
+	}
+
+	return sessionEntries(session, turns), nil
+}
+
+// claudeContent splits message content, a string or a list of blocks, into
+// its blocks and its prompt text
+func claudeContent(raw json.RawMessage) ([]claudeBlock, string) {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return nil, text
+	}
+	var blocks []claudeBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return nil, ""
+	}
+	var parts []string
+	for _, block := range blocks {
+		if block.Type == "text" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return blocks, strings.Join(parts, "\n")
+}
+
+// ClaudeTranscripts lists the session transcripts Claude Code keeps under
+// configDir (usually ~/.claude) for the project in projectDir, or for every
+// project when projectDir is empty
+func ClaudeTranscripts(configDir, projectDir string) ([]string, error) {
+	pattern := filepath.Join(configDir, "projects", "*", "*.jsonl")
+	if projectDir != "" {
+		abs, err := filepath.Abs(projectDir)
+		if err != nil {
+			return nil, err
+		}
+		pattern = filepath.Join(configDir, "projects", claudeProjectKey(abs), "*.jsonl")
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Claude Code transcripts: %w", err)
+	}
+	return paths, nil
+}
+
+// claudeProjectKey is the directory name Claude Code files a project's
+// transcripts under: its path with everything but letters and digits
+// replaced by dashes
+func claudeProjectKey(dir string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, dir)
+}
+
+// ParseClaudeTranscriptFile parses the transcript at path
+func ParseClaudeTranscriptFile(path string) ([]UsageLog, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer file.Close()
+	return ParseClaudeTranscript(file)
+}
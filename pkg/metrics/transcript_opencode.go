@@ -0,0 +1,247 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenCodeAgent is the agent name entries imported from OpenCode sessions
+// are recorded under when a message doesn't name the OpenCode agent that
+// sent it
+const OpenCodeAgent = "opencode"
+
+// OpenCode stores each session as JSON files under its storage directory
+// (usually ~/.local/share/opencode/storage): session/<project>/<session>.json,
+// message/<session>/<message>.json and part/<message>/<part>.json.
+
+type openCodeSession struct {
+	ID        string `json:"id"`
+	Directory string `json:"directory"`
+}
+
+type openCodeMessage struct {
+	ID    string `json:"id"`
+	Role  string `json:"role"`
+	Agent string `json:"agent"`
+	Mode  string `json:"mode"`
+	Time  struct {
+		Created   int64 `json:"created"`
+		Completed int64 `json:"completed"`
+	} `json:"time"`
+	ModelID string          `json:"modelID"`
+	Cost    float64         `json:"cost"`
+	Error   json.RawMessage `json:"error"`
+	Tokens  struct {
+		Input     int64 `json:"input"`
+		Output    int64 `json:"output"`
+		Reasoning int64 `json:"reasoning"`
+		Cache     struct {
+			Write int64 `json:"write"`
+		} `json:"cache"`
+	} `json:"tokens"`
+}
+
+type openCodePart struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Synth  bool   `json:"synthetic"`
+	CallID string `json:"callID"`
+	Tool   string `json:"tool"`
+	State  struct {
+		Status string                 `json:"status"`
+		Input  map[string]interface{} `json:"input"`
+		Time   struct {
+			Start int64 `json:"start"`
+			End   int64 `json:"end"`
+		} `json:"time"`
+	} `json:"state"`
+}
+
+// OpenCodeSessions lists the IDs of the sessions OpenCode keeps under
+// storageDir for the project in projectDir, or for every project when
+// projectDir is empty
+func OpenCodeSessions(storageDir, projectDir string) ([]string, error) {
+	if projectDir != "" {
+		abs, err := filepath.Abs(projectDir)
+		if err != nil {
+			return nil, err
+		}
+		projectDir = abs
+	}
+
+	paths, err := filepath.Glob(filepath.Join(storageDir, "session", "*", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OpenCode sessions: %w", err)
+	}
+	var ids []string
+	for _, path := range paths {
+		var session openCodeSession
+		if err := readJSONFile(path, &session); err != nil || session.ID == "" {
+			continue
+		}
+		if projectDir == "" || filepath.Clean(session.Directory) == projectDir {
+			ids = append(ids, session.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// ParseOpenCodeSession reads a session from OpenCode's storage and returns the
+// skills its agents loaded and the commands it ran. OpenCode stores a
+// command's expanded prompt, so commands are only recognised when the prompt
+// still starts with /name. Tokens count input, output, reasoning and cache
+// writes.
+func ParseOpenCodeSession(storageDir, sessionID string) ([]UsageLog, error) {
+	session := transcriptSession{id: sessionID, idPrefix: "opencode-"}
+	if paths, _ := filepath.Glob(filepath.Join(storageDir, "session", "*", sessionID+".json")); len(paths) > 0 {
+		var info openCodeSession
+		if err := readJSONFile(paths[0], &info); err == nil {
+			session.project = projectName(info.Directory)
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(storageDir, "message", sessionID, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OpenCode messages: %w", err)
+	}
+	messages := make([]openCodeMessage, 0, len(paths))
+	for _, path := range paths {
+		var message openCodeMessage
+		if err := readJSONFile(path, &message); err != nil {
+			continue
+		}
+		messages = append(messages, message)
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		if messages[i].Time.Created != messages[j].Time.Created {
+			return messages[i].Time.Created < messages[j].Time.Created
+		}
+		return messages[i].ID < messages[j].ID
+	})
+
+	var turns []transcriptTurn
+	var turn *transcriptTurn
+	for _, message := range messages {
+		parts, err := openCodeParts(storageDir, message.ID)
+		if err != nil {
+			return nil, err
+		}
+		created := time.UnixMilli(message.Time.Created)
+
+		if message.Role == "user" {
+			if turn != nil {
+				turns = append(turns, *turn)
+			}
+			turn = &transcriptTurn{start: created, end: created, prompt: openCodeText(parts)}
+			if name, args, ok := strings.Cut(strings.TrimSpace(turn.prompt)+" ", " "); ok && strings.HasPrefix(name, "/") && len(name) > 1 {
+				turn.command = name
+				turn.commandID = message.ID
+				turn.prompt = strings.TrimSpace(args)
+			}
+			continue
+		}
+		if turn == nil {
+			continue
+		}
+
+		if session.agent == "" {
+			session.agent = openCodeAgentName(message)
+		}
+		step := transcriptStep{
+			at:     created,
+			model:  message.ModelID,
+			tokens: message.Tokens.Input + message.Tokens.Output + message.Tokens.Reasoning + message.Tokens.Cache.Write,
+			cost:   message.Cost,
+			failed: len(message.Error) > 0 && string(message.Error) != "null",
+		}
+		end := created
+		if message.Time.Completed > 0 {
+			end = time.UnixMilli(message.Time.Completed)
+		}
+		for _, part := range parts {
+			if part.Type != "tool" {
+				continue
+			}
+			at := created
+			if part.State.Time.Start > 0 {
+				at = time.UnixMilli(part.State.Time.Start)
+			}
+			if done := time.UnixMilli(part.State.Time.End); done.After(end) {
+				end = done
+			}
+			id := part.CallID
+			if id == "" {
+				id = part.ID
+			}
+			step.tools = append(step.tools, transcriptTool{
+				id:     id,
+				name:   part.Tool,
+				input:  part.State.Input,
+				at:     at,
+				failed: part.State.Status == "error",
+			})
+		}
+		turn.steps = append(turn.steps, step)
+		if end.After(turn.end) {
+			turn.end = end
+		}
+	}
+	if turn != nil {
+		turns = append(turns, *turn)
+	}
+
+	if session.agent == "" {
+		session.agent = OpenCodeAgent
+	}
+	return sessionEntries(session, turns), nil
+}
+
+func openCodeAgentName(message openCodeMessage) string {
+	if message.Agent != "" {
+		return message.Agent
+	}
+	return message.Mode
+}
+
+func openCodeParts(storageDir, messageID string) ([]openCodePart, error) {
+	paths, err := filepath.Glob(filepath.Join(storageDir, "part", messageID, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OpenCode message parts: %w", err)
+	}
+	sort.Strings(paths)
+	parts := make([]openCodePart, 0, len(paths))
+	for _, path := range paths {
+		var part openCodePart
+		if err := readJSONFile(path, &part); err == nil {
+			parts = append(parts, part)
+		}
+	}
+	return parts, nil
+}
+
+// openCodeText is the text the user typed, leaving out the synthetic parts
+// OpenCode adds, such as the contents of attached files
+func openCodeText(parts []openCodePart) string {
+	var texts []string
+	for _, part := range parts {
+		if part.Type == "text" && !part.Synth {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
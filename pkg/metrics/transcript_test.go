@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const claudeTranscript = `{"type":"summary","summary":"Earlier work"}
+{"type":"user","uuid":"u1","sessionId":"s1","cwd":"/home/dev/shop","timestamp":"2026-05-01T10:00:00.000Z","message":{"role":"user","content":"<command-name>/review</command-name>\n<command-args>the checkout flow</command-args>"}}
+{"type":"user","uuid":"u2","sessionId":"s1","timestamp":"2026-05-01T10:00:00.100Z","isMeta":true,"message":{"role":"user","content":"Review the code..."}}
+{"type":"assistant","uuid":"a1","sessionId":"s1","timestamp":"2026-05-01T10:00:05.000Z","message":{"id":"m1","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Looking"}],"usage":{"input_tokens":100,"output_tokens":20,"cache_creation_input_tokens":30,"cache_read_input_tokens":9999}}}
+{"type":"assistant","uuid":"a2","sessionId":"s1","timestamp":"2026-05-01T10:00:06.000Z","message":{"id":"m1","role":"assistant","model":"claude-sonnet-4","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/home/dev/shop/cart.go"}}],"usage":{"input_tokens":100,"output_tokens":20,"cache_creation_input_tokens":30}}}
+{"type":"user","uuid":"u3","sessionId":"s1","timestamp":"2026-05-01T10:00:07.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"package cart"}]}}
+{"type":"assistant","uuid":"a3","sessionId":"s1","timestamp":"2026-05-01T10:00:20.000Z","costUSD":0.02,"message":{"id":"m2","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Done"}],"usage":{"input_tokens":50,"output_tokens":50}}}
+{"type":"user","uuid":"u4","sessionId":"s1","timestamp":"2026-05-01T11:00:00.000Z","message":{"role":"user","content":[{"type":"text","text":"Brainstorm a refund feature"}]}}
+{"type":"assistant","uuid":"a4","sessionId":"s1","timestamp":"2026-05-01T11:00:02.000Z","message":{"id":"m3","role":"assistant","model":"claude-opus-4","content":[{"type":"tool_use","id":"t2","name":"Skill","input":{"skill":"superpowers:brainstorming"}}],"usage":{"input_tokens":10,"output_tokens":5}}}
+{"type":"user","uuid":"u5","sessionId":"s1","timestamp":"2026-05-01T11:00:03.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","is_error":true,"content":"Unknown skill"}]}}
+{"type":"assistant","uuid":"a5","sessionId":"s1","timestamp":"2026-05-01T11:00:10.000Z","message":{"id":"m4","role":"assistant","model":"claude-opus-4","content":[{"type":"text","text":"Sorry"}],"usage":{"input_tokens":10,"output_tokens":5}}}
+not json
+`
+
+func TestParseClaudeTranscript(t *testing.T) {
+	entries, err := ParseClaudeTranscript(strings.NewReader(claudeTranscript))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	review := entries[0]
+	assert.Equal(t, "claude-code-u1", review.ID)
+	assert.Equal(t, "2026-05-01T10:00:00Z", review.Timestamp)
+	assert.Equal(t, ClaudeCodeAgent, review.Agent)
+	assert.Equal(t, Invocation{Type: "command", Name: "/review"}, review.Invocation)
+	assert.Equal(t, "the checkout flow", review.Context.Task)
+	assert.Equal(t, "shop", review.Context.Project)
+	assert.Equal(t, []string{"/home/dev/shop/cart.go"}, review.Context.Files)
+	assert.Equal(t, int64(20000), review.Outcome.Duration)
+	assert.Equal(t, int64(250), review.Outcome.TokensUsed, "repeated usage is counted once and cache reads not at all")
+	assert.InDelta(t, 0.02, review.Outcome.Cost, 1e-9)
+	assert.True(t, review.Outcome.Success)
+	assert.Equal(t, "claude-sonnet-4", review.Metadata.Model)
+	assert.Equal(t, "s1", review.Metadata.SessionID)
+	assert.Equal(t, []string{"Read"}, review.Metadata.ToolCalls)
+
+	skill := entries[1]
+	assert.Equal(t, "claude-code-t2", skill.ID)
+	assert.Equal(t, Invocation{Type: "skill", Name: "superpowers:brainstorming", Category: "superpowers"}, skill.Invocation)
+	assert.Equal(t, "Brainstorm a refund feature", skill.Context.Task)
+	assert.Equal(t, int64(8000), skill.Outcome.Duration)
+	assert.Equal(t, int64(30), skill.Outcome.TokensUsed)
+	assert.False(t, skill.Outcome.Success)
+}
+
+func TestClaudeTranscripts(t *testing.T) {
+	configDir := t.TempDir()
+	project := filepath.Join(t.TempDir(), "my_shop")
+	require.NoError(t, os.MkdirAll(project, 0755))
+
+	own := filepath.Join(configDir, "projects", claudeProjectKey(project))
+	other := filepath.Join(configDir, "projects", "-elsewhere")
+	for _, dir := range []string{own, other} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "s1.jsonl"), nil, 0644))
+	}
+	assert.NotContains(t, claudeProjectKey(project), "_")
+
+	paths, err := ClaudeTranscripts(configDir, project)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(own, "s1.jsonl")}, paths)
+
+	paths, err = ClaudeTranscripts(configDir, "")
+	require.NoError(t, err)
+	assert.Len(t, paths, 2)
+}
+
+func TestParseOpenCodeSession(t *testing.T) {
+	storage := t.TempDir()
+	write := func(path, content string) {
+		full := filepath.Join(storage, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+
+	write("session/p1/ses_1.json", `{"id":"ses_1","directory":"/home/dev/shop"}`)
+	write("session/p1/ses_2.json", `{"id":"ses_2","directory":"/home/dev/other"}`)
+	write("message/ses_1/msg_1.json", `{"id":"msg_1","role":"user","time":{"created":1777629600000}}`)
+	write("part/msg_1/prt_1.json", `{"id":"prt_1","type":"text","text":"/test cart package"}`)
+	write("part/msg_1/prt_2.json", `{"id":"prt_2","type":"text","text":"contents of cart.go","synthetic":true}`)
+	write("message/ses_1/msg_2.json", `{"id":"msg_2","role":"assistant","agent":"sisyphus","modelID":"gpt-5","cost":0.5,
+		"time":{"created":1777629601000,"completed":1777629630000},
+		"tokens":{"input":1000,"output":200,"reasoning":50,"cache":{"read":5000,"write":10}}}`)
+	write("part/msg_2/prt_3.json", `{"id":"prt_3","type":"tool","tool":"skill","callID":"call_1",
+		"state":{"status":"completed","input":{"name":"go-testing"},"time":{"start":1777629605000,"end":1777629606000}}}`)
+	write("part/msg_2/prt_4.json", `{"id":"prt_4","type":"tool","tool":"edit","callID":"call_2",
+		"state":{"status":"error","input":{"filePath":"/home/dev/shop/cart_test.go"},"time":{"start":1777629610000,"end":1777629612000}}}`)
+
+	ids, err := OpenCodeSessions(storage, "/home/dev/shop")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ses_1"}, ids)
+
+	entries, err := ParseOpenCodeSession(storage, "ses_1")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	command := entries[0]
+	assert.Equal(t, "opencode-msg_1", command.ID)
+	assert.Equal(t, "sisyphus", command.Agent)
+	assert.Equal(t, Invocation{Type: "command", Name: "/test"}, command.Invocation)
+	assert.Equal(t, "cart package", command.Context.Task)
+	assert.Equal(t, "shop", command.Context.Project)
+	assert.Equal(t, int64(30000), command.Outcome.Duration)
+	assert.Equal(t, int64(1260), command.Outcome.TokensUsed)
+	assert.InDelta(t, 0.5, command.Outcome.Cost, 1e-9)
+	assert.True(t, command.Outcome.Success, "a failed edit doesn't fail the command")
+	assert.Equal(t, []string{"skill", "edit"}, command.Metadata.ToolCalls)
+	assert.Equal(t, []string{"/home/dev/shop/cart_test.go"}, command.Context.Files)
+
+	skill := entries[1]
+	assert.Equal(t, "opencode-call_1", skill.ID)
+	assert.Equal(t, Invocation{Type: "skill", Name: "go-testing"}, skill.Invocation)
+	assert.Equal(t, int64(25000), skill.Outcome.Duration)
+	assert.True(t, skill.Outcome.Success)
+}
+
+func TestLogger_ImportSkipsRecordedEntries(t *testing.T) {
+	logger := NewLogger(t.TempDir())
+	entries, err := ParseClaudeTranscript(strings.NewReader(claudeTranscript))
+	require.NoError(t, err)
+
+	imported, err := logger.Import(entries)
+	require.NoError(t, err)
+	assert.Equal(t, 2, imported)
+
+	imported, err = logger.Import(entries)
+	require.NoError(t, err)
+	assert.Equal(t, 0, imported)
+
+	stored, err := logger.Query(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, stored, 2)
+}
@@ -9,8 +9,11 @@ const (
 )
 
 type UsageLog struct {
-	ID         string     `json:"id"`
-	Timestamp  string     `json:"timestamp"`
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	// User is who the entry came from in team usage; local entries leave it
+	// empty
+	User       string     `json:"user,omitempty"`
 	Agent      string     `json:"agent"`
 	Invocation Invocation `json:"invocation"`
 	Context    Context    `json:"context"`
@@ -113,3 +116,33 @@ type PercentImprovement struct {
 	SuccessRate   float64 `json:"successRate"`
 	TokensPerTask float64 `json:"tokensPerTask"`
 }
+
+// TeamReport summarizes the usage a team shares with the coordination server
+type TeamReport struct {
+	Project      string              `json:"project"`
+	Days         int                 `json:"days"`
+	Contributors int                 `json:"contributors"`
+	Metrics      ProductivityMetrics `json:"metrics"`
+	Patterns     []UsagePattern      `json:"patterns"`
+	Adoption     []Adoption          `json:"adoption"`
+	// ByUser is left out when the server hides who uploaded what
+	ByUser []UserUsage `json:"byUser,omitempty"`
+}
+
+// Adoption is how widely one rule, skill or command is used across a team
+type Adoption struct {
+	Type        string  `json:"type"`
+	Name        string  `json:"name"`
+	Invocations int     `json:"invocations"`
+	Users       int     `json:"users"`
+	SuccessRate float64 `json:"successRate"`
+}
+
+// UserUsage is one team member's share of a TeamReport
+type UserUsage struct {
+	User        string  `json:"user"`
+	Invocations int     `json:"invocations"`
+	SuccessRate float64 `json:"successRate"`
+	TokensUsed  int64   `json:"tokensUsed"`
+	Cost        float64 `json:"cost"`
+}
@@ -0,0 +1,49 @@
+package plugins
+
+// AgentDefinition declares an agent adapter in plugin.yaml, so nexus can
+// configure agents it has no built-in support for. Paths are relative to the
+// workspace root.
+//
+//	agents:
+//	  - name: windsurf
+//	    detect: {commands: [windsurf], paths: ["~/.codeium/windsurf"]}
+//	    rules_format: md
+//	    rules_dir: .windsurf/rules
+//	    commands_dir: .windsurf/workflows
+//	    mcp: [{path: .windsurf/mcp.json, format: mcpServers}]
+//	    gitignore: [.windsurf/]
+type AgentDefinition struct {
+	Name   string      `yaml:"name"`
+	Detect AgentDetect `yaml:"detect,omitempty"`
+	// Template is rendered to Output with the project's template data
+	Template string `yaml:"template,omitempty"`
+	Output   string `yaml:"output,omitempty"`
+	// RulesFormat is "md" or "mdc"
+	RulesFormat string `yaml:"rules_format,omitempty"`
+	RulesDir    string `yaml:"rules_dir,omitempty"`
+	// SkillsLayout is "skill" for <dir>/<name>/SKILL.md, the default, or
+	// "mdc" to write skills as rules
+	SkillsLayout string         `yaml:"skills_layout,omitempty"`
+	SkillsDir    string         `yaml:"skills_dir,omitempty"`
+	CommandsDir  string         `yaml:"commands_dir,omitempty"`
+	MCP          []AgentMCPFile `yaml:"mcp,omitempty"`
+	Gitignore    []string       `yaml:"gitignore,omitempty"`
+}
+
+// AgentDetect finds an installed agent. The agent is detected when any
+// command is on PATH, any path exists or any environment variable is set.
+type AgentDetect struct {
+	Commands []string `yaml:"commands,omitempty"`
+	// Paths may start with ~ for the home directory
+	Paths []string `yaml:"paths,omitempty"`
+	Env   []string `yaml:"env,omitempty"`
+}
+
+// AgentMCPFile is an MCP config file written for an agent
+type AgentMCPFile struct {
+	Path string `yaml:"path"`
+	// Format is one of mcpServers, claude-code, claude-desktop or opencode
+	Format string `yaml:"format"`
+	// Always writes the file even when no plugin declares MCP servers
+	Always bool `yaml:"always,omitempty"`
+}
@@ -0,0 +1,274 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxContentSize bounds the size of files read by content conditions
+const maxContentSize = 1 << 20
+
+// skippedDirs are not searched by recursive globs
+var skippedDirs = map[string]bool{
+	".git":           true,
+	"node_modules":   true,
+	".nexus-runtime": true,
+}
+
+// Condition decides whether a plugin is active in a workspace. Every field
+// that is set must match:
+//
+//	conditions:
+//	  - file: go.mod                      # glob relative to the workspace root, ** allowed
+//	  - content: {file: package.json, pattern: '"react"'}
+//	  - env: CI                           # set and non-empty; "NAME=value" matches exactly
+//	  - agent: cursor                     # detected agent
+//	  - any: [{file: requirements.txt}, {file: pyproject.toml}]
+//	  - not: {file: "**/*.py"}
+type Condition struct {
+	File    string            `yaml:"file,omitempty"`
+	Content *ContentCondition `yaml:"content,omitempty"`
+	Env     string            `yaml:"env,omitempty"`
+	Agent   string            `yaml:"agent,omitempty"`
+	All     []Condition       `yaml:"all,omitempty"`
+	Any     []Condition       `yaml:"any,omitempty"`
+	Not     *Condition        `yaml:"not,omitempty"`
+}
+
+// ContentCondition matches when a file matching the File glob has content
+// matching the Pattern regular expression
+type ContentCondition struct {
+	File    string `yaml:"file"`
+	Pattern string `yaml:"pattern"`
+}
+
+// Conditions is the conditions list of a plugin manifest. The plugin is active
+// when all of them match; an empty list is always active.
+type Conditions []Condition
+
+// Environment is what conditions are evaluated against
+type Environment struct {
+	// Root is the workspace root that file globs are relative to
+	Root string
+	// Agents are the detected agents, e.g. "cursor" or "claude-code"
+	Agents []string
+	// LookupEnv reads environment variables; os.LookupEnv when nil
+	LookupEnv func(string) (string, bool)
+}
+
+// BuiltinConditions activate well-known plugins that ship without a manifest
+var BuiltinConditions = map[string]Conditions{
+	"golang": {{Any: []Condition{{File: "go.mod"}, {File: "go.sum"}}}},
+	"node":   {{File: "package.json"}},
+	"python": {{Any: []Condition{{File: "requirements.txt"}, {File: "pyproject.toml"}}}},
+}
+
+// Match reports whether all conditions match in env
+func (cs Conditions) Match(env Environment) (bool, error) {
+	return Condition{All: cs}.Match(env)
+}
+
+// Match reports whether the condition matches in env. It fails on invalid
+// globs or regular expressions.
+func (c Condition) Match(env Environment) (bool, error) {
+	checks := []func(Environment) (bool, error){
+		c.matchFile,
+		c.matchContent,
+		c.matchEnv,
+		c.matchAgent,
+		c.matchAll,
+		c.matchAny,
+		c.matchNot,
+	}
+	for _, check := range checks {
+		ok, err := check(env)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (c Condition) matchFile(env Environment) (bool, error) {
+	if c.File == "" {
+		return true, nil
+	}
+	found := false
+	err := walkGlob(env.Root, c.File, func(string) (bool, error) {
+		found = true
+		return true, nil
+	})
+	return found, err
+}
+
+func (c Condition) matchContent(env Environment) (bool, error) {
+	if c.Content == nil {
+		return true, nil
+	}
+	re, err := regexp.Compile(c.Content.Pattern)
+	if err != nil {
+		return false, fmt.Errorf("invalid content pattern %q: %w", c.Content.Pattern, err)
+	}
+
+	found := false
+	err = walkGlob(env.Root, c.Content.File, func(file string) (bool, error) {
+		info, err := os.Stat(file)
+		if err != nil || info.IsDir() || info.Size() > maxContentSize {
+			return false, nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return false, nil
+		}
+		found = re.Match(data)
+		return found, nil
+	})
+	return found, err
+}
+
+func (c Condition) matchEnv(env Environment) (bool, error) {
+	if c.Env == "" {
+		return true, nil
+	}
+	lookup := env.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	name, want, exact := strings.Cut(c.Env, "=")
+	value, ok := lookup(name)
+	if exact {
+		return ok && value == want, nil
+	}
+	return ok && value != "", nil
+}
+
+func (c Condition) matchAgent(env Environment) (bool, error) {
+	if c.Agent == "" {
+		return true, nil
+	}
+	for _, agent := range env.Agents {
+		if agent == c.Agent {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c Condition) matchAll(env Environment) (bool, error) {
+	for _, sub := range c.All {
+		ok, err := sub.Match(env)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (c Condition) matchAny(env Environment) (bool, error) {
+	if len(c.Any) == 0 {
+		return true, nil
+	}
+	for _, sub := range c.Any {
+		ok, err := sub.Match(env)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c Condition) matchNot(env Environment) (bool, error) {
+	if c.Not == nil {
+		return true, nil
+	}
+	ok, err := c.Not.Match(env)
+	return !ok, err
+}
+
+// walkGlob calls visit for each path under root matching pattern until visit
+// returns true. Patterns use forward slashes; "**" matches any number of
+// directories.
+func walkGlob(root, pattern string, visit func(string) (bool, error)) error {
+	pattern = path.Clean(strings.TrimPrefix(filepath.ToSlash(pattern), "./"))
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid file pattern %q: %w", pattern, err)
+	}
+
+	// Plain paths need no directory walk
+	if !strings.ContainsAny(pattern, "*?[") {
+		file := filepath.Join(root, filepath.FromSlash(pattern))
+		if _, err := os.Stat(file); err != nil {
+			return nil
+		}
+		_, err := visit(file)
+		return err
+	}
+
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories cannot match
+			return nil
+		}
+		if d.IsDir() && skippedDirs[d.Name()] && file != root {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil || rel == "." {
+			return nil
+		}
+		if !matchGlob(pattern, filepath.ToSlash(rel)) {
+			return nil
+		}
+
+		done, err := visit(file)
+		if err != nil {
+			return err
+		}
+		if done {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// matchGlob matches a slash-separated path against a pattern where "**"
+// matches zero or more path segments
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,137 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestConditionMatch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/app")
+	writeFile(t, root, "web/package.json", `{"dependencies": {"react": "^18.0.0"}}`)
+	writeFile(t, root, "services/api/main.py", "print('hi')")
+	writeFile(t, root, "node_modules/left-pad/index.ts", "")
+
+	env := Environment{
+		Root:   root,
+		Agents: []string{"cursor"},
+		LookupEnv: func(name string) (string, bool) {
+			values := map[string]string{"CI": "true", "PROFILE": "strict", "EMPTY": ""}
+			v, ok := values[name]
+			return v, ok
+		},
+	}
+
+	tests := []struct {
+		name string
+		cond Condition
+		want bool
+	}{
+		{name: "plain file", cond: Condition{File: "go.mod"}, want: true},
+		{name: "missing file", cond: Condition{File: "Cargo.toml"}, want: false},
+		{name: "single level glob", cond: Condition{File: "*.mod"}, want: true},
+		{name: "recursive glob", cond: Condition{File: "**/*.py"}, want: true},
+		{name: "recursive glob at root", cond: Condition{File: "**/go.mod"}, want: true},
+		{name: "skipped dirs", cond: Condition{File: "**/*.ts"}, want: false},
+		{name: "content", cond: Condition{Content: &ContentCondition{File: "**/package.json", Pattern: `"react"`}}, want: true},
+		{name: "content mismatch", cond: Condition{Content: &ContentCondition{File: "**/package.json", Pattern: `"vue"`}}, want: false},
+		{name: "env set", cond: Condition{Env: "CI"}, want: true},
+		{name: "env empty", cond: Condition{Env: "EMPTY"}, want: false},
+		{name: "env value", cond: Condition{Env: "PROFILE=strict"}, want: true},
+		{name: "env other value", cond: Condition{Env: "PROFILE=loose"}, want: false},
+		{name: "agent", cond: Condition{Agent: "cursor"}, want: true},
+		{name: "missing agent", cond: Condition{Agent: "opencode"}, want: false},
+		{name: "all", cond: Condition{All: []Condition{{File: "go.mod"}, {Env: "CI"}}}, want: true},
+		{name: "all fails", cond: Condition{All: []Condition{{File: "go.mod"}, {Env: "MISSING"}}}, want: false},
+		{name: "any", cond: Condition{Any: []Condition{{File: "Cargo.toml"}, {File: "go.mod"}}}, want: true},
+		{name: "any fails", cond: Condition{Any: []Condition{{File: "Cargo.toml"}, {Agent: "opencode"}}}, want: false},
+		{name: "not", cond: Condition{Not: &Condition{File: "Cargo.toml"}}, want: true},
+		{name: "fields combine", cond: Condition{File: "go.mod", Agent: "opencode"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cond.Match(env)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConditionMatch_Errors(t *testing.T) {
+	env := Environment{Root: t.TempDir()}
+
+	_, err := Condition{Content: &ContentCondition{File: "*", Pattern: "("}}.Match(env)
+	assert.ErrorContains(t, err, "invalid content pattern")
+
+	_, err = Condition{File: "[a-"}.Match(env)
+	assert.ErrorContains(t, err, "invalid file pattern")
+}
+
+func TestConditions_FromManifest(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "requirements.txt", "flask")
+	writeFile(t, root, "plugin.yaml", `plugin:
+  name: python-web
+  conditions:
+    - any:
+        - file: requirements.txt
+        - file: pyproject.toml
+    - not:
+        env: NEXUS_DISABLE_PYTHON
+`)
+
+	manifest, err := LoadManifest(filepath.Join(root, "plugin.yaml"))
+	require.NoError(t, err)
+	require.Len(t, manifest.Plugin.Conditions, 2)
+
+	env := Environment{Root: root, LookupEnv: func(string) (string, bool) { return "", false }}
+	ok, err := manifest.Plugin.Conditions.Match(env)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	env.LookupEnv = func(string) (string, bool) { return "1", true }
+	ok, err = manifest.Plugin.Conditions.Match(env)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	var empty Conditions
+	ok, err = empty.Match(env)
+	require.NoError(t, err)
+	assert.True(t, ok, "plugins without conditions are always active")
+}
+
+func TestLoadManifest_FlatForm(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "plugin.yaml", "name: extracted\nversion: 1.0.0\nconditions:\n  - file: go.mod\nrules: ['*']\n")
+
+	manifest, err := LoadManifest(filepath.Join(root, "plugin.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "extracted", manifest.Plugin.Name)
+	assert.Equal(t, Conditions{{File: "go.mod"}}, manifest.Plugin.Conditions)
+}
+
+func TestCondition_UnmarshalNested(t *testing.T) {
+	var cs Conditions
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- all:
+    - content: {file: package.json, pattern: react}
+    - agent: claude-code
+`), &cs))
+	require.Len(t, cs, 1)
+	require.Len(t, cs[0].All, 2)
+	assert.Equal(t, "react", cs[0].All[0].Content.Pattern)
+	assert.Equal(t, "claude-code", cs[0].All[1].Agent)
+}
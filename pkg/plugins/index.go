@@ -0,0 +1,180 @@
+package plugins
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nexus/nexus/pkg/tracing"
+)
+
+// indexFiles are the catalog files looked up at the root of a git index
+var indexFiles = []string{"index.yaml", "index.yml", "index.json"}
+
+// Index is a catalog of published plugins, written as YAML or JSON:
+//
+//	plugins:
+//	  - name: golang-base
+//	    description: Go conventions and review rules
+//	    repository: https://github.com/acme/nexus-plugins.git
+//	    path: golang/base
+//	    versions: ["1.0.0", "1.2.0"]
+type Index struct {
+	Plugins []IndexEntry `yaml:"plugins" json:"plugins"`
+}
+
+// IndexEntry describes a plugin published in an index
+type IndexEntry struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Repository  string   `yaml:"repository" json:"repository"`
+	Path        string   `yaml:"path,omitempty" json:"path,omitempty"`
+	Versions    []string `yaml:"versions,omitempty" json:"versions,omitempty"`
+	Tags        []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// Latest returns the highest released version of the entry, or "" when it lists none
+func (e IndexEntry) Latest() string {
+	var latest *Version
+	for _, s := range e.Versions {
+		v, err := ParseVersion(s)
+		if err != nil || v.Prerelease != "" {
+			continue
+		}
+		if latest == nil || v.Compare(*latest) > 0 {
+			latest = &v
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.String()
+}
+
+// LoadIndex reads a plugin index from source: an http(s) URL serving the
+// catalog, a git repository ("git+https://...", or any URL ending in .git)
+// holding index.yaml at its root, or a local file
+func LoadIndex(source string) (*Index, error) {
+	var data []byte
+	var err error
+
+	switch {
+	case strings.HasPrefix(source, "git+") || strings.HasSuffix(source, ".git"):
+		data, err = readGitIndex(strings.TrimPrefix(source, "git+"))
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		data, err = readHTTPIndex(source)
+	default:
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin index %s: %w", source, err)
+	}
+
+	return ParseIndex(data)
+}
+
+// ParseIndex parses a YAML or JSON plugin index
+func ParseIndex(data []byte) (*Index, error) {
+	var index Index
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse plugin index: %w", err)
+	}
+
+	seen := make(map[string]bool, len(index.Plugins))
+	for _, entry := range index.Plugins {
+		if entry.Name == "" || entry.Repository == "" {
+			return nil, fmt.Errorf("invalid plugin index: every plugin needs a name and repository")
+		}
+		if seen[entry.Name] {
+			return nil, fmt.Errorf("invalid plugin index: plugin %s is listed twice", entry.Name)
+		}
+		seen[entry.Name] = true
+	}
+
+	return &index, nil
+}
+
+func readHTTPIndex(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second, Transport: tracing.Transport(nil)}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func readGitIndex(repository string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "nexus-plugin-index-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.Command("git", "clone", "--quiet", "--depth", "1", repository, dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("git clone failed: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	for _, name := range indexFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("repository has none of %s", strings.Join(indexFiles, ", "))
+}
+
+// Lookup returns the entry named name
+func (idx *Index) Lookup(name string) (IndexEntry, bool) {
+	for _, entry := range idx.Plugins {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return IndexEntry{}, false
+}
+
+// Search returns the entries whose name, description or tags contain term,
+// ignoring case, sorted by name. An empty term matches every entry.
+func (idx *Index) Search(term string) []IndexEntry {
+	term = strings.ToLower(strings.TrimSpace(term))
+
+	var matches []IndexEntry
+	for _, entry := range idx.Plugins {
+		haystack := strings.ToLower(entry.Name + "\n" + entry.Description + "\n" + strings.Join(entry.Tags, "\n"))
+		if strings.Contains(haystack, term) {
+			matches = append(matches, entry)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+	return matches
+}
+
+// ParsePluginRef splits "name@constraint" into its parts. The constraint is
+// "" when the reference has none.
+func ParsePluginRef(ref string) (string, string, error) {
+	name, constraint, _ := strings.Cut(strings.TrimSpace(ref), "@")
+	if name == "" {
+		return "", "", fmt.Errorf("invalid plugin reference %q", ref)
+	}
+	if constraint != "" {
+		if _, err := ParseConstraint(constraint); err != nil {
+			return "", "", err
+		}
+	}
+	return name, constraint, nil
+}
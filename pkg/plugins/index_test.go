@@ -0,0 +1,100 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIndex = `plugins:
+  - name: golang-base
+    description: Go conventions and review rules
+    repository: https://github.com/acme/nexus-plugins.git
+    path: golang/base
+    versions: ["1.0.0", "1.2.0", "1.3.0-rc.1"]
+    tags: [go]
+  - name: node-base
+    description: Node.js tooling
+    repository: https://github.com/acme/node-plugin.git
+`
+
+func TestParseIndex(t *testing.T) {
+	idx, err := ParseIndex([]byte(testIndex))
+	require.NoError(t, err)
+	require.Len(t, idx.Plugins, 2)
+
+	entry, ok := idx.Lookup("golang-base")
+	require.True(t, ok)
+	assert.Equal(t, "golang/base", entry.Path)
+	assert.Equal(t, "1.2.0", entry.Latest(), "pre-releases are not the latest version")
+
+	_, ok = idx.Lookup("missing")
+	assert.False(t, ok)
+
+	jsonIdx, err := ParseIndex([]byte(`{"plugins": [{"name": "a", "repository": "https://example.com/a.git"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "a", jsonIdx.Plugins[0].Name)
+
+	_, err = ParseIndex([]byte("plugins:\n  - name: a\n"))
+	assert.ErrorContains(t, err, "needs a name and repository")
+
+	_, err = ParseIndex([]byte("plugins:\n  - {name: a, repository: r}\n  - {name: a, repository: r}\n"))
+	assert.ErrorContains(t, err, "listed twice")
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx, err := ParseIndex([]byte(testIndex))
+	require.NoError(t, err)
+
+	assert.Len(t, idx.Search(""), 2)
+	assert.Equal(t, "golang-base", idx.Search("REVIEW")[0].Name, "description matches ignore case")
+	assert.Equal(t, "golang-base", idx.Search("go")[0].Name)
+	assert.Len(t, idx.Search("node"), 1)
+	assert.Empty(t, idx.Search("rust"))
+}
+
+func TestLoadIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testIndex))
+	}))
+	defer server.Close()
+
+	idx, err := LoadIndex(server.URL + "/index.yaml")
+	require.NoError(t, err)
+	assert.Len(t, idx.Plugins, 2)
+
+	_, err = LoadIndex(server.URL + "/missing.yaml")
+	assert.ErrorContains(t, err, "404")
+
+	path := filepath.Join(t.TempDir(), "index.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testIndex), 0644))
+	idx, err = LoadIndex(path)
+	require.NoError(t, err)
+	assert.Len(t, idx.Plugins, 2)
+}
+
+func TestParsePluginRef(t *testing.T) {
+	name, constraint, err := ParsePluginRef("golang-base@^1.2")
+	require.NoError(t, err)
+	assert.Equal(t, "golang-base", name)
+	assert.Equal(t, "^1.2", constraint)
+
+	name, constraint, err = ParsePluginRef("golang-base")
+	require.NoError(t, err)
+	assert.Equal(t, "golang-base", name)
+	assert.Empty(t, constraint)
+
+	_, _, err = ParsePluginRef("golang-base@^abc")
+	assert.Error(t, err)
+	_, _, err = ParsePluginRef("@1.0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MCPServer is an MCP server contributed by a plugin. Local servers set
+// Command; remote servers set URL.
+//
+//	mcp_servers:
+//	  github:
+//	    command: npx
+//	    args: ["-y", "@modelcontextprotocol/server-github"]
+//	    env: {GITHUB_TOKEN: "${GITHUB_TOKEN}"}
+//	  docs:
+//	    url: https://mcp.example.com/sse
+type MCPServer struct {
+	Command string            `yaml:"command,omitempty" json:"command,omitempty"`
+	Args    []string          `yaml:"args,omitempty" json:"args,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	URL     string            `yaml:"url,omitempty" json:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+// IsRemote reports whether the server is reached over HTTP rather than started locally
+func (s MCPServer) IsRemote() bool {
+	return s.URL != ""
+}
+
+// Validate checks that the server sets exactly one of command and url
+func (s MCPServer) Validate() error {
+	switch {
+	case s.Command == "" && s.URL == "":
+		return fmt.Errorf("either command or url is required")
+	case s.Command != "" && s.URL != "":
+		return fmt.Errorf("command and url are mutually exclusive")
+	case s.URL != "" && !strings.HasPrefix(s.URL, "http://") && !strings.HasPrefix(s.URL, "https://"):
+		return fmt.Errorf("url %q must use http or https", s.URL)
+	}
+	return nil
+}
+
+// MCPContribution is the set of MCP servers declared by one plugin
+type MCPContribution struct {
+	Plugin  string
+	Servers map[string]MCPServer
+}
+
+// MCPConflictError reports an MCP server name declared differently by two plugins
+type MCPConflictError struct {
+	Server  string
+	Plugins []string
+}
+
+func (e *MCPConflictError) Error() string {
+	return fmt.Sprintf("MCP server %q is declared differently by plugins %s; rename it in one of them",
+		e.Server, strings.Join(e.Plugins, " and "))
+}
+
+// MergeMCPServers merges the servers of all contributions. A server declared
+// identically by several plugins is kept once; differing declarations of the
+// same name are a conflict.
+func MergeMCPServers(contributions []MCPContribution) (map[string]MCPServer, error) {
+	merged := make(map[string]MCPServer)
+	owners := make(map[string]string)
+
+	for _, contribution := range contributions {
+		names := make([]string, 0, len(contribution.Servers))
+		for name := range contribution.Servers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			server := contribution.Servers[name]
+			if err := server.Validate(); err != nil {
+				return nil, fmt.Errorf("plugin %s: MCP server %q: %w", contribution.Plugin, name, err)
+			}
+
+			if existing, ok := merged[name]; ok {
+				if !reflect.DeepEqual(existing, server) {
+					return nil, &MCPConflictError{Server: name, Plugins: []string{owners[name], contribution.Plugin}}
+				}
+				continue
+			}
+			merged[name] = server
+			owners[name] = contribution.Plugin
+		}
+	}
+
+	return merged, nil
+}
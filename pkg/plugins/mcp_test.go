@@ -0,0 +1,41 @@
+package plugins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPServerValidate(t *testing.T) {
+	assert.NoError(t, MCPServer{Command: "npx"}.Validate())
+	assert.NoError(t, MCPServer{URL: "https://mcp.example.com/sse"}.Validate())
+	assert.ErrorContains(t, MCPServer{}.Validate(), "either command or url")
+	assert.ErrorContains(t, MCPServer{Command: "npx", URL: "https://x"}.Validate(), "mutually exclusive")
+	assert.ErrorContains(t, MCPServer{URL: "ftp://x"}.Validate(), "http or https")
+}
+
+func TestMergeMCPServers(t *testing.T) {
+	github := MCPServer{Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-github"}}
+	docs := MCPServer{URL: "https://mcp.example.com/sse"}
+
+	merged, err := MergeMCPServers([]MCPContribution{
+		{Plugin: "golang-base", Servers: map[string]MCPServer{"github": github}},
+		{Plugin: "docs", Servers: map[string]MCPServer{"docs": docs, "github": github}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]MCPServer{"github": github, "docs": docs}, merged)
+
+	_, err = MergeMCPServers([]MCPContribution{
+		{Plugin: "golang-base", Servers: map[string]MCPServer{"github": github}},
+		{Plugin: "other", Servers: map[string]MCPServer{"github": {Command: "github-mcp"}}},
+	})
+	var conflict *MCPConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, "github", conflict.Server)
+	assert.Equal(t, []string{"golang-base", "other"}, conflict.Plugins)
+
+	_, err = MergeMCPServers([]MCPContribution{{Plugin: "broken", Servers: map[string]MCPServer{"empty": {}}}})
+	assert.ErrorContains(t, err, `plugin broken: MCP server "empty"`)
+}
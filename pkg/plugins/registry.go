@@ -18,8 +18,59 @@ type Plugin struct {
 	Description  string            `yaml:"description,omitempty"`
 	Repository   string            `yaml:"repository,omitempty"`
 	Path         string            `yaml:"path,omitempty"` // Subpath within repo
-	Dependencies []string          `yaml:"dependencies,omitempty"`
+	Dependencies Dependencies      `yaml:"dependencies,omitempty"`
 	Metadata     map[string]string `yaml:"metadata,omitempty"`
+	// Conditions decide whether the plugin is active in a workspace
+	Conditions Conditions `yaml:"conditions,omitempty"`
+	// MCPServers are added to the MCP config of every agent while the plugin is active
+	MCPServers map[string]MCPServer `yaml:"mcp_servers,omitempty"`
+	// Agents are adapters for agents nexus has no built-in support for
+	Agents []AgentDefinition `yaml:"agents,omitempty"`
+}
+
+// Dependencies maps plugin names to version constraints. In plugin.yaml it is
+// either a map ({golang-base: "^1.2"}) or a plain list of names, which accepts
+// any version.
+type Dependencies map[string]string
+
+// UnmarshalYAML accepts both the map and the list form
+func (d *Dependencies) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := value.Decode(&names); err != nil {
+			return err
+		}
+		*d = make(Dependencies, len(names))
+		for _, name := range names {
+			(*d)[name] = "*"
+		}
+		return nil
+	case yaml.MappingNode:
+		var constraints map[string]string
+		if err := value.Decode(&constraints); err != nil {
+			return err
+		}
+		for name, constraint := range constraints {
+			if constraint == "" {
+				constraints[name] = "*"
+			}
+		}
+		*d = constraints
+		return nil
+	default:
+		return fmt.Errorf("dependencies must be a list or a map, line %d", value.Line)
+	}
+}
+
+// Names returns the dependency names in sorted order
+func (d Dependencies) Names() []string {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // PluginManifest is the plugin.yaml structure
@@ -86,15 +137,31 @@ func (r *Registry) discoverLocalPlugins(pluginsDir string) error {
 
 // loadPluginManifest loads a plugin.yaml file
 func (r *Registry) loadPluginManifest(path string) (*PluginManifest, error) {
+	return LoadManifest(path)
+}
+
+// LoadManifest loads a plugin.yaml file. Manifests either nest their fields
+// under a plugin key or, like those written by nexus config extract, list
+// them at the top level.
+func LoadManifest(path string) (*PluginManifest, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	return parseManifest(data)
+}
 
+// parseManifest parses the contents of a plugin.yaml file
+func parseManifest(data []byte) (*PluginManifest, error) {
 	var manifest PluginManifest
 	if err := yaml.Unmarshal(data, &manifest); err != nil {
 		return nil, err
 	}
+	if manifest.Plugin.Name == "" {
+		if err := yaml.Unmarshal(data, &manifest.Plugin); err != nil {
+			return nil, err
+		}
+	}
 
 	return &manifest, nil
 }
@@ -129,7 +196,7 @@ func (r *Registry) ResolveDependencies(pluginNames []string) ([]string, error) {
 		processed[current] = true
 
 		plugin := r.plugins[current]
-		for _, dep := range plugin.Dependencies {
+		for _, dep := range plugin.Dependencies.Names() {
 			if _, exists := r.plugins[dep]; !exists {
 				return nil, fmt.Errorf("dependency %s of plugin %s not found", dep, current)
 			}
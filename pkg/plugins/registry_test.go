@@ -84,12 +84,12 @@ func TestRegistry_ResolveDependencies(t *testing.T) {
 	registry.plugins["myorg/git"] = &Plugin{
 		Name:         "git",
 		Version:      "1.0.0",
-		Dependencies: []string{"core/base"},
+		Dependencies: Dependencies{"core/base": "*"},
 	}
 	registry.plugins["myorg/verification"] = &Plugin{
 		Name:         "verification",
 		Version:      "1.0.0",
-		Dependencies: []string{"myorg/git"},
+		Dependencies: Dependencies{"myorg/git": "*"},
 	}
 
 	tests := []struct {
@@ -141,8 +141,8 @@ func TestRegistry_ResolveDependencies_Circular(t *testing.T) {
 	registry := NewRegistry()
 
 	// Setup circular dependency
-	registry.plugins["a"] = &Plugin{Name: "a", Dependencies: []string{"b"}}
-	registry.plugins["b"] = &Plugin{Name: "b", Dependencies: []string{"a"}}
+	registry.plugins["a"] = &Plugin{Name: "a", Dependencies: Dependencies{"b": "*"}}
+	registry.plugins["b"] = &Plugin{Name: "b", Dependencies: Dependencies{"a": "*"}}
 
 	_, err := registry.ResolveDependencies([]string{"a"})
 	assert.Error(t, err)
@@ -0,0 +1,478 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Tag is a version tag in a plugin repository
+type Tag struct {
+	Name    string
+	Version Version
+	SHA     string
+}
+
+// TagLister lists the version tags of a plugin repository
+type TagLister interface {
+	ListTags(repository string) ([]Tag, error)
+}
+
+// GitTagLister lists tags of remote repositories with git ls-remote
+type GitTagLister struct{}
+
+// ListTags returns the tags of repository that parse as semantic versions,
+// with annotated tags resolved to the commit they point at
+func (GitTagLister) ListTags(repository string) ([]Tag, error) {
+	cmd := exec.Command("git", "ls-remote", "--tags", repository)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", repository, err)
+	}
+	return parseLsRemoteTags(string(output)), nil
+}
+
+// Head returns the commit at the tip of repository's default branch
+func (GitTagLister) Head(repository string) (string, error) {
+	cmd := exec.Command("git", "ls-remote", repository, "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD of %s: %w", repository, err)
+	}
+	sha, _, ok := strings.Cut(strings.TrimSpace(string(output)), "\t")
+	if !ok {
+		return "", fmt.Errorf("repository %s has no HEAD", repository)
+	}
+	return sha, nil
+}
+
+// LoadManifest reads the plugin.yaml that repository has under subdir at tag,
+// from a shallow clone of the tag that fetches no other files
+func (GitTagLister) LoadManifest(repository, subdir string, tag Tag) (*Plugin, error) {
+	dir, err := os.MkdirTemp("", "nexus-manifest-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	clone := exec.Command("git", "clone", "--quiet", "--depth", "1", "--no-checkout", "--filter=blob:none",
+		"--branch", tag.Name, repository, dir)
+	if output, err := clone.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s at %s: %w: %s", repository, tag.Name, err, strings.TrimSpace(string(output)))
+	}
+
+	object := "HEAD:" + path.Join(subdir, "plugin.yaml")
+	exists := exec.Command("git", "cat-file", "-e", object)
+	exists.Dir = dir
+	if exists.Run() != nil {
+		return nil, nil
+	}
+
+	show := exec.Command("git", "show", object)
+	show.Dir = dir
+	data, err := show.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin.yaml of %s at %s: %w", repository, tag.Name, err)
+	}
+	manifest, err := parseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse plugin.yaml of %s at %s: %w", repository, tag.Name, err)
+	}
+	return &manifest.Plugin, nil
+}
+
+// parseLsRemoteTags parses git ls-remote --tags output
+func parseLsRemoteTags(output string) []Tag {
+	byName := make(map[string]*Tag)
+	var order []string
+
+	for _, line := range strings.Split(output, "\n") {
+		sha, ref, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		name, ok := strings.CutPrefix(ref, "refs/tags/")
+		if !ok {
+			continue
+		}
+		name, peeled := strings.CutSuffix(name, "^{}")
+
+		version, err := ParseVersion(name)
+		if err != nil {
+			continue
+		}
+
+		tag, exists := byName[name]
+		if !exists {
+			tag = &Tag{Name: name, Version: version}
+			byName[name] = tag
+			order = append(order, name)
+		}
+		// The peeled entry of an annotated tag carries the commit SHA
+		if peeled || tag.SHA == "" {
+			tag.SHA = sha
+		}
+	}
+
+	tags := make([]Tag, 0, len(order))
+	for _, name := range order {
+		tags = append(tags, *byName[name])
+	}
+	return tags
+}
+
+// Requirement is a version constraint placed on a plugin
+type Requirement struct {
+	// By is the plugin declaring the requirement, or "project" for the config
+	By         string
+	Constraint string
+}
+
+// Resolution is the version selected for a plugin
+type Resolution struct {
+	Name    string
+	Version string
+	// Tag and SHA are set when the version was resolved against repository tags
+	Tag          string
+	SHA          string
+	Plugin       *Plugin
+	Requirements []Requirement
+}
+
+// ConflictError explains why no version of a plugin satisfies its requirements
+type ConflictError struct {
+	Plugin       string
+	Requirements []Requirement
+	Available    []string
+}
+
+func (e *ConflictError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cannot resolve a version of plugin %s:\n", e.Plugin)
+	for _, req := range e.Requirements {
+		fmt.Fprintf(&b, "  - %s requires %s\n", req.By, req.Constraint)
+	}
+	if len(e.Available) == 0 {
+		b.WriteString("no versions are available")
+	} else {
+		fmt.Fprintf(&b, "no version satisfies all of them (available: %s)", strings.Join(e.Available, ", "))
+	}
+	return b.String()
+}
+
+// ManifestLoader reads the manifest a plugin repository has at a tag. When the
+// resolver's TagLister implements it, each candidate version brings the
+// dependencies declared at that tag rather than those in the registry.
+type ManifestLoader interface {
+	// LoadManifest returns the plugin declared under subdir at tag, or nil when
+	// the tag has no plugin.yaml
+	LoadManifest(repository, subdir string, tag Tag) (*Plugin, error)
+}
+
+// Resolver selects plugin versions that satisfy every constraint in the
+// dependency graph. It tries the highest matching version of each plugin
+// first and backtracks when that version's dependencies can't be satisfied.
+type Resolver struct {
+	registry *Registry
+	tags     TagLister
+}
+
+// NewResolver creates a resolver. Plugins with a repository are resolved
+// against its tags when tags is non-nil; all other plugins must satisfy
+// their constraints with the version in their manifest.
+func NewResolver(registry *Registry, tags TagLister) *Resolver {
+	return &Resolver{registry: registry, tags: tags}
+}
+
+// Resolve selects a version for each plugin in requirements, which maps plugin
+// names to the project's constraints, and for their transitive dependencies
+func (r *Resolver) Resolve(requirements map[string]string) (map[string]*Resolution, error) {
+	roots := make([]string, 0, len(requirements))
+	for name := range requirements {
+		roots = append(roots, name)
+	}
+	sort.Strings(roots)
+
+	// Checks that every plugin exists and the graph has no cycles
+	if _, err := r.registry.ResolveDependencies(roots); err != nil {
+		return nil, err
+	}
+
+	s := &search{
+		resolver:  r,
+		reqs:      make(map[string][]Requirement),
+		selected:  make(map[string]*Resolution),
+		tagCache:  make(map[string][]Tag),
+		manifests: make(map[string]*Plugin),
+	}
+	for _, name := range roots {
+		s.reqs[name] = append(s.reqs[name], Requirement{By: "project", Constraint: normalizeConstraint(requirements[name])})
+	}
+	if err := s.solve(); err != nil {
+		return nil, err
+	}
+	for name, resolution := range s.selected {
+		resolution.Requirements = s.reqs[name]
+	}
+	return s.selected, nil
+}
+
+// search is the state of one Resolve call: the requirements gathered so far
+// and the versions selected for the plugins they name
+type search struct {
+	resolver  *Resolver
+	reqs      map[string][]Requirement
+	selected  map[string]*Resolution
+	tagCache  map[string][]Tag
+	manifests map[string]*Plugin
+}
+
+// candidate is a version of a plugin the search can select
+type candidate struct {
+	version string
+	tag     *Tag
+}
+
+// solve selects a version for the first required plugin without one, then
+// recurses. When the rest of the graph can't be solved with that version it
+// undoes the selection and tries the next lower one.
+func (s *search) solve() error {
+	name := s.next()
+	if name == "" {
+		return nil
+	}
+	plugin, ok := s.resolver.registry.GetPlugin(name)
+	if !ok {
+		return fmt.Errorf("plugin %s not found", name)
+	}
+
+	reqs := s.reqs[name]
+	satisfies, unconstrained, err := parseRequirements(name, reqs)
+	if err != nil {
+		return err
+	}
+	candidates, err := s.candidates(plugin)
+	if err != nil {
+		return err
+	}
+
+	var available []string
+	var downstream error
+	for _, c := range candidates {
+		version, parseErr := ParseVersion(c.version)
+		if parseErr == nil {
+			available = append(available, version.String())
+		}
+		// Untagged plugins without a constraint take their manifest's version
+		// as is; tags always have to match, so "*" skips pre-releases
+		if (c.tag != nil || !unconstrained) && (parseErr != nil || !satisfies(version)) {
+			continue
+		}
+
+		deps, err := s.dependencies(name, plugin, c)
+		if err != nil {
+			return err
+		}
+		selected := plugin
+		if !equalDependencies(deps, plugin.Dependencies) {
+			copied := *plugin
+			copied.Dependencies = deps
+			selected = &copied
+		}
+
+		resolution := &Resolution{Name: name, Version: c.version, Plugin: selected}
+		if c.tag != nil {
+			resolution.Version = c.tag.Version.String()
+			resolution.Tag = c.tag.Name
+			resolution.SHA = c.tag.SHA
+		}
+
+		err = s.try(resolution, deps)
+		if err == nil {
+			return nil
+		}
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			return err
+		}
+		if downstream == nil {
+			downstream = err
+		}
+	}
+
+	// Report the conflict met below the highest matching version, which is
+	// the one the user most likely expected
+	if downstream != nil {
+		return downstream
+	}
+	return &ConflictError{Plugin: name, Requirements: append([]Requirement(nil), reqs...), Available: available}
+}
+
+// try selects resolution, adds the requirements deps places on other plugins
+// and solves the rest of the graph, undoing all of it on failure
+func (s *search) try(resolution *Resolution, deps Dependencies) error {
+	name := resolution.Name
+	added := make(map[string]int)
+	undo := func() {
+		delete(s.selected, name)
+		for dep, n := range added {
+			s.reqs[dep] = s.reqs[dep][:len(s.reqs[dep])-n]
+			if len(s.reqs[dep]) == 0 {
+				delete(s.reqs, dep)
+			}
+		}
+	}
+
+	s.selected[name] = resolution
+	for _, dep := range deps.Names() {
+		s.reqs[dep] = append(s.reqs[dep], Requirement{By: name, Constraint: normalizeConstraint(deps[dep])})
+		added[dep]++
+
+		// A dependency selected earlier must still satisfy everything
+		// placed on it
+		if chosen, ok := s.selected[dep]; ok {
+			satisfies, unconstrained, err := parseRequirements(dep, s.reqs[dep])
+			if err != nil {
+				undo()
+				return err
+			}
+			if unconstrained {
+				continue
+			}
+			version, parseErr := ParseVersion(chosen.Version)
+			if parseErr != nil || !satisfies(version) {
+				conflict := &ConflictError{Plugin: dep, Requirements: append([]Requirement(nil), s.reqs[dep]...), Available: []string{chosen.Version}}
+				undo()
+				return conflict
+			}
+		}
+	}
+
+	if err := s.solve(); err != nil {
+		undo()
+		return err
+	}
+	return nil
+}
+
+// next returns the first plugin, by name, that is required but not selected
+func (s *search) next() string {
+	var names []string
+	for name := range s.reqs {
+		if _, ok := s.selected[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// candidates lists the versions of plugin to try, highest first: its
+// repository's tags, or only the version in its manifest for untagged and
+// local plugins
+func (s *search) candidates(plugin *Plugin) ([]candidate, error) {
+	if plugin.Repository != "" && s.resolver.tags != nil {
+		tags, ok := s.tagCache[plugin.Repository]
+		if !ok {
+			var err error
+			tags, err = s.resolver.tags.ListTags(plugin.Repository)
+			if err != nil {
+				return nil, err
+			}
+			sort.SliceStable(tags, func(i, j int) bool { return tags[i].Version.Compare(tags[j].Version) > 0 })
+			s.tagCache[plugin.Repository] = tags
+		}
+
+		if len(tags) > 0 {
+			candidates := make([]candidate, 0, len(tags))
+			for i := range tags {
+				candidates = append(candidates, candidate{version: tags[i].Name, tag: &tags[i]})
+			}
+			return candidates, nil
+		}
+	}
+
+	return []candidate{{version: plugin.Version}}, nil
+}
+
+// dependencies returns what candidate c of plugin depends on: the
+// dependencies declared at its tag when the tag lister can read them, and
+// those in the registry otherwise
+func (s *search) dependencies(name string, plugin *Plugin, c candidate) (Dependencies, error) {
+	deps := plugin.Dependencies
+	if loader, ok := s.resolver.tags.(ManifestLoader); ok && c.tag != nil {
+		key := plugin.Repository + "@" + c.tag.Name
+		manifest, cached := s.manifests[key]
+		if !cached {
+			var err error
+			manifest, err = loader.LoadManifest(plugin.Repository, plugin.Path, *c.tag)
+			if err != nil {
+				return nil, err
+			}
+			s.manifests[key] = manifest
+		}
+		if manifest != nil {
+			deps = manifest.Dependencies
+		}
+	}
+
+	for _, dep := range deps.Names() {
+		if _, ok := s.resolver.registry.GetPlugin(dep); !ok {
+			return nil, fmt.Errorf("dependency %s of plugin %s not found", dep, name)
+		}
+	}
+	return deps, nil
+}
+
+// parseRequirements returns a check for versions satisfying every
+// requirement in reqs, and whether none of them constrains the version
+func parseRequirements(name string, reqs []Requirement) (func(Version) bool, bool, error) {
+	constraints := make([]Constraint, 0, len(reqs))
+	unconstrained := true
+	for _, req := range reqs {
+		c, err := ParseConstraint(req.Constraint)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s requires plugin %s: %w", req.By, name, err)
+		}
+		if c.String() != "*" {
+			unconstrained = false
+		}
+		constraints = append(constraints, c)
+	}
+
+	satisfies := func(v Version) bool {
+		for _, c := range constraints {
+			if !c.Check(v) {
+				return false
+			}
+		}
+		return true
+	}
+	return satisfies, unconstrained, nil
+}
+
+func equalDependencies(a, b Dependencies) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, constraint := range a {
+		if other, ok := b[name]; !ok || other != constraint {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeConstraint(constraint string) string {
+	if strings.TrimSpace(constraint) == "" {
+		return "*"
+	}
+	return constraint
+}
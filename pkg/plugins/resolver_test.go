@@ -0,0 +1,208 @@
+package plugins
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type fakeTagLister map[string][]Tag
+
+func (f fakeTagLister) ListTags(repository string) ([]Tag, error) {
+	return f[repository], nil
+}
+
+// fakeManifestLister also serves the dependencies each tag declares, keyed by
+// repository and tag name
+type fakeManifestLister struct {
+	fakeTagLister
+	deps map[string]Dependencies
+}
+
+func (f fakeManifestLister) LoadManifest(repository, subdir string, tag Tag) (*Plugin, error) {
+	deps, ok := f.deps[repository+"@"+tag.Name]
+	if !ok {
+		return nil, nil
+	}
+	return &Plugin{Dependencies: deps}, nil
+}
+
+func tags(t *testing.T, names ...string) []Tag {
+	t.Helper()
+	result := make([]Tag, 0, len(names))
+	for _, name := range names {
+		v, err := ParseVersion(name)
+		require.NoError(t, err)
+		result = append(result, Tag{Name: name, Version: v, SHA: "sha-" + name})
+	}
+	return result
+}
+
+func TestDependencies_UnmarshalYAML(t *testing.T) {
+	var listForm Plugin
+	require.NoError(t, yaml.Unmarshal([]byte("name: a\ndependencies:\n  - core/base\n"), &listForm))
+	assert.Equal(t, Dependencies{"core/base": "*"}, listForm.Dependencies)
+
+	var mapForm Plugin
+	require.NoError(t, yaml.Unmarshal([]byte("name: a\ndependencies:\n  golang-base: \"^1.2\"\n  core/base: \"\"\n"), &mapForm))
+	assert.Equal(t, Dependencies{"golang-base": "^1.2", "core/base": "*"}, mapForm.Dependencies)
+	assert.Equal(t, []string{"core/base", "golang-base"}, mapForm.Dependencies.Names())
+}
+
+func TestResolver_PicksHighestMatchingTag(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddPlugin("golang-base", &Plugin{Name: "golang-base", Repository: "https://example.com/golang-base.git"})
+	registry.AddPlugin("go-tools", &Plugin{
+		Name:         "go-tools",
+		Version:      "0.3.0",
+		Dependencies: Dependencies{"golang-base": "^1.2"},
+	})
+
+	lister := fakeTagLister{
+		"https://example.com/golang-base.git": tags(t, "v1.1.0", "v1.2.0", "v1.4.2", "v2.0.0", "v1.5.0-rc.1"),
+	}
+
+	resolutions, err := NewResolver(registry, lister).Resolve(map[string]string{"go-tools": "*"})
+	require.NoError(t, err)
+
+	base := resolutions["golang-base"]
+	require.NotNil(t, base)
+	assert.Equal(t, "1.4.2", base.Version)
+	assert.Equal(t, "v1.4.2", base.Tag)
+	assert.Equal(t, "sha-v1.4.2", base.SHA)
+
+	assert.Equal(t, "0.3.0", resolutions["go-tools"].Version)
+	assert.Empty(t, resolutions["go-tools"].Tag)
+}
+
+func TestResolver_ReportsConflicts(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddPlugin("golang-base", &Plugin{Name: "golang-base", Repository: "repo"})
+	registry.AddPlugin("go-tools", &Plugin{Name: "go-tools", Version: "1.0.0", Dependencies: Dependencies{"golang-base": "~1.1.0"}})
+
+	lister := fakeTagLister{"repo": tags(t, "v1.1.3", "v1.2.0")}
+
+	_, err := NewResolver(registry, lister).Resolve(map[string]string{
+		"go-tools":    "*",
+		"golang-base": "^1.2",
+	})
+	require.Error(t, err)
+
+	var conflict *ConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, "golang-base", conflict.Plugin)
+	assert.Contains(t, err.Error(), "project requires ^1.2")
+	assert.Contains(t, err.Error(), "go-tools requires ~1.1.0")
+	assert.Contains(t, err.Error(), "available: 1.2.0, 1.1.3")
+}
+
+func TestResolver_BacktracksOnDependencyConflicts(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddPlugin("go-tools", &Plugin{Name: "go-tools", Repository: "tools"})
+	registry.AddPlugin("golang-base", &Plugin{Name: "golang-base", Repository: "base"})
+
+	// go-tools 2.0.0 needs golang-base 2, which the project rules out, so the
+	// resolver has to fall back to go-tools 1.5.0
+	lister := fakeManifestLister{
+		fakeTagLister: fakeTagLister{
+			"tools": tags(t, "v1.5.0", "v2.0.0"),
+			"base":  tags(t, "v1.3.0", "v2.1.0"),
+		},
+		deps: map[string]Dependencies{
+			"tools@v2.0.0": {"golang-base": "^2"},
+			"tools@v1.5.0": {"golang-base": "^1.2"},
+		},
+	}
+
+	resolutions, err := NewResolver(registry, lister).Resolve(map[string]string{
+		"go-tools":    "*",
+		"golang-base": "^1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1.5.0", resolutions["go-tools"].Version)
+	assert.Equal(t, Dependencies{"golang-base": "^1.2"}, resolutions["go-tools"].Plugin.Dependencies)
+	assert.Equal(t, "1.3.0", resolutions["golang-base"].Version)
+	assert.Len(t, resolutions["golang-base"].Requirements, 2)
+
+	// With no go-tools release compatible with golang-base 1, the conflict
+	// names the dependency and who required what
+	lister.deps["tools@v1.5.0"] = Dependencies{"golang-base": "^2"}
+	_, err = NewResolver(registry, lister).Resolve(map[string]string{
+		"go-tools":    "*",
+		"golang-base": "^1",
+	})
+	var conflict *ConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, "golang-base", conflict.Plugin)
+	assert.Contains(t, err.Error(), "project requires ^1")
+	assert.Contains(t, err.Error(), "go-tools requires ^2")
+}
+
+func TestResolver_LocalManifestVersion(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddPlugin("core/base", &Plugin{Name: "base", Version: "1.0.0"})
+
+	_, err := NewResolver(registry, nil).Resolve(map[string]string{"core/base": ">=1.0 <2"})
+	assert.NoError(t, err)
+
+	_, err = NewResolver(registry, nil).Resolve(map[string]string{"core/base": "^2"})
+	var conflict *ConflictError
+	assert.True(t, errors.As(err, &conflict))
+
+	_, err = NewResolver(registry, nil).Resolve(map[string]string{"core/base": "not-a-range"})
+	assert.Error(t, err)
+}
+
+func TestGitTagLister_LoadManifest(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	git("init", "--quiet")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "go-tools"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "go-tools", "plugin.yaml"),
+		[]byte("plugin:\n  name: go-tools\n  version: 1.0.0\n  dependencies:\n    golang-base: ^1.2\n"), 0644))
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1.0.0")
+
+	plugin, err := GitTagLister{}.LoadManifest(repo, "go-tools", Tag{Name: "v1.0.0"})
+	require.NoError(t, err)
+	require.NotNil(t, plugin)
+	assert.Equal(t, Dependencies{"golang-base": "^1.2"}, plugin.Dependencies)
+
+	plugin, err = GitTagLister{}.LoadManifest(repo, "", Tag{Name: "v1.0.0"})
+	require.NoError(t, err)
+	assert.Nil(t, plugin, "a tag without plugin.yaml declares nothing")
+}
+
+func TestParseLsRemoteTags(t *testing.T) {
+	output := "aaa\trefs/tags/v1.0.0\n" +
+		"bbb\trefs/tags/v1.1.0\n" +
+		"ccc\trefs/tags/v1.1.0^{}\n" +
+		"ddd\trefs/tags/nightly\n"
+
+	got := parseLsRemoteTags(output)
+	require.Len(t, got, 2)
+	assert.Equal(t, "v1.0.0", got[0].Name)
+	assert.Equal(t, "aaa", got[0].SHA)
+	assert.Equal(t, "v1.1.0", got[1].Name)
+	assert.Equal(t, "ccc", got[1].SHA, "annotated tags resolve to the peeled commit")
+}
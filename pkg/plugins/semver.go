@@ -0,0 +1,278 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version (major.minor.patch[-prerelease])
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseVersion parses a semantic version, accepting a leading "v" and
+// missing minor or patch components ("1.2" is 1.2.0)
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return Version{}, fmt.Errorf("empty version")
+	}
+
+	// Build metadata does not take part in precedence
+	s, _, _ = strings.Cut(s, "+")
+
+	var v Version
+	core, pre, _ := strings.Cut(s, "-")
+	v.Prerelease = pre
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		*nums[i] = n
+	}
+
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or higher than o
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	// A pre-release sorts before the release it precedes
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	default:
+		return comparePrerelease(v.Prerelease, o.Prerelease)
+	}
+}
+
+// comparePrerelease orders pre-release strings by their dot-separated
+// identifiers: numeric identifiers compare numerically and sort before
+// alphanumeric ones, which compare in ASCII order, and a shorter run of equal
+// identifiers sorts first (so 1.0.0-alpha < 1.0.0-alpha.1 < 1.0.0-alpha.beta)
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Constraint is a set of version ranges. A version satisfies the constraint
+// when it falls in any of the ranges.
+//
+// Supported syntax: exact versions ("1.2.3", "=1.2.3"), comparisons
+// (">=1.2 <2.0"), caret ("^1.2"), tilde ("~1.2.3"), wildcards ("1.x", "*")
+// and alternatives joined by "||".
+type Constraint struct {
+	raw    string
+	ranges [][]comparator
+}
+
+type comparator struct {
+	op      string
+	version Version
+}
+
+// ParseConstraint parses a version constraint. An empty string matches any version.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: strings.TrimSpace(s)}
+	if c.raw == "" || c.raw == "*" || c.raw == "latest" {
+		c.raw = "*"
+		return c, nil
+	}
+
+	for _, alt := range strings.Split(c.raw, "||") {
+		var rng []comparator
+		for _, term := range strings.Fields(alt) {
+			cmps, err := parseTerm(term)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("invalid constraint %q: %w", s, err)
+			}
+			rng = append(rng, cmps...)
+		}
+		if len(rng) == 0 {
+			return Constraint{}, fmt.Errorf("invalid constraint %q: empty range", s)
+		}
+		c.ranges = append(c.ranges, rng)
+	}
+
+	return c, nil
+}
+
+func parseTerm(term string) ([]comparator, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(term, op); ok {
+			v, err := ParseVersion(rest)
+			if err != nil {
+				return nil, err
+			}
+			return []comparator{{op: op, version: v}}, nil
+		}
+	}
+
+	switch term[0] {
+	case '^':
+		v, err := ParseVersion(term[1:])
+		if err != nil {
+			return nil, err
+		}
+		// ^1.2.3 := >=1.2.3 <2.0.0, ^0.2.3 := >=0.2.3 <0.3.0, ^0.0.3 := >=0.0.3 <0.0.4
+		upper := Version{Major: v.Major + 1}
+		switch {
+		case v.Major == 0 && v.Minor == 0 && strings.Count(term, ".") == 2:
+			upper = Version{Patch: v.Patch + 1}
+		case v.Major == 0 && strings.Count(term, ".") >= 1:
+			upper = Version{Minor: v.Minor + 1}
+		}
+		return []comparator{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	case '~':
+		v, err := ParseVersion(term[1:])
+		if err != nil {
+			return nil, err
+		}
+		// ~1.2.3 := >=1.2.3 <1.3.0, ~1 := >=1.0.0 <2.0.0
+		upper := Version{Major: v.Major, Minor: v.Minor + 1}
+		if !strings.Contains(term, ".") {
+			upper = Version{Major: v.Major + 1}
+		}
+		return []comparator{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	}
+
+	if strings.Count(term, ".") == 2 && !strings.ContainsAny(term, "xX*") {
+		v, err := ParseVersion(term)
+		if err != nil {
+			return nil, err
+		}
+		return []comparator{{op: "=", version: v}}, nil
+	}
+
+	// Wildcards and partial versions: 1.x, 1.2.*, 1.2
+	parts := strings.Split(strings.TrimPrefix(term, "v"), ".")
+	var fixed []int
+	for _, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", term)
+		}
+		fixed = append(fixed, n)
+	}
+
+	switch len(fixed) {
+	case 0:
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	case 1:
+		return []comparator{{op: ">=", version: Version{Major: fixed[0]}}, {op: "<", version: Version{Major: fixed[0] + 1}}}, nil
+	case 2:
+		// A plain "1.2" is a partial version and is treated like 1.2.x
+		return []comparator{{op: ">=", version: Version{Major: fixed[0], Minor: fixed[1]}}, {op: "<", version: Version{Major: fixed[0], Minor: fixed[1] + 1}}}, nil
+	default:
+		return []comparator{{op: "=", version: Version{Major: fixed[0], Minor: fixed[1], Patch: fixed[2]}}}, nil
+	}
+}
+
+// Check reports whether v satisfies the constraint. Pre-release versions
+// only match when the constraint names a pre-release of the same version.
+func (c Constraint) Check(v Version) bool {
+	if c.ranges == nil {
+		return v.Prerelease == ""
+	}
+
+	for _, rng := range c.ranges {
+		if rangeMatches(rng, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func rangeMatches(rng []comparator, v Version) bool {
+	allowPrerelease := v.Prerelease == ""
+	for _, cmp := range rng {
+		d := v.Compare(cmp.version)
+		ok := false
+		switch cmp.op {
+		case "=":
+			ok = d == 0
+		case ">":
+			ok = d > 0
+		case ">=":
+			ok = d >= 0
+		case "<":
+			ok = d < 0
+		case "<=":
+			ok = d <= 0
+		}
+		if !ok {
+			return false
+		}
+		if cmp.version.Prerelease != "" && cmp.version.Major == v.Major && cmp.version.Minor == v.Minor && cmp.version.Patch == v.Patch {
+			allowPrerelease = true
+		}
+	}
+	return allowPrerelease
+}
+
+func (c Constraint) String() string {
+	return c.raw
+}
@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Version
+		wantErr bool
+	}{
+		{input: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{input: "v2.0.1", want: Version{Major: 2, Patch: 1}},
+		{input: "1.4", want: Version{Major: 1, Minor: 4}},
+		{input: "1.0.0-beta.1+build5", want: Version{Major: 1, Prerelease: "beta.1"}},
+		{input: "latest", wantErr: true},
+		{input: "1.2.3.4", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseVersion(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	v := func(s string) Version {
+		parsed, err := ParseVersion(s)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	assert.Equal(t, -1, v("1.2.3").Compare(v("1.10.0")))
+	assert.Equal(t, 1, v("2.0.0").Compare(v("1.99.99")))
+	assert.Equal(t, 0, v("v1.2").Compare(v("1.2.0")))
+	assert.Equal(t, -1, v("1.0.0-rc.1").Compare(v("1.0.0")))
+	assert.Equal(t, -1, v("1.0.0-alpha").Compare(v("1.0.0-beta")))
+
+	// Pre-release precedence from the SemVer spec, lowest first
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		assert.Equal(t, -1, v(ordered[i-1]).Compare(v(ordered[i])), "%s < %s", ordered[i-1], ordered[i])
+		assert.Equal(t, 1, v(ordered[i]).Compare(v(ordered[i-1])), "%s > %s", ordered[i], ordered[i-1])
+	}
+	assert.Equal(t, -1, v("1.0.0-rc.9").Compare(v("1.0.0-rc.10")))
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{constraint: "^1.2", matches: []string{"1.2.0", "1.9.9"}, rejects: []string{"1.1.9", "2.0.0", "1.3.0-beta"}},
+		{constraint: "^0.2.3", matches: []string{"0.2.3", "0.2.9"}, rejects: []string{"0.3.0", "0.2.2"}},
+		{constraint: "^0.0.3", matches: []string{"0.0.3"}, rejects: []string{"0.0.4"}},
+		{constraint: "~1.2.3", matches: []string{"1.2.3", "1.2.10"}, rejects: []string{"1.3.0"}},
+		{constraint: "~1", matches: []string{"1.0.0", "1.9.0"}, rejects: []string{"2.0.0"}},
+		{constraint: ">=1.0 <2.0", matches: []string{"1.0.0", "1.5.2"}, rejects: []string{"0.9.0", "2.0.0"}},
+		{constraint: "1.x", matches: []string{"1.0.0", "1.7.3"}, rejects: []string{"2.0.0"}},
+		{constraint: "1.2", matches: []string{"1.2.0", "1.2.5"}, rejects: []string{"1.3.0"}},
+		{constraint: "1.2.3", matches: []string{"1.2.3"}, rejects: []string{"1.2.4"}},
+		{constraint: "^1.0 || ^3.0", matches: []string{"1.4.0", "3.1.0"}, rejects: []string{"2.0.0"}},
+		{constraint: "*", matches: []string{"0.0.1", "9.0.0"}, rejects: []string{"1.0.0-rc.1"}},
+		{constraint: ">=1.0.0-rc.1", matches: []string{"1.0.0-rc.2", "1.0.0"}, rejects: []string{"1.1.0-beta"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			require.NoError(t, err)
+
+			for _, s := range tt.matches {
+				v, err := ParseVersion(s)
+				require.NoError(t, err)
+				assert.True(t, c.Check(v), "%s should satisfy %s", s, tt.constraint)
+			}
+			for _, s := range tt.rejects {
+				v, err := ParseVersion(s)
+				require.NoError(t, err)
+				assert.False(t, c.Check(v), "%s should not satisfy %s", s, tt.constraint)
+			}
+		})
+	}
+}
+
+func TestParseConstraint_Invalid(t *testing.T) {
+	for _, input := range []string{"^abc", ">=", "1.y", "||"} {
+		_, err := ParseConstraint(input)
+		assert.Error(t, err, input)
+	}
+}
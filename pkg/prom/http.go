@@ -0,0 +1,72 @@
+package prom
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// HTTPMetrics counts the requests a server handles and observes their latency
+type HTTPMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewHTTPMetrics registers <namespace>_http_requests_total and
+// <namespace>_http_request_duration_seconds with r
+func NewHTTPMetrics(r prometheus.Registerer, namespace string) *HTTPMetrics {
+	factory := promauto.With(r)
+	return &HTTPMetrics{
+		requests: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route and status code",
+		}, []string{"method", "route", "status"}),
+		duration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests, by method and route",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+}
+
+// Middleware records every request next handles. Requests are labelled with
+// the mux pattern that matches them rather than their path, so IDs in paths
+// don't each make a series.
+func (m *HTTPMetrics) Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		m.requests.WithLabelValues(r.Method, route, strconv.Itoa(recorder.status)).Inc()
+		m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder captures the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Flush keeps streaming responses such as server-sent events working
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
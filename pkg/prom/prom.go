@@ -0,0 +1,40 @@
+// Package prom holds the Prometheus instrumentation shared by the
+// coordination server and the node agent, built on client_golang.
+package prom
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler serves the metrics g gathers, in OpenMetrics when the scraper asks
+// for it and the Prometheus text format otherwise
+func Handler(g prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// GaugeFunc is a gauge whose series are computed on each scrape
+type GaugeFunc struct {
+	desc    *prometheus.Desc
+	collect func(set func(value float64, labelValues ...string))
+}
+
+// NewGaugeFunc creates a gauge computed on each scrape. collect calls set once
+// per series.
+func NewGaugeFunc(name, help string, labels []string, collect func(set func(value float64, labelValues ...string))) *GaugeFunc {
+	return &GaugeFunc{desc: prometheus.NewDesc(name, help, labels, nil), collect: collect}
+}
+
+// Describe implements prometheus.Collector
+func (g *GaugeFunc) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+// Collect implements prometheus.Collector
+func (g *GaugeFunc) Collect(ch chan<- prometheus.Metric) {
+	g.collect(func(value float64, labelValues ...string) {
+		ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, value, labelValues...)
+	})
+}
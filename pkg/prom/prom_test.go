@@ -0,0 +1,66 @@
+package prom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, g prometheus.Gatherer, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	Handler(g).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGaugeFunc(t *testing.T) {
+	r := prometheus.NewRegistry()
+	r.MustRegister(NewGaugeFunc("app_nodes", "Nodes by status", []string{"status"}, func(set func(float64, ...string)) {
+		set(2, "idle")
+		set(1, `a"b\c`)
+	}))
+
+	body := scrape(t, r, "").Body.String()
+	assert.Contains(t, body, "# TYPE app_nodes gauge")
+	assert.Contains(t, body, `app_nodes{status="idle"} 2`)
+	assert.Contains(t, body, `app_nodes{status="a\"b\\c"} 1`)
+}
+
+func TestHandler_OpenMetrics(t *testing.T) {
+	r := prometheus.NewRegistry()
+	r.MustRegister(NewGaugeFunc("app_up", "Up", nil, func(set func(float64, ...string)) { set(1) }))
+
+	rec := scrape(t, r, "")
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.NotContains(t, rec.Body.String(), "# EOF")
+
+	rec = scrape(t, r, "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5")
+	assert.Contains(t, rec.Header().Get("Content-Type"), "application/openmetrics-text")
+	assert.Contains(t, rec.Body.String(), "# EOF")
+}
+
+func TestHTTPMetrics_Middleware(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewHTTPMetrics(r, "app")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/items/", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := m.Middleware(mux, mux)
+
+	for _, path := range []string{"/items/1", "/items/2", "/nope"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, r, "").Body.String()
+	assert.Contains(t, body, `app_http_requests_total{method="GET",route="/items/",status="418"} 2`)
+	assert.Contains(t, body, `app_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `app_http_request_duration_seconds_count{method="GET",route="/items/"} 2`)
+}
@@ -2,8 +2,10 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,8 +19,10 @@ import (
 	"github.com/docker/go-connections/nat"
 	"github.com/nexus/nexus/pkg/config"
 	"github.com/nexus/nexus/pkg/provider"
+	"github.com/nexus/nexus/pkg/tracing"
 	"github.com/nexus/nexus/pkg/transport"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // DockerClientInterface defines the methods needed by DockerProvider
@@ -32,6 +36,7 @@ type DockerClientInterface interface {
 	ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error)
 	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
 	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerStatsOneShot(ctx context.Context, containerID string) (container.StatsResponseReader, error)
 }
 
 // Ensure client.Client implements DockerClientInterface at compile time
@@ -63,7 +68,10 @@ func (p *DockerProvider) Name() string {
 	return "docker"
 }
 
-func (p *DockerProvider) Create(ctx context.Context, sessionID string, workspacePath string, rawConfig interface{}) (*provider.Session, error) {
+func (p *DockerProvider) Create(ctx context.Context, sessionID string, workspacePath string, rawConfig interface{}) (_ *provider.Session, err error) {
+	ctx, span := tracing.Start(ctx, "docker.Create", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	cfg, ok := rawConfig.(*config.Config)
 	if !ok {
 		return nil, fmt.Errorf("invalid config type")
@@ -99,7 +107,10 @@ func (p *DockerProvider) Create(ctx context.Context, sessionID string, workspace
 	return p.createLocal(ctx, sessionID, workspacePath, cfg)
 }
 
-func (p *DockerProvider) Start(ctx context.Context, sessionID string) error {
+func (p *DockerProvider) Start(ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.Start", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	if p.remote != "" {
 		t, err := p.CreateTransport("remote-docker")
 		if err != nil {
@@ -153,7 +164,72 @@ func (p *DockerProvider) GetPortMappings(ctx context.Context, sessionID string)
 	return portMappings, nil
 }
 
-func (p *DockerProvider) Stop(ctx context.Context, sessionID string) error {
+// Stats reports the CPU time and memory of each running nexus container.
+// Containers whose stats can't be read are logged and left out.
+func (p *DockerProvider) Stats(ctx context.Context) ([]provider.ResourceUsage, error) {
+	if p.remote != "" {
+		return nil, fmt.Errorf("stats not supported for remote Docker")
+	}
+
+	containers, err := p.cli.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var usage []provider.ResourceUsage
+	for _, c := range containers {
+		sessionID, ok := c.Labels["nexus.session.id"]
+		if !ok {
+			continue
+		}
+
+		// A container may stop between the list and its stats call; report
+		// the others rather than none
+		stats, err := p.containerStats(ctx, c.ID)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping container stats", "container", c.ID, "session", sessionID, "error", err)
+			continue
+		}
+
+		// Like docker stats, don't count page cache the kernel can reclaim
+		memory := stats.MemoryStats.Usage
+		inactive := stats.MemoryStats.Stats["inactive_file"]
+		if inactive == 0 {
+			inactive = stats.MemoryStats.Stats["total_inactive_file"]
+		}
+		if inactive < memory {
+			memory -= inactive
+		}
+
+		usage = append(usage, provider.ResourceUsage{
+			SessionID:        sessionID,
+			Provider:         p.Name(),
+			CPUSeconds:       float64(stats.CPUStats.CPUUsage.TotalUsage) / 1e9,
+			MemoryBytes:      memory,
+			MemoryLimitBytes: stats.MemoryStats.Limit,
+		})
+	}
+	return usage, nil
+}
+
+func (p *DockerProvider) containerStats(ctx context.Context, containerID string) (*container.StatsResponse, error) {
+	resp, err := p.cli.ContainerStatsOneShot(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode stats: %w", err)
+	}
+	return &stats, nil
+}
+
+func (p *DockerProvider) Stop(ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.Stop", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	if p.remote != "" {
 		t, err := p.CreateTransport("remote-docker")
 		if err != nil {
@@ -182,7 +258,10 @@ func (p *DockerProvider) Stop(ctx context.Context, sessionID string) error {
 	return p.cli.ContainerStop(ctx, sessionID, container.StopOptions{})
 }
 
-func (p *DockerProvider) Destroy(ctx context.Context, sessionID string) error {
+func (p *DockerProvider) Destroy(ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.Destroy", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	if p.remote != "" {
 		t, err := p.CreateTransport("remote-docker")
 		if err != nil {
@@ -211,7 +290,10 @@ func (p *DockerProvider) Destroy(ctx context.Context, sessionID string) error {
 	return p.cli.ContainerRemove(ctx, sessionID, container.RemoveOptions{Force: true})
 }
 
-func (p *DockerProvider) Exec(ctx context.Context, sessionID string, opts provider.ExecOptions) error {
+func (p *DockerProvider) Exec(ctx context.Context, sessionID string, opts provider.ExecOptions) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.Exec", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	if p.remote != "" {
 		dockerCmd := append([]string{"docker", "exec", sessionID}, opts.Cmd...)
 
@@ -277,7 +359,10 @@ func (p *DockerProvider) Exec(ctx context.Context, sessionID string, opts provid
 	return nil
 }
 
-func (p *DockerProvider) List(ctx context.Context) ([]provider.Session, error) {
+func (p *DockerProvider) List(ctx context.Context) (_ []provider.Session, err error) {
+	ctx, span := tracing.Start(ctx, "docker.List")
+	defer func() { tracing.End(span, err) }()
+
 	if p.remote != "" {
 		return p.listRemote(ctx)
 	}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"net"
 	"testing"
@@ -58,6 +59,7 @@ type MockDockerClient struct {
 	ContainerExecAttachFn func(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error)
 	ContainerListFn       func(ctx context.Context, options container.ListOptions) ([]types.Container, error)
 	ContainerInspectFn    func(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerStatsFn      func(ctx context.Context, containerID string) (container.StatsResponseReader, error)
 }
 
 func (m *MockDockerClient) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
@@ -126,6 +128,13 @@ func (m *MockDockerClient) ContainerInspect(ctx context.Context, containerID str
 	return types.ContainerJSON{}, nil
 }
 
+func (m *MockDockerClient) ContainerStatsOneShot(ctx context.Context, containerID string) (container.StatsResponseReader, error) {
+	if m.ContainerStatsFn != nil {
+		return m.ContainerStatsFn(ctx, containerID)
+	}
+	return container.StatsResponseReader{Body: io.NopCloser(bytes.NewReader([]byte("{}")))}, nil
+}
+
 // TestNewDockerProviderWithClient verifies the factory function.
 func TestNewDockerProviderWithClient(t *testing.T) {
 	mock := &MockDockerClient{}
@@ -553,6 +562,42 @@ func TestDockerProvider_List_WithSessions(t *testing.T) {
 	assert.Equal(t, 0, sessions[1].SSHPort)
 }
 
+// TestDockerProvider_Stats tests resource usage of running nexus containers.
+func TestDockerProvider_Stats(t *testing.T) {
+	mock := &MockDockerClient{}
+
+	mock.ContainerListFn = func(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+		return []types.Container{
+			{ID: "container-1", Labels: map[string]string{"nexus.session.id": "session-1"}},
+			{ID: "stopped", Labels: map[string]string{"nexus.session.id": "session-2"}},
+			{ID: "unrelated", Labels: map[string]string{}},
+		}, nil
+	}
+	mock.ContainerStatsFn = func(ctx context.Context, containerID string) (container.StatsResponseReader, error) {
+		assert.NotEqual(t, "unrelated", containerID)
+		if containerID == "stopped" {
+			return container.StatsResponseReader{}, errors.New("container stopped is not running")
+		}
+		body := `{"cpu_stats":{"cpu_usage":{"total_usage":2500000000}},` +
+			`"memory_stats":{"usage":300,"limit":1000,"stats":{"inactive_file":100}}}`
+		return container.StatsResponseReader{Body: io.NopCloser(bytes.NewReader([]byte(body)))}, nil
+	}
+
+	p := NewDockerProviderWithClient(mock)
+
+	usage, err := p.Stats(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, provider.ResourceUsage{
+		SessionID:        "session-1",
+		Provider:         "docker",
+		CPUSeconds:       2.5,
+		MemoryBytes:      200,
+		MemoryLimitBytes: 1000,
+	}, usage[0])
+}
+
 // TestDockerProvider_List_NonnexusContainers tests filtering non-nexus containers.
 func TestDockerProvider_List_NonnexusContainers(t *testing.T) {
 	mock := &MockDockerClient{}
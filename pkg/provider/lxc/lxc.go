@@ -2,6 +2,7 @@ package lxc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,7 +11,9 @@ import (
 
 	"github.com/nexus/nexus/pkg/config"
 	"github.com/nexus/nexus/pkg/provider"
+	"github.com/nexus/nexus/pkg/tracing"
 	"github.com/nexus/nexus/pkg/transport"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type LXCProvider struct {
@@ -35,7 +38,10 @@ func (p *LXCProvider) Name() string {
 	return p.name
 }
 
-func (p *LXCProvider) Create(ctx context.Context, sessionID string, workspacePath string, rawConfig interface{}) (*provider.Session, error) {
+func (p *LXCProvider) Create(ctx context.Context, sessionID string, workspacePath string, rawConfig interface{}) (_ *provider.Session, err error) {
+	ctx, span := tracing.Start(ctx, "lxc.Create", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	var cfg *config.Config
 	if rawConfig != nil {
 		var ok bool
@@ -77,7 +83,10 @@ func (p *LXCProvider) Create(ctx context.Context, sessionID string, workspacePat
 	return p.createLocal(ctx, sessionID, workspacePath, cfg)
 }
 
-func (p *LXCProvider) Start(ctx context.Context, sessionID string) error {
+func (p *LXCProvider) Start(ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "lxc.Start", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	containerName := fmt.Sprintf("nexus-%s", sessionID)
 
 	if p.remote != "" {
@@ -113,7 +122,10 @@ func (p *LXCProvider) Start(ctx context.Context, sessionID string) error {
 	return nil
 }
 
-func (p *LXCProvider) Stop(ctx context.Context, sessionID string) error {
+func (p *LXCProvider) Stop(ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "lxc.Stop", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	containerName := fmt.Sprintf("nexus-%s", sessionID)
 
 	if p.remote != "" {
@@ -149,7 +161,10 @@ func (p *LXCProvider) Stop(ctx context.Context, sessionID string) error {
 	return nil
 }
 
-func (p *LXCProvider) Destroy(ctx context.Context, sessionID string) error {
+func (p *LXCProvider) Destroy(ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "lxc.Destroy", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	containerName := fmt.Sprintf("nexus-%s", sessionID)
 
 	if p.remote != "" {
@@ -185,14 +200,20 @@ func (p *LXCProvider) Destroy(ctx context.Context, sessionID string) error {
 	return nil
 }
 
-func (p *LXCProvider) List(ctx context.Context) ([]provider.Session, error) {
+func (p *LXCProvider) List(ctx context.Context) (_ []provider.Session, err error) {
+	ctx, span := tracing.Start(ctx, "lxc.List")
+	defer func() { tracing.End(span, err) }()
+
 	if p.remote != "" {
 		return p.listRemote(ctx)
 	}
 	return p.listLocal(ctx)
 }
 
-func (p *LXCProvider) Exec(ctx context.Context, sessionID string, opts provider.ExecOptions) error {
+func (p *LXCProvider) Exec(ctx context.Context, sessionID string, opts provider.ExecOptions) (err error) {
+	ctx, span := tracing.Start(ctx, "lxc.Exec", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	if p.remote != "" {
 		containerName := fmt.Sprintf("nexus-%s", sessionID)
 		lxcCmd := append([]string{"lxc", "exec", containerName, "--"}, opts.Cmd...)
@@ -430,6 +451,53 @@ func (p *LXCProvider) listRemote(ctx context.Context) ([]provider.Session, error
 	return sessions, nil
 }
 
+// Stats reports the CPU time and memory of each running nexus container
+func (p *LXCProvider) Stats(ctx context.Context) ([]provider.ResourceUsage, error) {
+	if p.remote != "" {
+		return nil, fmt.Errorf("stats not supported for remote LXC")
+	}
+
+	output, err := exec.CommandContext(ctx, "lxc", "list", "--format", "json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list LXC containers: %w", err)
+	}
+	return parseLXCStats(output)
+}
+
+// parseLXCStats reads the usage of running nexus containers from the output
+// of lxc list --format json
+func parseLXCStats(output []byte) ([]provider.ResourceUsage, error) {
+	var containers []struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		State  *struct {
+			CPU struct {
+				Usage int64 `json:"usage"`
+			} `json:"cpu"`
+			Memory struct {
+				Usage int64 `json:"usage"`
+			} `json:"memory"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(output, &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse LXC container list: %w", err)
+	}
+
+	var usage []provider.ResourceUsage
+	for _, c := range containers {
+		if !strings.HasPrefix(c.Name, "nexus-") || !strings.EqualFold(c.Status, "running") || c.State == nil {
+			continue
+		}
+		usage = append(usage, provider.ResourceUsage{
+			SessionID:   strings.TrimPrefix(c.Name, "nexus-"),
+			Provider:    "lxc",
+			CPUSeconds:  float64(c.State.CPU.Usage) / 1e9,
+			MemoryBytes: uint64(c.State.Memory.Usage),
+		})
+	}
+	return usage, nil
+}
+
 func (p *LXCProvider) GetPortMappings(ctx context.Context, sessionID string) (map[string]int, error) {
 	if mappings, exists := p.portMappings[sessionID]; exists {
 		return mappings, nil
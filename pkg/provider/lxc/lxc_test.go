@@ -176,3 +176,17 @@ func TestLXCProvider_Exec_Integration(t *testing.T) {
 	}
 	assert.NoError(t, err)
 }
+
+func TestParseLXCStats(t *testing.T) {
+	output := []byte(`[
+		{"name": "nexus-abc", "status": "Running", "state": {"cpu": {"usage": 1500000000}, "memory": {"usage": 4096}}},
+		{"name": "nexus-stopped", "status": "Stopped", "state": null},
+		{"name": "other", "status": "Running", "state": {"cpu": {"usage": 1}, "memory": {"usage": 1}}}
+	]`)
+
+	usage, err := parseLXCStats(output)
+	require.NoError(t, err)
+	assert.Equal(t, []provider.ResourceUsage{
+		{SessionID: "abc", Provider: "lxc", CPUSeconds: 1.5, MemoryBytes: 4096},
+	}, usage)
+}
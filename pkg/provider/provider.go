@@ -33,3 +33,19 @@ type Provider interface {
 	Exec(ctx context.Context, sessionID string, opts ExecOptions) error
 	List(ctx context.Context) ([]Session, error)
 }
+
+// ResourceUsage is what one workspace container or VM is using. CPU time is
+// cumulative; callers derive utilisation from successive samples.
+type ResourceUsage struct {
+	SessionID        string  `json:"session_id"`
+	Provider         string  `json:"provider"`
+	CPUSeconds       float64 `json:"cpu_seconds"`
+	MemoryBytes      uint64  `json:"memory_bytes"`
+	MemoryLimitBytes uint64  `json:"memory_limit_bytes,omitempty"`
+}
+
+// StatsProvider is implemented by providers that can report the resources
+// their running workspaces use
+type StatsProvider interface {
+	Stats(ctx context.Context) ([]ResourceUsage, error)
+}
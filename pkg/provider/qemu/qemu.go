@@ -6,12 +6,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/nexus/nexus/pkg/config"
 	"github.com/nexus/nexus/pkg/provider"
+	"github.com/nexus/nexus/pkg/tracing"
 	"github.com/nexus/nexus/pkg/transport"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type QEMUProvider struct {
@@ -38,7 +41,10 @@ func (p *QEMUProvider) Name() string {
 }
 
 // Create creates a QEMU disk image and prepares VM configuration
-func (p *QEMUProvider) Create(ctx context.Context, sessionID string, workspacePath string, rawConfig interface{}) (*provider.Session, error) {
+func (p *QEMUProvider) Create(ctx context.Context, sessionID string, workspacePath string, rawConfig interface{}) (_ *provider.Session, err error) {
+	ctx, span := tracing.Start(ctx, "qemu.Create", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	cfg, ok := rawConfig.(*config.Config)
 	if !ok {
 		return nil, fmt.Errorf("invalid config type")
@@ -137,7 +143,10 @@ func (p *QEMUProvider) Create(ctx context.Context, sessionID string, workspacePa
 }
 
 // Start launches the QEMU VM
-func (p *QEMUProvider) Start(ctx context.Context, sessionID string) error {
+func (p *QEMUProvider) Start(ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "qemu.Start", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	vmDir := filepath.Join(p.baseDir, sessionID)
 
 	// Start VM in background with nohup
@@ -151,7 +160,10 @@ func (p *QEMUProvider) Start(ctx context.Context, sessionID string) error {
 }
 
 // Stop gracefully stops the QEMU VM
-func (p *QEMUProvider) Stop(ctx context.Context, sessionID string) error {
+func (p *QEMUProvider) Stop(ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "qemu.Stop", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	// Send ACPI shutdown signal
 	if _, err := p.execRemote(ctx, fmt.Sprintf("echo 'system_powerdown' | nc -U %s/qemu-monitor.sock",
 		filepath.Join(p.baseDir, sessionID))); err == nil {
@@ -164,7 +176,10 @@ func (p *QEMUProvider) Stop(ctx context.Context, sessionID string) error {
 }
 
 // Destroy removes VM resources
-func (p *QEMUProvider) Destroy(ctx context.Context, sessionID string) error {
+func (p *QEMUProvider) Destroy(ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "qemu.Destroy", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	if err := p.Stop(ctx, sessionID); err != nil {
 		// Continue cleanup even if stop fails
 	}
@@ -178,7 +193,10 @@ func (p *QEMUProvider) Destroy(ctx context.Context, sessionID string) error {
 }
 
 // Exec executes commands inside the VM via SSH
-func (p *QEMUProvider) Exec(ctx context.Context, sessionID string, opts provider.ExecOptions) error {
+func (p *QEMUProvider) Exec(ctx context.Context, sessionID string, opts provider.ExecOptions) (err error) {
+	ctx, span := tracing.Start(ctx, "qemu.Exec", attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+
 	vmDir := filepath.Join(p.baseDir, sessionID)
 	sshKeyPath := filepath.Join(vmDir, "id_rsa")
 
@@ -212,7 +230,10 @@ func (p *QEMUProvider) Exec(ctx context.Context, sessionID string, opts provider
 }
 
 // List returns all QEMU VMs managed by nexus
-func (p *QEMUProvider) List(ctx context.Context) ([]provider.Session, error) {
+func (p *QEMUProvider) List(ctx context.Context) (_ []provider.Session, err error) {
+	ctx, span := tracing.Start(ctx, "qemu.List")
+	defer func() { tracing.End(span, err) }()
+
 	output, err := p.execRemote(ctx, "ps aux | grep '[q]emu-system' | grep -oE '%s-[a-z0-9_-]+' | sort -u")
 	if err != nil {
 		return []provider.Session{}, nil // No VMs running
@@ -233,6 +254,141 @@ func (p *QEMUProvider) List(ctx context.Context) ([]provider.Session, error) {
 	return sessions, nil
 }
 
+// Stats reports the CPU time and memory of each running nexus VM, read from
+// the QEMU processes in /proc
+func (p *QEMUProvider) Stats(ctx context.Context) ([]provider.ResourceUsage, error) {
+	if p.remote != "" {
+		return nil, fmt.Errorf("stats not supported for remote QEMU")
+	}
+	return qemuStats("/proc", p.baseDir)
+}
+
+// clockTicks is the kernel's USER_HZ, the unit of CPU times in /proc
+const clockTicks = 100
+
+func qemuStats(procRoot, baseDir string) ([]provider.ResourceUsage, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", procRoot, err)
+	}
+
+	var usage []provider.ResourceUsage
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		procDir := filepath.Join(procRoot, entry.Name())
+
+		// Processes may exit while we read them; skip any we can't
+		cmdline, err := os.ReadFile(filepath.Join(procDir, "cmdline"))
+		if err != nil {
+			continue
+		}
+		args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+		if !strings.HasPrefix(filepath.Base(args[0]), "qemu-system") {
+			continue
+		}
+		sessionID, memoryLimit := parseQEMUArgs(args, baseDir)
+		if sessionID == "" {
+			continue
+		}
+
+		cpuSeconds, rss, err := processUsage(procDir)
+		if err != nil {
+			continue
+		}
+		usage = append(usage, provider.ResourceUsage{
+			SessionID:        sessionID,
+			Provider:         "qemu",
+			CPUSeconds:       cpuSeconds,
+			MemoryBytes:      rss,
+			MemoryLimitBytes: memoryLimit,
+		})
+	}
+	return usage, nil
+}
+
+// parseQEMUArgs finds the session a QEMU command line belongs to from its
+// disk image, baseDir/<session>/<session>.qcow2, and the guest memory it was
+// given with -m
+func parseQEMUArgs(args []string, baseDir string) (string, uint64) {
+	var sessionID string
+	var memory uint64
+	for i := 0; i+1 < len(args); i++ {
+		switch args[i] {
+		case "-hda":
+			vmDir := filepath.Dir(args[i+1])
+			if filepath.Dir(vmDir) == filepath.Clean(baseDir) {
+				sessionID = filepath.Base(vmDir)
+			}
+		case "-m":
+			memory = parseQEMUMemory(args[i+1])
+		}
+	}
+	return sessionID, memory
+}
+
+// parseQEMUMemory parses a -m size such as 4G, 512M or size=2048. QEMU reads
+// sizes without a suffix as MiB.
+func parseQEMUMemory(value string) uint64 {
+	value = strings.TrimPrefix(value, "size=")
+	if i := strings.Index(value, ","); i >= 0 {
+		value = value[:i]
+	}
+	multiplier := uint64(1 << 20)
+	switch {
+	case strings.HasSuffix(value, "K"), strings.HasSuffix(value, "k"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(value, "M"), strings.HasSuffix(value, "m"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(value, "G"), strings.HasSuffix(value, "g"):
+		multiplier = 1 << 30
+	case strings.HasSuffix(value, "T"), strings.HasSuffix(value, "t"):
+		multiplier = 1 << 40
+	}
+	n, err := strconv.ParseUint(strings.TrimRight(value, "KkMmGgTt"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n * multiplier
+}
+
+// processUsage reads the CPU time and resident memory of the process whose
+// /proc directory is procDir
+func processUsage(procDir string) (float64, uint64, error) {
+	stat, err := os.ReadFile(filepath.Join(procDir, "stat"))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The command name may contain spaces, so count fields from after it;
+	// utime and stime are fields 14 and 15 of the whole line
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("malformed %s/stat", procDir)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 13 {
+		return 0, 0, fmt.Errorf("malformed %s/stat", procDir)
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+
+	status, err := os.ReadFile(filepath.Join(procDir, "status"))
+	if err != nil {
+		return 0, 0, err
+	}
+	var rss uint64
+	for _, line := range strings.Split(string(status), "\n") {
+		if value, ok := strings.CutPrefix(line, "VmRSS:"); ok {
+			kb, _ := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+			rss = kb * 1024
+			break
+		}
+	}
+
+	return float64(utime+stime) / clockTicks, rss, nil
+}
+
 // Helper methods
 
 func (p *QEMUProvider) execRemote(ctx context.Context, cmd string) (string, error) {
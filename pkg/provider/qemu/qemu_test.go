@@ -708,3 +708,41 @@ func TestEnvironment(t *testing.T) {
 	t.Logf("QEMU available: %v", isCommandAvailable("qemu-system-x86_64"))
 	t.Logf("SSH available: %v", isCommandAvailable("ssh"))
 }
+
+// TestQEMUStats tests reading VM usage from a fake /proc
+func TestQEMUStats(t *testing.T) {
+	procRoot := t.TempDir()
+	baseDir := "/home/dev/.nexus/qemu"
+
+	writeProc := func(pid string, args []string, stat, status string) {
+		dir := filepath.Join(procRoot, pid)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		cmdline := ""
+		for _, arg := range args {
+			cmdline += arg + "\x00"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "status"), []byte(status), 0644))
+	}
+
+	writeProc("100", []string{"qemu-system-x86_64", "-hda", baseDir + "/ws1/ws1.qcow2", "-m", "4G"},
+		"100 (qemu-system-x86) S 1 100 100 0 -1 0 0 0 0 0 250 50 0 0 20 0 4 0",
+		"Name:\tqemu-system-x86\nVmRSS:\t  2048 kB\n")
+	writeProc("200", []string{"/bin/bash"}, "200 (bash) S 1", "VmRSS:\t1 kB\n")
+	writeProc("300", []string{"qemu-system-x86_64", "-hda", "/elsewhere/vm/vm.qcow2"}, "", "")
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "self"), 0755))
+
+	usage, err := qemuStats(procRoot, baseDir)
+	require.NoError(t, err)
+	assert.Equal(t, []provider.ResourceUsage{{
+		SessionID:        "ws1",
+		Provider:         "qemu",
+		CPUSeconds:       3,
+		MemoryBytes:      2048 * 1024,
+		MemoryLimitBytes: 4 << 30,
+	}}, usage)
+
+	assert.Equal(t, uint64(512<<20), parseQEMUMemory("512"))
+	assert.Equal(t, uint64(2<<30), parseQEMUMemory("size=2G,slots=2"))
+}
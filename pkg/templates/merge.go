@@ -1,15 +1,15 @@
 package templates
 
 import (
-	"bytes"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
-	"text/template"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/nexus/nexus/pkg/plugins"
 )
 
 // TemplateData represents the merged template data
@@ -24,12 +24,10 @@ type PluginData struct {
 }
 
 type PluginManifest struct {
-	Name        string `yaml:"name"`
-	Version     string `yaml:"version,omitempty"`
-	Description string `yaml:"description,omitempty"`
-	Conditions  []struct {
-		File string `yaml:"file"`
-	} `yaml:"conditions,omitempty"`
+	Name        string             `yaml:"name"`
+	Version     string             `yaml:"version,omitempty"`
+	Description string             `yaml:"description,omitempty"`
+	Conditions  plugins.Conditions `yaml:"conditions,omitempty"`
 }
 
 func (m *Manager) Merge(baseDir string, _ []string, extends []string) (*TemplateData, error) {
@@ -127,12 +125,9 @@ func (m *Manager) loadTemplatesFromExtendsRepo(repoDir string, data *TemplateDat
 		pluginDir := filepath.Join(repoDir, pluginName)
 		plugin := m.getOrCreatePlugin(data, pluginName)
 
-		// Load skills, rules, commands from each subdirectory
-		for _, templateType := range []string{"skills", "rules", "commands"} {
-			subDir := filepath.Join(pluginDir, templateType)
-			if err := m.loadTemplatesFromDir(subDir, plugin); err != nil {
-				return err
-			}
+		// Load skills, rules, commands from their subdirectories
+		if err := m.loadTemplatesFromDir(pluginDir, plugin); err != nil {
+			return err
 		}
 	}
 
@@ -190,7 +185,8 @@ func (m *Manager) loadTemplatesFromDir(dir string, plugin *PluginData) error {
 		}
 		templateType := parts[0]
 
-		filename := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		base, isTemplate := templateBase(path)
+		filename := strings.TrimSuffix(base, filepath.Ext(base))
 
 		content, err := os.ReadFile(path)
 		if err != nil {
@@ -209,26 +205,49 @@ func (m *Manager) loadTemplatesFromDir(dir string, plugin *PluginData) error {
 			return nil
 		}
 
-		if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+		if isTemplate {
+			if strings.HasSuffix(base, ".md") || strings.HasSuffix(base, ".mdc") {
+				target[filename] = markdownData(path, content, true)
+			}
+		} else if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
 			var data interface{}
 			if err := yaml.Unmarshal(content, &data); err != nil {
 				return fmt.Errorf("failed to parse YAML %s: %w", path, err)
 			}
 			target[filename] = data
 		} else if strings.HasSuffix(path, ".md") || strings.HasSuffix(path, ".mdc") {
-			frontmatter, mdContent := parseMarkdown(content)
-			ruleData := make(map[string]interface{})
-			for k, v := range frontmatter {
-				ruleData[k] = v
-			}
-			ruleData["content"] = mdContent
-			target[filename] = ruleData
+			target[filename] = markdownData(path, content, false)
 		}
 
 		return nil
 	})
 }
 
+// templateBase returns the file name of path without TemplateExt, and
+// whether it had it
+func templateBase(path string) (string, bool) {
+	base := filepath.Base(path)
+	trimmed := strings.TrimSuffix(base, TemplateExt)
+	return trimmed, trimmed != base
+}
+
+// markdownData returns a markdown rule, skill or command as its frontmatter
+// plus its body under "content". Templates also record where their body
+// starts, so render errors point at the right line.
+func markdownData(path string, content []byte, isTemplate bool) map[string]interface{} {
+	frontmatter, body := parseMarkdown(content)
+	data := make(map[string]interface{})
+	for k, v := range frontmatter {
+		data[k] = v
+	}
+	data["content"] = body
+	if isTemplate {
+		line := strings.Count(string(content[:len(content)-len(body)]), "\n") + 1
+		data[TemplateKey] = Source{File: path, Line: line}
+	}
+	return data
+}
+
 func parseMarkdown(content []byte) (map[string]interface{}, string) {
 	contentStr := string(content)
 
@@ -301,12 +320,13 @@ func (m *Manager) applyOverrideForType(agentDir, templateType string, target map
 			return nil
 		}
 
-		isMd := strings.HasSuffix(path, ".md") || strings.HasSuffix(path, ".mdc")
+		base, isTemplate := templateBase(path)
+		isMd := strings.HasSuffix(base, ".md") || strings.HasSuffix(base, ".mdc")
 		if !isMd {
 			return nil
 		}
 
-		filename := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		filename := strings.TrimSuffix(base, filepath.Ext(base))
 
 		content, err := os.ReadFile(path)
 		if err != nil {
@@ -318,36 +338,13 @@ func (m *Manager) applyOverrideForType(agentDir, templateType string, target map
 			return nil
 		}
 
-		var overrideData map[string]interface{}
-		frontmatter, mdContent := parseMarkdown(content)
-		ruleData := make(map[string]interface{})
-		for k, v := range frontmatter {
-			ruleData[k] = v
-		}
-		ruleData["content"] = mdContent
-		overrideData = map[string]interface{}{
-			filename: ruleData,
-		}
-
-		for key, value := range overrideData {
-			target[key] = value
-		}
-
+		target[filename] = markdownData(path, content, isTemplate)
 		return nil
 	})
 }
 
-// RenderTemplate renders a Go template string with the provided data
+// RenderTemplate renders a Go template string with the provided data. It can
+// include partials from the partials directory.
 func (m *Manager) RenderTemplate(templateStr string, data interface{}) (string, error) {
-	tmpl, err := template.New("template").Parse(templateStr)
-	if err != nil {
-		return "", err
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", err
-	}
-
-	return buf.String(), nil
+	return NewRenderer(filepath.Join(m.baseDir, "partials")).Render(Source{File: "template", Line: 1}, templateStr, data)
 }
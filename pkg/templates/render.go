@@ -0,0 +1,187 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateExt marks rule, skill and command markdown files rendered as
+// templates, e.g. rules/testing.md.tpl
+const TemplateExt = ".tpl"
+
+// TemplateKey holds the Source of a markdown rule, skill or command loaded
+// from a TemplateExt file. Its content is rendered before it is written.
+const TemplateKey = "_template"
+
+// maxIncludeDepth bounds nested includes so a partial including itself fails
+// instead of recursing forever
+const maxIncludeDepth = 16
+
+// Source locates template text in the file it was read from
+type Source struct {
+	File string `json:"file" yaml:"file"`
+	// Line is the line of File the text starts on
+	Line int `json:"line" yaml:"line"`
+}
+
+// RenderError is a template parse or execution error located in its file
+type RenderError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *RenderError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+// Renderer renders config, rule, skill and command templates with nexus's
+// helper functions:
+//
+//	toJson   marshals a value to JSON
+//	indent   prefixes every line with n spaces: {{ toJson .MCPServers | indent 2 }}
+//	default  falls back when a value is empty: {{ .Facts.GitRemote | default "none" }}
+//	include  renders a partial by name: {{ include "testing.md" . }}
+//
+// Partials are looked up in the renderer's partial directories, in order.
+type Renderer struct {
+	partialDirs []string
+}
+
+// NewRenderer creates a renderer that includes partials from partialDirs
+func NewRenderer(partialDirs ...string) *Renderer {
+	return &Renderer{partialDirs: partialDirs}
+}
+
+// RenderFile renders the template at path
+func (r *Renderer) RenderFile(path string, data interface{}) (string, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+	return r.Render(Source{File: path, Line: 1}, string(text), data)
+}
+
+// Render renders text read from src. Errors carry src's file and the line in
+// it the error is on.
+func (r *Renderer) Render(src Source, text string, data interface{}) (string, error) {
+	return r.render(src, text, data, 0)
+}
+
+func (r *Renderer) render(src Source, text string, data interface{}, depth int) (string, error) {
+	// Blank lines in front of the text make text/template count lines as
+	// they are numbered in the file
+	pad := ""
+	if src.Line > 1 {
+		pad = strings.Repeat("\n", src.Line-1)
+	}
+
+	tmpl, err := template.New(src.File).Funcs(r.funcs(depth)).Parse(pad + text)
+	if err != nil {
+		return "", locateError(src.File, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", locateError(src.File, err)
+	}
+
+	// The padding may already be trimmed by a leading {{-
+	out := buf.String()
+	for i := 0; i < len(pad) && strings.HasPrefix(out, "\n"); i++ {
+		out = out[1:]
+	}
+	return out, nil
+}
+
+func (r *Renderer) funcs(depth int) template.FuncMap {
+	return template.FuncMap{
+		"toJson":  toJSON,
+		"indent":  indent,
+		"default": defaultValue,
+		"include": func(name string, data interface{}) (string, error) {
+			if depth >= maxIncludeDepth {
+				return "", fmt.Errorf("partial %s: includes nested more than %d deep", name, maxIncludeDepth)
+			}
+			path, err := r.findPartial(name)
+			if err != nil {
+				return "", err
+			}
+			text, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read partial %s: %w", path, err)
+			}
+			return r.render(Source{File: path, Line: 1}, string(text), data, depth+1)
+		},
+	}
+}
+
+func (r *Renderer) findPartial(name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("partial %q must be a relative path", name)
+	}
+	for _, dir := range r.partialDirs {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("partial %q not found in %s", name, strings.Join(r.partialDirs, ", "))
+}
+
+// templateErrorPattern matches text/template errors, which look like
+// "template: <name>:<line>[:<col>]: <message>"
+var templateErrorPattern = regexp.MustCompile(`^template: (.*?):(\d+):(?:\d+:)? (.*)$`)
+
+// locateError turns a text/template error about the template named file
+// into a RenderError
+func locateError(file string, err error) error {
+	match := templateErrorPattern.FindStringSubmatch(err.Error())
+	if match == nil || match[1] != file {
+		return &RenderError{File: file, Line: 1, Message: err.Error()}
+	}
+	line, _ := strconv.Atoi(match[2])
+	return &RenderError{File: file, Line: line, Message: match[3]}
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// indent prefixes every line of s, including the first, with n spaces
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// defaultValue returns value, or fallback when value is missing or the zero
+// value of its type
+func defaultValue(fallback, value interface{}) interface{} {
+	if value == nil {
+		return fallback
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		if v.Len() == 0 {
+			return fallback
+		}
+	default:
+		if v.IsZero() {
+			return fallback
+		}
+	}
+	return value
+}
@@ -137,3 +137,102 @@ func TestGetRepoSHA(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, sha, 40) // SHA length
 }
+
+func TestLoadTemplatesFromExtendsRepo(t *testing.T) {
+	repoDir := t.TempDir()
+	pluginDir := filepath.Join(repoDir, "golang")
+	for _, dir := range []string{"rules", "skills", "commands"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(pluginDir, dir), 0755))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, "rules", "style.md"), []byte("Use gofmt"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, "skills", "bench.yaml"), []byte("name: bench\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, "commands", "test.md"), []byte("Run go test"), 0644))
+
+	data := &TemplateData{Plugins: make(map[string]*PluginData)}
+	m := NewManager(repoDir)
+	assert.NoError(t, m.loadTemplatesFromExtendsRepo(repoDir, data))
+
+	plugin := data.Plugins["golang"]
+	if assert.NotNil(t, plugin) {
+		assert.Contains(t, plugin.Rules, "style")
+		assert.Contains(t, plugin.Skills, "bench")
+		assert.Contains(t, plugin.Commands, "test")
+	}
+}
+
+func TestRenderer_Helpers(t *testing.T) {
+	r := NewRenderer()
+	data := map[string]interface{}{
+		"Servers": map[string]interface{}{"db": map[string]interface{}{"port": 5432}},
+		"Remote":  "",
+		"Langs":   []string{"go"},
+	}
+
+	result, err := r.Render(Source{File: "t.tpl", Line: 1}, `{{ toJson .Servers }}|{{ .Remote | default "none" }}|{{ .Langs | default "-" }}|{{ "a\nb" | indent 2 }}`, data)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"db":{"port":5432}}|none|[go]|  a
+  b`, result)
+}
+
+func TestRenderer_Include(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "greeting.md"), []byte("Hello {{ .Name }}"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "loop.md"), []byte(`{{ include "loop.md" . }}`), 0644))
+	r := NewRenderer(filepath.Join(dir, "missing"), dir)
+
+	result, err := r.Render(Source{File: "t.tpl", Line: 1}, `{{ include "greeting.md" . | indent 2 }}`, map[string]string{"Name": "nexus"})
+	assert.NoError(t, err)
+	assert.Equal(t, "  Hello nexus", result)
+
+	_, err = r.Render(Source{File: "t.tpl", Line: 1}, `{{ include "nope.md" . }}`, nil)
+	assert.ErrorContains(t, err, `partial "nope.md" not found`)
+
+	_, err = r.Render(Source{File: "t.tpl", Line: 1}, `{{ include "loop.md" . }}`, nil)
+	assert.ErrorContains(t, err, "nested more than")
+
+	_, err = r.Render(Source{File: "t.tpl", Line: 1}, `{{ include "../etc/passwd" . }}`, nil)
+	assert.ErrorContains(t, err, "must be a relative path")
+}
+
+func TestRenderer_ErrorLocation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json.tpl")
+	assert.NoError(t, os.WriteFile(path, []byte("{\n  \"a\": {{ .A }},\n  \"b\": {{ .B.C }}\n}\n"), 0644))
+	r := NewRenderer()
+
+	_, err := r.RenderFile(path, map[string]interface{}{"A": 1, "B": 2})
+	var renderErr *RenderError
+	if assert.ErrorAs(t, err, &renderErr) {
+		assert.Equal(t, path, renderErr.File)
+		assert.Equal(t, 3, renderErr.Line)
+	}
+
+	// Lines count from where the text starts in its file
+	_, err = r.Render(Source{File: "rule.md.tpl", Line: 4}, "ok\n{{ if }}", nil)
+	if assert.ErrorAs(t, err, &renderErr) {
+		assert.Equal(t, "rule.md.tpl", renderErr.File)
+		assert.Equal(t, 5, renderErr.Line)
+	}
+
+	result, err := r.Render(Source{File: "rule.md.tpl", Line: 4}, "{{- \"x\" }}\ny", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "x\ny", result)
+}
+
+func TestLoadTemplatesFromDir_Templates(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "rules"), 0755))
+	path := filepath.Join(dir, "rules", "testing.md.tpl")
+	assert.NoError(t, os.WriteFile(path, []byte("---\ndescription: Tests\n---\nRun {{ .Cmd }}\n"), 0644))
+
+	m := NewManager(dir)
+	plugin := m.getOrCreatePlugin(&TemplateData{}, "base")
+	assert.NoError(t, m.loadTemplatesFromDir(dir, plugin))
+
+	rule, ok := plugin.Rules["testing"].(map[string]interface{})
+	if assert.True(t, ok) {
+		assert.Equal(t, "Tests", rule["description"])
+		assert.Equal(t, "Run {{ .Cmd }}\n", rule["content"])
+		assert.Equal(t, Source{File: path, Line: 4}, rule[TemplateKey])
+	}
+}
@@ -0,0 +1,160 @@
+// Package tracing exports OpenTelemetry traces over OTLP and carries W3C
+// trace context between the CLI, the coordination server and node agents.
+// Tracing is off unless enabled in config or with NEXUS_TRACING_ENABLED.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer every nexus span comes from
+const instrumentationName = "github.com/nexus/nexus"
+
+// Config configures trace export
+type Config struct {
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318.
+	// Empty falls back to OTEL_EXPORTER_OTLP_ENDPOINT, then localhost:4318.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// Headers are sent with every export, e.g. for collector auth
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// SampleRatio is the fraction of new traces recorded; 0 records all.
+	// Traces started upstream follow the upstream sampling decision.
+	SampleRatio float64 `yaml:"sample_ratio,omitempty" json:"sample_ratio,omitempty"`
+}
+
+// ApplyEnv overrides c with NEXUS_TRACING_ENABLED, NEXUS_TRACING_ENDPOINT
+// and NEXUS_TRACING_SAMPLE_RATIO when they are set
+func (c *Config) ApplyEnv() {
+	if enabled, err := strconv.ParseBool(os.Getenv("NEXUS_TRACING_ENABLED")); err == nil {
+		c.Enabled = enabled
+	}
+	if endpoint := os.Getenv("NEXUS_TRACING_ENDPOINT"); endpoint != "" {
+		c.Endpoint = endpoint
+	}
+	if ratio, err := strconv.ParseFloat(os.Getenv("NEXUS_TRACING_SAMPLE_RATIO"), 64); err == nil {
+		c.SampleRatio = ratio
+	}
+}
+
+// Setup installs the W3C trace context propagator and, when cfg is enabled,
+// a tracer provider exporting spans for service over OTLP. The returned func
+// flushes pending spans; call it before exiting. With tracing disabled spans
+// are not recorded but incoming trace context is still passed on.
+func Setup(ctx context.Context, cfg Config, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL, semconv.ServiceName(service),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe trace resource: %w", err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named name as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, marking it failed when err is not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject returns the trace context of ctx as a map, for carrying it in
+// messages that aren't HTTP requests
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns ctx continuing the trace context carrier holds
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// Transport wraps base, or http.DefaultTransport when base is nil, so that
+// every request is traced and carries the trace context of its context
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
+
+// Middleware traces the requests next handles, continuing the trace context
+// callers send. Spans are named by the mux pattern that matches the request
+// rather than its path, so IDs in paths don't each make a span name.
+func Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			_, route := mux.Handler(r)
+			switch {
+			case route == "":
+				route = r.Method + " unmatched"
+			case !strings.Contains(route, " "):
+				// Patterns without a method match any
+				route = r.Method + " " + route
+			}
+			return route
+		}),
+	)
+}
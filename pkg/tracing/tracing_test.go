@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that keeps ended spans in memory
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	_, err := Setup(context.Background(), Config{}, "test")
+	require.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{}, "test")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestInjectExtract(t *testing.T) {
+	recorder := recordSpans(t)
+
+	ctx, parent := Start(context.Background(), "parent")
+	carrier := Inject(ctx)
+	require.Contains(t, carrier, "traceparent")
+
+	_, child := Start(Extract(context.Background(), carrier), "child")
+	End(child, errors.New("boom"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, parent.SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+
+	assert.Nil(t, Inject(context.Background()))
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/workspaces/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(Middleware(mux, mux))
+	defer server.Close()
+
+	ctx, span := Start(context.Background(), "client")
+	client := &http.Client{Transport: Transport(nil)}
+	for _, path := range []string{"/api/v1/workspaces/ws-1", "/missing"} {
+		req, err := http.NewRequestWithContext(ctx, "GET", server.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	span.End()
+
+	var names []string
+	for _, s := range recorder.Ended() {
+		if s.SpanKind().String() == "server" {
+			names = append(names, s.Name())
+			assert.Equal(t, span.SpanContext().TraceID(), s.SpanContext().TraceID())
+		}
+	}
+	assert.Equal(t, []string{"GET /api/v1/workspaces/{id}", "GET unmatched"}, names)
+}
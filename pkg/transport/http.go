@@ -16,6 +16,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/nexus/nexus/pkg/tracing"
 )
 
 type HTTPTransport struct {
@@ -98,12 +100,15 @@ func (h *HTTPTransport) IsConnected() bool {
 	return err == nil
 }
 
-func (h *HTTPTransport) Execute(ctx context.Context, cmd *Command) (*Result, error) {
+func (h *HTTPTransport) Execute(ctx context.Context, cmd *Command) (res *Result, err error) {
 	h.mu.RLock()
 	client := h.client
 	target := h.config.Target
 	h.mu.RUnlock()
 
+	ctx, span := startExecute(ctx, "http", target, cmd)
+	defer func() { endExecute(span, res, err) }()
+
 	if !h.IsConnected() {
 		return nil, ErrNotConnected
 	}
@@ -340,7 +345,7 @@ func buildHTTPClient(cfg *Config) (*http.Client, error) {
 	}
 
 	client := &http.Client{
-		Transport: transport,
+		Transport: tracing.Transport(transport),
 		Timeout:   timeout,
 	}
 
@@ -4,6 +4,10 @@ import (
 	"context"
 	"io"
 	"time"
+
+	"github.com/nexus/nexus/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Transport defines the interface for communication protocols
@@ -141,3 +145,24 @@ var (
 	ErrFileNotFound     = &TransportError{Type: "file", Message: "file not found", Retryable: false}
 	ErrPermissionDenied = &TransportError{Type: "permission", Message: "permission denied", Retryable: false}
 )
+
+// startExecute starts the span covering one Execute call. Only the program
+// is recorded, since arguments and environment can carry secrets.
+func startExecute(ctx context.Context, protocol, target string, cmd *Command) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("transport.protocol", protocol),
+		attribute.String("transport.target", target),
+	}
+	if len(cmd.Cmd) > 0 {
+		attrs = append(attrs, attribute.String("command.program", cmd.Cmd[0]))
+	}
+	return tracing.Start(ctx, "transport.Execute", attrs...)
+}
+
+// endExecute ends an Execute span, recording the exit code when there is one
+func endExecute(span trace.Span, result *Result, err error) {
+	if result != nil {
+		span.SetAttributes(attribute.Int("command.exit_code", result.ExitCode))
+	}
+	tracing.End(span, err)
+}
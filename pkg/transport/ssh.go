@@ -128,11 +128,15 @@ func (s *SSHTransport) IsConnected() bool {
 	return err == nil
 }
 
-func (s *SSHTransport) Execute(ctx context.Context, cmd *Command) (*Result, error) {
+func (s *SSHTransport) Execute(ctx context.Context, cmd *Command) (res *Result, err error) {
 	s.mu.RLock()
 	client := s.client
+	target := s.config.Target
 	s.mu.RUnlock()
 
+	ctx, span := startExecute(ctx, "ssh", target, cmd)
+	defer func() { endExecute(span, res, err) }()
+
 	if client == nil {
 		return nil, ErrNotConnected
 	}
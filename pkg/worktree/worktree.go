@@ -1,15 +1,61 @@
 package worktree
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
 type Manager interface {
 	Add(branch string) (string, error)
+	// AddFrom creates branch from baseRef (HEAD when empty) in a new worktree
+	// without touching the primary checkout
+	AddFrom(branch, baseRef string) (string, error)
 	Remove(branch string) error
+	// List returns the worktrees under the manager's base directory, as recorded by git
+	List() ([]Worktree, error)
+	// Prune removes git's metadata for worktrees whose directories no longer exist
+	Prune() error
+}
+
+// Worktree describes a git worktree
+type Worktree struct {
+	Path string
+	// Branch is the checked out branch, empty when HEAD is detached
+	Branch   string
+	Head     string
+	Dirty    bool
+	Locked   bool
+	Prunable bool
+}
+
+// Name returns the workspace name of the worktree: its branch, or its directory when detached
+func (w Worktree) Name() string {
+	if w.Branch != "" {
+		return w.Branch
+	}
+	return filepath.Base(w.Path)
+}
+
+// DirName maps a branch name to the directory name of its worktree, so that
+// branches such as feature/login get a single flat directory
+func DirName(branch string) string {
+	return strings.ReplaceAll(branch, "/", "--")
+}
+
+// ValidateBranchName checks that branch is a legal git branch name
+func ValidateBranchName(branch string) error {
+	if branch == "" || strings.HasPrefix(branch, "-") {
+		return fmt.Errorf("invalid branch name: %q", branch)
+	}
+	if err := exec.Command("git", "check-ref-format", "--branch", branch).Run(); err != nil {
+		return fmt.Errorf("invalid branch name: %q", branch)
+	}
+	return nil
 }
 
 type gitManager struct {
@@ -25,44 +71,40 @@ func NewManager(repoPath string, baseDir string) Manager {
 }
 
 func (m *gitManager) Add(branch string) (string, error) {
-	wtPath := filepath.Join(m.BaseDir, branch)
-	if _, err := os.Stat(wtPath); err == nil {
-		return wtPath, nil
+	return m.AddFrom(branch, "")
+}
+
+func (m *gitManager) AddFrom(branch, baseRef string) (string, error) {
+	if err := ValidateBranchName(branch); err != nil {
+		return "", err
 	}
 
-	currentBranch, err := m.getCurrentBranch()
-	if err != nil {
-		return "", fmt.Errorf("failed to get current branch: %w", err)
-	}
-
-	if currentBranch == branch {
-		safeBranches := []string{"main", "master"}
-		switched := false
-		for _, safeBranch := range safeBranches {
-			if m.branchExists(safeBranch) {
-				if err := m.switchToBranch(safeBranch); err == nil {
-					switched = true
-					break
-				}
-			}
+	wtPath := filepath.Join(m.BaseDir, DirName(branch))
+	if _, err := os.Stat(wtPath); err == nil {
+		existing, err := m.find(wtPath)
+		if err != nil {
+			return "", err
 		}
-		if !switched {
-			return "", fmt.Errorf("cannot create worktree: currently on branch %s and no safe branch (main/master) found", branch)
+		if existing != nil && existing.Branch != "" && existing.Branch != branch {
+			return "", fmt.Errorf("worktree directory %s is already used by branch %s", wtPath, existing.Branch)
 		}
+		return wtPath, nil
 	}
 
-	if !m.branchExists(branch) {
-		cmd := exec.Command("git", "checkout", "-b", branch)
-		cmd.Dir = m.RepoPath
-		if output, err := cmd.CombinedOutput(); err != nil {
-			return "", fmt.Errorf("failed to create branch %s: %w, output: %s", branch, err, string(output))
+	var args []string
+	if m.branchExists(branch) {
+		if baseRef != "" {
+			return "", fmt.Errorf("branch %s already exists; cannot create it from %s", branch, baseRef)
 		}
-		if currentBranch != "" && currentBranch != branch {
-			_ = m.switchToBranch(currentBranch)
+		args = []string{"worktree", "add", wtPath, branch}
+	} else {
+		if baseRef == "" {
+			baseRef = "HEAD"
 		}
+		args = []string{"worktree", "add", "-b", branch, wtPath, baseRef}
 	}
 
-	cmd := exec.Command("git", "worktree", "add", wtPath, branch)
+	cmd := exec.Command("git", args...)
 	cmd.Dir = m.RepoPath
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return "", fmt.Errorf("failed to create worktree: %w, output: %s", err, string(output))
@@ -71,34 +113,129 @@ func (m *gitManager) Add(branch string) (string, error) {
 	return wtPath, nil
 }
 
-func (m *gitManager) getCurrentBranch() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	cmd.Dir = m.RepoPath
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return string(output[:len(output)-1]), nil
-}
-
 func (m *gitManager) branchExists(branch string) bool {
 	cmd := exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/"+branch)
 	cmd.Dir = m.RepoPath
 	return cmd.Run() == nil
 }
 
-func (m *gitManager) switchToBranch(branch string) error {
-	cmd := exec.Command("git", "checkout", branch)
+func (m *gitManager) Remove(branch string) error {
+	wtPath := filepath.Join(m.BaseDir, DirName(branch))
+
+	cmd := exec.Command("git", "worktree", "remove", "--force", wtPath)
 	cmd.Dir = m.RepoPath
-	return cmd.Run()
+	_ = cmd.Run()
+
+	if err := os.RemoveAll(wtPath); err != nil {
+		return err
+	}
+	return m.Prune()
 }
 
-func (m *gitManager) Remove(branch string) error {
-	wtPath := filepath.Join(m.BaseDir, branch)
+func (m *gitManager) Prune() error {
+	cmd := exec.Command("git", "worktree", "prune")
+	cmd.Dir = m.RepoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to prune worktrees: %w, output: %s", err, string(output))
+	}
+	return nil
+}
 
-	cmd := exec.Command("git", "worktree", "remove", branch)
+func (m *gitManager) List() ([]Worktree, error) {
+	cmd := exec.Command("git", "worktree", "list", "--porcelain")
 	cmd.Dir = m.RepoPath
-	_ = cmd.Run()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	baseDir, err := canonicalPath(m.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var worktrees []Worktree
+	for _, wt := range parsePorcelain(output) {
+		path, err := canonicalPath(wt.Path)
+		if err != nil || filepath.Dir(path) != baseDir {
+			continue
+		}
+		if !wt.Prunable {
+			wt.Dirty = isDirty(wt.Path)
+		}
+		worktrees = append(worktrees, wt)
+	}
+	return worktrees, nil
+}
+
+// find returns the registered worktree at path, or nil if git does not know it
+func (m *gitManager) find(path string) (*Worktree, error) {
+	worktrees, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	want, err := canonicalPath(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, wt := range worktrees {
+		if got, err := canonicalPath(wt.Path); err == nil && got == want {
+			return &wt, nil
+		}
+	}
+	return nil, nil
+}
+
+// parsePorcelain parses the output of git worktree list --porcelain
+func parsePorcelain(output []byte) []Worktree {
+	var worktrees []Worktree
+	var current *Worktree
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, Worktree{Path: value})
+			current = &worktrees[len(worktrees)-1]
+		case "HEAD":
+			if current != nil {
+				current.Head = value
+			}
+		case "branch":
+			if current != nil {
+				current.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "locked":
+			if current != nil {
+				current.Locked = true
+			}
+		case "prunable":
+			if current != nil {
+				current.Prunable = true
+			}
+		}
+	}
+	return worktrees
+}
+
+func isDirty(path string) bool {
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = path
+	output, err := cmd.Output()
+	return err == nil && len(bytes.TrimSpace(output)) > 0
+}
 
-	return os.RemoveAll(wtPath)
+// canonicalPath resolves path to an absolute path with symlinks evaluated, so
+// paths reported by git compare equal to the manager's base directory
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	return abs, nil
 }
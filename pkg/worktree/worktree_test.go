@@ -4,9 +4,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTestRepo(t *testing.T) string {
@@ -73,3 +75,97 @@ func TestManager_Remove(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NoDirExists(t, wtPath)
 }
+
+func TestManager_AddFromBaseRef(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	defer os.RemoveAll(filepath.Dir(repoPath))
+
+	runGit := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v, output: %s", args, err, string(output))
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	base := runGit(repoPath, "rev-parse", "HEAD")
+	runGit(repoPath, "tag", "v1")
+	os.WriteFile(filepath.Join(repoPath, "CHANGELOG.md"), []byte("later"), 0644)
+	runGit(repoPath, "add", "CHANGELOG.md")
+	runGit(repoPath, "commit", "-m", "Second commit")
+
+	manager := NewManager(repoPath, filepath.Join(filepath.Dir(repoPath), "worktrees"))
+
+	wtPath, err := manager.AddFrom("feature/login", "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "feature--login", filepath.Base(wtPath))
+	assert.Equal(t, base, runGit(wtPath, "rev-parse", "HEAD"))
+	assert.NoFileExists(t, filepath.Join(wtPath, "CHANGELOG.md"))
+
+	assert.Equal(t, "main", runGit(repoPath, "rev-parse", "--abbrev-ref", "HEAD"), "primary checkout must be untouched")
+}
+
+func TestManager_AddCurrentBranchDoesNotSwitchCheckout(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	defer os.RemoveAll(filepath.Dir(repoPath))
+
+	manager := NewManager(repoPath, filepath.Join(filepath.Dir(repoPath), "worktrees"))
+
+	_, err := manager.Add("main")
+	assert.Error(t, err)
+
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = repoPath
+	output, _ := cmd.Output()
+	assert.Equal(t, "main", strings.TrimSpace(string(output)))
+}
+
+func TestManager_ListAndPrune(t *testing.T) {
+	repoPath := setupTestRepo(t)
+	defer os.RemoveAll(filepath.Dir(repoPath))
+
+	manager := NewManager(repoPath, filepath.Join(filepath.Dir(repoPath), "worktrees"))
+
+	cleanPath, err := manager.Add("clean")
+	require.NoError(t, err)
+	dirtyPath, err := manager.Add("team/dirty")
+	require.NoError(t, err)
+	os.WriteFile(filepath.Join(dirtyPath, "scratch.txt"), []byte("wip"), 0644)
+
+	worktrees, err := manager.List()
+	require.NoError(t, err)
+	require.Len(t, worktrees, 2, "primary checkout should not be listed")
+
+	byName := map[string]Worktree{}
+	for _, wt := range worktrees {
+		byName[wt.Name()] = wt
+	}
+	assert.False(t, byName["clean"].Dirty)
+	assert.True(t, byName["team/dirty"].Dirty)
+	assert.Len(t, byName["clean"].Head, 40)
+
+	require.NoError(t, os.RemoveAll(cleanPath))
+	worktrees, err = manager.List()
+	require.NoError(t, err)
+	for _, wt := range worktrees {
+		if wt.Branch == "clean" {
+			assert.True(t, wt.Prunable)
+		}
+	}
+
+	require.NoError(t, manager.Prune())
+	worktrees, err = manager.List()
+	require.NoError(t, err)
+	require.Len(t, worktrees, 1)
+	assert.Equal(t, "team/dirty", worktrees[0].Branch)
+}
+
+func TestValidateBranchName(t *testing.T) {
+	assert.NoError(t, ValidateBranchName("feature/login"))
+	assert.Error(t, ValidateBranchName(""))
+	assert.Error(t, ValidateBranchName("-rf"))
+	assert.Error(t, ValidateBranchName("bad..name"))
+	assert.Error(t, ValidateBranchName("trailing/"))
+}